@@ -26,13 +26,6 @@ const (
 )
 
 func main() {
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000"}, // Разрешаем доступ с фронта на localhost:3000
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
-	})
-
 	cfg := config.Load("auth-service/config/local.yaml")
 
 	log := utils.New(cfg.Env)
@@ -43,7 +36,7 @@ func main() {
 		log.Info("Starting auth service")
 	}
 
-	application := app.NewApp(log, cfg.GRPC.Port, cfg.StoragePath, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	application := app.NewApp(log, cfg.GRPC.Port, cfg.GRPC.TrustedProxies, cfg.GRPC.InternalAppIDs, cfg.StoragePath, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.PasswordReset, cfg.EmailVerification, cfg.MFA, cfg.Signing, cfg.BruteForce, cfg.PasswordHash, cfg.PasswordPolicy, cfg.OAuth, cfg.Federation, cfg.Audit, cfg.Mailer)
 
 	go func() {
 		err := application.GRPCServer.Run()
@@ -67,9 +60,16 @@ func main() {
 
 	mainMux := http.NewServeMux()
 
-	// grpc-gateway API
+	// grpc-gateway API, в том числе OpenID Connect: /.well-known/openid-configuration,
+	// /.well-known/jwks.json и /userinfo (токен из заголовка Authorization шлюз передаёт в метаданных)
 	mainMux.Handle("/", mux)
 
+	// OAuth 2.0: редиректы браузера и form-urlencoded запросы клиентов обрабатываются без grpc-gateway
+	mainMux.Handle("/oauth/", application.OAuthHandler)
+
+	// вход через внешних провайдеров OpenID Connect, тоже с редиректами браузера
+	mainMux.Handle("/federation/", application.FederationHandler)
+
 	// Swagger UI - отдаём статику из swagger/dist по пути /swagger-ui/
 	fsSwaggerUI := http.FileServer(http.Dir("auth-service/swagger/dist"))
 	mainMux.Handle("/swagger-ui/", http.StripPrefix("/swagger-ui/", fsSwaggerUI))
@@ -79,6 +79,16 @@ func main() {
 		http.ServeFile(w, r, "auth-service/swagger/apidocs.swagger.json")
 	})
 
+	c := cors.New(cors.Options{
+		// Разрешаем доступ с фронта на localhost:3000 и с origin, разрешённых приложениям
+		AllowOriginVaryRequestFunc: func(r *http.Request, origin string) (bool, []string) {
+			return origin == "http://localhost:3000" || application.AllowsOrigin(r.Context(), origin), nil
+		},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: true,
+	})
+
 	// HTTP сервер с поддержкой CORS
 	handler := c.Handler(mainMux)
 
@@ -112,6 +122,6 @@ func main() {
 		log.Error("Failed to shutdown HTTP gateway", slog.String("error", err.Error()))
 	}
 
-	application.GRPCServer.Stop()
+	application.Stop()
 	log.Info("Application stopped")
 }
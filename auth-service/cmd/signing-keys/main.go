@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/14kear/forum-project/auth-service/internal/lib/secretbox"
+	"github.com/14kear/forum-project/auth-service/internal/services/signing"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/forum-project/auth-service/internal/storage/postgres"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Ротация ключей подписи токенов. Рассчитана на запуск по расписанию (cron):
+// с -max-age ключ меняется, только если активный ключ приложения старше заданного возраста.
+func main() {
+	var dbURL, encryptionKey, algorithm string
+	var appID int
+	var maxAge, retireAfter time.Duration
+
+	flag.StringVar(&dbURL, "db-url", "", "PostgreSQL connection string")
+	flag.StringVar(&encryptionKey, "encryption-key", os.Getenv("MFA_ENCRYPTION_KEY"), "Base64 key that encrypts private keys (defaults to MFA_ENCRYPTION_KEY)")
+	flag.IntVar(&appID, "app-id", 0, "Rotate keys of a single app (default: all apps)")
+	flag.StringVar(&algorithm, "algorithm", jwt.AlgEdDSA, "Algorithm of new keys: RS256 or EdDSA")
+	flag.DurationVar(&maxAge, "max-age", 0, "Rotate only if the active key is older than this (default: always rotate)")
+	flag.DurationVar(&retireAfter, "retire-after", 168*time.Hour, "How long the previous key stays valid, not less than refresh_ttl")
+	flag.Parse()
+
+	if dbURL == "" {
+		panic("db-url is required")
+	}
+	if encryptionKey == "" {
+		panic("encryption-key is required")
+	}
+
+	cipher, err := secretbox.NewFromBase64(encryptionKey)
+	if err != nil {
+		panic(fmt.Errorf("invalid encryption key: %w", err))
+	}
+
+	db, err := postgres.New(dbURL)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := context.Background()
+
+	appIDs := []int{appID}
+	if appID == 0 {
+		appIDs, err = db.AppIDs(ctx)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	keys := signing.New(log, db, cipher, signing.Config{Algorithm: algorithm, RetireAfter: retireAfter})
+
+	for _, id := range appIDs {
+		if maxAge > 0 {
+			active, err := db.ActiveSigningKey(ctx, id)
+			if err != nil && !errors.Is(err, storage.ErrSigningKeyNotFound) {
+				panic(err)
+			}
+			if err == nil && time.Since(active.CreatedAt) < maxAge {
+				fmt.Printf("app %d: active key %s is fresh, skipping\n", id, active.KID)
+				continue
+			}
+		}
+
+		key, err := keys.Rotate(ctx, id, algorithm)
+		if err != nil {
+			panic(fmt.Errorf("app %d: rotation failed: %w", id, err))
+		}
+
+		fmt.Printf("app %d: new active key %s\n", id, key.ID)
+	}
+}
@@ -1,28 +1,227 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	grpcapp "github.com/14kear/forum-project/auth-service/internal/app/grpc"
+	"github.com/14kear/forum-project/auth-service/internal/config"
+	federationhttp "github.com/14kear/forum-project/auth-service/internal/http/federation"
+	oauthhttp "github.com/14kear/forum-project/auth-service/internal/http/oauth"
+	"github.com/14kear/forum-project/auth-service/internal/lib/clientinfo"
+	"github.com/14kear/forum-project/auth-service/internal/lib/mailer"
+	"github.com/14kear/forum-project/auth-service/internal/lib/oidc"
+	"github.com/14kear/forum-project/auth-service/internal/lib/passhash"
+	"github.com/14kear/forum-project/auth-service/internal/lib/passpolicy"
+	"github.com/14kear/forum-project/auth-service/internal/lib/secretbox"
 	"github.com/14kear/forum-project/auth-service/internal/services/auth"
+	"github.com/14kear/forum-project/auth-service/internal/services/signing"
 	"github.com/14kear/forum-project/auth-service/internal/storage/postgres"
 	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// identityProviderTimeout ограничивает запросы к внешним провайдерам OpenID Connect
+const identityProviderTimeout = 10 * time.Second
+
 type App struct {
 	GRPCServer *grpcapp.App
+	// OAuthHandler обслуживает /oauth/; монтируется рядом с grpc-gateway
+	OAuthHandler http.Handler
+	// FederationHandler обслуживает вход через внешних провайдеров /federation/
+	FederationHandler http.Handler
+	// AllowsOrigin сообщает, разрешён ли origin фронтенда какому-нибудь приложению (для CORS)
+	AllowsOrigin func(ctx context.Context, origin string) bool
+
+	cancel context.CancelFunc
 }
 
-func NewApp(log *slog.Logger, grpcPort int, storagePath string, accessTokenTTL time.Duration, refreshTokenTTL time.Duration) *App {
+func NewApp(
+	log *slog.Logger,
+	grpcPort int,
+	trustedProxies []string,
+	internalAppIDs []int,
+	storagePath string,
+	accessTokenTTL time.Duration,
+	refreshTokenTTL time.Duration,
+	passwordResetCfg config.PasswordResetConfig,
+	emailVerificationCfg config.EmailVerificationConfig,
+	mfaCfg config.MFAConfig,
+	signingCfg config.SigningConfig,
+	bruteForceCfg config.BruteForceConfig,
+	passwordHashCfg config.PasswordHashConfig,
+	passwordPolicyCfg config.PasswordPolicyConfig,
+	oauthCfg config.OAuthConfig,
+	federationCfg config.FederationConfig,
+	auditCfg config.AuditConfig,
+	mailerCfg config.MailerConfig,
+) *App {
 	storage, err := postgres.New(storagePath)
 	if err != nil {
 		panic(err)
 	}
 
-	authService := auth.NewAuth(log, storage, storage, storage, storage, accessTokenTTL, refreshTokenTTL)
+	mail, err := newMailer(log, mailerCfg)
+	if err != nil {
+		panic(err)
+	}
+
+	secrets, err := secretbox.NewFromBase64(mfaCfg.EncryptionKey)
+	if err != nil {
+		panic(err)
+	}
+
+	hasher, err := passhash.New(passhash.Config{
+		Algorithm:     passwordHashCfg.Algorithm,
+		Argon2Memory:  passwordHashCfg.Argon2Memory,
+		Argon2Time:    passwordHashCfg.Argon2Time,
+		Argon2Threads: passwordHashCfg.Argon2Threads,
+		BcryptCost:    passwordHashCfg.BcryptCost,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	policy, err := passpolicy.New(passpolicy.Config{
+		MinLength:             passwordPolicyCfg.MinLength,
+		MaxLength:             passwordPolicyCfg.MaxLength,
+		RequireLower:          passwordPolicyCfg.RequireLower,
+		RequireUpper:          passwordPolicyCfg.RequireUpper,
+		RequireDigit:          passwordPolicyCfg.RequireDigit,
+		RequireSymbol:         passwordPolicyCfg.RequireSymbol,
+		RejectEmailSimilar:    passwordPolicyCfg.RejectEmailSimilar,
+		BreachedPasswordsFile: passwordPolicyCfg.BreachedPasswordsFile,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	keys := signing.New(log, storage, secrets, signing.Config{
+		Algorithm:       signingCfg.Algorithm,
+		RetireAfter:     signingCfg.RetireAfter,
+		CacheTTL:        signingCfg.CacheTTL,
+		LegacyHMACUntil: signingCfg.LegacyHMACUntil,
+	})
+
+	passwordReset := auth.PasswordResetConfig{
+		TokenTTL: passwordResetCfg.TokenTTL,
+		URL:      passwordResetCfg.URL,
+	}
+
+	emailVerification := auth.EmailVerificationConfig{
+		TokenTTL:         emailVerificationCfg.TokenTTL,
+		URL:              emailVerificationCfg.URL,
+		RequiredForLogin: emailVerificationCfg.RequiredForLogin,
+		ChangeURL:        emailVerificationCfg.ChangeURL,
+	}
+
+	mfa := auth.MFAConfig{
+		Issuer:            mfaCfg.Issuer,
+		ChallengeTTL:      mfaCfg.ChallengeTTL,
+		MaxAttempts:       mfaCfg.MaxAttempts,
+		RequiredForAdmins: mfaCfg.RequiredForAdmins,
+	}
+
+	bruteForce := auth.BruteForceConfig{
+		EmailThreshold:  bruteForceCfg.EmailThreshold,
+		IPThreshold:     bruteForceCfg.IPThreshold,
+		LockoutDuration: bruteForceCfg.LockoutDuration,
+		MaxLockout:      bruteForceCfg.MaxLockout,
+		Window:          bruteForceCfg.Window,
+	}
+
+	oauth := auth.OAuthConfig{
+		CodeTTL: oauthCfg.CodeTTL,
+		Issuer:  strings.TrimSuffix(oauthCfg.Issuer, "/"),
+	}
+
+	federation := auth.FederationConfig{
+		StateTTL:  federationCfg.StateTTL,
+		Providers: make(map[string]auth.IdentityProvider, len(federationCfg.Providers)),
+	}
+	for _, provider := range federationCfg.Providers {
+		federation.Providers[provider.Name] = oidc.New(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  oauth.Issuer + "/federation/" + url.PathEscape(provider.Name) + "/callback",
+			Scopes:       provider.Scopes,
+		}, &http.Client{Timeout: identityProviderTimeout})
+	}
+
+	audit := auth.AuditConfig{
+		Enabled:   auditCfg.Enabled,
+		Retention: auditCfg.Retention,
+	}
+
+	authService := auth.NewAuth(
+		log, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, hasher, policy, mail, secrets, keys,
+		accessTokenTTL, refreshTokenTTL, passwordReset, emailVerification, mfa, bruteForce, oauth, federation, audit,
+	)
+
+	proxies, err := clientinfo.ParseTrustedProxies(trustedProxies)
+	if err != nil {
+		panic(err)
+	}
+
+	grpcApp := grpcapp.NewApp(log, authService, grpcPort, proxies, internalAppIDs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// очистка журнала аудита по сроку хранения
+	if audit.Retention > 0 {
+		go func() {
+			ticker := time.NewTicker(auditCfg.SweepInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					log.Info("audit sweep goroutine stopped")
+					return
+				case <-ticker.C:
+					ctxTimeout, cancel := context.WithTimeout(ctx, time.Minute)
+					_, err := authService.SweepAuditEvents(ctxTimeout)
+					cancel()
 
-	grpcApp := grpcapp.NewApp(log, authService, grpcPort)
+					if err != nil {
+						log.Error("failed to sweep audit events", slog.Any("error", err))
+					}
+				}
+			}
+		}()
+	}
 
 	return &App{
-		GRPCServer: grpcApp,
+		GRPCServer:        grpcApp,
+		OAuthHandler:      oauthhttp.New(log, authService, oauthCfg.ConsentURL),
+		FederationHandler: federationhttp.New(log, authService, federationCfg.ResultURL),
+		AllowsOrigin: func(ctx context.Context, origin string) bool {
+			allowed, err := authService.OriginAllowed(ctx, origin)
+			if err != nil {
+				log.Error("failed to check allowed origin", slog.String("origin", origin), slog.Any("error", err))
+			}
+			return allowed
+		},
+		cancel: cancel,
+	}
+}
+
+// Stop останавливает фоновые задачи и gRPC сервер
+func (a *App) Stop() {
+	a.cancel()
+	a.GRPCServer.Stop()
+}
+
+func newMailer(log *slog.Logger, cfg config.MailerConfig) (auth.Mailer, error) {
+	switch cfg.Type {
+	case mailer.TypeLog, "":
+		return mailer.NewLogMailer(log), nil
+	case mailer.TypeFile:
+		return mailer.NewFileMailer(cfg.Dir)
+	default:
+		return nil, fmt.Errorf("unknown mailer type %q", cfg.Type)
 	}
 }
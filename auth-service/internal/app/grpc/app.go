@@ -3,9 +3,11 @@ package grpcapp
 import (
 	"fmt"
 	authgrpc "github.com/14kear/forum-project/auth-service/internal/grpc/auth"
+	"github.com/14kear/forum-project/auth-service/internal/lib/clientinfo"
 	"google.golang.org/grpc"
 	"log/slog"
 	"net"
+	"net/netip"
 )
 
 type App struct {
@@ -14,9 +16,12 @@ type App struct {
 	port       int
 }
 
-func NewApp(log *slog.Logger, authService authgrpc.Auth, port int) *App {
+func NewApp(log *slog.Logger, authService authgrpc.Auth, port int, trustedProxies []netip.Prefix, internalAppIDs []int) *App {
 	//gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(authInterceptor(authService)))
-	gRPCServer := grpc.NewServer()
+	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		clientinfo.UnaryServerInterceptor(trustedProxies),
+		authgrpc.ServiceAuthInterceptor(authService, internalAppIDs),
+	))
 
 	authgrpc.Register(gRPCServer, authService)
 	return &App{
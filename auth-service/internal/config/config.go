@@ -7,17 +7,150 @@ import (
 )
 
 type Config struct {
-	Env             string        `yaml:"env" env-default:"local"`
-	StoragePath     string        `yaml:"storage_path" env-required:"true"`
-	GRPC            GRPCConfig    `yaml:"grpc"`
-	HTTP            HTTPConfig    `yaml:"http"`
-	AccessTokenTTL  time.Duration `yaml:"access_ttl" env-required:"true"`
-	RefreshTokenTTL time.Duration `yaml:"refresh_ttl" env-required:"true"`
+	Env               string                  `yaml:"env" env-default:"local"`
+	StoragePath       string                  `yaml:"storage_path" env-required:"true"`
+	GRPC              GRPCConfig              `yaml:"grpc"`
+	HTTP              HTTPConfig              `yaml:"http"`
+	AccessTokenTTL    time.Duration           `yaml:"access_ttl" env-required:"true"`
+	RefreshTokenTTL   time.Duration           `yaml:"refresh_ttl" env-required:"true"`
+	PasswordReset     PasswordResetConfig     `yaml:"password_reset"`
+	EmailVerification EmailVerificationConfig `yaml:"email_verification"`
+	MFA               MFAConfig               `yaml:"mfa"`
+	Signing           SigningConfig           `yaml:"signing"`
+	BruteForce        BruteForceConfig        `yaml:"brute_force"`
+	PasswordHash      PasswordHashConfig      `yaml:"password_hash"`
+	PasswordPolicy    PasswordPolicyConfig    `yaml:"password_policy"`
+	OAuth             OAuthConfig             `yaml:"oauth"`
+	Federation        FederationConfig        `yaml:"federation"`
+	Audit             AuditConfig             `yaml:"audit"`
+	Mailer            MailerConfig            `yaml:"mailer"`
+}
+
+type PasswordResetConfig struct {
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	URL      string        `yaml:"url" env-default:"http://localhost:3000/reset-password"`
+}
+
+// EmailVerificationConfig — подтверждение email при регистрации и при его смене.
+// RequiredForLogin запрещает вход, пока email не подтверждён. ChangeURL — страница подтверждения нового адреса.
+type EmailVerificationConfig struct {
+	TokenTTL         time.Duration `yaml:"token_ttl" env-default:"24h"`
+	URL              string        `yaml:"url" env-default:"http://localhost:3000/verify-email"`
+	RequiredForLogin bool          `yaml:"required_for_login" env-default:"false"`
+	ChangeURL        string        `yaml:"change_url" env-default:"http://localhost:3000/confirm-email-change"`
+}
+
+// MFAConfig — второй фактор (TOTP). EncryptionKey — 32 байта в base64, которыми шифруются
+// TOTP-секреты и закрытые ключи подписи в базе; в окружениях кроме local его нужно задавать
+// через MFA_ENCRYPTION_KEY.
+type MFAConfig struct {
+	Issuer            string        `yaml:"issuer" env-default:"forum-project"`
+	EncryptionKey     string        `yaml:"encryption_key" env:"MFA_ENCRYPTION_KEY" env-required:"true"`
+	ChallengeTTL      time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+	MaxAttempts       int           `yaml:"max_attempts" env-default:"5"`
+	RequiredForAdmins bool          `yaml:"required_for_admins" env-default:"true"`
+}
+
+// SigningConfig — подпись токенов ключами приложений из таблицы signing_keys; открытые части
+// публикуются в /.well-known/jwks.json. Algorithm (RS256 или EdDSA) — алгоритм новых ключей;
+// HS256 отключает автоматическое создание ключей, и приложения без ключа подписывают токены секретом.
+// RetireAfter — сколько прежний ключ принимается после ротации, не меньше refresh_ttl.
+// LegacyHMACUntil — до какого момента при RS256 и EdDSA принимаются токены без kid, подписанные
+// секретом приложения; по умолчанию они не принимаются.
+type SigningConfig struct {
+	Algorithm       string        `yaml:"algorithm" env-default:"EdDSA"`
+	RetireAfter     time.Duration `yaml:"retire_after" env-default:"168h"`
+	CacheTTL        time.Duration `yaml:"cache_ttl" env-default:"1m"`
+	LegacyHMACUntil time.Time     `yaml:"legacy_hmac_until"`
+}
+
+// BruteForceConfig — блокировка входа после неудачных попыток. Счётчики ведутся в Postgres
+// отдельно по email и по IP; порог 0 отключает счётчик. Каждая неудача после окончания
+// блокировки удваивает её срок, но не больше max_lockout. Счётчик сбрасывается через window
+// после последней неудачи.
+type BruteForceConfig struct {
+	EmailThreshold  int           `yaml:"email_threshold" env-default:"5"`
+	IPThreshold     int           `yaml:"ip_threshold" env-default:"50"`
+	LockoutDuration time.Duration `yaml:"lockout_duration" env-default:"1m"`
+	MaxLockout      time.Duration `yaml:"max_lockout" env-default:"1h"`
+	Window          time.Duration `yaml:"window" env-default:"1h"`
+}
+
+// PasswordHashConfig — алгоритм (argon2id или bcrypt) и параметры хэширования новых паролей.
+// Argon2Memory задаётся в КиБ. Хэши со старыми параметрами пересчитываются при входе.
+type PasswordHashConfig struct {
+	Algorithm     string `yaml:"algorithm" env-default:"argon2id"`
+	Argon2Memory  uint32 `yaml:"argon2_memory" env-default:"65536"`
+	Argon2Time    uint32 `yaml:"argon2_time" env-default:"3"`
+	Argon2Threads uint8  `yaml:"argon2_threads" env-default:"2"`
+	BcryptCost    int    `yaml:"bcrypt_cost" env-default:"10"`
+}
+
+// PasswordPolicyConfig — требования к новым паролям. MaxLength задаётся в байтах и по умолчанию
+// равен 72: длиннее bcrypt молча обрезает пароль. BreachedPasswordsFile — необязательный файл
+// с hex-префиксами SHA-1 утёкших паролей, читается при старте.
+type PasswordPolicyConfig struct {
+	MinLength             int    `yaml:"min_length" env-default:"8"`
+	MaxLength             int    `yaml:"max_length" env-default:"72"`
+	RequireLower          bool   `yaml:"require_lower" env-default:"true"`
+	RequireUpper          bool   `yaml:"require_upper" env-default:"false"`
+	RequireDigit          bool   `yaml:"require_digit" env-default:"true"`
+	RequireSymbol         bool   `yaml:"require_symbol" env-default:"false"`
+	RejectEmailSimilar    bool   `yaml:"reject_email_similar" env-default:"true"`
+	BreachedPasswordsFile string `yaml:"breached_passwords_file"`
+}
+
+// OAuthConfig — сервер авторизации OAuth 2.0. CodeTTL — время жизни кода авторизации,
+// ConsentURL — страница согласия фронтенда, куда /oauth/authorize отправляет пользователя.
+// Issuer — внешний адрес HTTP-шлюза: claim iss в ID token и основа адресов в OpenID discovery.
+type OAuthConfig struct {
+	CodeTTL    time.Duration `yaml:"code_ttl" env-default:"1m"`
+	ConsentURL string        `yaml:"consent_url" env-default:"http://localhost:3000/oauth/consent"`
+	Issuer     string        `yaml:"issuer" env-default:"http://localhost:8080"`
+}
+
+// FederationConfig — вход через внешних провайдеров OpenID Connect. StateTTL — сколько ждать возвращения
+// пользователя от провайдера, ResultURL — страница фронтенда, которой callback передаёт токены или ошибку.
+// Callback провайдера — {oauth.issuer}/federation/{name}/callback, его нужно зарегистрировать у провайдера.
+type FederationConfig struct {
+	StateTTL  time.Duration            `yaml:"state_ttl" env-default:"10m"`
+	ResultURL string                   `yaml:"result_url" env-default:"http://localhost:3000/sso/callback"`
+	Providers []IdentityProviderConfig `yaml:"providers"`
+}
+
+// IdentityProviderConfig — регистрация сервиса как клиента у провайдера. Name используется в адресах /federation/{name}/.
+type IdentityProviderConfig struct {
+	Name         string   `yaml:"name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// AuditConfig — журнал аудита событий безопасности в таблице security_events. Retention — сколько
+// хранятся события (0 — бессрочно); очистка запускается раз в sweep_interval.
+type AuditConfig struct {
+	Enabled       bool          `yaml:"enabled" env-default:"true"`
+	Retention     time.Duration `yaml:"retention" env-default:"2160h"`
+	SweepInterval time.Duration `yaml:"sweep_interval" env-default:"1h"`
+}
+
+// MailerConfig — куда отправлять письма: log пишет их в лог, file сохраняет в каталог Dir
+type MailerConfig struct {
+	Type string `yaml:"type" env-default:"log"`
+	Dir  string `yaml:"dir" env-default:"mail"`
 }
 
 type GRPCConfig struct {
 	Port    int           `yaml:"port"`
 	Timeout time.Duration `yaml:"timeout"`
+	// TrustedProxies — адреса и подсети прокси, чьему x-forwarded-for можно верить (например,
+	// grpc-gateway на том же хосте). От остальных IP клиента — адрес соединения.
+	TrustedProxies []string `yaml:"trusted_proxies" env-default:"127.0.0.1/32,::1/128"`
+	// InternalAppIDs — приложения сервисов системы (например, форума), которым доступны внутренние RPC:
+	// выгрузка данных пользователей, список банов и удалённых аккаунтов. Остальным приложениям,
+	// включая OAuth-клиентов, они недоступны.
+	InternalAppIDs []int `yaml:"internal_app_ids" env-default:"1"`
 }
 
 type HTTPConfig struct {
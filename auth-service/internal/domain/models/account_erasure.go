@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// AccountErasure — событие удаления аккаунта. По нему другие сервисы удаляют
+// или обезличивают данные пользователя UserID у себя.
+type AccountErasure struct {
+	ID        int64
+	UserID    int64
+	CreatedAt time.Time
+}
@@ -1,7 +1,33 @@
 package models
 
+import "time"
+
+// Типы OAuth-клиентов. Публичный клиент (SPA, мобильное приложение) не может хранить секрет
+// и проходит только по PKCE.
+const (
+	ClientTypeConfidential = "confidential"
+	ClientTypePublic       = "public"
+)
+
 type App struct {
 	ID     int
 	Name   string
 	Secret string
+	// ClientType и RedirectURIs — настройки OAuth-клиента
+	ClientType   string
+	RedirectURIs []string
+	// AccessTokenTTL и RefreshTokenTTL переопределяют сроки из конфига; 0 — срок из конфига
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// AllowedOrigins — origin фронтендов приложения, которым разрешены запросы к HTTP API (CORS)
+	AllowedOrigins []string
+	// Disabled — приложение отключено: вход в него и его токены не принимаются
+	Disabled  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsOAuthClient сообщает, может ли приложение получать токены через /oauth/authorize
+func (a App) IsOAuthClient() bool {
+	return len(a.RedirectURIs) > 0
 }
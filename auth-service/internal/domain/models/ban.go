@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Виды блокировок пользователя
+const (
+	// BanKindBan — бан, бессрочный или до ExpiresAt
+	BanKindBan = "ban"
+	// BanKindSuspension — временная приостановка, всегда со сроком
+	BanKindSuspension = "suspension"
+	// BanKindDeleted — аккаунт удалён; действует, пока могут оставаться неистёкшие access токены
+	BanKindDeleted = "deleted"
+)
+
+// Ban — блокировка пользователя. Нулевой ExpiresAt означает бессрочную блокировку.
+type Ban struct {
+	UserID    int64
+	Kind      string
+	Reason    string
+	ExpiresAt time.Time
+	BannedBy  int64
+	CreatedAt time.Time
+}
+
+// Active сообщает, действует ли блокировка в момент now
+func (b Ban) Active(now time.Time) bool {
+	return b.ExpiresAt.IsZero() || now.Before(b.ExpiresAt)
+}
@@ -0,0 +1,11 @@
+package models
+
+// AccessClaims — данные пользователя из проверенного access token
+type AccessClaims struct {
+	UserID        int64
+	Email         string
+	EmailVerified bool
+	Roles         []string
+	// Scope — scope OAuth-доступа; пуст у токенов, выданных через Login
+	Scope []string
+}
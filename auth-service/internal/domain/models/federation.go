@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ExternalIdentity — пользователь, подтверждённый ID token внешнего провайдера OpenID Connect
+type ExternalIdentity struct {
+	// Subject — идентификатор пользователя у провайдера (claim sub)
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// UserIdentity связывает учётную запись у внешнего провайдера с пользователем
+type UserIdentity struct {
+	UserID   int64
+	Provider string
+	Subject  string
+	// Email — адрес, который провайдер сообщил при привязке
+	Email     string
+	CreatedAt time.Time
+}
+
+// FederationState — незавершённый вход через внешнего провайдера. Хранится по хэшу параметра state,
+// пока пользователь не вернётся от провайдера.
+type FederationState struct {
+	StateHash    []byte
+	Provider     string
+	AppID        int
+	Nonce        string
+	CodeVerifier string
+	// LinkUserID — пользователь, который привязывает учётную запись провайдера; 0 для обычного входа
+	LinkUserID int64
+	ExpiresAt  time.Time
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// По чему считаются неудачные попытки входа
+const (
+	LockoutKindEmail = "email"
+	LockoutKindIP    = "ip"
+)
+
+// LoginLockout — счётчик неудачных попыток входа по email или IP.
+// Пока LockedUntil в будущем, вход по этому ключу запрещён.
+type LoginLockout struct {
+	Kind          string
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+// TOTP — второй фактор пользователя. Secret зашифрован и расшифровывается только в сервисе.
+type TOTP struct {
+	UserID       int64
+	Secret       []byte
+	Enabled      bool
+	LastUsedStep int64
+}
+
+// MFAChallenge — незавершённый вход, ожидающий код второго фактора
+type MFAChallenge struct {
+	UserID int64
+	AppID  int
+}
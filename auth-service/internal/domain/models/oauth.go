@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AuthorizationCode — выданный, но ещё не обменянный код авторизации OAuth
+type AuthorizationCode struct {
+	CodeHash            []byte
+	AppID               int
+	UserID              int64
+	RedirectURI         string
+	Scope               []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce — параметр nonce запроса OpenID Connect, возвращается клиенту в ID token
+	Nonce     string
+	ExpiresAt time.Time
+}
+
+// OAuthConsent — согласие пользователя на доступ клиента к перечисленным scope
+type OAuthConsent struct {
+	UserID    int64
+	AppID     int
+	AppName   string
+	Scope     []string
+	GrantedAt time.Time
+}
@@ -0,0 +1,7 @@
+package models
+
+// Role — роль пользователя и права, которые она даёт
+type Role struct {
+	Name        string
+	Permissions []string
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Типы событий безопасности
+const (
+	// SecurityEventRegister — регистрация пользователя
+	SecurityEventRegister = "register"
+	// SecurityEventLogin — вход паролем, через MFA или через внешнего провайдера
+	SecurityEventLogin = "login"
+	// SecurityEventRefresh — обновление пары токенов по refresh token
+	SecurityEventRefresh = "refresh"
+	// SecurityEventRefreshTokenReuse — предъявлен уже использованный refresh token, семья токенов отозвана
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	// SecurityEventLogout — выход
+	SecurityEventLogout = "logout"
+	// SecurityEventRoleAssigned — пользователю выдана роль
+	SecurityEventRoleAssigned = "role_assigned"
+	// SecurityEventRoleRevoked — у пользователя отозвана роль
+	SecurityEventRoleRevoked = "role_revoked"
+	// SecurityEventPasswordChange — смена пароля по текущему паролю или сброс по ссылке из письма
+	SecurityEventPasswordChange = "password_change"
+	// SecurityEventUserBanned — пользователь забанен или приостановлен
+	SecurityEventUserBanned = "user_banned"
+	// SecurityEventUserUnbanned — с пользователя снята блокировка
+	SecurityEventUserUnbanned = "user_unbanned"
+)
+
+// Результаты событий безопасности
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// SecurityEvent — запись журнала аудита. UserID и AppID равны 0, если неизвестны.
+type SecurityEvent struct {
+	ID        int64
+	UserID    int64
+	AppID     int
+	Type      string
+	Outcome   string
+	Client    ClientInfo
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// SecurityEventFilter — выборка из журнала аудита. Нулевые поля не ограничивают выборку.
+// BeforeID задаёт страницу: возвращаются события с меньшим id, от новых к старым.
+type SecurityEventFilter struct {
+	UserID   int64
+	AppID    int
+	Type     string
+	Outcome  string
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64
+	Limit    int
+}
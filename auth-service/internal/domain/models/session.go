@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ClientInfo — данные о клиенте, выполнившем вход
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session — вход пользователя в приложение. Идентификатор сессии — семья refresh токенов,
+// поэтому он не меняется при обновлении токенов.
+type Session struct {
+	ID         int64
+	AppID      int
+	AppName    string
+	Client     ClientInfo
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// RefreshTokenRecord — строка refresh_tokens без самого токена, для выгрузки данных пользователя
+type RefreshTokenRecord struct {
+	ID               int64
+	AppID            int
+	SessionID        int64
+	Client           ClientInfo
+	SessionCreatedAt time.Time
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	// RotatedAt — когда токен заменён следующим токеном сессии; нулевое, если не заменялся
+	RotatedAt time.Time
+	Revoked   bool
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Состояния ключа подписи: активным подписываются новые токены, уходящий ещё принимается
+// при проверке, выведенный из оборота больше не принимается и не публикуется в JWKS
+const (
+	SigningKeyActive   = "active"
+	SigningKeyRetiring = "retiring"
+	SigningKeyRetired  = "retired"
+)
+
+// SigningKey — ключ подписи токенов приложения. PrivateKey — зашифрованный PEM.
+type SigningKey struct {
+	KID        string
+	AppID      int
+	Algorithm  string
+	PrivateKey []byte
+	State      string
+	CreatedAt  time.Time
+	// RetiringAt — когда ключ стал уходящим; нулевое значение у активного ключа
+	RetiringAt time.Time
+}
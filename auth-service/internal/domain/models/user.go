@@ -1,7 +1,22 @@
 package models
 
+import "time"
+
 type User struct {
-	ID       int64
-	Email    string
-	PassHash []byte
+	ID            int64
+	Email         string
+	PassHash      []byte
+	EmailVerified bool
+	// DisplayName, Username, AvatarURL и Bio — профиль, который пользователь заполняет сам.
+	// Пустой Username означает, что имя пользователя не выбрано.
+	DisplayName string
+	Username    string
+	AvatarURL   string
+	Bio         string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// Roles — названия действующих ролей; заполняется только при выпуске токенов
+	Roles []string
+	// Scope — scope OAuth-доступа; заполняется только при выпуске токенов через /oauth/token
+	Scope []string
 }
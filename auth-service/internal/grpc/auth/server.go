@@ -2,12 +2,24 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
 	"github.com/14kear/forum-project/auth-service/internal/services/auth"
+	"github.com/14kear/forum-project/auth-service/internal/services/signing"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
 	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"math"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // HANDLERS
@@ -29,7 +41,65 @@ type Auth interface {
 		appID int,
 	) (newAccessToken string, newRefreshToken string, err error)
 	Logout(ctx context.Context, refreshToken string, appID int) (err error)
-	ValidateToken(ctx context.Context, accessToken string, appID int) (int64, string, error)
+	ValidateToken(ctx context.Context, accessToken string, appID int) (models.AccessClaims, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	VerifyMFA(
+		ctx context.Context,
+		mfaToken string,
+		code string,
+	) (accessToken string, refreshToken string, userID int64, err error)
+	EnrollTOTP(ctx context.Context, accessToken string, appID int) (secret string, uri string, err error)
+	ConfirmTOTP(ctx context.Context, accessToken string, appID int, code string) (recoveryCodes []string, err error)
+	DisableTOTP(ctx context.Context, accessToken string, appID int, code string) error
+	JWKS(ctx context.Context) ([]jwt.JWK, error)
+	RotateSigningKey(ctx context.Context, accessToken string, appID int, targetAppID int, algorithm string) (kid string, err error)
+	ListSessions(ctx context.Context, accessToken string, appID int) ([]models.Session, error)
+	RevokeSession(ctx context.Context, accessToken string, appID int, sessionID int64) error
+	RevokeAllSessions(ctx context.Context, accessToken string, appID int) error
+	ListLoginLockouts(ctx context.Context, accessToken string, appID int) ([]models.LoginLockout, error)
+	ClearLoginLockout(ctx context.Context, accessToken string, appID int, kind, key string) error
+	HasPermission(ctx context.Context, userID int64, permission string) (bool, error)
+	AssignRole(ctx context.Context, accessToken string, appID int, userID int64, role string) error
+	RevokeRole(ctx context.Context, accessToken string, appID int, userID int64, role string) error
+	ListOAuthConsents(ctx context.Context, accessToken string, appID int) ([]models.OAuthConsent, error)
+	RevokeOAuthConsent(ctx context.Context, accessToken string, appID int, clientID int) error
+	OpenIDConfiguration() auth.OpenIDConfiguration
+	UserInfo(ctx context.Context, accessToken string) (auth.UserInfo, error)
+	CreateApp(ctx context.Context, accessToken string, appID int, settings auth.AppSettings) (models.App, error)
+	ListApps(ctx context.Context, accessToken string, appID int) ([]models.App, error)
+	UpdateApp(ctx context.Context, accessToken string, appID int, targetAppID int, settings auth.AppSettings) (models.App, error)
+	RotateAppSecret(ctx context.Context, accessToken string, appID int, targetAppID int) (secret string, err error)
+	DisableApp(ctx context.Context, accessToken string, appID int, targetAppID int) error
+	GetProfile(ctx context.Context, accessToken string, appID int) (models.User, error)
+	UpdateProfile(ctx context.Context, accessToken string, appID int, profile auth.Profile) (models.User, error)
+	ChangePassword(
+		ctx context.Context,
+		accessToken string,
+		appID int,
+		currentPassword string,
+		newPassword string,
+	) (newAccessToken string, newRefreshToken string, err error)
+	ChangeEmail(ctx context.Context, accessToken string, appID int, password, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	DeleteAccount(ctx context.Context, accessToken string, appID int, password string, userID int64) error
+	AccountErasures(ctx context.Context, afterID int64, limit int) ([]models.AccountErasure, error)
+	ExportUserData(ctx context.Context, userID int64) (models.User, []models.RefreshTokenRecord, error)
+	AuthenticateApp(ctx context.Context, appID int, secret string) error
+	ListAuditEvents(
+		ctx context.Context,
+		accessToken string,
+		appID int,
+		filter models.SecurityEventFilter,
+		pageSize int,
+		pageToken string,
+	) (events []models.SecurityEvent, nextPageToken string, err error)
+	BanUser(ctx context.Context, accessToken string, appID int, userID int64, reason string, expiresAt time.Time) (models.Ban, error)
+	SuspendUser(ctx context.Context, accessToken string, appID int, userID int64, reason string, expiresAt time.Time) (models.Ban, error)
+	UnbanUser(ctx context.Context, accessToken string, appID int, userID int64) error
+	ActiveBans(ctx context.Context) ([]models.Ban, error)
 }
 
 type serverAPI struct {
@@ -52,9 +122,27 @@ func (s *serverAPI) Login(ctx context.Context, req *ssov1.LoginRequest) (*ssov1.
 
 	accessToken, refreshToken, userID, err := s.auth.Login(ctx, req.GetEmail(), req.GetPassword(), int(req.GetAppId()))
 	if err != nil {
+		var challenge *auth.MFAChallengeError
+		if errors.As(err, &challenge) {
+			return &ssov1.LoginResponse{MfaRequired: true, MfaToken: challenge.Token}, nil
+		}
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
 		}
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			return nil, status.Error(codes.FailedPrecondition, "email is not verified")
+		}
+		if errors.Is(err, auth.ErrAppDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "app is disabled")
+		}
+		var locked *auth.LoginLockedError
+		if errors.As(err, &locked) {
+			return nil, loginLockedError(ctx, locked)
+		}
+		var banned *auth.UserBannedError
+		if errors.As(err, &banned) {
+			return nil, userBannedError(banned)
+		}
 		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
@@ -74,6 +162,10 @@ func (s *serverAPI) Register(ctx context.Context, req *ssov1.RegisterRequest) (*
 		if errors.Is(err, auth.ErrUserExists) {
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
 		}
+		var weak *auth.PasswordPolicyError
+		if errors.As(err, &weak) {
+			return nil, weakPasswordError("password", weak)
+		}
 		return nil, status.Error(codes.Internal, "internal server error")
 	}
 	return &ssov1.RegisterResponse{UserId: userID}, nil
@@ -97,6 +189,10 @@ func (s *serverAPI) IsAdmin(ctx context.Context, req *ssov1.IsAdminRequest) (*ss
 func (s *serverAPI) RefreshTokens(ctx context.Context, req *ssov1.RefreshTokenRequest) (*ssov1.RefreshTokenResponse, error) {
 	accessToken, refreshToken, err := s.auth.RefreshTokens(ctx, req.GetRefreshToken(), int(req.GetAppId()))
 	if err != nil {
+		var banned *auth.UserBannedError
+		if errors.As(err, &banned) {
+			return nil, userBannedError(banned)
+		}
 		return nil, status.Error(codes.Unauthenticated, "invalid or expired refresh token")
 	}
 
@@ -113,12 +209,1136 @@ func (s *serverAPI) Logout(ctx context.Context, req *ssov1.LogoutRequest) (*ssov
 }
 
 func (s *serverAPI) ValidateToken(ctx context.Context, req *ssov1.ValidateTokenRequest) (*ssov1.ValidateTokenResponse, error) {
-	userID, email, err := s.auth.ValidateToken(ctx, req.GetAccessToken(), int(req.GetAppId()))
+	claims, err := s.auth.ValidateToken(ctx, req.GetAccessToken(), int(req.GetAppId()))
 	if err != nil {
+		// PermissionDenied, а не Unauthenticated: обновление токенов забаненному не поможет
+		var banned *auth.UserBannedError
+		if errors.As(err, &banned) {
+			return nil, userBannedError(banned)
+		}
 		return nil, status.Error(codes.Unauthenticated, "invalid access token")
 	}
 
-	return &ssov1.ValidateTokenResponse{UserId: userID, Email: email}, nil
+	return &ssov1.ValidateTokenResponse{
+		UserId:        claims.UserID,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Roles:         claims.Roles,
+		Scopes:        claims.Scope,
+	}, nil
+}
+
+func (s *serverAPI) GetJWKS(ctx context.Context, _ *ssov1.GetJWKSRequest) (*ssov1.GetJWKSResponse, error) {
+	keys, err := s.auth.JWKS(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	resp := &ssov1.GetJWKSResponse{Keys: make([]*ssov1.JWK, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, &ssov1.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Use: key.Use,
+			Alg: key.Alg,
+			N:   optional(key.N),
+			E:   optional(key.E),
+			Crv: optional(key.Crv),
+			X:   optional(key.X),
+		})
+	}
+
+	return resp, nil
+}
+
+// optional оставляет поле JWK пустым, чтобы в JSON не попадали параметры другого типа ключа
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func (s *serverAPI) RequestPasswordReset(ctx context.Context, req *ssov1.RequestPasswordResetRequest) (*ssov1.RequestPasswordResetResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.auth.RequestPasswordReset(ctx, req.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.RequestPasswordResetResponse{}, nil
+}
+
+func (s *serverAPI) ResetPassword(ctx context.Context, req *ssov1.ResetPasswordRequest) (*ssov1.ResetPasswordResponse, error) {
+	if err := validateResetPassword(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.ResetPassword(ctx, req.GetToken(), req.GetNewPassword()); err != nil {
+		if errors.Is(err, auth.ErrInvalidResetToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired reset token")
+		}
+		var weak *auth.PasswordPolicyError
+		if errors.As(err, &weak) {
+			return nil, weakPasswordError("new_password", weak)
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.ResetPasswordResponse{}, nil
+}
+
+func (s *serverAPI) VerifyEmail(ctx context.Context, req *ssov1.VerifyEmailRequest) (*ssov1.VerifyEmailResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if err := s.auth.VerifyEmail(ctx, req.GetToken()); err != nil {
+		if errors.Is(err, auth.ErrInvalidVerifyToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired verification token")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.VerifyEmailResponse{}, nil
+}
+
+func (s *serverAPI) ResendVerification(ctx context.Context, req *ssov1.ResendVerificationRequest) (*ssov1.ResendVerificationResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.auth.ResendVerification(ctx, req.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.ResendVerificationResponse{}, nil
+}
+
+func (s *serverAPI) VerifyMFA(ctx context.Context, req *ssov1.VerifyMFARequest) (*ssov1.LoginResponse, error) {
+	if err := validateVerifyMFA(req); err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, userID, err := s.auth.VerifyMFA(ctx, req.GetMfaToken(), req.GetCode())
+	if err != nil {
+		return nil, mfaError(err)
+	}
+
+	return &ssov1.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		UserId:       userID}, nil
+}
+
+func (s *serverAPI) EnrollTOTP(ctx context.Context, req *ssov1.EnrollTOTPRequest) (*ssov1.EnrollTOTPResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	secret, uri, err := s.auth.EnrollTOTP(ctx, req.GetAccessToken(), int(req.GetAppId()))
+	if err != nil {
+		return nil, mfaError(err)
+	}
+
+	return &ssov1.EnrollTOTPResponse{Secret: secret, OtpauthUri: uri}, nil
+}
+
+func (s *serverAPI) ConfirmTOTP(ctx context.Context, req *ssov1.ConfirmTOTPRequest) (*ssov1.ConfirmTOTPResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	recoveryCodes, err := s.auth.ConfirmTOTP(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetCode())
+	if err != nil {
+		return nil, mfaError(err)
+	}
+
+	return &ssov1.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *serverAPI) DisableTOTP(ctx context.Context, req *ssov1.DisableTOTPRequest) (*ssov1.DisableTOTPResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	if err := s.auth.DisableTOTP(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetCode()); err != nil {
+		return nil, mfaError(err)
+	}
+
+	return &ssov1.DisableTOTPResponse{}, nil
+}
+
+func (s *serverAPI) RotateSigningKey(ctx context.Context, req *ssov1.RotateSigningKeyRequest) (*ssov1.RotateSigningKeyResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	targetAppID := req.GetTargetAppId()
+	if targetAppID == emptyValue {
+		targetAppID = req.GetAppId()
+	}
+
+	kid, err := s.auth.RotateSigningKey(ctx, req.GetAccessToken(), int(req.GetAppId()), int(targetAppID), req.GetAlgorithm())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidAccessToken):
+			return nil, status.Error(codes.Unauthenticated, "invalid access token")
+		case errors.Is(err, auth.ErrPermissionDenied):
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		case errors.Is(err, auth.ErrAppNotFound):
+			return nil, status.Error(codes.NotFound, "app not found")
+		case errors.Is(err, signing.ErrUnsupportedAlgorithm):
+			return nil, status.Error(codes.InvalidArgument, "algorithm must be RS256 or EdDSA")
+		case errors.Is(err, storage.ErrSigningKeyExists):
+			return nil, status.Error(codes.Aborted, "signing key is being rotated concurrently")
+		default:
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &ssov1.RotateSigningKeyResponse{Kid: kid}, nil
+}
+
+func (s *serverAPI) ListSessions(ctx context.Context, req *ssov1.ListSessionsRequest) (*ssov1.ListSessionsResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.auth.ListSessions(ctx, req.GetAccessToken(), int(req.GetAppId()))
+	if err != nil {
+		return nil, sessionError(err)
+	}
+
+	resp := &ssov1.ListSessionsResponse{Sessions: make([]*ssov1.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &ssov1.Session{
+			Id:         session.ID,
+			AppId:      int32(session.AppID),
+			AppName:    session.AppName,
+			Ip:         session.Client.IP,
+			UserAgent:  session.Client.UserAgent,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastUsedAt: session.LastUsedAt.Unix(),
+			ExpiresAt:  session.ExpiresAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) RevokeSession(ctx context.Context, req *ssov1.RevokeSessionRequest) (*ssov1.RevokeSessionResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetSessionId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "session_id is required")
+	}
+
+	if err := s.auth.RevokeSession(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetSessionId()); err != nil {
+		return nil, sessionError(err)
+	}
+
+	return &ssov1.RevokeSessionResponse{}, nil
+}
+
+func (s *serverAPI) RevokeAllSessions(ctx context.Context, req *ssov1.RevokeAllSessionsRequest) (*ssov1.RevokeAllSessionsResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.RevokeAllSessions(ctx, req.GetAccessToken(), int(req.GetAppId())); err != nil {
+		return nil, sessionError(err)
+	}
+
+	return &ssov1.RevokeAllSessionsResponse{}, nil
+}
+
+func (s *serverAPI) ListLoginLockouts(ctx context.Context, req *ssov1.ListLoginLockoutsRequest) (*ssov1.ListLoginLockoutsResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	lockouts, err := s.auth.ListLoginLockouts(ctx, req.GetAccessToken(), int(req.GetAppId()))
+	if err != nil {
+		return nil, lockoutError(err)
+	}
+
+	resp := &ssov1.ListLoginLockoutsResponse{Lockouts: make([]*ssov1.LoginLockout, 0, len(lockouts))}
+	for _, lockout := range lockouts {
+		resp.Lockouts = append(resp.Lockouts, &ssov1.LoginLockout{
+			Kind:          lockout.Kind,
+			Key:           lockout.Key,
+			Failures:      int32(lockout.Failures),
+			LastFailureAt: lockout.LastFailureAt.Unix(),
+			LockedUntil:   lockout.LockedUntil.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) ClearLoginLockout(ctx context.Context, req *ssov1.ClearLoginLockoutRequest) (*ssov1.ClearLoginLockoutResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	if err := s.auth.ClearLoginLockout(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetKind(), req.GetKey()); err != nil {
+		return nil, lockoutError(err)
+	}
+
+	return &ssov1.ClearLoginLockoutResponse{}, nil
+}
+
+func (s *serverAPI) HasPermission(ctx context.Context, req *ssov1.HasPermissionRequest) (*ssov1.HasPermissionResponse, error) {
+	if req.GetUserId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPermission() == "" {
+		return nil, status.Error(codes.InvalidArgument, "permission is required")
+	}
+
+	allowed, err := s.auth.HasPermission(ctx, req.GetUserId(), req.GetPermission())
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.HasPermissionResponse{Allowed: allowed}, nil
+}
+
+func (s *serverAPI) AssignRole(ctx context.Context, req *ssov1.AssignRoleRequest) (*ssov1.AssignRoleResponse, error) {
+	if err := validateRoleChange(req.GetAccessToken(), req.GetAppId(), req.GetUserId(), req.GetRole()); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.AssignRole(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetUserId(), req.GetRole()); err != nil {
+		return nil, roleError(err)
+	}
+
+	return &ssov1.AssignRoleResponse{}, nil
+}
+
+func (s *serverAPI) RevokeRole(ctx context.Context, req *ssov1.RevokeRoleRequest) (*ssov1.RevokeRoleResponse, error) {
+	if err := validateRoleChange(req.GetAccessToken(), req.GetAppId(), req.GetUserId(), req.GetRole()); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.RevokeRole(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetUserId(), req.GetRole()); err != nil {
+		return nil, roleError(err)
+	}
+
+	return &ssov1.RevokeRoleResponse{}, nil
+}
+
+// loginLockedError отвечает ResourceExhausted и передаёт в метаданных retry-after —
+// через сколько секунд можно повторить вход. grpc-gateway отдаёт его в заголовке Grpc-Metadata-Retry-After.
+func (s *serverAPI) ListOAuthConsents(ctx context.Context, req *ssov1.ListOAuthConsentsRequest) (*ssov1.ListOAuthConsentsResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	consents, err := s.auth.ListOAuthConsents(ctx, req.GetAccessToken(), int(req.GetAppId()))
+	if err != nil {
+		return nil, consentError(err)
+	}
+
+	resp := &ssov1.ListOAuthConsentsResponse{Consents: make([]*ssov1.OAuthConsent, 0, len(consents))}
+	for _, consent := range consents {
+		resp.Consents = append(resp.Consents, &ssov1.OAuthConsent{
+			ClientId:   int32(consent.AppID),
+			ClientName: consent.AppName,
+			Scopes:     consent.Scope,
+			GrantedAt:  consent.GrantedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) RevokeOAuthConsent(ctx context.Context, req *ssov1.RevokeOAuthConsentRequest) (*ssov1.RevokeOAuthConsentResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetClientId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "client_id is required")
+	}
+
+	if err := s.auth.RevokeOAuthConsent(ctx, req.GetAccessToken(), int(req.GetAppId()), int(req.GetClientId())); err != nil {
+		return nil, consentError(err)
+	}
+
+	return &ssov1.RevokeOAuthConsentResponse{}, nil
+}
+
+func (s *serverAPI) GetOpenIDConfiguration(_ context.Context, _ *ssov1.GetOpenIDConfigurationRequest) (*ssov1.GetOpenIDConfigurationResponse, error) {
+	cfg := s.auth.OpenIDConfiguration()
+
+	return &ssov1.GetOpenIDConfigurationResponse{
+		Issuer:                            cfg.Issuer,
+		AuthorizationEndpoint:             cfg.AuthorizationEndpoint,
+		TokenEndpoint:                     cfg.TokenEndpoint,
+		UserinfoEndpoint:                  cfg.UserInfoEndpoint,
+		JwksUri:                           cfg.JWKSURI,
+		ScopesSupported:                   cfg.ScopesSupported,
+		ResponseTypesSupported:            cfg.ResponseTypesSupported,
+		GrantTypesSupported:               cfg.GrantTypesSupported,
+		SubjectTypesSupported:             cfg.SubjectTypesSupported,
+		IdTokenSigningAlgValuesSupported:  cfg.IDTokenSigningAlgValuesSupported,
+		TokenEndpointAuthMethodsSupported: cfg.TokenEndpointAuthMethodsSupported,
+		CodeChallengeMethodsSupported:     cfg.CodeChallengeMethodsSupported,
+		ClaimsSupported:                   cfg.ClaimsSupported,
+	}, nil
+}
+
+func (s *serverAPI) UserInfo(ctx context.Context, req *ssov1.UserInfoRequest) (*ssov1.UserInfoResponse, error) {
+	accessToken := req.GetAccessToken()
+	if accessToken == "" {
+		accessToken = bearerToken(ctx)
+	}
+	if accessToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "access token is required")
+	}
+
+	info, err := s.auth.UserInfo(ctx, accessToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidAccessToken):
+			return nil, status.Error(codes.Unauthenticated, "invalid access token")
+		case errors.Is(err, auth.ErrInsufficientScope):
+			return nil, status.Error(codes.PermissionDenied, "access token does not grant the openid scope")
+		default:
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	resp := &ssov1.UserInfoResponse{Sub: info.Subject}
+	if info.Email != "" {
+		resp.Email = &info.Email
+		resp.EmailVerified = &info.EmailVerified
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) CreateApp(ctx context.Context, req *ssov1.CreateAppRequest) (*ssov1.CreateAppResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	app, err := s.auth.CreateApp(ctx, req.GetAccessToken(), int(req.GetAppId()), appSettings(req.GetSettings()))
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	resp := &ssov1.CreateAppResponse{App: appMessage(app)}
+	// публичный клиент не может хранить секрет, поэтому и не получает его
+	if app.ClientType == models.ClientTypeConfidential {
+		resp.Secret = app.Secret
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) ListApps(ctx context.Context, req *ssov1.ListAppsRequest) (*ssov1.ListAppsResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	apps, err := s.auth.ListApps(ctx, req.GetAccessToken(), int(req.GetAppId()))
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	resp := &ssov1.ListAppsResponse{Apps: make([]*ssov1.App, 0, len(apps))}
+	for _, app := range apps {
+		resp.Apps = append(resp.Apps, appMessage(app))
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) UpdateApp(ctx context.Context, req *ssov1.UpdateAppRequest) (*ssov1.UpdateAppResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetTargetAppId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "target_app_id is required")
+	}
+
+	app, err := s.auth.UpdateApp(ctx, req.GetAccessToken(), int(req.GetAppId()), int(req.GetTargetAppId()), appSettings(req.GetSettings()))
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return &ssov1.UpdateAppResponse{App: appMessage(app)}, nil
+}
+
+func (s *serverAPI) RotateAppSecret(ctx context.Context, req *ssov1.RotateAppSecretRequest) (*ssov1.RotateAppSecretResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetTargetAppId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "target_app_id is required")
+	}
+
+	secret, err := s.auth.RotateAppSecret(ctx, req.GetAccessToken(), int(req.GetAppId()), int(req.GetTargetAppId()))
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return &ssov1.RotateAppSecretResponse{Secret: secret}, nil
+}
+
+func (s *serverAPI) DisableApp(ctx context.Context, req *ssov1.DisableAppRequest) (*ssov1.DisableAppResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetTargetAppId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "target_app_id is required")
+	}
+
+	if err := s.auth.DisableApp(ctx, req.GetAccessToken(), int(req.GetAppId()), int(req.GetTargetAppId())); err != nil {
+		return nil, appError(err)
+	}
+
+	return &ssov1.DisableAppResponse{}, nil
+}
+
+func (s *serverAPI) GetProfile(ctx context.Context, req *ssov1.GetProfileRequest) (*ssov1.GetProfileResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	user, err := s.auth.GetProfile(ctx, req.GetAccessToken(), int(req.GetAppId()))
+	if err != nil {
+		return nil, profileError(err)
+	}
+
+	return &ssov1.GetProfileResponse{Profile: profileMessage(user)}, nil
+}
+
+func (s *serverAPI) UpdateProfile(ctx context.Context, req *ssov1.UpdateProfileRequest) (*ssov1.UpdateProfileResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	user, err := s.auth.UpdateProfile(ctx, req.GetAccessToken(), int(req.GetAppId()), auth.Profile{
+		DisplayName: req.GetDisplayName(),
+		Username:    req.GetUsername(),
+		AvatarURL:   req.GetAvatarUrl(),
+		Bio:         req.GetBio(),
+	})
+	if err != nil {
+		return nil, profileError(err)
+	}
+
+	return &ssov1.UpdateProfileResponse{Profile: profileMessage(user)}, nil
+}
+
+func (s *serverAPI) ChangePassword(ctx context.Context, req *ssov1.ChangePasswordRequest) (*ssov1.ChangePasswordResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetCurrentPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "current_password is required")
+	}
+	if req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "new_password is required")
+	}
+
+	accessToken, refreshToken, err := s.auth.ChangePassword(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetCurrentPassword(), req.GetNewPassword())
+	if err != nil {
+		var weak *auth.PasswordPolicyError
+		if errors.As(err, &weak) {
+			return nil, weakPasswordError("new_password", weak)
+		}
+		var locked *auth.LoginLockedError
+		if errors.As(err, &locked) {
+			return nil, loginLockedError(ctx, locked)
+		}
+		return nil, profileError(err)
+	}
+
+	return &ssov1.ChangePasswordResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *serverAPI) ChangeEmail(ctx context.Context, req *ssov1.ChangeEmailRequest) (*ssov1.ChangeEmailResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required")
+	}
+	if req.GetNewEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "new_email is required")
+	}
+	// как и при регистрации, принимается только голый адрес
+	if addr, err := mail.ParseAddress(req.GetNewEmail()); err != nil || addr.Address != req.GetNewEmail() {
+		return nil, status.Error(codes.InvalidArgument, "new_email is invalid")
+	}
+
+	if err := s.auth.ChangeEmail(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetPassword(), req.GetNewEmail()); err != nil {
+		var locked *auth.LoginLockedError
+		if errors.As(err, &locked) {
+			return nil, loginLockedError(ctx, locked)
+		}
+		return nil, profileError(err)
+	}
+
+	return &ssov1.ChangeEmailResponse{}, nil
+}
+
+func (s *serverAPI) ConfirmEmailChange(ctx context.Context, req *ssov1.ConfirmEmailChangeRequest) (*ssov1.ConfirmEmailChangeResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if err := s.auth.ConfirmEmailChange(ctx, req.GetToken()); err != nil {
+		return nil, profileError(err)
+	}
+
+	return &ssov1.ConfirmEmailChangeResponse{}, nil
+}
+
+func (s *serverAPI) DeleteAccount(ctx context.Context, req *ssov1.DeleteAccountRequest) (*ssov1.DeleteAccountResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetUserId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is invalid")
+	}
+	// удаление своего аккаунта подтверждается паролем
+	if req.GetUserId() == emptyValue && req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required")
+	}
+
+	if err := s.auth.DeleteAccount(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetPassword(), req.GetUserId()); err != nil {
+		var locked *auth.LoginLockedError
+		if errors.As(err, &locked) {
+			return nil, loginLockedError(ctx, locked)
+		}
+		return nil, accountError(err)
+	}
+
+	return &ssov1.DeleteAccountResponse{}, nil
+}
+
+func (s *serverAPI) ListAccountErasures(ctx context.Context, req *ssov1.ListAccountErasuresRequest) (*ssov1.ListAccountErasuresResponse, error) {
+	if req.GetAfterId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "after_id is invalid")
+	}
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit is invalid")
+	}
+
+	erasures, err := s.auth.AccountErasures(ctx, req.GetAfterId(), int(req.GetLimit()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	resp := &ssov1.ListAccountErasuresResponse{Erasures: make([]*ssov1.AccountErasure, 0, len(erasures))}
+	for _, erasure := range erasures {
+		resp.Erasures = append(resp.Erasures, &ssov1.AccountErasure{
+			Id:       erasure.ID,
+			UserId:   erasure.UserID,
+			ErasedAt: erasure.CreatedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) ExportUserData(ctx context.Context, req *ssov1.ExportUserDataRequest) (*ssov1.ExportUserDataResponse, error) {
+	if req.GetUserId() <= emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	user, records, err := s.auth.ExportUserData(ctx, req.GetUserId())
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	resp := &ssov1.ExportUserDataResponse{
+		Profile:       profileMessage(user),
+		RefreshTokens: make([]*ssov1.RefreshTokenRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		var rotatedAt int64
+		if !record.RotatedAt.IsZero() {
+			rotatedAt = record.RotatedAt.Unix()
+		}
+		resp.RefreshTokens = append(resp.RefreshTokens, &ssov1.RefreshTokenRecord{
+			Id:               record.ID,
+			AppId:            int32(record.AppID),
+			SessionId:        record.SessionID,
+			Ip:               record.Client.IP,
+			UserAgent:        record.Client.UserAgent,
+			SessionCreatedAt: record.SessionCreatedAt.Unix(),
+			CreatedAt:        record.CreatedAt.Unix(),
+			ExpiresAt:        record.ExpiresAt.Unix(),
+			RotatedAt:        rotatedAt,
+			Revoked:          record.Revoked,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) ListAuditEvents(ctx context.Context, req *ssov1.ListAuditEventsRequest) (*ssov1.ListAuditEventsResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	filter := models.SecurityEventFilter{
+		UserID:  req.GetFilter().GetUserId(),
+		AppID:   int(req.GetFilter().GetAppId()),
+		Type:    req.GetFilter().GetType(),
+		Outcome: req.GetFilter().GetOutcome(),
+	}
+	if since := req.GetFilter().GetSince(); since > 0 {
+		filter.Since = time.Unix(since, 0)
+	}
+	if until := req.GetFilter().GetUntil(); until > 0 {
+		filter.Until = time.Unix(until, 0)
+	}
+
+	events, nextPageToken, err := s.auth.ListAuditEvents(ctx, req.GetAccessToken(), int(req.GetAppId()), filter, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, auditError(err)
+	}
+
+	resp := &ssov1.ListAuditEventsResponse{
+		Events:        make([]*ssov1.AuditEvent, 0, len(events)),
+		NextPageToken: nextPageToken,
+	}
+	for _, event := range events {
+		details, err := json.Marshal(event.Details)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		resp.Events = append(resp.Events, &ssov1.AuditEvent{
+			Id:        event.ID,
+			Type:      event.Type,
+			Outcome:   event.Outcome,
+			UserId:    event.UserID,
+			AppId:     int32(event.AppID),
+			Ip:        event.Client.IP,
+			UserAgent: event.Client.UserAgent,
+			Details:   string(details),
+			CreatedAt: event.CreatedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) BanUser(ctx context.Context, req *ssov1.BanUserRequest) (*ssov1.BanUserResponse, error) {
+	if err := validateBan(req.GetAccessToken(), req.GetAppId(), req.GetUserId(), req.GetReason()); err != nil {
+		return nil, err
+	}
+	if req.GetExpiresAt() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expires_at is invalid")
+	}
+
+	var expiresAt time.Time
+	if req.GetExpiresAt() > 0 {
+		expiresAt = time.Unix(req.GetExpiresAt(), 0)
+	}
+
+	ban, err := s.auth.BanUser(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetUserId(), req.GetReason(), expiresAt)
+	if err != nil {
+		return nil, banError(err)
+	}
+
+	return &ssov1.BanUserResponse{Ban: banMessage(ban)}, nil
+}
+
+func (s *serverAPI) SuspendUser(ctx context.Context, req *ssov1.SuspendUserRequest) (*ssov1.SuspendUserResponse, error) {
+	if err := validateBan(req.GetAccessToken(), req.GetAppId(), req.GetUserId(), req.GetReason()); err != nil {
+		return nil, err
+	}
+	if req.GetExpiresAt() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expires_at is required")
+	}
+
+	ban, err := s.auth.SuspendUser(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetUserId(), req.GetReason(), time.Unix(req.GetExpiresAt(), 0))
+	if err != nil {
+		return nil, banError(err)
+	}
+
+	return &ssov1.SuspendUserResponse{Ban: banMessage(ban)}, nil
+}
+
+func (s *serverAPI) UnbanUser(ctx context.Context, req *ssov1.UnbanUserRequest) (*ssov1.UnbanUserResponse, error) {
+	if err := validateAccessToken(req.GetAccessToken(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+	if req.GetUserId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if err := s.auth.UnbanUser(ctx, req.GetAccessToken(), int(req.GetAppId()), req.GetUserId()); err != nil {
+		return nil, banError(err)
+	}
+
+	return &ssov1.UnbanUserResponse{}, nil
+}
+
+func (s *serverAPI) ListActiveBans(ctx context.Context, _ *ssov1.ListActiveBansRequest) (*ssov1.ListActiveBansResponse, error) {
+	bans, err := s.auth.ActiveBans(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	resp := &ssov1.ListActiveBansResponse{Bans: make([]*ssov1.UserBan, 0, len(bans))}
+	for _, ban := range bans {
+		resp.Bans = append(resp.Bans, banMessage(ban))
+	}
+
+	return resp, nil
+}
+
+func banMessage(ban models.Ban) *ssov1.UserBan {
+	var expiresAt int64
+	if !ban.ExpiresAt.IsZero() {
+		expiresAt = ban.ExpiresAt.Unix()
+	}
+
+	return &ssov1.UserBan{
+		UserId:    ban.UserID,
+		Kind:      ban.Kind,
+		Reason:    ban.Reason,
+		ExpiresAt: expiresAt,
+		BannedBy:  ban.BannedBy,
+		CreatedAt: ban.CreatedAt.Unix(),
+	}
+}
+
+func profileMessage(user models.User) *ssov1.Profile {
+	return &ssov1.Profile{
+		UserId:        user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		DisplayName:   user.DisplayName,
+		Username:      user.Username,
+		AvatarUrl:     user.AvatarURL,
+		Bio:           user.Bio,
+		CreatedAt:     user.CreatedAt.Unix(),
+		UpdatedAt:     user.UpdatedAt.Unix(),
+	}
+}
+
+func appSettings(settings *ssov1.AppSettings) auth.AppSettings {
+	return auth.AppSettings{
+		Name:            settings.GetName(),
+		ClientType:      settings.GetClientType(),
+		RedirectURIs:    settings.GetRedirectUris(),
+		AllowedOrigins:  settings.GetAllowedOrigins(),
+		AccessTokenTTL:  time.Duration(settings.GetAccessTokenTtlSeconds()) * time.Second,
+		RefreshTokenTTL: time.Duration(settings.GetRefreshTokenTtlSeconds()) * time.Second,
+	}
+}
+
+// appMessage переводит приложение в ответ без секрета
+func appMessage(app models.App) *ssov1.App {
+	return &ssov1.App{
+		Id: int32(app.ID),
+		Settings: &ssov1.AppSettings{
+			Name:                   app.Name,
+			ClientType:             app.ClientType,
+			RedirectUris:           app.RedirectURIs,
+			AllowedOrigins:         app.AllowedOrigins,
+			AccessTokenTtlSeconds:  int64(app.AccessTokenTTL / time.Second),
+			RefreshTokenTtlSeconds: int64(app.RefreshTokenTTL / time.Second),
+		},
+		Disabled:  app.Disabled,
+		CreatedAt: app.CreatedAt.Unix(),
+		UpdatedAt: app.UpdatedAt.Unix(),
+	}
+}
+
+// bearerToken достаёт токен из заголовка Authorization: Bearer, который grpc-gateway
+// передаёт в метаданных authorization
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	for _, value := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(value, "Bearer "); ok {
+			return token
+		}
+	}
+
+	return ""
+}
+
+func loginLockedError(ctx context.Context, locked *auth.LoginLockedError) error {
+	retryAfter := int64(math.Ceil(locked.RetryAfter.Seconds()))
+	if err := grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(retryAfter, 10))); err != nil {
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return status.Errorf(codes.ResourceExhausted, "too many failed login attempts, retry after %d seconds", retryAfter)
+}
+
+// userBannedError отвечает PermissionDenied; срок приостановки или временного бана попадает в сообщение
+func userBannedError(banned *auth.UserBannedError) error {
+	verb := "banned"
+	if banned.Kind == models.BanKindSuspension {
+		verb = "suspended"
+	}
+
+	if banned.ExpiresAt.IsZero() {
+		return status.Errorf(codes.PermissionDenied, "user is %s", verb)
+	}
+	return status.Errorf(codes.PermissionDenied, "user is %s until %s", verb, banned.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// weakPasswordError возвращает InvalidArgument с BadRequest, в котором каждое нарушенное правило
+// политики паролей — отдельный FieldViolation с кодом правила в Reason
+func weakPasswordError(field string, weak *auth.PasswordPolicyError) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range weak.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: v.Description,
+			Reason:      v.Rule,
+		})
+	}
+
+	st, err := status.New(codes.InvalidArgument, "password does not meet policy").WithDetails(badRequest)
+	if err != nil {
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return st.Err()
+}
+
+// roleError переводит ошибки управления ролями в gRPC-статусы
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, auth.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, auth.ErrRoleNotFound):
+		return status.Error(codes.InvalidArgument, "unknown role")
+	case errors.Is(err, auth.ErrRoleNotAssigned):
+		return status.Error(codes.NotFound, "role is not assigned to user")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// lockoutError переводит ошибки управления блокировками входа в gRPC-статусы
+func lockoutError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, auth.ErrInvalidLockoutKind):
+		return status.Error(codes.InvalidArgument, "kind must be email or ip")
+	case errors.Is(err, auth.ErrLockoutNotFound):
+		return status.Error(codes.NotFound, "lockout not found")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// appError переводит ошибки управления приложениями в gRPC-статусы
+func appError(err error) error {
+	var invalid *auth.AppSettingsError
+	if errors.As(err, &invalid) {
+		st, detailsErr := status.New(codes.InvalidArgument, "invalid app settings").WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       "settings." + invalid.Field,
+				Description: invalid.Description,
+			}},
+		})
+		if detailsErr != nil {
+			return status.Error(codes.Internal, "internal server error")
+		}
+		return st.Err()
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, auth.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, auth.ErrAppExists):
+		return status.Error(codes.AlreadyExists, "app with this name already exists")
+	case errors.Is(err, auth.ErrDisableCurrentApp):
+		return status.Error(codes.FailedPrecondition, "cannot disable the app of the current session")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// profileError переводит ошибки профиля, смены пароля и email в gRPC-статусы
+func profileError(err error) error {
+	var invalid *auth.ProfileError
+	if errors.As(err, &invalid) {
+		st, detailsErr := status.New(codes.InvalidArgument, "invalid profile").WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       invalid.Field,
+				Description: invalid.Description,
+			}},
+		})
+		if detailsErr != nil {
+			return status.Error(codes.Internal, "internal server error")
+		}
+		return st.Err()
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return status.Error(codes.InvalidArgument, "invalid password")
+	case errors.Is(err, auth.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, auth.ErrUsernameTaken):
+		return status.Error(codes.AlreadyExists, "username already taken")
+	case errors.Is(err, auth.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, "email already in use")
+	case errors.Is(err, auth.ErrEmailUnchanged):
+		return status.Error(codes.InvalidArgument, "new email matches the current one")
+	case errors.Is(err, auth.ErrInvalidVerifyToken):
+		return status.Error(codes.InvalidArgument, "invalid or expired email change token")
+	case errors.Is(err, auth.ErrAppDisabled):
+		return status.Error(codes.PermissionDenied, "app is disabled")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// accountError переводит ошибки удаления аккаунта в gRPC-статусы
+func accountError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return status.Error(codes.InvalidArgument, "invalid password")
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, auth.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// auditError переводит ошибки чтения журнала аудита в gRPC-статусы
+func auditError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, auth.ErrInvalidAuditFilter):
+		return status.Error(codes.InvalidArgument, "outcome must be success or failure, since must be before until")
+	case errors.Is(err, auth.ErrInvalidPageToken):
+		return status.Error(codes.InvalidArgument, "invalid page_token")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// banError переводит ошибки управления банами в gRPC-статусы
+func banError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, auth.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, auth.ErrUserNotBanned):
+		return status.Error(codes.NotFound, "user is not banned")
+	case errors.Is(err, auth.ErrInvalidBanExpiry):
+		return status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	case errors.Is(err, auth.ErrCannotBanSelf):
+		return status.Error(codes.FailedPrecondition, "cannot ban yourself")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// consentError переводит ошибки управления согласиями OAuth в gRPC-статусы
+func consentError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrConsentNotFound):
+		return status.Error(codes.NotFound, "oauth consent not found")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// sessionError переводит ошибки управления сессиями в gRPC-статусы
+func sessionError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrSessionNotFound):
+		return status.Error(codes.NotFound, "session not found")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// mfaError переводит ошибки второго фактора в gRPC-статусы
+func mfaError(err error) error {
+	var banned *auth.UserBannedError
+	if errors.As(err, &banned) {
+		return userBannedError(banned)
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, "invalid access token")
+	case errors.Is(err, auth.ErrInvalidMFAToken):
+		return status.Error(codes.Unauthenticated, "invalid or expired mfa token")
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		return status.Error(codes.InvalidArgument, "invalid mfa code")
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		return status.Error(codes.FailedPrecondition, "mfa is already enabled")
+	case errors.Is(err, auth.ErrMFANotEnabled):
+		return status.Error(codes.FailedPrecondition, "mfa is not enabled")
+	case errors.Is(err, auth.ErrAppDisabled):
+		return status.Error(codes.PermissionDenied, "app is disabled")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
 }
 
 func validateLogin(req *ssov1.LoginRequest) error {
@@ -139,6 +1359,10 @@ func validateRegister(req *ssov1.RegisterRequest) error {
 	if req.GetEmail() == "" {
 		return status.Error(codes.InvalidArgument, "email is required")
 	}
+	// принимается только голый адрес, без отображаемого имени вида "Name <user@host>"
+	if addr, err := mail.ParseAddress(req.GetEmail()); err != nil || addr.Address != req.GetEmail() {
+		return status.Error(codes.InvalidArgument, "email is invalid")
+	}
 	if req.GetPassword() == "" {
 		return status.Error(codes.InvalidArgument, "password is required")
 	}
@@ -153,3 +1377,64 @@ func validateIsAdmin(req *ssov1.IsAdminRequest) error {
 
 	return nil
 }
+
+func validateResetPassword(req *ssov1.ResetPasswordRequest) error {
+	if req.GetToken() == "" {
+		return status.Error(codes.InvalidArgument, "token is required")
+	}
+	if req.GetNewPassword() == "" {
+		return status.Error(codes.InvalidArgument, "new_password is required")
+	}
+
+	return nil
+}
+
+func validateVerifyMFA(req *ssov1.VerifyMFARequest) error {
+	if req.GetMfaToken() == "" {
+		return status.Error(codes.InvalidArgument, "mfa_token is required")
+	}
+	if req.GetCode() == "" {
+		return status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	return nil
+}
+
+func validateRoleChange(accessToken string, appID int32, userID int64, role string) error {
+	if err := validateAccessToken(accessToken, appID); err != nil {
+		return err
+	}
+	if userID == emptyValue {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if role == "" {
+		return status.Error(codes.InvalidArgument, "role is required")
+	}
+
+	return nil
+}
+
+func validateBan(accessToken string, appID int32, userID int64, reason string) error {
+	if err := validateAccessToken(accessToken, appID); err != nil {
+		return err
+	}
+	if userID == emptyValue {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	return nil
+}
+
+func validateAccessToken(accessToken string, appID int32) error {
+	if accessToken == "" {
+		return status.Error(codes.InvalidArgument, "access_token is required")
+	}
+	if appID == emptyValue {
+		return status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/14kear/forum-project/auth-service/internal/services/auth"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"slices"
+	"strconv"
+)
+
+// Метаданные, в которых сервисы передают id своего приложения и его секрет
+const (
+	AppIDMetadataKey     = "x-app-id"
+	AppSecretMetadataKey = "x-app-secret"
+)
+
+// internalMethods — RPC для других сервисов системы, а не для пользователей. Они отдают данные
+// любых пользователей без access token, поэтому вызывающий сервис подтверждает себя секретом
+// своего приложения, и это приложение должно быть в списке сервисных. Секрет есть и у сторонних
+// OAuth-клиентов, поэтому одного секрета недостаточно. Эти RPC не публикуются через grpc-gateway,
+// а gRPC-порт auth-service не должен быть доступен снаружи; проверка учётных данных — вторая линия защиты.
+var internalMethods = map[string]bool{
+	ssov1.Auth_ListAccountErasures_FullMethodName: true,
+	ssov1.Auth_ExportUserData_FullMethodName:      true,
+	ssov1.Auth_ListActiveBans_FullMethodName:      true,
+}
+
+// AppAuthenticator проверяет учётные данные приложения вызывающего сервиса
+type AppAuthenticator interface {
+	AuthenticateApp(ctx context.Context, appID int, secret string) error
+}
+
+// ServiceAuthInterceptor пропускает к внутренним RPC только вызовы сервисных приложений serviceAppIDs
+// с действующими учётными данными в метаданных x-app-id и x-app-secret. Остальные RPC не затрагиваются.
+func ServiceAuthInterceptor(authenticator AppAuthenticator, serviceAppIDs []int) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !internalMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		appIDs, secrets := md.Get(AppIDMetadataKey), md.Get(AppSecretMetadataKey)
+		if len(appIDs) != 1 || len(secrets) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "service credentials are required")
+		}
+
+		appID, err := strconv.Atoi(appIDs[0])
+		if err != nil || !slices.Contains(serviceAppIDs, appID) {
+			return nil, status.Error(codes.Unauthenticated, "invalid service credentials")
+		}
+
+		if err := authenticator.AuthenticateApp(ctx, appID, secrets[0]); err != nil {
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				return nil, status.Error(codes.Unauthenticated, "invalid service credentials")
+			}
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/services/auth"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+// appSecrets принимает приложение, если его секрет совпадает
+type appSecrets map[int]string
+
+func (s appSecrets) AuthenticateApp(_ context.Context, appID int, secret string) error {
+	if expected, ok := s[appID]; !ok || expected != secret {
+		return fmt.Errorf("auth.AuthenticateApp: %w", auth.ErrInvalidCredentials)
+	}
+	return nil
+}
+
+func TestServiceAuthInterceptor(t *testing.T) {
+	const (
+		forumAppID  = 1
+		oauthClient = 5
+	)
+	interceptor := ServiceAuthInterceptor(appSecrets{forumAppID: "forum-secret", oauthClient: "client-secret"}, []int{forumAppID})
+
+	call := func(method string, kv ...string) error {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		return err
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		kv     []string
+		code   codes.Code
+	}{
+		{name: "service app", method: ssov1.Auth_ExportUserData_FullMethodName, kv: []string{"x-app-id", "1", "x-app-secret", "forum-secret"}, code: codes.OK},
+		{name: "oauth client with valid secret", method: ssov1.Auth_ExportUserData_FullMethodName, kv: []string{"x-app-id", "5", "x-app-secret", "client-secret"}, code: codes.Unauthenticated},
+		{name: "wrong secret", method: ssov1.Auth_ListActiveBans_FullMethodName, kv: []string{"x-app-id", "1", "x-app-secret", "client-secret"}, code: codes.Unauthenticated},
+		{name: "no credentials", method: ssov1.Auth_ListAccountErasures_FullMethodName, code: codes.Unauthenticated},
+		{name: "public rpc", method: ssov1.Auth_Login_FullMethodName, code: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := call(tt.method, tt.kv...)
+			if tt.code == codes.OK {
+				require.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
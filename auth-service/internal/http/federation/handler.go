@@ -0,0 +1,221 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/14kear/forum-project/auth-service/internal/services/auth"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Коды ошибок, которые страница фронтенда получает во фрагменте адреса
+const (
+	errorInvalidRequest        = "invalid_request"
+	errorAccessDenied          = "access_denied"
+	errorInvalidState          = "invalid_state"
+	errorLoginFailed           = "login_failed"
+	errorEmailMissing          = "email_missing"
+	errorAccountExists         = "account_exists"
+	errorIdentityAlreadyLinked = "identity_already_linked"
+	errorEmailNotVerified      = "email_not_verified"
+	errorServerError           = "server_error"
+)
+
+// Federation — часть сервиса auth, которая обслуживает вход через внешних провайдеров
+type Federation interface {
+	StartFederatedLogin(ctx context.Context, provider string, appID int) (string, error)
+	StartIdentityLink(ctx context.Context, accessToken string, appID int, provider string) (string, error)
+	CompleteFederatedLogin(ctx context.Context, provider, state, code string) (auth.FederatedLoginResult, error)
+}
+
+// Handler обслуживает /federation/{provider}/: вход через провайдера, его callback и явную привязку.
+// Как и /oauth/, эти эндпоинты работают с редиректами браузера и не проходят через grpc-gateway.
+type Handler struct {
+	log        *slog.Logger
+	federation Federation
+	resultURL  string
+	mux        *http.ServeMux
+}
+
+// New создаёт обработчик. resultURL — страница фронтенда, на которую callback возвращает пользователя:
+// токены, признак привязки, MFA-токен или ошибка передаются во фрагменте адреса, чтобы не попасть в логи.
+func New(log *slog.Logger, federation Federation, resultURL string) *Handler {
+	h := &Handler{
+		log:        log,
+		federation: federation,
+		resultURL:  resultURL,
+		mux:        http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /federation/{provider}/login", h.login)
+	h.mux.HandleFunc("POST /federation/{provider}/link", h.link)
+	h.mux.HandleFunc("GET /federation/{provider}/callback", h.callback)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type linkResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// login отправляет браузер на вход у провайдера; приложение, в которое входит пользователь, — в параметре app_id
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(r.URL.Query().Get("app_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, "app_id is required")
+		return
+	}
+
+	redirectTo, err := h.federation.StartFederatedLogin(r.Context(), r.PathValue("provider"), appID)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrUnknownIdentityProvider):
+			writeError(w, http.StatusNotFound, errorInvalidRequest, "unknown identity provider")
+		case errors.Is(err, auth.ErrAppNotFound):
+			writeError(w, http.StatusBadRequest, errorInvalidRequest, "unknown app_id")
+		case errors.Is(err, auth.ErrAppDisabled):
+			writeError(w, http.StatusForbidden, errorAccessDenied, "app is disabled")
+		default:
+			h.log.Error("failed to start federated login", sl.Err(err))
+			writeError(w, http.StatusBadGateway, errorServerError, "identity provider is unavailable")
+		}
+		return
+	}
+
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
+
+// link вызывается фронтендом от имени вошедшего пользователя: access token в заголовке Authorization,
+// приложение в параметре app_id. Возвращает адрес, на который нужно отправить браузер.
+func (h *Handler) link(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, "malformed form")
+		return
+	}
+
+	appID, err := strconv.Atoi(r.Form.Get("app_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, "app_id is required")
+		return
+	}
+
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || accessToken == "" {
+		writeError(w, http.StatusUnauthorized, "invalid_token", "access token is required")
+		return
+	}
+
+	redirectTo, err := h.federation.StartIdentityLink(r.Context(), accessToken, appID, r.PathValue("provider"))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidAccessToken):
+			writeError(w, http.StatusUnauthorized, "invalid_token", "invalid access token")
+		case errors.Is(err, auth.ErrUnknownIdentityProvider):
+			writeError(w, http.StatusNotFound, errorInvalidRequest, "unknown identity provider")
+		default:
+			h.log.Error("failed to start identity link", sl.Err(err))
+			writeError(w, http.StatusBadGateway, errorServerError, "identity provider is unavailable")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, linkResponse{RedirectTo: redirectTo})
+}
+
+// callback принимает пользователя от провайдера и возвращает его на страницу фронтенда
+func (h *Handler) callback(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	q := r.URL.Query()
+
+	// провайдер сообщил об ошибке, например пользователь отказался входить
+	if q.Get("error") != "" {
+		h.log.Info("identity provider returned an error", slog.String("provider", provider), slog.String("error", q.Get("error")))
+		h.redirectResult(w, r, url.Values{"error": {errorAccessDenied}})
+		return
+	}
+
+	if q.Get("state") == "" || q.Get("code") == "" {
+		h.redirectResult(w, r, url.Values{"error": {errorInvalidRequest}})
+		return
+	}
+
+	result, err := h.federation.CompleteFederatedLogin(r.Context(), provider, q.Get("state"), q.Get("code"))
+	if err != nil {
+		var challenge *auth.MFAChallengeError
+		if errors.As(err, &challenge) {
+			h.redirectResult(w, r, url.Values{
+				"mfa_token":      {challenge.Token},
+				"mfa_expires_at": {strconv.FormatInt(challenge.ExpiresAt.Unix(), 10)},
+			})
+			return
+		}
+
+		h.redirectResult(w, r, url.Values{"error": {h.errorCode(err)}})
+		return
+	}
+
+	if result.Linked {
+		h.redirectResult(w, r, url.Values{"linked": {provider}})
+		return
+	}
+
+	h.redirectResult(w, r, url.Values{
+		"access_token":  {result.AccessToken},
+		"refresh_token": {result.RefreshToken},
+		"user_id":       {strconv.FormatInt(result.UserID, 10)},
+	})
+}
+
+func (h *Handler) errorCode(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrUnknownIdentityProvider):
+		return errorInvalidRequest
+	case errors.Is(err, auth.ErrInvalidFederationState):
+		return errorInvalidState
+	case errors.Is(err, auth.ErrFederatedLoginFailed):
+		return errorLoginFailed
+	case errors.Is(err, auth.ErrFederatedEmailMissing):
+		return errorEmailMissing
+	case errors.Is(err, auth.ErrFederatedAccountExists):
+		return errorAccountExists
+	case errors.Is(err, auth.ErrIdentityAlreadyLinked):
+		return errorIdentityAlreadyLinked
+	case errors.Is(err, auth.ErrEmailNotVerified):
+		return errorEmailNotVerified
+	case errors.Is(err, auth.ErrAppDisabled), errors.Is(err, auth.ErrUserBanned):
+		return errorAccessDenied
+	default:
+		h.log.Error("failed to complete federated login", sl.Err(err))
+		return errorServerError
+	}
+}
+
+// redirectResult возвращает браузер на страницу фронтенда с параметрами во фрагменте
+func (h *Handler) redirectResult(w http.ResponseWriter, r *http.Request, params url.Values) {
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, h.resultURL+"#"+params.Encode(), http.StatusFound)
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, errorResponse{Error: code, ErrorDescription: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,280 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/services/auth"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeRefreshToken      = "refresh_token"
+)
+
+// OAuth — часть сервиса auth, которая обслуживает протокол OAuth 2.0
+type OAuth interface {
+	CheckAuthorizationRequest(ctx context.Context, req auth.AuthorizationRequest) (models.App, []string, error)
+	Authorize(ctx context.Context, accessToken string, appID int, req auth.AuthorizationRequest, decision string) (auth.AuthorizationResult, error)
+	ExchangeAuthorizationCode(ctx context.Context, clientID int, clientSecret, code, redirectURI, codeVerifier string) (auth.OAuthTokens, error)
+	RefreshOAuthTokens(ctx context.Context, clientID int, clientSecret, refreshToken, scope string) (auth.OAuthTokens, error)
+}
+
+// Handler обслуживает /oauth/authorize и /oauth/token. Эти эндпоинты работают с редиректами
+// браузера и form-urlencoded запросами клиентов, поэтому они не проходят через grpc-gateway.
+type Handler struct {
+	log        *slog.Logger
+	oauth      OAuth
+	consentURL string
+	mux        *http.ServeMux
+}
+
+// New создаёт обработчик. consentURL — страница согласия фронтенда, на которую GET /oauth/authorize
+// перенаправляет пользователя с исходными параметрами запроса.
+func New(log *slog.Logger, oauth OAuth, consentURL string) *Handler {
+	h := &Handler{
+		log:        log,
+		oauth:      oauth,
+		consentURL: consentURL,
+		mux:        http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /oauth/authorize", h.authorizePage)
+	h.mux.HandleFunc("POST /oauth/authorize", h.authorize)
+	h.mux.HandleFunc("POST /oauth/token", h.token)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type authorizeResponse struct {
+	RedirectTo      string            `json:"redirect_to,omitempty"`
+	ConsentRequired bool              `json:"consent_required,omitempty"`
+	ClientName      string            `json:"client_name,omitempty"`
+	Scope           []string          `json:"scope,omitempty"`
+	ScopeDetails    map[string]string `json:"scope_descriptions,omitempty"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// authorizePage проверяет запрос клиента и отправляет пользователя на страницу согласия.
+// Ошибки клиента и redirect_uri показываются пользователю: перенаправлять на непроверенный адрес нельзя.
+func (h *Handler) authorizePage(w http.ResponseWriter, r *http.Request) {
+	req, err := authorizationRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, err.Error())
+		return
+	}
+
+	if _, _, err := h.oauth.CheckAuthorizationRequest(r.Context(), req); err != nil {
+		if h.writeAuthorizeError(w, r, req, err) {
+			return
+		}
+		h.log.Error("failed to check authorization request", sl.Err(err))
+		writeError(w, http.StatusInternalServerError, auth.OAuthServerError, "")
+		return
+	}
+
+	http.Redirect(w, r, h.consentURL+"?"+r.URL.RawQuery, http.StatusFound)
+}
+
+// authorize вызывается страницей согласия от имени вошедшего пользователя: access token фронтенда
+// передаётся в заголовке Authorization, приложение фронтенда — в параметре app_id.
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "malformed form")
+		return
+	}
+
+	req, err := authorizationRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, err.Error())
+		return
+	}
+
+	appID, err := strconv.Atoi(r.Form.Get("app_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "app_id is required")
+		return
+	}
+
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || accessToken == "" {
+		writeError(w, http.StatusUnauthorized, "invalid_token", "access token is required")
+		return
+	}
+
+	result, err := h.oauth.Authorize(r.Context(), accessToken, appID, req, r.Form.Get("decision"))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidAccessToken):
+			writeError(w, http.StatusUnauthorized, "invalid_token", "invalid access token")
+		case errors.Is(err, auth.ErrInvalidConsentDecision):
+			writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, auth.ErrInvalidConsentDecision.Error())
+		case h.writeAuthorizeError(w, r, req, err):
+		default:
+			h.log.Error("failed to authorize oauth client", sl.Err(err))
+			writeError(w, http.StatusInternalServerError, auth.OAuthServerError, "")
+		}
+		return
+	}
+
+	resp := authorizeResponse{
+		RedirectTo:      result.RedirectTo,
+		ConsentRequired: result.ConsentRequired,
+	}
+	if result.ConsentRequired {
+		resp.ClientName = result.ClientName
+		resp.Scope = result.Scope
+		resp.ScopeDetails = make(map[string]string, len(result.Scope))
+		for _, s := range result.Scope {
+			resp.ScopeDetails[s] = auth.OAuthScopes[s]
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeAuthorizeError отвечает на ошибку проверки запроса авторизации и сообщает, была ли ошибка обработана
+func (h *Handler) writeAuthorizeError(w http.ResponseWriter, r *http.Request, req auth.AuthorizationRequest, err error) bool {
+	var oauthErr *auth.OAuthError
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidOAuthClient):
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidClient, "unknown client_id")
+	case errors.Is(err, auth.ErrInvalidRedirectURI):
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "redirect_uri is not registered for the client")
+	case errors.As(err, &oauthErr):
+		if r.Method == http.MethodGet {
+			http.Redirect(w, r, req.ErrorRedirect(oauthErr), http.StatusFound)
+		} else {
+			writeJSON(w, http.StatusOK, authorizeResponse{RedirectTo: req.ErrorRedirect(oauthErr)})
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// token обменивает код авторизации или refresh token на токены (RFC 6749, раздел 3.2).
+// Клиент аутентифицируется заголовком Basic или параметрами client_id и client_secret.
+func (h *Handler) token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "malformed form")
+		return
+	}
+
+	clientID, clientSecret, basic := r.BasicAuth()
+	if !basic {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	id, err := strconv.Atoi(clientID)
+	if err != nil {
+		writeClientError(w, basic)
+		return
+	}
+
+	var tokens auth.OAuthTokens
+
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case grantTypeAuthorizationCode:
+		tokens, err = h.oauth.ExchangeAuthorizationCode(r.Context(), id, clientSecret,
+			r.PostForm.Get("code"), r.PostForm.Get("redirect_uri"), r.PostForm.Get("code_verifier"))
+	case grantTypeRefreshToken:
+		tokens, err = h.oauth.RefreshOAuthTokens(r.Context(), id, clientSecret,
+			r.PostForm.Get("refresh_token"), r.PostForm.Get("scope"))
+	case "":
+		writeError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "grant_type is required")
+		return
+	default:
+		writeError(w, http.StatusBadRequest, auth.OAuthUnsupportedGrantType, "unsupported grant_type "+grantType)
+		return
+	}
+
+	if err != nil {
+		var oauthErr *auth.OAuthError
+		switch {
+		case errors.As(err, &oauthErr) && oauthErr.Code == auth.OAuthInvalidClient:
+			writeClientError(w, basic)
+		case errors.As(err, &oauthErr):
+			writeError(w, http.StatusBadRequest, oauthErr.Code, oauthErr.Description)
+		default:
+			h.log.Error("failed to issue oauth tokens", sl.Err(err))
+			writeError(w, http.StatusInternalServerError, auth.OAuthServerError, "")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      tokens.IDToken,
+		Scope:        strings.Join(tokens.Scope, " "),
+	})
+}
+
+func authorizationRequest(r *http.Request) (auth.AuthorizationRequest, error) {
+	clientID, err := strconv.Atoi(r.FormValue("client_id"))
+	if err != nil {
+		return auth.AuthorizationRequest{}, errors.New("client_id is required")
+	}
+
+	return auth.AuthorizationRequest{
+		ClientID:            clientID,
+		RedirectURI:         r.FormValue("redirect_uri"),
+		ResponseType:        r.FormValue("response_type"),
+		Scope:               r.FormValue("scope"),
+		State:               r.FormValue("state"),
+		CodeChallenge:       r.FormValue("code_challenge"),
+		CodeChallengeMethod: r.FormValue("code_challenge_method"),
+		Nonce:               r.FormValue("nonce"),
+	}, nil
+}
+
+// writeClientError отвечает invalid_client; клиенту, приславшему Basic, RFC 6749 требует вернуть 401
+func writeClientError(w http.ResponseWriter, basic bool) {
+	if basic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		writeError(w, http.StatusUnauthorized, auth.OAuthInvalidClient, "client authentication failed")
+		return
+	}
+	writeError(w, http.StatusBadRequest, auth.OAuthInvalidClient, "client authentication failed")
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, errorResponse{Error: code, ErrorDescription: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
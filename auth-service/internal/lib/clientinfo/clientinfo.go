@@ -0,0 +1,128 @@
+package clientinfo
+
+import (
+	"context"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// NewContext сохраняет данные о клиенте в контексте
+func NewContext(ctx context.Context, info models.ClientInfo) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// FromContext возвращает данные о клиенте; если их нет, поля пустые
+func FromContext(ctx context.Context) models.ClientInfo {
+	info, _ := ctx.Value(ctxKey{}).(models.ClientInfo)
+	return info
+}
+
+// ParseTrustedProxies разбирает адреса и подсети прокси, которым доверяется x-forwarded-for
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	proxies := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return proxies, nil
+}
+
+// UnaryServerInterceptor заполняет данные о клиенте из метаданных gRPC-запроса.
+// x-forwarded-for учитывается только от доверенных прокси trustedProxies.
+func UnaryServerInterceptor(trustedProxies []netip.Prefix) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(NewContext(ctx, FromIncomingContext(ctx, trustedProxies)), req)
+	}
+}
+
+// FromIncomingContext извлекает IP и User-Agent клиента. IP — адрес соединения; если соединение
+// пришло от доверенного прокси (например, grpc-gateway), IP берётся из x-forwarded-for справа
+// налево: первый адрес, добавленный не доверенным прокси. Записи левее клиент мог подставить сам,
+// поэтому они не используются. User-Agent grpc-gateway передаёт в grpcgateway-user-agent;
+// он берётся со слов клиента и годится для показа пользователю, но не для проверок доступа.
+func FromIncomingContext(ctx context.Context, trustedProxies []netip.Prefix) models.ClientInfo {
+	var info models.ClientInfo
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(info.IP); err == nil {
+			info.IP = host
+		}
+	}
+
+	if trusted(info.IP, trustedProxies) {
+		hops := forwardedHops(md)
+		for i := len(hops) - 1; i >= 0; i-- {
+			info.IP = hops[i]
+			if !trusted(hops[i], trustedProxies) {
+				break
+			}
+		}
+	}
+
+	info.UserAgent = first(md, "grpcgateway-user-agent")
+	if info.UserAgent == "" {
+		info.UserAgent = first(md, "user-agent")
+	}
+
+	return info
+}
+
+// forwardedHops возвращает адреса из всех заголовков x-forwarded-for по порядку
+func forwardedHops(md metadata.MD) []string {
+	var hops []string
+	for _, value := range md.Get("x-forwarded-for") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+func trusted(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
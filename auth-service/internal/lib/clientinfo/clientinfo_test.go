@@ -0,0 +1,76 @@
+package clientinfo
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func testProxies(t *testing.T) []netip.Prefix {
+	proxies, err := ParseTrustedProxies([]string{"127.0.0.1/32", "::1/128", "10.0.0.0/8"})
+	require.NoError(t, err)
+	return proxies
+}
+
+func incoming(peerIP string, pairs ...string) context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(peerIP), Port: 50000}})
+}
+
+func TestFromIncomingContext_Gateway(t *testing.T) {
+	ctx := incoming("127.0.0.1",
+		"x-forwarded-for", "203.0.113.7, 10.0.0.1",
+		"grpcgateway-user-agent", "Mozilla/5.0",
+		"user-agent", "grpc-go/1.71.0",
+	)
+
+	info := FromIncomingContext(ctx, testProxies(t))
+	assert.Equal(t, "203.0.113.7", info.IP)
+	assert.Equal(t, "Mozilla/5.0", info.UserAgent)
+}
+
+func TestFromIncomingContext_SpoofedForwardedFor(t *testing.T) {
+	// клиент прислал x-forwarded-for сам, grpc-gateway дописал его настоящий адрес
+	ctx := incoming("127.0.0.1", "x-forwarded-for", "198.51.100.1, 203.0.113.7")
+
+	assert.Equal(t, "203.0.113.7", FromIncomingContext(ctx, testProxies(t)).IP)
+
+	// смена подставленного адреса не меняет IP, по которому блокируется вход
+	ctx = incoming("127.0.0.1", "x-forwarded-for", "192.0.2.99, 203.0.113.7")
+
+	assert.Equal(t, "203.0.113.7", FromIncomingContext(ctx, testProxies(t)).IP)
+}
+
+func TestFromIncomingContext_UntrustedPeerForwardedFor(t *testing.T) {
+	// прямой gRPC-вызов не от прокси: x-forwarded-for игнорируется
+	ctx := incoming("192.0.2.10", "x-forwarded-for", "198.51.100.1")
+
+	assert.Equal(t, "192.0.2.10", FromIncomingContext(ctx, testProxies(t)).IP)
+}
+
+func TestFromIncomingContext_DirectGRPC(t *testing.T) {
+	ctx := incoming("192.0.2.10", "user-agent", "grpc-go/1.71.0")
+
+	info := FromIncomingContext(ctx, testProxies(t))
+	assert.Equal(t, "192.0.2.10", info.IP)
+	assert.Equal(t, "grpc-go/1.71.0", info.UserAgent)
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.1.2.3", " 192.168.0.0/16 "})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.1.2.3/32"), netip.MustParsePrefix("192.168.0.0/16")}, proxies)
+
+	_, err = ParseTrustedProxies([]string{"gateway"})
+	require.Error(t, err)
+}
+
+func TestFromContext_Empty(t *testing.T) {
+	assert.Equal(t, "", FromContext(context.Background()).IP)
+}
@@ -1,8 +1,11 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"github.com/14kear/forum-project/auth-service/internal/domain/models"
 	"github.com/golang-jwt/jwt/v5"
+	"strings"
 	"time"
 )
 
@@ -11,13 +14,16 @@ type TokenPair struct {
 	RefreshToken string
 }
 
-func NewTokenPair(user models.User, app models.App, accessTTL, refreshTTL time.Duration) (*TokenPair, error) {
-	accessToken, err := newAccessToken(user, app, accessTTL)
+// NewTokenPair выпускает access и refresh токены. Если key не задан, токены подписываются
+// HS256 секретом приложения, иначе — ключом key с его идентификатором в заголовке kid.
+// Scope пользователя (токены OAuth-клиентов) попадает в оба токена.
+func NewTokenPair(user models.User, app models.App, key *SigningKey, accessTTL, refreshTTL time.Duration) (*TokenPair, error) {
+	accessToken, err := newAccessToken(user, app, key, accessTTL)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := newRefreshToken(user, app, refreshTTL)
+	refreshToken, err := newRefreshToken(user, app, key, refreshTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -28,27 +34,99 @@ func NewTokenPair(user models.User, app models.App, accessTTL, refreshTTL time.D
 	}, nil
 }
 
-func newAccessToken(user models.User, app models.App, ttl time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
+func newAccessToken(user models.User, app models.App, key *SigningKey, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{}
 
 	claims["uid"] = user.ID
 	claims["email"] = user.Email
+	claims["email_verified"] = user.EmailVerified
+	claims["roles"] = user.Roles
 	claims["app_id"] = app.ID
 	claims["typ"] = "access"
 	claims["exp"] = time.Now().Add(ttl).Unix()
+	setScope(claims, user.Scope)
 
-	return token.SignedString([]byte(app.Secret))
+	return sign(claims, app, key)
 }
 
-func newRefreshToken(user models.User, app models.App, ttl time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
+func newRefreshToken(user models.User, app models.App, key *SigningKey, ttl time.Duration) (string, error) {
+	// jti делает токены уникальными: использованный при ротации токен остаётся в хранилище,
+	// и новый токен, выпущенный в ту же секунду, не должен с ним совпасть
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{}
 
 	claims["uid"] = user.ID
 	claims["email"] = user.Email
+	claims["app_id"] = app.ID
 	claims["typ"] = "refresh"
+	claims["jti"] = base64.RawURLEncoding.EncodeToString(jti)
 	claims["exp"] = time.Now().Add(ttl).Unix()
+	setScope(claims, user.Scope)
+
+	return sign(claims, app, key)
+}
+
+// IDToken — содержимое ID token OpenID Connect
+type IDToken struct {
+	// Issuer — идентификатор сервера авторизации (claim iss)
+	Issuer string
+	// Subject — идентификатор пользователя (claim sub)
+	Subject string
+	// Audience — client_id клиента, которому выдан токен (claim aud)
+	Audience string
+	// Nonce из запроса авторизации; пуст для токенов, выданных при обновлении
+	Nonce string
+	// Email попадает в токен вместе с EmailVerified, только если не пуст
+	Email         string
+	EmailVerified bool
+}
+
+// NewIDToken выпускает ID token (OpenID Connect Core 1.0, раздел 2). Подписывается так же,
+// как access token приложения, поэтому клиент проверяет его по JWKS или секретом приложения.
+func NewIDToken(idToken IDToken, app models.App, key *SigningKey, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{}
+
+	claims["iss"] = idToken.Issuer
+	claims["sub"] = idToken.Subject
+	claims["aud"] = idToken.Audience
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+	if idToken.Nonce != "" {
+		claims["nonce"] = idToken.Nonce
+	}
+	if idToken.Email != "" {
+		claims["email"] = idToken.Email
+		claims["email_verified"] = idToken.EmailVerified
+	}
+
+	return sign(claims, app, key)
+}
+
+// setScope добавляет claim scope в формате RFC 6749: значения через пробел
+func setScope(claims jwt.MapClaims, scope []string) {
+	if len(scope) > 0 {
+		claims["scope"] = strings.Join(scope, " ")
+	}
+}
+
+func sign(claims jwt.MapClaims, app models.App, key *SigningKey) (string, error) {
+	if key == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(app.Secret))
+	}
+
+	method, err := key.Method()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
 
-	return token.SignedString([]byte(app.Secret))
+	return token.SignedString(key.Private)
 }
@@ -0,0 +1,255 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	rsaKeyBits = 2048
+)
+
+var (
+	ErrKeyNotFound          = errors.New("signing key not found")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+)
+
+// SigningKey — асимметричный ключ подписи токенов. ID попадает в заголовок kid,
+// по нему проверяющая сторона находит открытый ключ в JWKS.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+}
+
+// Public возвращает открытую часть ключа
+func (k SigningKey) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// Method возвращает метод подписи golang-jwt для алгоритма ключа
+func (k SigningKey) Method() (jwt.SigningMethod, error) {
+	return signingMethod(k.Algorithm)
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// GenerateKey создаёт новый ключ для алгоритма alg (RS256 или EdDSA), kid — отпечаток ключа
+func GenerateKey(alg string) (SigningKey, error) {
+	var private crypto.Signer
+
+	switch alg {
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		private = key
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		private = key
+	default:
+		return SigningKey{}, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	return NewSigningKey(alg, private, "")
+}
+
+// NewSigningKey проверяет, что ключ подходит для алгоритма. Пустой kid заменяется отпечатком ключа (RFC 7638).
+func NewSigningKey(alg string, private crypto.Signer, kid string) (SigningKey, error) {
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if alg != AlgRS256 {
+			return SigningKey{}, fmt.Errorf("%w: RSA key cannot be used with %q", ErrUnsupportedAlgorithm, alg)
+		}
+	case ed25519.PrivateKey:
+		if alg != AlgEdDSA {
+			return SigningKey{}, fmt.Errorf("%w: Ed25519 key cannot be used with %q", ErrUnsupportedAlgorithm, alg)
+		}
+	default:
+		return SigningKey{}, fmt.Errorf("%w: unsupported key type %T", ErrUnsupportedAlgorithm, private)
+	}
+
+	key := SigningKey{Algorithm: alg, Private: private, ID: kid}
+	if key.ID == "" {
+		key.ID = Thumbprint(key.Public())
+	}
+
+	return key, nil
+}
+
+// LoadKey читает закрытый ключ в PEM (PKCS#8 или PKCS#1 для RSA)
+func LoadKey(path, alg, kid string) (SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	private, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return NewSigningKey(alg, private, kid)
+}
+
+// ParsePrivateKeyPEM разбирает закрытый ключ в PEM
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrUnsupportedAlgorithm, key)
+	}
+
+	return signer, nil
+}
+
+// MarshalPrivateKeyPEM кодирует закрытый ключ в PEM (PKCS#8)
+func MarshalPrivateKeyPEM(private crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// PublicJWK описывает открытую часть ключа для публикации в JWKS
+func PublicJWK(key SigningKey) JWK {
+	jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
+
+// PublicKey восстанавливает открытый ключ из JWK (RSA или OKP Ed25519)
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA key parameters")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedAlgorithm, k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: key type %q", ErrUnsupportedAlgorithm, k.Kty)
+	}
+}
+
+// Thumbprint — отпечаток открытого ключа по RFC 7638, используется как kid по умолчанию
+func Thumbprint(pub crypto.PublicKey) string {
+	// RFC 7638 требует только обязательные поля в лексикографическом порядке
+	var members any
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		}
+	case ed25519.PublicKey:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{
+			Crv: "Ed25519",
+			Kty: "OKP",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+	default:
+		return ""
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,74 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+// Пример из RFC 7638, раздел 3.1
+func TestThumbprint_RFC7638(t *testing.T) {
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", Thumbprint(pub))
+}
+
+func TestPublicJWK(t *testing.T) {
+	key, err := GenerateKey(AlgEdDSA)
+	require.NoError(t, err)
+
+	jwk := PublicJWK(key)
+	assert.Equal(t, "OKP", jwk.Kty)
+	assert.Equal(t, "Ed25519", jwk.Crv)
+	assert.Equal(t, key.ID, jwk.Kid)
+	assert.Empty(t, jwk.N)
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	require.NoError(t, err)
+	assert.Equal(t, key.Public(), ed25519.PublicKey(x))
+}
+
+func TestPrivateKeyPEM_RoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgEdDSA} {
+		key, err := GenerateKey(alg)
+		require.NoError(t, err)
+
+		data, err := MarshalPrivateKeyPEM(key.Private)
+		require.NoError(t, err)
+
+		private, err := ParsePrivateKeyPEM(data)
+		require.NoError(t, err)
+
+		parsed, err := NewSigningKey(alg, private, "")
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, parsed.ID)
+	}
+}
+
+func TestNewSigningKey_AlgorithmMismatch(t *testing.T) {
+	key, err := GenerateKey(AlgEdDSA)
+	require.NoError(t, err)
+
+	_, err = NewSigningKey(AlgRS256, key.Private, "")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestJWK_PublicKey_RoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgEdDSA} {
+		key, err := GenerateKey(alg)
+		require.NoError(t, err)
+
+		pub, err := PublicJWK(key).PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, key.Public(), pub)
+	}
+
+	_, err := JWK{Kty: "EC", Crv: "P-256"}.PublicKey()
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TypeLog  = "log"
+	TypeFile = "file"
+)
+
+// LogMailer не отправляет письма, а пишет их в лог. Подходит для локальной разработки.
+type LogMailer struct {
+	log *slog.Logger
+}
+
+func NewLogMailer(log *slog.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+func (m *LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.log.Info("email",
+		slog.String("to", to),
+		slog.String("subject", subject),
+		slog.String("body", body),
+	)
+	return nil
+}
+
+// FileMailer сохраняет каждое письмо в отдельный .eml файл в каталоге dir
+type FileMailer struct {
+	dir string
+	seq atomic.Int64
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+	const op = "mailer.NewFileMailer"
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &FileMailer{dir: dir}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, to, subject, body string) error {
+	const op = "mailer.FileMailer.Send"
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%d.eml", now.UTC().Format("20060102T150405.000000000"), m.seq.Add(1))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(body)
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+// Package oidc — клиент внешнего провайдера OpenID Connect для федеративного входа:
+// поток authorization code с PKCE и проверка ID token по JWKS провайдера.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	libjwt "github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	scopeOpenID = "openid"
+	// maxResponseSize ограничивает ответы провайдера, которые читаются целиком
+	maxResponseSize = 1 << 20
+)
+
+var (
+	// ErrInvalidIDToken — ID token не прошёл проверку подписи, iss, aud, срока действия или nonce
+	ErrInvalidIDToken = errors.New("invalid id token")
+	// ErrTokenExchange — провайдер отказал в обмене кода на токены
+	ErrTokenExchange = errors.New("token exchange failed")
+)
+
+// Config — регистрация сервиса как клиента у провайдера
+type Config struct {
+	// Issuer — идентификатор провайдера; метаданные читаются из Issuer/.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL — callback сервиса, зарегистрированный у провайдера
+	RedirectURL string
+	// Scopes запрашиваются вместе с openid
+	Scopes []string
+}
+
+// Provider — клиент одного провайдера. Метаданные и ключи загружаются при первом обращении,
+// поэтому недоступный провайдер не мешает запуску сервиса.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     map[string]any
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// New создаёт клиента провайдера. Если client не задан, используется http.DefaultClient.
+func New(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL возвращает адрес входа у провайдера для запроса с кодом авторизации и PKCE (S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization_endpoint: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("response_type", "code")
+	q.Set("scope", p.scope())
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange обменивает код авторизации на ID token и проверяет его подпись, iss, aud, срок действия и nonce
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (models.ExternalIdentity, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return models.ExternalIdentity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return models.ExternalIdentity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// RFC 6749, раздел 2.3.1: идентификатор и секрет кодируются как form-urlencoded
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return models.ExternalIdentity{}, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return models.ExternalIdentity{}, fmt.Errorf("%w: status %d: %v", ErrTokenExchange, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return models.ExternalIdentity{}, fmt.Errorf("%w: status %d: %s", ErrTokenExchange, resp.StatusCode, body.Error)
+	}
+	if body.IDToken == "" {
+		return models.ExternalIdentity{}, fmt.Errorf("%w: response has no id_token", ErrTokenExchange)
+	}
+
+	return p.verify(ctx, md, body.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, md metadata, rawIDToken, nonce string) (models.ExternalIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, p.keyFunc(ctx),
+		jwt.WithValidMethods([]string{libjwt.AlgRS256, libjwt.AlgEdDSA}),
+		jwt.WithIssuer(md.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return models.ExternalIdentity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return models.ExternalIdentity{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return models.ExternalIdentity{}, fmt.Errorf("%w: sub claim is missing", ErrInvalidIDToken)
+	}
+
+	identity := models.ExternalIdentity{Subject: subject}
+	identity.Email, _ = claims["email"].(string)
+
+	// некоторые провайдеры передают email_verified строкой
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	return identity, nil
+}
+
+// keyFunc находит ключ провайдера по kid. Незнакомый kid означает, что провайдер сменил ключи,
+// и JWKS перечитывается один раз.
+func (p *Provider) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		p.mu.Lock()
+		key, ok := p.keys[kid]
+		p.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+
+		keys, err := p.fetchKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		p.mu.Lock()
+		p.keys = keys
+		p.mu.Unlock()
+
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]any, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []libjwt.JWK `json:"keys"`
+	}
+	if err := p.getJSON(ctx, md.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// ключи неподдерживаемых типов пропускаются: ими подписаны токены, которые всё равно не примем
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// discover читает метаданные провайдера и запоминает их после первого успешного запроса
+func (p *Provider) discover(ctx context.Context) (metadata, error) {
+	p.mu.Lock()
+	cached := p.metadata
+	p.mu.Unlock()
+	if cached != nil {
+		return *cached, nil
+	}
+
+	var md metadata
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &md); err != nil {
+		return metadata{}, fmt.Errorf("discover %s: %w", p.cfg.Issuer, err)
+	}
+
+	// OpenID Connect Discovery 1.0, раздел 4.3: issuer в документе должен совпадать с настроенным
+	if md.Issuer != p.cfg.Issuer {
+		return metadata{}, fmt.Errorf("discover %s: issuer mismatch: %q", p.cfg.Issuer, md.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return metadata{}, fmt.Errorf("discover %s: incomplete provider metadata", p.cfg.Issuer)
+	}
+
+	p.mu.Lock()
+	p.metadata = &md
+	p.mu.Unlock()
+
+	return md, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// scope — openid и настроенные scope через пробел, без повторов
+func (p *Provider) scope() string {
+	scopes := []string{scopeOpenID}
+	for _, s := range p.cfg.Scopes {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
@@ -0,0 +1,117 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/oidc"
+	"github.com/14kear/forum-project/auth-service/internal/lib/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	redirectURL = "https://sso.example/federation/corp/callback"
+	verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func newProvider(idp *oidctest.Provider) *oidc.Provider {
+	return oidc.New(oidc.Config{
+		Issuer:       idp.Issuer,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"email", "openid"},
+	}, nil)
+}
+
+// login проходит вход у провайдера и возвращает код из редиректа на callback
+func login(t *testing.T, provider *oidc.Provider, nonce string) string {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(verifier))
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", nonce, base64.RawURLEncoding.EncodeToString(sum[:]))
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email", u.Query().Get("scope"))
+	assert.Equal(t, redirectURL, u.Query().Get("redirect_uri"))
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", location.Query().Get("state"))
+
+	return location.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	idp.SetUser(models.ExternalIdentity{Subject: "alice", Email: "alice@corp.example", EmailVerified: true})
+
+	provider := newProvider(idp)
+	code := login(t, provider, "nonce-1")
+
+	identity, err := provider.Exchange(context.Background(), code, verifier, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExternalIdentity{Subject: "alice", Email: "alice@corp.example", EmailVerified: true}, identity)
+}
+
+func TestProvider_Exchange_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(jwt.MapClaims)
+		nonce    string
+		verifier string
+		wantErr  error
+	}{
+		{name: "nonce mismatch", nonce: "other-nonce", wantErr: oidc.ErrInvalidIDToken},
+		{name: "another audience", mutate: func(c jwt.MapClaims) { c["aud"] = "another-client" }, wantErr: oidc.ErrInvalidIDToken},
+		{name: "another issuer", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }, wantErr: oidc.ErrInvalidIDToken},
+		{name: "expired", mutate: func(c jwt.MapClaims) { c["exp"] = 1 }, wantErr: oidc.ErrInvalidIDToken},
+		{name: "wrong code verifier", verifier: "wrong-verifier-wrong-verifier-wrong-verifier", wantErr: oidc.ErrTokenExchange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.NewProvider(t)
+			idp.SetUser(models.ExternalIdentity{Subject: "alice", Email: "alice@corp.example", EmailVerified: true})
+			idp.MutateIDToken(tt.mutate)
+
+			provider := newProvider(idp)
+			code := login(t, provider, "nonce-1")
+
+			nonce, codeVerifier := "nonce-1", verifier
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if tt.verifier != "" {
+				codeVerifier = tt.verifier
+			}
+
+			_, err := provider.Exchange(context.Background(), code, codeVerifier, nonce)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+
+	provider := oidc.New(oidc.Config{Issuer: idp.Issuer + "/", ClientID: oidctest.ClientID, RedirectURL: redirectURL}, nil)
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	require.Error(t, err)
+}
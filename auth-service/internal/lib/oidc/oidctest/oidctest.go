@@ -0,0 +1,188 @@
+// Package oidctest — провайдер OpenID Connect в памяти процесса для тестов федеративного входа.
+// Вход у провайдера не требует действий пользователя: /authorize сразу возвращает код для
+// пользователя, заданного SetUser.
+package oidctest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	libjwt "github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ClientID     = "sso-test-client"
+	ClientSecret = "sso-test-secret"
+)
+
+// Provider — тестовый провайдер. Issuer совпадает с адресом сервера.
+type Provider struct {
+	Server *httptest.Server
+	Issuer string
+
+	key libjwt.SigningKey
+
+	mu    sync.Mutex
+	user  models.ExternalIdentity
+	codes map[string]authorization
+	// mutate меняет claims ID token перед подписью, чтобы тесты могли испортить токен
+	mutate func(jwt.MapClaims)
+}
+
+type authorization struct {
+	user          models.ExternalIdentity
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// NewProvider запускает провайдера; сервер останавливается по окончании теста
+func NewProvider(t *testing.T) *Provider {
+	t.Helper()
+
+	key, err := libjwt.GenerateKey(libjwt.AlgRS256)
+	if err != nil {
+		t.Fatalf("generate idp key: %v", err)
+	}
+
+	p := &Provider{key: key, codes: make(map[string]authorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+
+	p.Server = httptest.NewServer(mux)
+	p.Issuer = p.Server.URL
+	t.Cleanup(p.Server.Close)
+
+	return p
+}
+
+// SetUser задаёт пользователя, который «входит» у провайдера при следующем /authorize
+func (p *Provider) SetUser(user models.ExternalIdentity) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.user = user
+}
+
+// MutateIDToken задаёт изменение claims для следующих ID token
+func (p *Provider) MutateIDToken(mutate func(jwt.MapClaims)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mutate = mutate
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                 p.Issuer,
+		"authorization_endpoint": p.Issuer + "/authorize",
+		"token_endpoint":         p.Issuer + "/token",
+		"jwks_uri":               p.Issuer + "/jwks",
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []libjwt.JWK{libjwt.PublicJWK(p.key)}})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != ClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	code := "code-" + strconv.Itoa(len(p.codes)+1)
+	p.codes[code] = authorization{
+		user:          p.user,
+		redirectURI:   redirect.String(),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != ClientID || secret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	auth, found := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	mutate := p.mutate
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !found || auth.redirectURI != r.PostFormValue("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer,
+		"sub":            auth.user.Subject,
+		"aud":            ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+		"nonce":          auth.nonce,
+		"email":          auth.user.Email,
+		"email_verified": auth.user.EmailVerified,
+	}
+	if mutate != nil {
+		mutate(claims)
+	}
+
+	method, _ := p.key.Method()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = p.key.ID
+
+	idToken, err := token.SignedString(p.key.Private)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "upstream-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,156 @@
+package passhash
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var (
+	ErrUnknownFormat        = errors.New("unknown password hash format")
+	ErrUnsupportedAlgorithm = errors.New("password hash algorithm must be argon2id or bcrypt")
+)
+
+// Config — алгоритм и параметры, с которыми хэшируются новые пароли
+type Config struct {
+	Algorithm string
+	// Argon2Memory — объём памяти argon2id в КиБ
+	Argon2Memory  uint32
+	Argon2Time    uint32
+	Argon2Threads uint8
+	BcryptCost    int
+}
+
+// Hasher хэширует пароли. Алгоритм и параметры записываются в сам хэш: argon2id — в формате
+// PHC ($argon2id$v=19$m=...,t=...,p=...$salt$hash), bcrypt — в своём стандартном ($2a$cost$...).
+// Поэтому хэши, созданные со старыми настройками, продолжают проверяться, а Verify сообщает,
+// что их пора пересчитать.
+type Hasher struct {
+	cfg Config
+}
+
+func New(cfg Config) (*Hasher, error) {
+	const op = "passhash.New"
+
+	switch cfg.Algorithm {
+	case AlgArgon2id:
+		if cfg.Argon2Memory == 0 || cfg.Argon2Time == 0 || cfg.Argon2Threads == 0 {
+			return nil, fmt.Errorf("%s: argon2id memory, time and threads must be positive", op)
+		}
+	case AlgBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("%s: bcrypt cost must be between %d and %d", op, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnsupportedAlgorithm, cfg.Algorithm)
+	}
+
+	return &Hasher{cfg: cfg}, nil
+}
+
+// Hash хэширует пароль текущим алгоритмом с текущими параметрами
+func (h *Hasher) Hash(password string) ([]byte, error) {
+	if h.cfg.Algorithm == AlgBcrypt {
+		return bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	params := argon2Params{memory: h.cfg.Argon2Memory, time: h.cfg.Argon2Time, threads: h.cfg.Argon2Threads}
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2KeyLen)
+
+	return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// Verify проверяет пароль. needsRehash означает, что хэш создан другим алгоритмом или с другими
+// параметрами и после успешного входа его стоит пересчитать методом Hash.
+func (h *Hasher) Verify(hash []byte, password string) (ok bool, needsRehash bool, err error) {
+	switch {
+	case bytes.HasPrefix(hash, []byte("$argon2id$")):
+		params, salt, key, err := parseArgon2(hash)
+		if err != nil {
+			return false, false, err
+		}
+
+		actual := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false, nil
+		}
+
+		current := argon2Params{memory: h.cfg.Argon2Memory, time: h.cfg.Argon2Time, threads: h.cfg.Argon2Threads}
+		return true, h.cfg.Algorithm != AlgArgon2id || params != current || len(key) != argon2KeyLen, nil
+
+	case bytes.HasPrefix(hash, []byte("$2")):
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, err
+		}
+
+		cost, err := bcrypt.Cost(hash)
+		if err != nil {
+			return false, false, err
+		}
+		return true, h.cfg.Algorithm != AlgBcrypt || cost != h.cfg.BcryptCost, nil
+
+	default:
+		return false, false, ErrUnknownFormat
+	}
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func parseArgon2(hash []byte) (argon2Params, []byte, []byte, error) {
+	var (
+		params  argon2Params
+		version int
+	)
+
+	// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+	parts := bytes.Split(hash, []byte("$"))
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	if _, err := fmt.Sscanf(string(parts[2]), "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: argon2 version %q", ErrUnknownFormat, parts[2])
+	}
+	if _, err := fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, fmt.Errorf("%w: argon2 parameters %q", ErrUnknownFormat, parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(string(parts[4]))
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("%w: %v", ErrUnknownFormat, err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(string(parts[5]))
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("%w: %v", ErrUnknownFormat, err)
+	}
+
+	return params, salt, key, nil
+}
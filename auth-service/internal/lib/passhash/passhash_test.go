@@ -0,0 +1,103 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func argon2Config() Config {
+	return Config{Algorithm: AlgArgon2id, Argon2Memory: 8 * 1024, Argon2Time: 1, Argon2Threads: 1, BcryptCost: bcrypt.MinCost}
+}
+
+func TestHasher_Argon2id(t *testing.T) {
+	h, err := New(argon2Config())
+	require.NoError(t, err)
+
+	hash, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(hash), "$argon2id$v=19$m=8192,t=1,p=1$"))
+
+	ok, needsRehash, err := h.Verify(hash, "correct horse")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	ok, _, err = h.Verify(hash, "wrong horse")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestHasher_SaltIsRandom(t *testing.T) {
+	h, err := New(argon2Config())
+	require.NoError(t, err)
+
+	first, err := h.Hash("password")
+	require.NoError(t, err)
+	second, err := h.Hash("password")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+}
+
+func TestHasher_RehashOutdatedParameters(t *testing.T) {
+	old, err := New(argon2Config())
+	require.NoError(t, err)
+	hash, err := old.Hash("password")
+	require.NoError(t, err)
+
+	cfg := argon2Config()
+	cfg.Argon2Time = 2
+	current, err := New(cfg)
+	require.NoError(t, err)
+
+	ok, needsRehash, err := current.Verify(hash, "password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+}
+
+func TestHasher_RehashBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	h, err := New(argon2Config())
+	require.NoError(t, err)
+
+	ok, needsRehash, err := h.Verify(legacy, "password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	cfg := argon2Config()
+	cfg.Algorithm = AlgBcrypt
+	h, err = New(cfg)
+	require.NoError(t, err)
+
+	ok, needsRehash, err = h.Verify(legacy, "password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+}
+
+func TestHasher_UnknownFormat(t *testing.T) {
+	h, err := New(argon2Config())
+	require.NoError(t, err)
+
+	_, _, err = h.Verify([]byte("plain-text"), "plain-text")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, _, err = h.Verify([]byte("$argon2id$v=19$m=x$salt$key"), "password")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(Config{Algorithm: "md5"})
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = New(Config{Algorithm: AlgBcrypt, BcryptCost: 1})
+	require.Error(t, err)
+}
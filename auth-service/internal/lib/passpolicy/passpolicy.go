@@ -0,0 +1,219 @@
+package passpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Коды правил. Попадают в ответ сервера, поэтому менять их нельзя.
+const (
+	RuleMinLength    = "min_length"
+	RuleMaxLength    = "max_length"
+	RuleLowercase    = "lowercase"
+	RuleUppercase    = "uppercase"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RuleEmailSimilar = "email_similarity"
+	RuleBreached     = "breached"
+
+	// bcrypt учитывает только первые 72 байта пароля
+	bcryptMaxBytes = 72
+
+	minPrefixLen = 5
+	// часть email до @ короче этого не проверяется на вхождение в пароль
+	minEmailLocalLen = 3
+)
+
+// Config — требования к паролю
+type Config struct {
+	// MinLength — минимальная длина в символах
+	MinLength int
+	// MaxLength — максимальная длина в байтах. Ноль означает 72 байта: длиннее bcrypt молча обрезает пароль.
+	MaxLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// RejectEmailSimilar запрещает пароли, совпадающие с email или содержащие его часть до @
+	RejectEmailSimilar bool
+	// BreachedPasswordsFile — файл с hex-префиксами SHA-1 утёкших паролей, по одному в строке
+	// (допускается формат PREFIX:count). Пустой путь отключает проверку.
+	BreachedPasswordsFile string
+}
+
+// Violation — нарушенное правило
+type Violation struct {
+	Rule        string
+	Description string
+}
+
+// Policy проверяет пароли на соответствие требованиям
+type Policy struct {
+	cfg Config
+	// breached — префиксы SHA-1 утёкших паролей в верхнем регистре, prefixLens — их встречающиеся длины
+	breached   map[string]struct{}
+	prefixLens []int
+}
+
+// New создаёт политику и загружает список утёкших паролей, если он задан
+func New(cfg Config) (*Policy, error) {
+	const op = "passpolicy.New"
+
+	if cfg.MaxLength == 0 {
+		cfg.MaxLength = bcryptMaxBytes
+	}
+	if cfg.MinLength < 0 || cfg.MaxLength < 0 || cfg.MinLength > cfg.MaxLength {
+		return nil, fmt.Errorf("%s: invalid length limits %d..%d", op, cfg.MinLength, cfg.MaxLength)
+	}
+
+	p := &Policy{cfg: cfg}
+
+	if cfg.BreachedPasswordsFile != "" {
+		if err := p.loadBreached(cfg.BreachedPasswordsFile); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return p, nil
+}
+
+// Check возвращает все нарушенные правила; пустой результат означает, что пароль подходит
+func (p *Policy) Check(password, email string) []Violation {
+	var violations []Violation
+
+	if utf8.RuneCountInString(password) < p.cfg.MinLength {
+		violations = append(violations, Violation{
+			Rule:        RuleMinLength,
+			Description: fmt.Sprintf("password must be at least %d characters long", p.cfg.MinLength),
+		})
+	}
+	if len(password) > p.cfg.MaxLength {
+		violations = append(violations, Violation{
+			Rule:        RuleMaxLength,
+			Description: fmt.Sprintf("password must be at most %d bytes long", p.cfg.MaxLength),
+		})
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.cfg.RequireLower && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Description: "password must contain a lowercase letter"})
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Description: "password must contain an uppercase letter"})
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Description: "password must contain a digit"})
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Description: "password must contain a symbol"})
+	}
+
+	if p.cfg.RejectEmailSimilar && similarToEmail(password, email) {
+		violations = append(violations, Violation{Rule: RuleEmailSimilar, Description: "password must not contain the email address"})
+	}
+
+	if p.isBreached(password) {
+		violations = append(violations, Violation{Rule: RuleBreached, Description: "password has appeared in a data breach"})
+	}
+
+	return violations
+}
+
+func similarToEmail(password, email string) bool {
+	if email == "" {
+		return false
+	}
+
+	password = strings.ToLower(password)
+	email = strings.ToLower(email)
+	if password == email {
+		return true
+	}
+
+	local, _, _ := strings.Cut(email, "@")
+	return len(local) >= minEmailLocalLen && strings.Contains(password, local)
+}
+
+func (p *Policy) isBreached(password string) bool {
+	if len(p.breached) == 0 {
+		return false
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	for _, n := range p.prefixLens {
+		if _, ok := p.breached[hash[:n]]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) loadBreached(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p.breached = map[string]struct{}{}
+	lens := map[int]struct{}{}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(text, ":")
+		prefix = strings.ToUpper(prefix)
+		if len(prefix) < minPrefixLen || len(prefix) > sha1.Size*2 {
+			return fmt.Errorf("%s:%d: prefix must be %d to %d hex characters", path, line, minPrefixLen, sha1.Size*2)
+		}
+		if _, err := hex.DecodeString(padEven(prefix)); err != nil {
+			return fmt.Errorf("%s:%d: invalid hex prefix", path, line)
+		}
+
+		p.breached[prefix] = struct{}{}
+		lens[len(prefix)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for n := range lens {
+		p.prefixLens = append(p.prefixLens, n)
+	}
+
+	return nil
+}
+
+// padEven дополняет нечётный префикс до целых байт, чтобы его можно было проверить hex.DecodeString
+func padEven(prefix string) string {
+	if len(prefix)%2 == 1 {
+		return prefix + "0"
+	}
+	return prefix
+}
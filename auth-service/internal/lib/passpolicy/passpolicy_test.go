@@ -0,0 +1,110 @@
+package passpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strictConfig() Config {
+	return Config{
+		MinLength:          10,
+		RequireLower:       true,
+		RequireUpper:       true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		RejectEmailSimilar: true,
+	}
+}
+
+func rules(violations []Violation) []string {
+	var out []string
+	for _, v := range violations {
+		out = append(out, v.Rule)
+	}
+	return out
+}
+
+func TestPolicy_StrongPasswordPasses(t *testing.T) {
+	p, err := New(strictConfig())
+	require.NoError(t, err)
+
+	assert.Empty(t, p.Check("Correct-Horse-42", "alice@example.com"))
+}
+
+func TestPolicy_ReportsEveryViolation(t *testing.T) {
+	p, err := New(strictConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol, RuleEmailSimilar},
+		rules(p.Check("alice", "alice@example.com")),
+	)
+}
+
+func TestPolicy_MinLengthCountsCharacters(t *testing.T) {
+	p, err := New(Config{MinLength: 4})
+	require.NoError(t, err)
+
+	// 4 символа кириллицы — 8 байт
+	assert.Empty(t, p.Check("паро", ""))
+	assert.Equal(t, []string{RuleMinLength}, rules(p.Check("абв", "")))
+}
+
+func TestPolicy_MaxLengthDefaultsToBcryptLimit(t *testing.T) {
+	p, err := New(Config{})
+	require.NoError(t, err)
+
+	assert.Empty(t, p.Check(strings.Repeat("a", 72), ""))
+	assert.Equal(t, []string{RuleMaxLength}, rules(p.Check(strings.Repeat("a", 73), "")))
+	// ограничение в байтах: 37 символов кириллицы занимают 74 байта
+	assert.Equal(t, []string{RuleMaxLength}, rules(p.Check(strings.Repeat("я", 37), "")))
+}
+
+func TestPolicy_EmailSimilarity(t *testing.T) {
+	p, err := New(Config{RejectEmailSimilar: true})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, p.Check("Alice@Example.com", "alice@example.com"))
+	assert.NotEmpty(t, p.Check("xxALICExx", "alice@example.com"))
+	// слишком короткая часть до @ не проверяется
+	assert.Empty(t, p.Check("bobcat", "bo@example.com"))
+	assert.Empty(t, p.Check("anything", ""))
+}
+
+func TestPolicy_InvalidLengthLimits(t *testing.T) {
+	_, err := New(Config{MinLength: 80})
+	assert.Error(t, err)
+}
+
+func TestPolicy_BreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	// SHA-1("123456")   = 7C4A8D09CA3762AF61E59520943DC26494F8941B
+	content := "# утёкшие пароли\n5baa6:3861493\n\n7C4A8D09CA3762AF61E59520943DC26494F8941B\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	p, err := New(Config{BreachedPasswordsFile: path})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{RuleBreached}, rules(p.Check("password", "")))
+	assert.Equal(t, []string{RuleBreached}, rules(p.Check("123456", "")))
+	assert.Empty(t, p.Check("Correct-Horse-42", ""))
+}
+
+func TestPolicy_BreachedFileErrors(t *testing.T) {
+	_, err := New(Config{BreachedPasswordsFile: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+
+	for _, content := range []string{"5BA\n", "XYZXYZ\n"} {
+		path := filepath.Join(t.TempDir(), "breached.txt")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		_, err := New(Config{BreachedPasswordsFile: path})
+		assert.Error(t, err, content)
+	}
+}
@@ -0,0 +1,73 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize — размер ключа AES-256
+const KeySize = 32
+
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// Box шифрует небольшие секреты (например, TOTP) перед записью в базу. AES-256-GCM,
+// случайный nonce хранится в начале шифротекста.
+type Box struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (*Box, error) {
+	const op = "secretbox.New"
+
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%s: key must be %d bytes, got %d", op, KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// NewFromBase64 создаёт Box по ключу в base64, в таком виде ключ хранится в конфиге
+func NewFromBase64(key string) (*Box, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox.NewFromBase64: %w", err)
+	}
+	return New(raw)
+}
+
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("secretbox.Seal: %w", err)
+	}
+
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (b *Box) Open(ciphertext []byte) ([]byte, error) {
+	n := b.aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, fmt.Errorf("secretbox.Open: %w", ErrInvalidCiphertext)
+	}
+
+	plaintext, err := b.aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox.Open: %w", ErrInvalidCiphertext)
+	}
+
+	return plaintext, nil
+}
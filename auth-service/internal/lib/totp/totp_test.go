@@ -0,0 +1,66 @@
+package totp
+
+import (
+	"encoding/base32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// секрет и ожидаемые значения из приложения B к RFC 6238 (SHA1), последние 6 цифр
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, code, tt.unix)
+	}
+}
+
+func TestValidate_Skew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	prev, err := Code(rfcSecret, Step(now)-1)
+	require.NoError(t, err)
+
+	step, ok := Validate(rfcSecret, prev, now)
+	require.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+
+	old, err := Code(rfcSecret, Step(now)-2)
+	require.NoError(t, err)
+
+	_, ok = Validate(rfcSecret, old, now)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	u, err := url.Parse(URI("forum-project", "user@example.com", secret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/forum-project:user@example.com", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+	assert.Equal(t, "forum-project", u.Query().Get("issuer"))
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+)
+
+const (
+	defaultErasuresLimit = 100
+	maxErasuresLimit     = 1000
+)
+
+// DeleteAccount удаляет аккаунт. Без userID (или со своим id) владелец access token удаляет себя и
+// подтверждает это паролем; чужой аккаунт может удалить только обладатель PermissionUsersDelete.
+// Вместе с пользователем удаляются его сессии, роли и прочие данные, а для остальных сервисов
+// записывается событие удаления аккаунта (см. AccountErasures).
+func (auth *Auth) DeleteAccount(ctx context.Context, accessToken string, appID int, password string, userID int64) error {
+	const op = "auth.DeleteAccount"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if userID == 0 || userID == claims.UserID {
+		userID = claims.UserID
+
+		user, err := auth.userProvider.UserByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			}
+			auth.log.Error("failed to get user", slog.String("op", op), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := auth.verifyCurrentPassword(ctx, user, password); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		allowed, err := auth.HasPermission(ctx, claims.UserID, PermissionUsersDelete)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !allowed {
+			auth.log.Warn("action denied", slog.Int64("user_id", claims.UserID), slog.String("permission", PermissionUsersDelete))
+			return fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", userID), slog.Int64("deleted_by", claims.UserID))
+
+	erasureID, err := auth.userSaver.DeleteUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to delete user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("account deleted", slog.Int64("erasure_id", erasureID))
+	return nil
+}
+
+// AccountErasures возвращает события удаления аккаунтов с id больше afterID по возрастанию id.
+// Сервисы, хранящие данные пользователей, забирают события по порядку и запоминают id последнего.
+func (auth *Auth) AccountErasures(ctx context.Context, afterID int64, limit int) ([]models.AccountErasure, error) {
+	const op = "auth.AccountErasures"
+
+	if limit <= 0 {
+		limit = defaultErasuresLimit
+	}
+	limit = min(limit, maxErasuresLimit)
+
+	erasures, err := auth.userProvider.AccountErasures(ctx, afterID, limit)
+	if err != nil {
+		auth.log.Error("failed to get account erasures", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return erasures, nil
+}
+
+// ExportUserData возвращает всё, что auth-service хранит о пользователе: профиль и все его refresh токены
+// (без значений токенов). Вызывается другими сервисами при выгрузке персональных данных.
+func (auth *Auth) ExportUserData(ctx context.Context, userID int64) (models.User, []models.RefreshTokenRecord, error) {
+	const op = "auth.ExportUserData"
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", userID))
+
+	user, err := auth.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.User{}, nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return models.User{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	records, err := auth.tokenStorage.RefreshTokenRecords(ctx, userID)
+	if err != nil {
+		log.Error("failed to get refresh tokens", sl.Err(err))
+		return models.User{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user data exported", slog.Int("refresh_tokens", len(records)))
+	return user, records, nil
+}
@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxAppNameLength ограничивает название приложения
+const maxAppNameLength = 100
+
+var (
+	// ErrInvalidAppSettings — настройки приложения не прошли проверку, подробности в *AppSettingsError
+	ErrInvalidAppSettings = errors.New("invalid app settings")
+	// ErrDisableCurrentApp — администратор пытается отключить приложение, через которое вошёл
+	ErrDisableCurrentApp = errors.New("cannot disable the app of the current session")
+)
+
+// AppSettings — настройки приложения, которые задаёт администратор
+type AppSettings struct {
+	Name string
+	// ClientType — confidential или public; по умолчанию confidential
+	ClientType     string
+	RedirectURIs   []string
+	AllowedOrigins []string
+	// AccessTokenTTL и RefreshTokenTTL — 0 означает срок из конфига
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// AppSettingsError — поле Field настроек приложения недопустимо
+type AppSettingsError struct {
+	Field       string
+	Description string
+}
+
+func (e *AppSettingsError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidAppSettings, e.Field, e.Description)
+}
+
+func (e *AppSettingsError) Unwrap() error {
+	return ErrInvalidAppSettings
+}
+
+// CreateApp создаёт приложение и возвращает его вместе с секретом. Требует права auth.apps.manage.
+func (auth *Auth) CreateApp(ctx context.Context, accessToken string, appID int, settings AppSettings) (models.App, error) {
+	const op = "auth.CreateApp"
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionAppsManage)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := newAppFromSettings(settings)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.String("name", app.Name))
+
+	if app.Secret, err = newOneTimeToken(); err != nil {
+		log.Error("failed to generate app secret", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app.ID, err = auth.appSaver.SaveApp(ctx, app)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppExists) {
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppExists)
+		}
+		log.Error("failed to save app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app created", slog.Int("app_id", app.ID), slog.Int64("created_by", claims.UserID))
+
+	created, err := auth.appProvider.App(ctx, app.ID)
+	if err != nil {
+		log.Error("failed to get app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// ListApps возвращает все приложения, включая отключённые. Требует права auth.apps.manage.
+func (auth *Auth) ListApps(ctx context.Context, accessToken string, appID int) ([]models.App, error) {
+	const op = "auth.ListApps"
+
+	if _, err := auth.requirePermission(ctx, accessToken, appID, PermissionAppsManage); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	apps, err := auth.appProvider.Apps(ctx)
+	if err != nil {
+		auth.log.Error("failed to list apps", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+// UpdateApp заменяет настройки приложения targetAppID целиком. Секрет не меняется.
+// Требует права auth.apps.manage.
+func (auth *Auth) UpdateApp(ctx context.Context, accessToken string, appID int, targetAppID int, settings AppSettings) (models.App, error) {
+	const op = "auth.UpdateApp"
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionAppsManage)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := newAppFromSettings(settings)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app.ID = targetAppID
+
+	log := auth.log.With(slog.String("op", op), slog.Int("target_app_id", targetAppID))
+
+	if err := auth.appSaver.UpdateApp(ctx, app); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrAppNotFound):
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		case errors.Is(err, storage.ErrAppExists):
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppExists)
+		}
+		log.Error("failed to update app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app updated", slog.Int64("updated_by", claims.UserID))
+
+	updated, err := auth.appProvider.App(ctx, targetAppID)
+	if err != nil {
+		log.Error("failed to get app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// RotateAppSecret выдаёт приложению targetAppID новый секрет. Прежний секрет перестаёт действовать сразу:
+// токены, подписанные им без ключа подписи, больше не принимаются. Требует права auth.apps.manage.
+func (auth *Auth) RotateAppSecret(ctx context.Context, accessToken string, appID int, targetAppID int) (string, error) {
+	const op = "auth.RotateAppSecret"
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionAppsManage)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int("target_app_id", targetAppID))
+
+	secret, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate app secret", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.appSaver.UpdateAppSecret(ctx, targetAppID, secret); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return "", fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.Error("failed to update app secret", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app secret rotated", slog.Int64("rotated_by", claims.UserID))
+	return secret, nil
+}
+
+// DisableApp отключает приложение targetAppID: вход в него, обновление и проверка его токенов
+// перестают работать, выданные refresh токены отзываются. Требует права auth.apps.manage.
+func (auth *Auth) DisableApp(ctx context.Context, accessToken string, appID int, targetAppID int) error {
+	const op = "auth.DisableApp"
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionAppsManage)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// иначе администратор потеряет доступ к этим RPC вместе с отключённым приложением
+	if targetAppID == appID {
+		return fmt.Errorf("%s: %w", op, ErrDisableCurrentApp)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int("target_app_id", targetAppID))
+
+	if err := auth.appSaver.DisableApp(ctx, targetAppID); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.Error("failed to disable app", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app disabled", slog.Int64("disabled_by", claims.UserID))
+	return nil
+}
+
+// OriginAllowed сообщает, разрешены ли запросы к HTTP API с origin хотя бы одному включённому приложению
+func (auth *Auth) OriginAllowed(ctx context.Context, origin string) (bool, error) {
+	const op = "auth.OriginAllowed"
+
+	allowed, err := auth.appProvider.AppAllowsOrigin(ctx, origin)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return allowed, nil
+}
+
+// AuthenticateApp проверяет учётные данные приложения: оно должно существовать, быть включённым
+// и иметь секрет secret. При несовпадении возвращает ErrInvalidCredentials. Принадлежность
+// приложения сервисам системы проверяет вызывающий.
+func (auth *Auth) AuthenticateApp(ctx context.Context, appID int, secret string) error {
+	const op = "auth.AuthenticateApp"
+
+	app, err := auth.activeApp(ctx, appID)
+	if err != nil {
+		if errors.Is(err, ErrAppNotFound) || errors.Is(err, ErrAppDisabled) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+		auth.log.Error("failed to get app", slog.String("op", op), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(app.Secret)) != 1 {
+		auth.log.Warn("service authentication failed", slog.String("op", op), slog.Int("app_id", appID))
+		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	return nil
+}
+
+// activeApp возвращает приложение, в которое можно входить и токены которого принимаются
+func (auth *Auth) activeApp(ctx context.Context, appID int) (models.App, error) {
+	app, err := auth.appProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.App{}, ErrAppNotFound
+		}
+		return models.App{}, err
+	}
+
+	if app.Disabled {
+		auth.log.Info("app is disabled", slog.Int("app_id", appID))
+		return models.App{}, ErrAppDisabled
+	}
+
+	return app, nil
+}
+
+// tokenTTLs возвращает сроки жизни токенов приложения: собственные или, если они не заданы, из конфига
+func (auth *Auth) tokenTTLs(app models.App) (accessTTL, refreshTTL time.Duration) {
+	accessTTL, refreshTTL = auth.accessTokenTTL, auth.refreshTokenTTL
+	if app.AccessTokenTTL > 0 {
+		accessTTL = app.AccessTokenTTL
+	}
+	if app.RefreshTokenTTL > 0 {
+		refreshTTL = app.RefreshTokenTTL
+	}
+	return accessTTL, refreshTTL
+}
+
+// newAppFromSettings проверяет настройки и переносит их в приложение
+func newAppFromSettings(settings AppSettings) (models.App, error) {
+	name := strings.TrimSpace(settings.Name)
+	if name == "" || len(name) > maxAppNameLength {
+		return models.App{}, &AppSettingsError{Field: "name", Description: fmt.Sprintf("must be 1 to %d characters long", maxAppNameLength)}
+	}
+
+	clientType := settings.ClientType
+	if clientType == "" {
+		clientType = models.ClientTypeConfidential
+	}
+	if clientType != models.ClientTypeConfidential && clientType != models.ClientTypePublic {
+		return models.App{}, &AppSettingsError{Field: "client_type", Description: "must be confidential or public"}
+	}
+
+	// RFC 6749, раздел 3.1.2: redirect URI абсолютный и без фрагмента
+	for _, uri := range settings.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return models.App{}, &AppSettingsError{Field: "redirect_uris", Description: fmt.Sprintf("%q must be absolute and without fragment", uri)}
+		}
+	}
+
+	// origin сравнивается с заголовком Origin как строка, поэтому принимается только в каноническом виде
+	for _, origin := range settings.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Scheme+"://"+u.Host != origin {
+			return models.App{}, &AppSettingsError{Field: "allowed_origins", Description: fmt.Sprintf("%q must be scheme://host[:port]", origin)}
+		}
+	}
+
+	if settings.AccessTokenTTL < 0 || settings.AccessTokenTTL%time.Second != 0 {
+		return models.App{}, &AppSettingsError{Field: "access_token_ttl", Description: "must be a non-negative number of seconds"}
+	}
+	if settings.RefreshTokenTTL < 0 || settings.RefreshTokenTTL%time.Second != 0 {
+		return models.App{}, &AppSettingsError{Field: "refresh_token_ttl", Description: "must be a non-negative number of seconds"}
+	}
+
+	return models.App{
+		Name:            name,
+		ClientType:      clientType,
+		RedirectURIs:    settings.RedirectURIs,
+		AllowedOrigins:  settings.AllowedOrigins,
+		AccessTokenTTL:  settings.AccessTokenTTL,
+		RefreshTokenTTL: settings.RefreshTokenTTL,
+	}, nil
+}
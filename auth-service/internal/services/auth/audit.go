@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/clientinfo"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// AuditConfig — журнал аудита событий безопасности
+type AuditConfig struct {
+	// Enabled включает запись регистраций, входов, обновлений токенов, выходов, смены ролей и пароля.
+	// Повторное использование refresh token записывается всегда.
+	Enabled bool
+	// Retention — сколько хранятся события журнала; 0 — бессрочно
+	Retention time.Duration
+}
+
+var (
+	// ErrInvalidAuditFilter — неизвестный результат события или пустой интервал времени
+	ErrInvalidAuditFilter = errors.New("invalid audit event filter")
+	// ErrInvalidPageToken — токен страницы не выдавался ListAuditEvents
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// ListAuditEvents возвращает страницу журнала аудита от новых событий к старым и токен следующей
+// страницы (пустой на последней). Требует права auth.audit.read.
+func (auth *Auth) ListAuditEvents(ctx context.Context, accessToken string, appID int, filter models.SecurityEventFilter, pageSize int, pageToken string) ([]models.SecurityEvent, string, error) {
+	const op = "auth.ListAuditEvents"
+
+	if filter.Outcome != "" && filter.Outcome != models.AuditOutcomeSuccess && filter.Outcome != models.AuditOutcomeFailure {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidAuditFilter)
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidAuditFilter)
+	}
+
+	filter.BeforeID = 0
+	if pageToken != "" {
+		beforeID, err := strconv.ParseInt(pageToken, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+		}
+		filter.BeforeID = beforeID
+	}
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionAuditRead)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", claims.UserID))
+
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	}
+	pageSize = min(pageSize, maxAuditPageSize)
+
+	// лишнее событие показывает, что за этой страницей есть следующая
+	filter.Limit = pageSize + 1
+
+	events, err := auth.securityEvents.SecurityEvents(ctx, filter)
+	if err != nil {
+		log.Error("failed to list audit events", sl.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var nextPageToken string
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		nextPageToken = strconv.FormatInt(events[len(events)-1].ID, 10)
+	}
+
+	log.Info("audit log read", slog.Int("events", len(events)))
+	return events, nextPageToken, nil
+}
+
+// SweepAuditEvents удаляет события журнала аудита старше AuditConfig.Retention и возвращает их число
+func (auth *Auth) SweepAuditEvents(ctx context.Context) (int64, error) {
+	const op = "auth.SweepAuditEvents"
+
+	if auth.audit.Retention <= 0 {
+		return 0, nil
+	}
+
+	log := auth.log.With(slog.String("op", op))
+
+	removed, err := auth.securityEvents.DeleteSecurityEventsBefore(ctx, time.Now().Add(-auth.audit.Retention))
+	if err != nil {
+		log.Error("failed to delete old audit events", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if removed > 0 {
+		log.Info("old audit events deleted", slog.Int64("removed", removed))
+	}
+
+	return removed, nil
+}
+
+// auditSuccess записывает в журнал аудита успешное действие
+func (auth *Auth) auditSuccess(ctx context.Context, eventType string, userID int64, appID int, details map[string]any) {
+	if !auth.audit.Enabled {
+		return
+	}
+
+	auth.saveSecurityEvent(ctx, models.SecurityEvent{
+		UserID:  userID,
+		AppID:   appID,
+		Type:    eventType,
+		Outcome: models.AuditOutcomeSuccess,
+		Details: details,
+	})
+}
+
+// auditFailure записывает в журнал аудита неудачное действие; причина выводится из ошибки сервиса
+func (auth *Auth) auditFailure(ctx context.Context, eventType string, userID int64, appID int, cause error, details map[string]any) {
+	if !auth.audit.Enabled {
+		return
+	}
+
+	if details == nil {
+		details = map[string]any{}
+	}
+	details["reason"] = auditReason(cause)
+
+	auth.saveSecurityEvent(ctx, models.SecurityEvent{
+		UserID:  userID,
+		AppID:   appID,
+		Type:    eventType,
+		Outcome: models.AuditOutcomeFailure,
+		Details: details,
+	})
+}
+
+// saveSecurityEvent дополняет событие адресом и User-Agent клиента и записывает его. Ошибка только
+// логируется: сбой журнала не должен мешать пользователю войти.
+func (auth *Auth) saveSecurityEvent(ctx context.Context, event models.SecurityEvent) {
+	event.Client = clientinfo.FromContext(ctx)
+
+	if err := auth.securityEvents.SaveSecurityEvent(ctx, event); err != nil {
+		auth.log.Error("failed to save security event", slog.String("type", event.Type), slog.Int64("user_id", event.UserID), sl.Err(err))
+	}
+}
+
+// auditReason — короткая причина отказа для журнала аудита
+func auditReason(err error) string {
+	switch {
+	case errors.Is(err, ErrTooManyLoginAttempts):
+		return "locked"
+	case errors.Is(err, ErrInvalidCredentials):
+		return "invalid_credentials"
+	case errors.Is(err, ErrEmailNotVerified):
+		return "email_not_verified"
+	case errors.Is(err, ErrAppNotFound), errors.Is(err, ErrAppDisabled):
+		return "app_unavailable"
+	case errors.Is(err, ErrInvalidMFAToken):
+		return "invalid_mfa_token"
+	case errors.Is(err, ErrInvalidMFACode):
+		return "invalid_mfa_code"
+	case errors.Is(err, ErrFederatedLoginFailed):
+		return "identity_provider_rejected"
+	case errors.Is(err, ErrPermissionDenied):
+		return "permission_denied"
+	case errors.Is(err, ErrUserExists):
+		return "user_exists"
+	case errors.Is(err, ErrWeakPassword):
+		return "weak_password"
+	case errors.Is(err, ErrRefreshTokenReused):
+		return "refresh_token_reused"
+	case errors.Is(err, ErrUserBanned):
+		return "banned"
+	default:
+		return "rejected"
+	}
+}
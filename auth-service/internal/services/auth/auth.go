@@ -2,28 +2,102 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/clientinfo"
 	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/14kear/forum-project/auth-service/internal/lib/passpolicy"
 	"github.com/14kear/forum-project/auth-service/internal/storage"
 	"github.com/14kear/sso-prettyslog/slogpretty/errors"
 	jwtGo "github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"log/slog"
+	"net/url"
 	"time"
 )
 
 type Auth struct {
-	log             *slog.Logger
-	userSaver       UserSaver
-	userProvider    UserProvider
-	appProvider     AppProvider
-	tokenStorage    TokenStorage
-	accessTokenTTL  time.Duration
-	refreshTokenTTL time.Duration
+	log                  *slog.Logger
+	userSaver            UserSaver
+	userProvider         UserProvider
+	appProvider          AppProvider
+	appSaver             AppSaver
+	tokenStorage         TokenStorage
+	passwordResetStorage PasswordResetStorage
+	verificationStorage  EmailVerificationStorage
+	mfaStorage           MFAStorage
+	securityEvents       SecurityEventStorage
+	lockouts             LoginLockoutStorage
+	roles                RoleStorage
+	oauthStorage         OAuthStorage
+	federationStorage    FederationStorage
+	bans                 BanStorage
+	passwordHasher       PasswordHasher
+	passwordPolicy       PasswordPolicy
+	mailer               Mailer
+	secretCipher         SecretCipher
+	keys                 KeyProvider
+	accessTokenTTL       time.Duration
+	refreshTokenTTL      time.Duration
+	passwordReset        PasswordResetConfig
+	emailVerification    EmailVerificationConfig
+	mfa                  MFAConfig
+	bruteForce           BruteForceConfig
+	oauth                OAuthConfig
+	federation           FederationConfig
+	audit                AuditConfig
+}
+
+// PasswordResetConfig — параметры сброса пароля
+type PasswordResetConfig struct {
+	// TokenTTL — время жизни токена сброса
+	TokenTTL time.Duration
+	// URL — адрес страницы сброса пароля, токен добавляется в query-параметр token
+	URL string
+}
+
+// EmailVerificationConfig — параметры подтверждения email
+type EmailVerificationConfig struct {
+	// TokenTTL — время жизни токена подтверждения
+	TokenTTL time.Duration
+	// URL — адрес страницы подтверждения, токен добавляется в query-параметр token
+	URL string
+	// RequiredForLogin запрещает вход пользователям с неподтверждённым email
+	RequiredForLogin bool
+	// ChangeURL — адрес страницы подтверждения нового email, токен добавляется в query-параметр token
+	ChangeURL string
+}
+
+// MFAConfig — параметры второго фактора (TOTP)
+type MFAConfig struct {
+	// Issuer — название сервиса, которое приложение-аутентификатор показывает рядом с аккаунтом
+	Issuer string
+	// ChallengeTTL — сколько живёт MFA-токен, выданный Login, пока пользователь вводит код
+	ChallengeTTL time.Duration
+	// MaxAttempts — сколько кодов можно ввести по одному MFA-токену
+	MaxAttempts int
+	// RequiredForAdmins — права администратора действуют, только если у пользователя включён TOTP
+	RequiredForAdmins bool
+}
+
+// BruteForceConfig — защита входа от перебора паролей. Неудачные попытки считаются отдельно
+// по email и по IP клиента; нулевой порог отключает соответствующий счётчик.
+type BruteForceConfig struct {
+	// EmailThreshold — сколько неудач подряд по одному email приводит к блокировке
+	EmailThreshold int
+	// IPThreshold — сколько неудач с одного IP приводит к блокировке
+	IPThreshold int
+	// LockoutDuration — срок первой блокировки; каждая следующая неудача после неё удваивает срок
+	LockoutDuration time.Duration
+	// MaxLockout — предельный срок блокировки
+	MaxLockout time.Duration
+	// Window — через сколько после последней неудачи счётчик начинается заново
+	Window time.Duration
 }
 
 type TokenOperation struct {
@@ -35,29 +109,209 @@ type TokenOperation struct {
 // TODO: структура для updateToken(userid, appid, token)
 
 type TokenStorage interface {
-	SaveToken(ctx context.Context, userID int64, appID int, token string, expiresAt time.Time) (int64, error)
+	SaveToken(ctx context.Context, userID int64, appID int, token string, expiresAt time.Time, client models.ClientInfo) (int64, error)
 	IsRefreshTokenValid(ctx context.Context, userID int64, appID int, token string) (bool, error)
 	DeleteRefreshToken(ctx context.Context, userID int64, appID int, token string) error
+	RevokeRefreshTokens(ctx context.Context, userID int64) error
+	// RotateRefreshToken заменяет oldToken на newToken в той же семье токенов. Если oldToken
+	// уже был заменён раньше, вся семья отзывается и возвращается storage.ErrRefreshTokenReused.
+	RotateRefreshToken(ctx context.Context, userID int64, appID int, oldToken, newToken string, expiresAt time.Time) (familyID int64, err error)
+	Sessions(ctx context.Context, userID int64) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID int64) error
+	// RefreshTokenRecords возвращает все refresh токены пользователя, включая отозванные и истёкшие
+	RefreshTokenRecords(ctx context.Context, userID int64) ([]models.RefreshTokenRecord, error)
+}
+
+// LoginLockoutStorage хранит счётчики неудачных попыток входа и блокировки
+type LoginLockoutStorage interface {
+	LoginLockedUntil(ctx context.Context, email, ip string) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, kind, key string, now, windowStart time.Time) (failures int, err error)
+	LockLogin(ctx context.Context, kind, key string, until time.Time) error
+	ResetLoginFailures(ctx context.Context, kind, key string) error
+	ActiveLoginLockouts(ctx context.Context, now time.Time) ([]models.LoginLockout, error)
+	ClearLoginLockout(ctx context.Context, kind, key string) error
+}
+
+// RoleStorage хранит роли пользователей. Роли и их права задаются миграциями.
+type RoleStorage interface {
+	UserRoles(ctx context.Context, userID int64) ([]models.Role, error)
+	AssignRole(ctx context.Context, userID int64, role string) error
+	RevokeRole(ctx context.Context, userID int64, role string) error
+}
+
+// OAuthStorage хранит коды авторизации OAuth и согласия пользователей на доступ клиентов
+type OAuthStorage interface {
+	SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash []byte, now time.Time) (models.AuthorizationCode, error)
+	OAuthConsent(ctx context.Context, userID int64, appID int) (models.OAuthConsent, error)
+	OAuthConsents(ctx context.Context, userID int64) ([]models.OAuthConsent, error)
+	SaveOAuthConsent(ctx context.Context, userID int64, appID int, scope []string) error
+	// DeleteOAuthConsent удаляет согласие и отзывает refresh токены, выданные клиенту
+	DeleteOAuthConsent(ctx context.Context, userID int64, appID int) error
+}
+
+// FederationStorage хранит незавершённые входы через внешних провайдеров и привязанные учётные записи провайдеров
+type FederationStorage interface {
+	SaveFederationState(ctx context.Context, state models.FederationState) error
+	ConsumeFederationState(ctx context.Context, stateHash []byte, provider string, now time.Time) (models.FederationState, error)
+	UserIdentity(ctx context.Context, provider, subject string) (models.UserIdentity, error)
+	SaveUserIdentity(ctx context.Context, identity models.UserIdentity) error
+	// SaveFederatedUser создаёт пользователя вместе с привязкой учётной записи провайдера
+	SaveFederatedUser(ctx context.Context, email string, passHash []byte, emailVerified bool, identity models.UserIdentity) (int64, error)
+}
+
+// IdentityProvider — внешний провайдер OpenID Connect (корпоративный IdP) для федеративного входа
+type IdentityProvider interface {
+	// AuthCodeURL возвращает адрес входа у провайдера для запроса с кодом авторизации и PKCE (S256)
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange обменивает код на ID token и проверяет его подпись, iss, aud, срок действия и nonce
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (models.ExternalIdentity, error)
+}
+
+// SecurityEventStorage — журнал аудита событий безопасности. Журнал только дополняется;
+// удаляются лишь записи старше срока хранения.
+type SecurityEventStorage interface {
+	SaveSecurityEvent(ctx context.Context, event models.SecurityEvent) error
+	SecurityEvents(ctx context.Context, filter models.SecurityEventFilter) ([]models.SecurityEvent, error)
+	DeleteSecurityEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// BanStorage хранит баны и приостановки пользователей. У пользователя не больше одной блокировки.
+type BanStorage interface {
+	// SaveBan сохраняет блокировку, заменяя прежнюю
+	SaveBan(ctx context.Context, ban models.Ban) error
+	DeleteBan(ctx context.Context, userID int64) error
+	// ActiveBan возвращает storage.ErrBanNotFound, если блокировки нет, и storage.ErrUserNotFound,
+	// если нет самого пользователя
+	ActiveBan(ctx context.Context, userID int64, now time.Time) (models.Ban, error)
+	ActiveBans(ctx context.Context, now time.Time) ([]models.Ban, error)
 }
 
 type UserSaver interface {
 	SaveUser(ctx context.Context, email string, passHash []byte) (uid int64, err error)
+	UpdatePassword(ctx context.Context, userID int64, passHash []byte) error
+	// UpdateProfile заменяет профиль пользователя user.ID; пустой Username снимает имя пользователя
+	UpdateProfile(ctx context.Context, user models.User) error
+	// DeleteUser удаляет пользователя со всеми его данными и записывает событие удаления аккаунта
+	DeleteUser(ctx context.Context, userID int64) (erasureID int64, err error)
 }
 
 type UserProvider interface {
 	User(ctx context.Context, email string) (user models.User, err error)
+	UserByID(ctx context.Context, userID int64) (user models.User, err error)
 	IsAdmin(ctx context.Context, userID int64) (bool, error)
+	// AccountErasures возвращает не более limit событий удаления аккаунтов с id больше afterID
+	AccountErasures(ctx context.Context, afterID int64, limit int) ([]models.AccountErasure, error)
+	// AccountErasuresSince возвращает события удаления аккаунтов, записанные не раньше since
+	AccountErasuresSince(ctx context.Context, since time.Time) ([]models.AccountErasure, error)
 }
 
 type AppProvider interface {
 	App(ctx context.Context, appID int) (models.App, error)
+	// Apps возвращает все приложения, включая отключённые
+	Apps(ctx context.Context) ([]models.App, error)
+	// AppAllowsOrigin сообщает, разрешён ли origin хотя бы одному включённому приложению
+	AppAllowsOrigin(ctx context.Context, origin string) (bool, error)
+}
+
+// AppSaver создаёт и меняет приложения
+type AppSaver interface {
+	SaveApp(ctx context.Context, app models.App) (int, error)
+	UpdateApp(ctx context.Context, app models.App) error
+	UpdateAppSecret(ctx context.Context, appID int, secret string) error
+	// DisableApp отключает приложение и отзывает его refresh токены
+	DisableApp(ctx context.Context, appID int) error
+}
+
+// PasswordResetStorage хранит хэши одноразовых токенов сброса пароля
+type PasswordResetStorage interface {
+	SavePasswordResetToken(ctx context.Context, userID int64, tokenHash []byte, expiresAt time.Time) error
+	// PasswordResetTokenUser возвращает владельца действующего токена, не погашая токен
+	PasswordResetTokenUser(ctx context.Context, tokenHash []byte, now time.Time) (userID int64, err error)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash []byte, now time.Time) (userID int64, err error)
+}
+
+// EmailVerificationStorage хранит хэши одноразовых токенов подтверждения email
+type EmailVerificationStorage interface {
+	SaveEmailVerificationToken(ctx context.Context, userID int64, tokenHash []byte, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash []byte, now time.Time) (userID int64, err error)
+	SaveEmailChangeToken(ctx context.Context, userID int64, newEmail string, tokenHash []byte, expiresAt time.Time) error
+	// ConfirmEmailChange погашает токен смены email и записывает новый адрес как подтверждённый
+	ConfirmEmailChange(ctx context.Context, tokenHash []byte, now time.Time) (userID int64, err error)
+}
+
+// MFAStorage хранит TOTP-секреты, коды восстановления и незавершённые входы с вторым фактором
+type MFAStorage interface {
+	SaveTOTPSecret(ctx context.Context, userID int64, secret []byte) error
+	TOTP(ctx context.Context, userID int64) (models.TOTP, error)
+	EnableTOTP(ctx context.Context, userID int64, step int64, recoveryCodeHashes [][]byte) error
+	DeleteTOTP(ctx context.Context, userID int64) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash []byte, now time.Time) error
+	SaveMFAChallenge(ctx context.Context, userID int64, appID int, tokenHash []byte, expiresAt time.Time) error
+	AttemptMFAChallenge(ctx context.Context, tokenHash []byte, now time.Time, maxAttempts int) (models.MFAChallenge, error)
+	CompleteMFAChallenge(ctx context.Context, tokenHash []byte, now time.Time) error
+}
+
+// SecretCipher шифрует секреты перед записью в хранилище
+type SecretCipher interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(ciphertext []byte) ([]byte, error)
+}
+
+// KeyProvider хранит ключи подписи токенов приложений, ротирует их и отдаёт открытые ключи для JWKS.
+// Если SigningKey возвращает nil, токены подписываются HS256 секретом приложения.
+type KeyProvider interface {
+	SigningKey(ctx context.Context, appID int) (*jwt.SigningKey, error)
+	VerificationKey(ctx context.Context, appID int, kid string) (jwt.SigningKey, error)
+	AcceptsHMAC(ctx context.Context, appID int) (bool, error)
+	PublicKeys(ctx context.Context) ([]jwt.SigningKey, error)
+	Rotate(ctx context.Context, appID int, algorithm string) (jwt.SigningKey, error)
+}
+
+// PasswordHasher хэширует пароли. Verify сообщает needsRehash, если хэш создан устаревшим
+// алгоритмом или с устаревшими параметрами.
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	Verify(hash []byte, password string) (ok bool, needsRehash bool, err error)
+}
+
+// PasswordPolicy проверяет новые пароли и возвращает все нарушенные правила
+type PasswordPolicy interface {
+	Check(password, email string) []passpolicy.Violation
+}
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
 }
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserExists         = errors.New("user already exists")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrAppNotFound        = errors.New("app not found")
+	ErrInvalidCredentials   = errors.New("invalid credentials")
+	ErrUserExists           = errors.New("user already exists")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrAppNotFound          = errors.New("app not found")
+	ErrAppExists            = errors.New("app with this name already exists")
+	ErrAppDisabled          = errors.New("app is disabled")
+	ErrInvalidResetToken    = errors.New("invalid or expired reset token")
+	ErrInvalidVerifyToken   = errors.New("invalid or expired verification token")
+	ErrEmailNotVerified     = errors.New("email is not verified")
+	ErrInvalidAccessToken   = errors.New("invalid access token")
+	ErrMFARequired          = errors.New("mfa code is required")
+	ErrMFAAlreadyEnabled    = errors.New("mfa is already enabled")
+	ErrMFANotEnabled        = errors.New("mfa is not enabled")
+	ErrInvalidMFACode       = errors.New("invalid mfa code")
+	ErrInvalidMFAToken      = errors.New("invalid or expired mfa token")
+	ErrPermissionDenied     = errors.New("permission denied")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+	ErrLockoutNotFound      = errors.New("login lockout not found")
+	ErrInvalidLockoutKind   = errors.New("lockout kind must be email or ip")
+	ErrWeakPassword         = errors.New("password does not meet policy")
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleNotAssigned      = errors.New("role is not assigned to user")
+	ErrConsentNotFound      = errors.New("oauth consent not found")
 )
 
 // NewAuth return a new instance of the Auth service
@@ -66,24 +320,71 @@ func NewAuth(
 	userSaver UserSaver,
 	userProvider UserProvider,
 	appProvider AppProvider,
+	appSaver AppSaver,
 	tokenStorage TokenStorage,
+	passwordResetStorage PasswordResetStorage,
+	verificationStorage EmailVerificationStorage,
+	mfaStorage MFAStorage,
+	securityEvents SecurityEventStorage,
+	lockouts LoginLockoutStorage,
+	roles RoleStorage,
+	oauthStorage OAuthStorage,
+	federationStorage FederationStorage,
+	bans BanStorage,
+	passwordHasher PasswordHasher,
+	passwordPolicy PasswordPolicy,
+	mailer Mailer,
+	secretCipher SecretCipher,
+	keys KeyProvider,
 	accessTokenTTL time.Duration,
 	refreshTokenTTL time.Duration,
+	passwordReset PasswordResetConfig,
+	emailVerification EmailVerificationConfig,
+	mfa MFAConfig,
+	bruteForce BruteForceConfig,
+	oauth OAuthConfig,
+	federation FederationConfig,
+	audit AuditConfig,
 ) *Auth {
 	return &Auth{
-		log:             log,
-		userSaver:       userSaver,
-		userProvider:    userProvider,
-		appProvider:     appProvider,
-		tokenStorage:    tokenStorage,
-		accessTokenTTL:  accessTokenTTL,
-		refreshTokenTTL: refreshTokenTTL,
+		log:                  log,
+		userSaver:            userSaver,
+		userProvider:         userProvider,
+		appProvider:          appProvider,
+		appSaver:             appSaver,
+		tokenStorage:         tokenStorage,
+		passwordResetStorage: passwordResetStorage,
+		verificationStorage:  verificationStorage,
+		mfaStorage:           mfaStorage,
+		securityEvents:       securityEvents,
+		lockouts:             lockouts,
+		roles:                roles,
+		oauthStorage:         oauthStorage,
+		federationStorage:    federationStorage,
+		bans:                 bans,
+		passwordHasher:       passwordHasher,
+		passwordPolicy:       passwordPolicy,
+		mailer:               mailer,
+		secretCipher:         secretCipher,
+		keys:                 keys,
+		accessTokenTTL:       accessTokenTTL,
+		refreshTokenTTL:      refreshTokenTTL,
+		passwordReset:        passwordReset,
+		emailVerification:    emailVerification,
+		mfa:                  mfa,
+		bruteForce:           bruteForce,
+		oauth:                oauth,
+		federation:           federation,
+		audit:                audit,
 	}
 }
 
 // Login checks if user with given credentials exists in the system and returns access token.
 // If user exists, but password is incorrect, returns error.
 // If user doesn`t exist, returns error.
+// If user has TOTP enabled, returns *MFAChallengeError with a token for VerifyMFA instead of tokens.
+// If there were too many failed attempts for the email or client IP, returns *LoginLockedError.
+// If user is banned or suspended, returns *UserBannedError.
 func (auth *Auth) Login(ctx context.Context, email, password string, appID int) (string, string, int64, error) {
 	const op = "auth.Login"
 
@@ -92,10 +393,23 @@ func (auth *Auth) Login(ctx context.Context, email, password string, appID int)
 
 	log.Info("attempting to login user")
 
+	if err := auth.checkLoginLockout(ctx, email); err != nil {
+		var locked *LoginLockedError
+		if errors.As(err, &locked) {
+			log.Warn("login is locked", slog.Duration("retry_after", locked.RetryAfter))
+			auth.auditFailure(ctx, models.SecurityEventLogin, 0, appID, err, map[string]any{"email": email})
+		} else {
+			log.Error("failed to check login lockout", sl.Err(err))
+		}
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	user, err := auth.userProvider.User(ctx, email)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			auth.log.Warn("user not found", sl.Err(err))
+			auth.recordLoginFailure(ctx, email)
+			auth.auditFailure(ctx, models.SecurityEventLogin, 0, appID, ErrInvalidCredentials, map[string]any{"email": email})
 			return "", "", 0, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 		}
 
@@ -103,34 +417,126 @@ func (auth *Auth) Login(ctx context.Context, email, password string, appID int)
 		return "", "", 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		auth.log.Info("invalid credentials", sl.Err(err))
+	ok, needsRehash, err := auth.passwordHasher.Verify(user.PassHash, password)
+	if err != nil {
+		log.Error("failed to verify password hash", sl.Err(err))
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		auth.log.Info("invalid credentials")
+		auth.recordLoginFailure(ctx, email)
+		auth.auditFailure(ctx, models.SecurityEventLogin, user.ID, appID, ErrInvalidCredentials, nil)
 		return "", "", 0, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
 
-	app, err := auth.appProvider.App(ctx, appID)
+	auth.resetLoginFailures(ctx, email)
+
+	if needsRehash {
+		auth.rehashPassword(ctx, user.ID, password)
+	}
+
+	if err := auth.checkBan(ctx, user.ID); err != nil {
+		if errors.Is(err, ErrUserBanned) {
+			log.Info("user is banned")
+			auth.auditFailure(ctx, models.SecurityEventLogin, user.ID, appID, err, nil)
+		}
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if auth.emailVerification.RequiredForLogin && !user.EmailVerified {
+		log.Info("email is not verified")
+		auth.auditFailure(ctx, models.SecurityEventLogin, user.ID, appID, ErrEmailNotVerified, nil)
+		return "", "", 0, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
+
+	app, err := auth.activeApp(ctx, appID)
+	if err != nil {
+		auth.auditFailure(ctx, models.SecurityEventLogin, user.ID, appID, err, nil)
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mfaEnabled, err := auth.mfaEnabled(ctx, user.ID)
 	if err != nil {
+		log.Error("failed to check mfa", sl.Err(err))
 		return "", "", 0, fmt.Errorf("%s: %w", op, err)
 	}
+	if mfaEnabled {
+		challenge, err := auth.newMFAChallenge(ctx, user.ID, appID)
+		if err != nil {
+			log.Error("failed to create mfa challenge", sl.Err(err))
+			return "", "", 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("password accepted, waiting for mfa code")
+		return "", "", 0, fmt.Errorf("%s: %w", op, challenge)
+	}
+
 	log.Info("successfully logged in")
 
-	tokenPair, err := jwt.NewTokenPair(user, app, auth.accessTokenTTL, auth.refreshTokenTTL)
+	tokenPair, err := auth.issueTokens(ctx, user, app)
 	if err != nil {
-		auth.log.Error("failed to generate token pair", sl.Err(err))
 		return "", "", 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	refreshTokenSave, errTokenSave := auth.tokenStorage.SaveToken(ctx, user.ID, appID, tokenPair.RefreshToken, time.Now().Add(auth.refreshTokenTTL))
+	auth.auditSuccess(ctx, models.SecurityEventLogin, user.ID, app.ID, map[string]any{"method": "password"})
+
+	return tokenPair.AccessToken, tokenPair.RefreshToken, user.ID, nil
+}
+
+// issueTokens выпускает пару токенов и сохраняет refresh token
+func (auth *Auth) issueTokens(ctx context.Context, user models.User, app models.App) (*jwt.TokenPair, error) {
+	user, err := auth.withRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := auth.signingKey(ctx, app)
+	if err != nil {
+		auth.log.Error("failed to get signing key", sl.Err(err))
+		return nil, err
+	}
+
+	accessTTL, refreshTTL := auth.tokenTTLs(app)
+
+	tokenPair, err := jwt.NewTokenPair(user, app, key, accessTTL, refreshTTL)
+	if err != nil {
+		auth.log.Error("failed to generate token pair", sl.Err(err))
+		return nil, err
+	}
+
+	refreshTokenSave, errTokenSave := auth.tokenStorage.SaveToken(ctx, user.ID, app.ID, tokenPair.RefreshToken, time.Now().Add(refreshTTL), clientinfo.FromContext(ctx))
 	if errTokenSave != nil {
 		auth.log.Error("failed to save refresh token", sl.Err(errTokenSave))
-		return "", "", 0, fmt.Errorf("%s: failed to store refresh token with id %d : %w", op, refreshTokenSave, errTokenSave)
+		return nil, fmt.Errorf("failed to store refresh token with id %d : %w", refreshTokenSave, errTokenSave)
 	}
 
-	return tokenPair.AccessToken, tokenPair.RefreshToken, user.ID, nil
+	return tokenPair, nil
+}
+
+// rehashPassword пересчитывает хэш пароля текущим алгоритмом после успешного входа.
+// Ошибка только логируется: пользователь уже вошёл, а хэш пересчитается при следующем входе.
+func (auth *Auth) rehashPassword(ctx context.Context, userID int64, password string) {
+	const op = "auth.rehashPassword"
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", userID))
+
+	passHash, err := auth.passwordHasher.Hash(password)
+	if err != nil {
+		log.Error("failed to generate hash password", sl.Err(err))
+		return
+	}
+
+	if err := auth.userSaver.UpdatePassword(ctx, userID, passHash); err != nil {
+		log.Error("failed to update password hash", sl.Err(err))
+		return
+	}
+
+	log.Info("password rehashed with current parameters")
 }
 
 // RegisterNewUser registers new user in the system and returns user ID.
 // If user with given username already exists, returns error.
+// If password does not meet the password policy, returns *PasswordPolicyError.
 func (auth *Auth) RegisterNewUser(ctx context.Context, email string, pass string) (int64, error) {
 	const op = "auth.RegisterNewUser"
 
@@ -139,8 +545,14 @@ func (auth *Auth) RegisterNewUser(ctx context.Context, email string, pass string
 
 	log.Info("registering user")
 
+	if err := auth.checkPassword(pass, email); err != nil {
+		log.Info("password rejected by policy", sl.Err(err))
+		auth.auditFailure(ctx, models.SecurityEventRegister, 0, 0, err, map[string]any{"email": email})
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	// хэш пароля + соль
-	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	passHash, err := auth.passwordHasher.Hash(pass)
 	if err != nil {
 		log.Error("failed to generate hash password", sl.Err(err))
 
@@ -151,6 +563,7 @@ func (auth *Auth) RegisterNewUser(ctx context.Context, email string, pass string
 	if err != nil {
 		if errors.Is(err, storage.ErrUserAlreadyExists) {
 			log.Warn("user already exists", sl.Err(err))
+			auth.auditFailure(ctx, models.SecurityEventRegister, 0, 0, ErrUserExists, map[string]any{"email": email})
 			return 0, fmt.Errorf("%s: %w", op, ErrUserExists)
 		}
 		log.Error("failed to save user", sl.Err(err))
@@ -159,6 +572,13 @@ func (auth *Auth) RegisterNewUser(ctx context.Context, email string, pass string
 	}
 
 	log.Info("user registered successfully")
+	auth.auditSuccess(ctx, models.SecurityEventRegister, id, 0, map[string]any{"email": email})
+
+	// регистрация не должна падать из-за почты: письмо можно запросить повторно через ResendVerification
+	if err := auth.sendVerificationEmail(ctx, models.User{ID: id, Email: email}); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+	}
+
 	return id, nil
 }
 
@@ -177,6 +597,18 @@ func (auth *Auth) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 		}
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+
+	if isAdmin && auth.mfa.RequiredForAdmins {
+		mfaEnabled, err := auth.mfaEnabled(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		if !mfaEnabled {
+			log.Warn("admin rights are ignored until mfa is enabled")
+			isAdmin = false
+		}
+	}
+
 	log.Info("checking successfully", slog.Bool("isAdmin", isAdmin))
 	return isAdmin, nil
 }
@@ -187,74 +619,132 @@ func (auth *Auth) RefreshTokens(ctx context.Context, refreshToken string, appID
 	log := auth.log.With(slog.String("op", op))
 	log.Info("refreshing token")
 
-	app, err := auth.appProvider.App(ctx, appID)
+	app, err := auth.activeApp(ctx, appID)
 	if err != nil {
+		auth.auditFailure(ctx, models.SecurityEventRefresh, 0, appID, err, nil)
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	token, err := jwtGo.ParseWithClaims(refreshToken, jwtGo.MapClaims{}, func(token *jwtGo.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwtGo.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	newTokens, user, err := auth.rotateTokens(ctx, refreshToken, app, nil)
+	if err != nil {
+		// повторное использование токена уже записано в журнал как refresh_token_reuse
+		if !errors.Is(err, ErrRefreshTokenReused) {
+			auth.auditFailure(ctx, models.SecurityEventRefresh, 0, app.ID, err, nil)
 		}
-		return []byte(app.Secret), nil
-	})
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("successfully refreshed tokens")
+	auth.auditSuccess(ctx, models.SecurityEventRefresh, user.ID, app.ID, nil)
+
+	return newTokens.AccessToken, newTokens.RefreshToken, nil
+}
+
+// rotateTokens проверяет refresh token приложения app и выпускает новую пару взамен.
+// Scope переносится из старого токена; непустой narrowScope сужает его и не может его расширить.
+// Возвращает владельца токена, в user.Scope — scope новой пары.
+func (auth *Auth) rotateTokens(ctx context.Context, refreshToken string, app models.App, narrowScope []string) (*jwt.TokenPair, models.User, error) {
+	log := auth.log.With(slog.Int("app_id", app.ID))
+
+	token, err := jwtGo.ParseWithClaims(refreshToken, jwtGo.MapClaims{}, auth.keyFunc(ctx, app))
 	if err != nil {
-		return "", "", fmt.Errorf("%s: invalid token: %w", op, err)
+		return nil, models.User{}, fmt.Errorf("invalid token: %w", err)
 	}
 
 	claims, ok := token.Claims.(jwtGo.MapClaims)
 	if !ok || !token.Valid {
-		return "", "", fmt.Errorf("%s: invalid token claims", op)
+		return nil, models.User{}, errors.New("invalid token claims")
 	}
 
 	if claims["typ"] != "refresh" {
-		return "", "", fmt.Errorf("%s: invalid token type: expected refresh, got %v", op, claims["typ"])
+		return nil, models.User{}, fmt.Errorf("invalid token type: expected refresh, got %v", claims["typ"])
 	}
 
 	exp, ok := claims["exp"].(float64)
 	if !ok {
-		return "", "", fmt.Errorf("%s: exp claim is missing or invalid", op)
+		return nil, models.User{}, errors.New("exp claim is missing or invalid")
 	}
 	if time.Unix(int64(exp), 0).Before(time.Now()) {
-		return "", "", fmt.Errorf("%s: refresh token is expired", op)
+		return nil, models.User{}, errors.New("refresh token is expired")
 	}
 
 	email, ok := claims["email"].(string)
 	if !ok {
 		log.Error("missing email in token claims", slog.Any("claims", claims))
-		return "", "", fmt.Errorf("%s: email claim missing or invalid", op)
+		return nil, models.User{}, errors.New("email claim missing or invalid")
+	}
+
+	rawScope, _ := claims["scope"].(string)
+	scope := parseScope(rawScope)
+	if len(narrowScope) > 0 {
+		if !scopeCovers(scope, narrowScope) {
+			return nil, models.User{}, &OAuthError{Code: OAuthInvalidScope, Description: "requested scope exceeds the granted scope"}
+		}
+		scope = narrowScope
 	}
 
 	user, err := auth.userProvider.User(ctx, email)
 	if err != nil {
 		log.Error("user not found by email", slog.String("email", email), slog.Any("err", err))
-		return "", "", fmt.Errorf("%s: failed to get user: %w", op, err)
+		return nil, models.User{}, fmt.Errorf("failed to get user: %w", err)
 	}
-	valid, err := auth.tokenStorage.IsRefreshTokenValid(ctx, user.ID, appID, refreshToken)
-	if err != nil {
-		return "", "", fmt.Errorf("%s: failed to validate refresh token: %w", op, err)
+	user.Scope = scope
+
+	// сессии забаненного не отзываются: после окончания приостановки refresh token снова действует
+	if err := auth.checkBan(ctx, user.ID); err != nil {
+		return nil, models.User{}, err
 	}
-	if !valid {
-		return "", "", fmt.Errorf("%s: refresh token is not valid", op)
+
+	user, err = auth.withRoles(ctx, user)
+	if err != nil {
+		return nil, models.User{}, fmt.Errorf("failed to get user roles: %w", err)
 	}
 
-	if err := auth.tokenStorage.DeleteRefreshToken(ctx, user.ID, appID, refreshToken); err != nil {
-		auth.log.Warn("failed to delete refresh token", sl.Err(err))
+	key, err := auth.signingKey(ctx, app)
+	if err != nil {
+		return nil, models.User{}, fmt.Errorf("failed to get signing key: %w", err)
 	}
 
-	newTokens, err := jwt.NewTokenPair(user, app, auth.accessTokenTTL, auth.refreshTokenTTL)
+	accessTTL, refreshTTL := auth.tokenTTLs(app)
+
+	newTokens, err := jwt.NewTokenPair(user, app, key, accessTTL, refreshTTL)
 	if err != nil {
-		return "", "", fmt.Errorf("%s: failed to generate token pair: %w", op, err)
+		return nil, models.User{}, fmt.Errorf("failed to generate token pair: %w", err)
 	}
 
-	if _, err := auth.tokenStorage.SaveToken(ctx, user.ID, appID, newTokens.RefreshToken, time.Now().Add(auth.refreshTokenTTL)); err != nil {
+	familyID, err := auth.tokenStorage.RotateRefreshToken(ctx, user.ID, app.ID, refreshToken, newTokens.RefreshToken, time.Now().Add(refreshTTL))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRefreshTokenReused):
+			auth.reportRefreshTokenReuse(ctx, user.ID, app.ID, familyID)
+			return nil, models.User{}, ErrRefreshTokenReused
+		case errors.Is(err, storage.ErrTokenNotFound):
+			return nil, models.User{}, errors.New("refresh token is not valid")
+		}
 		log.Error("failed to save new refresh token", sl.Err(err))
-		return "", "", fmt.Errorf("%s: failed to store new refresh token: %w", op, err)
+		return nil, models.User{}, fmt.Errorf("failed to store new refresh token: %w", err)
 	}
 
-	log.Info("successfully refreshed tokens")
+	return newTokens, user, nil
+}
 
-	return newTokens.AccessToken, newTokens.RefreshToken, nil
+// reportRefreshTokenReuse фиксирует повторное предъявление уже заменённого refresh token.
+// К этому моменту вся семья токенов уже отозвана хранилищем: токен мог быть украден,
+// и неизвестно, кто из двух предъявивших — законный владелец.
+func (auth *Auth) reportRefreshTokenReuse(ctx context.Context, userID int64, appID int, familyID int64) {
+	const op = "auth.reportRefreshTokenReuse"
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", userID), slog.Int("app_id", appID))
+	log.Warn("refresh token reuse detected, token family revoked", slog.Int64("family_id", familyID))
+
+	// записывается и при выключенном журнале аудита: по этому событию ищут утечки токенов
+	auth.saveSecurityEvent(ctx, models.SecurityEvent{
+		UserID:  userID,
+		AppID:   appID,
+		Type:    models.SecurityEventRefreshTokenReuse,
+		Outcome: models.AuditOutcomeFailure,
+		Details: map[string]any{"family_id": familyID},
+	})
 }
 
 func (auth *Auth) Logout(ctx context.Context, refreshToken string, appID int) error {
@@ -268,12 +758,7 @@ func (auth *Auth) Logout(ctx context.Context, refreshToken string, appID int) er
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	token, err := jwtGo.ParseWithClaims(refreshToken, jwtGo.MapClaims{}, func(token *jwtGo.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwtGo.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(app.Secret), nil
-	})
+	token, err := jwtGo.ParseWithClaims(refreshToken, jwtGo.MapClaims{}, auth.keyFunc(ctx, app))
 	if err != nil {
 		return fmt.Errorf("%s: invalid token: %w", op, err)
 	}
@@ -316,58 +801,306 @@ func (auth *Auth) Logout(ctx context.Context, refreshToken string, appID int) er
 	}
 
 	log.Info("successfully logged out user")
+	auth.auditSuccess(ctx, models.SecurityEventLogout, user.ID, appID, nil)
 	return nil
 }
 
 // ValidateToken валидирует access token! Валидация refresh token требует обращения к бд, поэтому реализована напрямую в RefreshTokens
-func (auth *Auth) ValidateToken(ctx context.Context, accessToken string, appID int) (int64, string, error) {
+func (auth *Auth) ValidateToken(ctx context.Context, accessToken string, appID int) (models.AccessClaims, error) {
 	const op = "auth.ValidateToken"
 	log := auth.log.With(slog.String("op", op))
 	log.Info("validating token")
 
-	app, err := auth.appProvider.App(ctx, appID)
+	app, err := auth.activeApp(ctx, appID)
 	if err != nil {
-		return 0, "", status.Errorf(codes.Internal, "%s: %v", op, err)
+		if errors.Is(err, ErrAppDisabled) {
+			return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: %v", op, err)
+		}
+		return models.AccessClaims{}, status.Errorf(codes.Internal, "%s: %v", op, err)
 	}
 
-	token, err := jwtGo.ParseWithClaims(accessToken, jwtGo.MapClaims{}, func(token *jwtGo.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwtGo.SigningMethodHMAC); !ok {
-			return nil, status.Errorf(codes.Unauthenticated, "%s: unexpected signing method: %v", op, token.Header["alg"])
-		}
-		return []byte(app.Secret), nil
-	})
+	token, err := jwtGo.ParseWithClaims(accessToken, jwtGo.MapClaims{}, auth.keyFunc(ctx, app))
 	if err != nil {
-		return 0, "", status.Errorf(codes.Unauthenticated, "%s: invalid token: %v", op, err)
+		return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: invalid token: %v", op, err)
 	}
 
 	claims, ok := token.Claims.(jwtGo.MapClaims)
 	if !ok || !token.Valid {
-		return 0, "", status.Error(codes.Unauthenticated, op+": invalid token claims")
+		return models.AccessClaims{}, status.Error(codes.Unauthenticated, op+": invalid token claims")
 	}
 
 	if typ, ok := claims["typ"].(string); !ok || typ != "access" {
-		return 0, "", status.Errorf(codes.Unauthenticated, "%s: invalid token type: expected access, got %v", op, claims["typ"])
+		return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: invalid token type: expected access, got %v", op, claims["typ"])
+	}
+
+	// ключ kid уже проверен на принадлежность приложению, но claim app_id должен совпадать всегда:
+	// токен одного приложения не должен приниматься от имени другого
+	if tokenAppID, ok := claims["app_id"].(float64); !ok || int(tokenAppID) != app.ID {
+		return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: token was issued for another app", op)
 	}
 
 	exp, ok := claims["exp"].(float64)
 	if !ok {
-		return 0, "", status.Errorf(codes.Unauthenticated, "%s: exp claim is missing or invalid", op)
+		return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: exp claim is missing or invalid", op)
 	}
 	if time.Unix(int64(exp), 0).Before(time.Now()) {
-		return 0, "", status.Error(codes.Unauthenticated, op+": token is expired")
+		return models.AccessClaims{}, status.Error(codes.Unauthenticated, op+": token is expired")
 	}
 
 	uidFloat, ok := claims["uid"].(float64)
 	if !ok {
-		return 0, "", status.Errorf(codes.Unauthenticated, "%s: userID (uid) not found or invalid in token", op)
+		return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: userID (uid) not found or invalid in token", op)
 	}
 	uid := int64(uidFloat)
 
+	// бан и удаление аккаунта действуют сразу, не дожидаясь истечения уже выданных токенов
+	if err := auth.checkBan(ctx, uid); err != nil {
+		if errors.Is(err, ErrUserBanned) {
+			log.Info("token of banned user rejected", slog.Int64("user_id", uid))
+			return models.AccessClaims{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			log.Info("token of deleted user rejected", slog.Int64("user_id", uid))
+			return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: user no longer exists", op)
+		}
+		return models.AccessClaims{}, status.Errorf(codes.Internal, "%s: %v", op, err)
+	}
+
 	email, ok := claims["email"].(string)
 	if !ok {
-		return 0, "", status.Errorf(codes.Unauthenticated, "%s: email claim missing or invalid", op)
+		return models.AccessClaims{}, status.Errorf(codes.Unauthenticated, "%s: email claim missing or invalid", op)
+	}
+
+	// в токенах, выпущенных до появления подтверждения email, claim отсутствует
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	// в токенах, выпущенных до появления ролей, claim отсутствует
+	var roles []string
+	if rawRoles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range rawRoles {
+			if name, ok := role.(string); ok {
+				roles = append(roles, name)
+			}
+		}
 	}
 
+	// scope есть только у токенов, выданных OAuth-клиентам
+	scope, _ := claims["scope"].(string)
+
 	log.Info("token validated successfully")
-	return uid, email, nil
+	return models.AccessClaims{UserID: uid, Email: email, EmailVerified: emailVerified, Roles: roles, Scope: parseScope(scope)}, nil
+}
+
+// RequestPasswordReset выпускает одноразовый токен сброса пароля и отправляет его на email.
+// Чтобы по ответу нельзя было узнать, зарегистрирован ли email, ошибка возвращается только
+// если не удалось проверить наличие пользователя; всё остальное только логируется.
+func (auth *Auth) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "auth.RequestPasswordReset"
+
+	log := auth.log.With(slog.String("op", op))
+	log.Info("requesting password reset")
+
+	user, err := auth.userProvider.User(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("password reset requested for unknown email")
+			return nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate reset token", sl.Err(err))
+		return nil
+	}
+
+	expiresAt := time.Now().Add(auth.passwordReset.TokenTTL)
+	if err := auth.passwordResetStorage.SavePasswordResetToken(ctx, user.ID, hashOneTimeToken(token), expiresAt); err != nil {
+		log.Error("failed to save reset token", sl.Err(err))
+		return nil
+	}
+
+	body := fmt.Sprintf(
+		"Someone requested a password reset for your account.\n\n"+
+			"To choose a new password, open %s\n\n"+
+			"The link expires at %s. If you did not request a reset, ignore this email.\n",
+		tokenLink(auth.passwordReset.URL, token), expiresAt.UTC().Format(time.RFC1123),
+	)
+	if err := auth.mailer.Send(ctx, user.Email, "Password reset", body); err != nil {
+		log.Error("failed to send reset email", sl.Err(err))
+		return nil
+	}
+
+	log.Info("password reset email sent", slog.Int64("userID", user.ID))
+	return nil
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса. Токен одноразовый.
+// После смены пароля все refresh токены пользователя отзываются.
+// Пароль, не прошедший политику, отклоняется с *PasswordPolicyError, токен при этом остаётся действующим.
+func (auth *Auth) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	const op = "auth.ResetPassword"
+
+	log := auth.log.With(slog.String("op", op))
+	log.Info("resetting password")
+
+	tokenHash := hashOneTimeToken(token)
+
+	userID, err := auth.passwordResetStorage.PasswordResetTokenUser(ctx, tokenHash, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrResetTokenNotFound) {
+			log.Warn("invalid reset token", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+
+		log.Error("failed to get reset token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", userID))
+
+	user, err := auth.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.checkPassword(newPassword, user.Email); err != nil {
+		log.Info("password rejected by policy", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// токен гасится только здесь: между проверкой и этим местом его мог использовать параллельный запрос
+	if _, err := auth.passwordResetStorage.ConsumePasswordResetToken(ctx, tokenHash, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrResetTokenNotFound) {
+			log.Warn("invalid reset token", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+
+		log.Error("failed to consume reset token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := auth.passwordHasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate hash password", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.userSaver.UpdatePassword(ctx, userID, passHash); err != nil {
+		log.Error("failed to update password", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.revokeAllSessions(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password reset successfully")
+	auth.auditSuccess(ctx, models.SecurityEventPasswordChange, userID, 0, map[string]any{"method": "reset"})
+	return nil
+}
+
+// VerifyEmail подтверждает email по токену из письма. Токен одноразовый.
+func (auth *Auth) VerifyEmail(ctx context.Context, token string) error {
+	const op = "auth.VerifyEmail"
+
+	log := auth.log.With(slog.String("op", op))
+	log.Info("verifying email")
+
+	userID, err := auth.verificationStorage.VerifyEmail(ctx, hashOneTimeToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrVerificationTokenNotFound) {
+			log.Warn("invalid verification token", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidVerifyToken)
+		}
+
+		log.Error("failed to verify email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email verified successfully", slog.Int64("userID", userID))
+	return nil
+}
+
+// ResendVerification повторно отправляет письмо с подтверждением. Как и RequestPasswordReset,
+// не раскрывает, зарегистрирован ли email и подтверждён ли он.
+func (auth *Auth) ResendVerification(ctx context.Context, email string) error {
+	const op = "auth.ResendVerification"
+
+	log := auth.log.With(slog.String("op", op))
+	log.Info("resending verification email")
+
+	user, err := auth.userProvider.User(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("verification requested for unknown email")
+			return nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.EmailVerified {
+		log.Info("email is already verified", slog.Int64("userID", user.ID))
+		return nil
+	}
+
+	if err := auth.sendVerificationEmail(ctx, user); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+	}
+
+	return nil
+}
+
+func (auth *Auth) sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, err := newOneTimeToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(auth.emailVerification.TokenTTL)
+	if err := auth.verificationStorage.SaveEmailVerificationToken(ctx, user.ID, hashOneTimeToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Welcome! Please confirm your email address.\n\n"+
+			"To confirm it, open %s\n\n"+
+			"The link expires at %s. If you did not create an account, ignore this email.\n",
+		tokenLink(auth.emailVerification.URL, token), expiresAt.UTC().Format(time.RFC1123),
+	)
+
+	return auth.mailer.Send(ctx, user.Email, "Confirm your email", body)
+}
+
+// newOneTimeToken возвращает случайный токен для ссылок из писем. В хранилище попадает только его хэш.
+func newOneTimeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashOneTimeToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func tokenLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil || base == "" {
+		return token
+	}
+
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+
+	return u.String()
 }
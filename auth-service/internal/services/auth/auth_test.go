@@ -7,7 +7,12 @@ import (
 	"errors"
 	"github.com/14kear/forum-project/auth-service/internal/config"
 	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/clientinfo"
 	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/14kear/forum-project/auth-service/internal/lib/passhash"
+	"github.com/14kear/forum-project/auth-service/internal/lib/passpolicy"
+	"github.com/14kear/forum-project/auth-service/internal/lib/secretbox"
+	"github.com/14kear/forum-project/auth-service/internal/lib/totp"
 	"github.com/14kear/forum-project/auth-service/internal/services/mocks"
 	"github.com/14kear/forum-project/auth-service/internal/storage"
 	"github.com/14kear/forum-project/auth-service/utils"
@@ -16,7 +21,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -28,7 +37,112 @@ func newTestAuth(
 	ts *mocks.MockTokenStorage,
 	ap *mocks.MockAppProvider,
 ) *Auth {
-	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), us, up, ap, ts, time.Minute, time.Hour)
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), us, up, ap, nil, ts, nil, nil, noMFA(ctrl), nil, nil, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+// legacyKeys — ключей подписи нет, токены подписываются секретом приложения
+func legacyKeys(ctrl *gomock.Controller) *mocks.MockKeyProvider {
+	kp := mocks.NewMockKeyProvider(ctrl)
+	kp.EXPECT().SigningKey(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	kp.EXPECT().AcceptsHMAC(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	return kp
+}
+
+// noMFA — хранилище, в котором ни у кого не включён второй фактор
+func noMFA(ctrl *gomock.Controller) *mocks.MockMFAStorage {
+	ms := mocks.NewMockMFAStorage(ctrl)
+	ms.EXPECT().TOTP(gomock.Any(), gomock.Any()).Return(models.TOTP{}, storage.ErrTOTPNotFound).AnyTimes()
+	return ms
+}
+
+func notBanned(ctrl *gomock.Controller) *mocks.MockBanStorage {
+	bs := mocks.NewMockBanStorage(ctrl)
+	bs.EXPECT().ActiveBan(gomock.Any(), gomock.Any(), gomock.Any()).Return(models.Ban{}, storage.ErrBanNotFound).AnyTimes()
+	return bs
+}
+
+func newTestAuthWithReset(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	us *mocks.MockUserSaver,
+	ts *mocks.MockTokenStorage,
+	rs *mocks.MockPasswordResetStorage,
+	m *mocks.MockMailer,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), us, up, nil, nil, ts, rs, nil, noMFA(ctrl), nil, nil, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), m, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{
+		TokenTTL: time.Hour,
+		URL:      "http://localhost:3000/reset-password",
+	}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+func newTestAuthWithVerification(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	us *mocks.MockUserSaver,
+	ap *mocks.MockAppProvider,
+	vs *mocks.MockEmailVerificationStorage,
+	m *mocks.MockMailer,
+	requiredForLogin bool,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), us, up, ap, nil, nil, nil, vs, noMFA(ctrl), nil, nil, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), m, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{
+		TokenTTL:         24 * time.Hour,
+		URL:              "http://localhost:3000/verify-email",
+		RequiredForLogin: requiredForLogin,
+	}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+func newTestAuthWithMFA(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	ts *mocks.MockTokenStorage,
+	ap *mocks.MockAppProvider,
+	ms *mocks.MockMFAStorage,
+) *Auth {
+	box, err := secretbox.New(make([]byte, secretbox.KeySize))
+	if err != nil {
+		panic(err)
+	}
+
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, ms, nil, nil, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, box, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{
+		Issuer:            "forum-project",
+		ChallengeTTL:      5 * time.Minute,
+		MaxAttempts:       5,
+		RequiredForAdmins: true,
+	}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+// regularUser — у любого пользователя только роль user
+func regularUser(ctrl *gomock.Controller) *mocks.MockRoleStorage {
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), gomock.Any()).
+		Return([]models.Role{{Name: RoleUser, Permissions: []string{PermissionPostCreate}}}, nil).AnyTimes()
+	return rs
+}
+
+// defaultPolicy — политика без требований, кроме предельной длины; в тестах политики её заменяют на strictPolicy
+func defaultPolicy() *passpolicy.Policy {
+	policy, err := passpolicy.New(passpolicy.Config{})
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+func strictPolicy() *passpolicy.Policy {
+	policy, err := passpolicy.New(passpolicy.Config{MinLength: 8, RequireDigit: true, RejectEmailSimilar: true})
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+// currentHasher — хэши из mustHash считаются актуальными и не пересчитываются при входе
+func currentHasher() *passhash.Hasher {
+	hasher, err := passhash.New(passhash.Config{Algorithm: passhash.AlgBcrypt, BcryptCost: bcrypt.DefaultCost})
+	if err != nil {
+		panic(err)
+	}
+	return hasher
 }
 
 func mustHash(s string) []byte {
@@ -67,7 +181,7 @@ func TestAuth_Login_Success(t *testing.T) {
 
 	mockUserProvider.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
 	mockAppProvider.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
-	mockTokenStorage.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	mockTokenStorage.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
 
 	authTest := newTestAuth(ctrl, mockUserProvider, nil, mockTokenStorage, mockAppProvider)
 
@@ -152,7 +266,7 @@ func TestAuth_Login_SaveTokenError(t *testing.T) {
 
 	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
 	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
-	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any()).Return(int64(1), errors.New("save token error"))
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), errors.New("save token error"))
 
 	authTest := newTestAuth(ctrl, up, nil, ts, ap)
 
@@ -166,9 +280,50 @@ func TestAuth_Register_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	us := mocks.NewMockUserSaver(ctrl)
+	vs := mocks.NewMockEmailVerificationStorage(ctrl)
+	m := mocks.NewMockMailer(ctrl)
+
 	us.EXPECT().SaveUser(gomock.Any(), "windows@mail.ru", gomock.Any()).Return(int64(111), nil)
 
-	authTest := newTestAuth(ctrl, nil, us, nil, nil)
+	var savedHash []byte
+	vs.EXPECT().SaveEmailVerificationToken(gomock.Any(), int64(111), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, hash []byte, expiresAt time.Time) error {
+			savedHash = hash
+			assert.WithinDuration(t, time.Now().Add(24*time.Hour), expiresAt, time.Minute)
+			return nil
+		})
+
+	var body string
+	m.EXPECT().Send(gomock.Any(), "windows@mail.ru", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _, b string) error {
+			body = b
+			return nil
+		})
+
+	authTest := newTestAuthWithVerification(ctrl, nil, us, nil, vs, m, false)
+
+	uid, err := authTest.RegisterNewUser(context.Background(), "windows@mail.ru", "pass")
+	require.NoError(t, err)
+	assert.Equal(t, int64(111), uid)
+
+	require.Contains(t, body, "http://localhost:3000/verify-email?token=")
+	start := strings.Index(body, "token=") + len("token=")
+	assert.Equal(t, hashOneTimeToken(strings.Fields(body[start:])[0]), savedHash)
+}
+
+func TestAuth_Register_MailerFailureIsHidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	us := mocks.NewMockUserSaver(ctrl)
+	vs := mocks.NewMockEmailVerificationStorage(ctrl)
+	m := mocks.NewMockMailer(ctrl)
+
+	us.EXPECT().SaveUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(111), nil)
+	vs.EXPECT().SaveEmailVerificationToken(gomock.Any(), int64(111), gomock.Any(), gomock.Any()).Return(nil)
+	m.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("smtp down"))
+
+	authTest := newTestAuthWithVerification(ctrl, nil, us, nil, vs, m, false)
 
 	uid, err := authTest.RegisterNewUser(context.Background(), "windows@mail.ru", "pass")
 	require.NoError(t, err)
@@ -189,6 +344,29 @@ func TestAuth_Register_UserExists(t *testing.T) {
 	assert.ErrorIs(t, err, ErrUserExists)
 }
 
+func TestAuth_Register_WeakPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// пользователь не сохраняется
+	us := mocks.NewMockUserSaver(ctrl)
+
+	authTest := newTestAuth(ctrl, nil, us, nil, nil)
+	authTest.passwordPolicy = strictPolicy()
+
+	_, err := authTest.RegisterNewUser(context.Background(), "new@mail.ru", "new")
+
+	var weak *PasswordPolicyError
+	require.ErrorAs(t, err, &weak)
+	assert.ErrorIs(t, err, ErrWeakPassword)
+
+	rules := make([]string, 0, len(weak.Violations))
+	for _, v := range weak.Violations {
+		rules = append(rules, v.Rule)
+	}
+	assert.Equal(t, []string{passpolicy.RuleMinLength, passpolicy.RuleDigit, passpolicy.RuleEmailSimilar}, rules)
+}
+
 func TestAuth_Register_SaveError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -247,7 +425,7 @@ func TestAuth_IsAdmin_Fail(t *testing.T) {
 }
 
 func buildRefreshToken(user models.User, app models.App, ttl time.Duration) string {
-	tokens, _ := jwt.NewTokenPair(user, app, time.Minute, ttl)
+	tokens, _ := jwt.NewTokenPair(user, app, nil, time.Minute, ttl)
 	return tokens.RefreshToken
 }
 
@@ -266,10 +444,7 @@ func TestAuth_RefreshTokens_Success(t *testing.T) {
 
 	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
 	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
-	ts.EXPECT().IsRefreshTokenValid(
-		gomock.Any(), user.ID, app.ID, refresh).Return(true, nil)
-	ts.EXPECT().DeleteRefreshToken(gomock.Any(), user.ID, app.ID, refresh).Return(nil)
-	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any()).Return(int64(228), nil)
+	ts.EXPECT().RotateRefreshToken(gomock.Any(), user.ID, app.ID, refresh, gomock.Any(), gomock.Any()).Return(int64(228), nil)
 
 	authTest := newTestAuth(ctrl, up, nil, ts, ap)
 
@@ -286,7 +461,7 @@ func TestAuth_RefreshTokens_BadType(t *testing.T) {
 	user := models.User{ID: 1, Email: "test@test.com", PassHash: mustHash("test")}
 	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
 
-	tp, _ := jwt.NewTokenPair(user, app, time.Minute, time.Hour)
+	tp, _ := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
 	badRefresh := tp.AccessToken
 
 	ap := mocks.NewMockAppProvider(ctrl)
@@ -358,13 +533,45 @@ func TestAuth_RefreshTokens_TokenInvalid(t *testing.T) {
 
 	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
 	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
-	ts.EXPECT().IsRefreshTokenValid(gomock.Any(), user.ID, app.ID, refresh).Return(false, errors.New("refresh token invalid"))
+	ts.EXPECT().RotateRefreshToken(gomock.Any(), user.ID, app.ID, refresh, gomock.Any(), gomock.Any()).Return(int64(0), storage.ErrTokenNotFound)
 
 	authTest := newTestAuth(ctrl, up, nil, ts, ap)
 
 	_, _, err := authTest.RefreshTokens(context.Background(), refresh, app.ID)
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "refresh token invalid")
+	assert.Contains(t, err.Error(), "refresh token is not valid")
+}
+
+func TestAuth_RefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 1, Email: "test@test.com", PassHash: mustHash("test")}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	refresh := buildRefreshToken(user, app, time.Hour)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	up := mocks.NewMockUserProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ts.EXPECT().RotateRefreshToken(gomock.Any(), user.ID, app.ID, refresh, gomock.Any(), gomock.Any()).Return(int64(42), storage.ErrRefreshTokenReused)
+	es.EXPECT().SaveSecurityEvent(gomock.Any(), models.SecurityEvent{
+		UserID:  user.ID,
+		AppID:   app.ID,
+		Type:    models.SecurityEventRefreshTokenReuse,
+		Outcome: models.AuditOutcomeFailure,
+		Details: map[string]any{"family_id": int64(42)},
+	}).Return(nil)
+
+	authTest := NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), es, nil, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+
+	at, rt, err := authTest.RefreshTokens(context.Background(), refresh, app.ID)
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.Empty(t, at)
+	assert.Empty(t, rt)
 }
 
 func TestAuth_RefreshTokens_FailSaveTokens(t *testing.T) {
@@ -381,10 +588,7 @@ func TestAuth_RefreshTokens_FailSaveTokens(t *testing.T) {
 
 	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
 	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
-	ts.EXPECT().IsRefreshTokenValid(
-		gomock.Any(), user.ID, app.ID, refresh).Return(true, nil)
-	ts.EXPECT().DeleteRefreshToken(gomock.Any(), user.ID, app.ID, refresh).Return(nil)
-	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any()).Return(int64(0), errors.New("failed to store new refresh token"))
+	ts.EXPECT().RotateRefreshToken(gomock.Any(), user.ID, app.ID, refresh, gomock.Any(), gomock.Any()).Return(int64(0), errors.New("connection reset"))
 
 	authTest := newTestAuth(ctrl, up, nil, ts, ap)
 
@@ -440,7 +644,7 @@ func TestAuth_Logout_BadType(t *testing.T) {
 
 	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
 	user := models.User{ID: 555, PassHash: mustHash("test")}
-	tp, _ := jwt.NewTokenPair(user, app, time.Minute, time.Hour)
+	tp, _ := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
 	badRefresh := tp.AccessToken
 
 	ap := mocks.NewMockAppProvider(ctrl)
@@ -460,7 +664,7 @@ func TestAuth_ValidateToken_Success(t *testing.T) {
 	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
 	user := models.User{Email: "test@test.com", PassHash: mustHash("test"), ID: int64(50)}
 
-	tokenPair, _ := jwt.NewTokenPair(user, app, time.Minute, time.Hour)
+	tokenPair, _ := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
 	at := tokenPair.AccessToken
 
 	ap := mocks.NewMockAppProvider(ctrl)
@@ -468,10 +672,11 @@ func TestAuth_ValidateToken_Success(t *testing.T) {
 
 	authTest := newTestAuth(ctrl, nil, nil, nil, ap)
 
-	uid, email, err := authTest.ValidateToken(context.Background(), at, app.ID)
+	claims, err := authTest.ValidateToken(context.Background(), at, app.ID)
 	require.NoError(t, err)
-	assert.Equal(t, int64(50), uid)
-	assert.Equal(t, user.Email, email)
+	assert.Equal(t, int64(50), claims.UserID)
+	assert.Equal(t, user.Email, claims.Email)
+	assert.False(t, claims.EmailVerified)
 }
 
 func TestAuth_ValidateToken_InvalidSigningMethod(t *testing.T) {
@@ -502,7 +707,7 @@ func TestAuth_ValidateToken_InvalidSigningMethod(t *testing.T) {
 
 	authTest := newTestAuth(ctrl, nil, nil, nil, ap)
 
-	_, _, err = authTest.ValidateToken(context.Background(), at, app.ID)
+	_, err = authTest.ValidateToken(context.Background(), at, app.ID)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unexpected signing method")
 }
@@ -514,7 +719,7 @@ func TestAuth_ValidateToken_TokenExpired(t *testing.T) {
 	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
 	user := models.User{ID: 50, Email: "test@test.com", PassHash: mustHash("test")}
 
-	tp, _ := jwt.NewTokenPair(user, app, -time.Hour, time.Hour)
+	tp, _ := jwt.NewTokenPair(user, app, nil, -time.Hour, time.Hour)
 	at := tp.AccessToken
 
 	ap := mocks.NewMockAppProvider(ctrl)
@@ -522,7 +727,7 @@ func TestAuth_ValidateToken_TokenExpired(t *testing.T) {
 
 	authTest := newTestAuth(ctrl, nil, nil, nil, ap)
 
-	_, _, err := authTest.ValidateToken(context.Background(), at, app.ID)
+	_, err := authTest.ValidateToken(context.Background(), at, app.ID)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "token is expired")
 }
@@ -535,9 +740,10 @@ func TestAuth_ValidateToken_BadIDClaim(t *testing.T) {
 	user := models.User{Email: "test@test.com", PassHash: mustHash("test")}
 
 	claims := jwtGo.MapClaims{
-		"email": user.Email,
-		"typ":   "access",
-		"exp":   time.Now().Add(time.Minute).Unix(),
+		"email":  user.Email,
+		"typ":    "access",
+		"app_id": app.ID,
+		"exp":    time.Now().Add(time.Minute).Unix(),
 	}
 	token := jwtGo.NewWithClaims(jwtGo.SigningMethodHS256, claims)
 	at, err := token.SignedString([]byte(app.Secret))
@@ -548,7 +754,7 @@ func TestAuth_ValidateToken_BadIDClaim(t *testing.T) {
 
 	auth := newTestAuth(ctrl, nil, nil, nil, ap)
 
-	_, _, err = auth.ValidateToken(context.Background(), at, app.ID)
+	_, err = auth.ValidateToken(context.Background(), at, app.ID)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "userID (uid) not found")
 }
@@ -561,9 +767,10 @@ func TestAuth_ValidateToken_BadEmailClaim(t *testing.T) {
 	user := models.User{ID: 50, PassHash: mustHash("test")}
 
 	claims := jwtGo.MapClaims{
-		"uid": user.ID,
-		"typ": "access",
-		"exp": time.Now().Add(time.Minute).Unix(),
+		"uid":    user.ID,
+		"typ":    "access",
+		"app_id": app.ID,
+		"exp":    time.Now().Add(time.Minute).Unix(),
 	}
 	token := jwtGo.NewWithClaims(jwtGo.SigningMethodHS256, claims)
 	at, err := token.SignedString([]byte(app.Secret))
@@ -574,7 +781,3527 @@ func TestAuth_ValidateToken_BadEmailClaim(t *testing.T) {
 
 	auth := newTestAuth(ctrl, nil, nil, nil, ap)
 
-	_, _, err = auth.ValidateToken(context.Background(), at, app.ID)
+	_, err = auth.ValidateToken(context.Background(), at, app.ID)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "email claim missing or invalid")
 }
+
+func TestAuth_ValidateToken_AppIDClaimMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	// claim app_id проверяется и у токенов без kid
+	claims := jwtGo.MapClaims{
+		"uid":    int64(50),
+		"email":  "test@test.com",
+		"typ":    "access",
+		"app_id": 2,
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	}
+	at, err := jwtGo.NewWithClaims(jwtGo.SigningMethodHS256, claims).SignedString([]byte(app.Secret))
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	auth := newTestAuth(ctrl, nil, nil, nil, ap)
+
+	_, err = auth.ValidateToken(context.Background(), at, app.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token was issued for another app")
+}
+
+func TestAuth_RequestPasswordReset_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	rs := mocks.NewMockPasswordResetStorage(ctrl)
+	m := mocks.NewMockMailer(ctrl)
+
+	user := models.User{ID: 7, Email: "test@test.com"}
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+
+	var savedHash []byte
+	rs.EXPECT().SavePasswordResetToken(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, hash []byte, expiresAt time.Time) error {
+			savedHash = hash
+			assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+			return nil
+		})
+
+	var body string
+	m.EXPECT().Send(gomock.Any(), user.Email, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _, b string) error {
+			body = b
+			return nil
+		})
+
+	auth := newTestAuthWithReset(ctrl, up, nil, nil, rs, m)
+
+	require.NoError(t, auth.RequestPasswordReset(context.Background(), user.Email))
+
+	// в письме токен в открытом виде, в хранилище — только его хэш
+	require.Contains(t, body, "http://localhost:3000/reset-password?token=")
+	start := strings.Index(body, "token=") + len("token=")
+	token := strings.Fields(body[start:])[0]
+	assert.Equal(t, hashOneTimeToken(token), savedHash)
+	assert.NotContains(t, string(savedHash), token)
+}
+
+func TestAuth_RequestPasswordReset_UnknownEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	rs := mocks.NewMockPasswordResetStorage(ctrl)
+	m := mocks.NewMockMailer(ctrl)
+
+	up.EXPECT().User(gomock.Any(), "nobody@test.com").Return(models.User{}, storage.ErrUserNotFound)
+
+	auth := newTestAuthWithReset(ctrl, up, nil, nil, rs, m)
+
+	// ответ не отличается от ответа для существующего пользователя
+	require.NoError(t, auth.RequestPasswordReset(context.Background(), "nobody@test.com"))
+}
+
+func TestAuth_RequestPasswordReset_MailerFailureIsHidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	rs := mocks.NewMockPasswordResetStorage(ctrl)
+	m := mocks.NewMockMailer(ctrl)
+
+	user := models.User{ID: 7, Email: "test@test.com"}
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	rs.EXPECT().SavePasswordResetToken(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(nil)
+	m.EXPECT().Send(gomock.Any(), user.Email, gomock.Any(), gomock.Any()).Return(errors.New("smtp down"))
+
+	auth := newTestAuthWithReset(ctrl, up, nil, nil, rs, m)
+
+	require.NoError(t, auth.RequestPasswordReset(context.Background(), user.Email))
+}
+
+func TestAuth_ResetPassword_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	us := mocks.NewMockUserSaver(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	rs := mocks.NewMockPasswordResetStorage(ctrl)
+
+	rs.EXPECT().PasswordResetTokenUser(gomock.Any(), hashOneTimeToken("reset-token"), gomock.Any()).Return(int64(7), nil)
+	up.EXPECT().UserByID(gomock.Any(), int64(7)).Return(models.User{ID: 7, Email: "user@mail.ru"}, nil)
+	rs.EXPECT().ConsumePasswordResetToken(gomock.Any(), hashOneTimeToken("reset-token"), gomock.Any()).Return(int64(7), nil)
+	us.EXPECT().UpdatePassword(gomock.Any(), int64(7), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, passHash []byte) error {
+			assert.NoError(t, bcrypt.CompareHashAndPassword(passHash, []byte("newPassword")))
+			return nil
+		})
+	ts.EXPECT().RevokeRefreshTokens(gomock.Any(), int64(7)).Return(nil)
+
+	auth := newTestAuthWithReset(ctrl, up, us, ts, rs, nil)
+
+	require.NoError(t, auth.ResetPassword(context.Background(), "reset-token", "newPassword"))
+}
+
+func TestAuth_ResetPassword_WeakPasswordKeepsToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	rs := mocks.NewMockPasswordResetStorage(ctrl)
+
+	rs.EXPECT().PasswordResetTokenUser(gomock.Any(), hashOneTimeToken("reset-token"), gomock.Any()).Return(int64(7), nil)
+	up.EXPECT().UserByID(gomock.Any(), int64(7)).Return(models.User{ID: 7, Email: "user@mail.ru"}, nil)
+	// ни токен, ни пароль не трогаются
+	rs.EXPECT().ConsumePasswordResetToken(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	auth := newTestAuthWithReset(ctrl, up, nil, nil, rs, nil)
+	auth.passwordPolicy = strictPolicy()
+
+	err := auth.ResetPassword(context.Background(), "reset-token", "user2024")
+
+	var weak *PasswordPolicyError
+	require.ErrorAs(t, err, &weak)
+	require.ErrorIs(t, err, ErrWeakPassword)
+	require.Len(t, weak.Violations, 1)
+	assert.Equal(t, passpolicy.RuleEmailSimilar, weak.Violations[0].Rule)
+}
+
+func TestAuth_ResetPassword_InvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rs := mocks.NewMockPasswordResetStorage(ctrl)
+	rs.EXPECT().PasswordResetTokenUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), storage.ErrResetTokenNotFound)
+
+	auth := newTestAuthWithReset(ctrl, nil, nil, nil, rs, nil)
+
+	err := auth.ResetPassword(context.Background(), "used-token", "newPassword")
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestAuth_Login_EmailNotVerified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+
+	authTest := newTestAuthWithVerification(ctrl, up, nil, nil, nil, nil, true)
+
+	_, _, _, err := authTest.Login(context.Background(), user.Email, "test", 1)
+	require.ErrorIs(t, err, ErrEmailNotVerified)
+}
+
+func TestAuth_Login_EmailVerifiedClaim(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test"), EmailVerified: true}
+
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil).Times(2)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	authTest := newTestAuth(ctrl, up, nil, ts, ap)
+	authTest.emailVerification.RequiredForLogin = true
+
+	at, _, _, err := authTest.Login(context.Background(), user.Email, "test", app.ID)
+	require.NoError(t, err)
+
+	claims, err := authTest.ValidateToken(context.Background(), at, app.ID)
+	require.NoError(t, err)
+	assert.True(t, claims.EmailVerified)
+}
+
+func TestAuth_VerifyEmail_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vs := mocks.NewMockEmailVerificationStorage(ctrl)
+	vs.EXPECT().VerifyEmail(gomock.Any(), hashOneTimeToken("verify-token"), gomock.Any()).Return(int64(7), nil)
+
+	auth := newTestAuthWithVerification(ctrl, nil, nil, nil, vs, nil, false)
+
+	require.NoError(t, auth.VerifyEmail(context.Background(), "verify-token"))
+}
+
+func TestAuth_VerifyEmail_InvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vs := mocks.NewMockEmailVerificationStorage(ctrl)
+	vs.EXPECT().VerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), storage.ErrVerificationTokenNotFound)
+
+	auth := newTestAuthWithVerification(ctrl, nil, nil, nil, vs, nil, false)
+
+	err := auth.VerifyEmail(context.Background(), "used-token")
+	require.ErrorIs(t, err, ErrInvalidVerifyToken)
+}
+
+func TestAuth_ResendVerification_AlreadyVerified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	vs := mocks.NewMockEmailVerificationStorage(ctrl)
+	m := mocks.NewMockMailer(ctrl)
+
+	user := models.User{ID: 7, Email: "test@test.com", EmailVerified: true}
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+
+	auth := newTestAuthWithVerification(ctrl, up, nil, nil, vs, m, false)
+
+	// письмо не отправляется, но ответ такой же, как для неподтверждённого email
+	require.NoError(t, auth.ResendVerification(context.Background(), user.Email))
+}
+
+func TestAuth_ResendVerification_UnknownEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), "nobody@test.com").Return(models.User{}, storage.ErrUserNotFound)
+
+	auth := newTestAuthWithVerification(ctrl, up, nil, nil, nil, nil, false)
+
+	require.NoError(t, auth.ResendVerification(context.Background(), "nobody@test.com"))
+}
+
+// enabledTOTP возвращает включённый второй фактор с известным секретом, зашифрованным так же, как в newTestAuthWithMFA
+func enabledTOTP(t *testing.T, a *Auth, userID int64) (models.TOTP, string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	encrypted, err := a.secretCipher.Seal([]byte(secret))
+	require.NoError(t, err)
+
+	return models.TOTP{UserID: userID, Secret: encrypted, Enabled: true}, secret
+}
+
+func TestAuth_Login_MFARequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ms := mocks.NewMockMFAStorage(ctrl)
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+
+	authTest := newTestAuthWithMFA(ctrl, up, nil, ap, ms)
+	factor, _ := enabledTOTP(t, authTest, user.ID)
+
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ms.EXPECT().TOTP(gomock.Any(), user.ID).Return(factor, nil)
+
+	var savedHash []byte
+	ms.EXPECT().SaveMFAChallenge(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, _ int, hash []byte, expiresAt time.Time) error {
+			savedHash = hash
+			assert.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Minute)
+			return nil
+		})
+
+	// токены не выдаются, пока не введён код
+	at, rt, _, err := authTest.Login(context.Background(), user.Email, "test", app.ID)
+	require.ErrorIs(t, err, ErrMFARequired)
+	assert.Empty(t, at)
+	assert.Empty(t, rt)
+
+	var challenge *MFAChallengeError
+	require.ErrorAs(t, err, &challenge)
+	assert.Equal(t, hashOneTimeToken(challenge.Token), savedHash)
+}
+
+func TestAuth_VerifyMFA_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ms := mocks.NewMockMFAStorage(ctrl)
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 123, Email: "test@test.com"}
+
+	authTest := newTestAuthWithMFA(ctrl, up, ts, ap, ms)
+	factor, secret := enabledTOTP(t, authTest, user.ID)
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+
+	ms.EXPECT().AttemptMFAChallenge(gomock.Any(), hashOneTimeToken("mfa-token"), gomock.Any(), 5).
+		Return(models.MFAChallenge{UserID: user.ID, AppID: app.ID}, nil)
+	ms.EXPECT().TOTP(gomock.Any(), user.ID).Return(factor, nil)
+	ms.EXPECT().UseTOTPStep(gomock.Any(), user.ID, step).Return(true, nil)
+	ms.EXPECT().CompleteMFAChallenge(gomock.Any(), hashOneTimeToken("mfa-token"), gomock.Any()).Return(nil)
+	up.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	at, rt, uid, err := authTest.VerifyMFA(context.Background(), "mfa-token", code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, at)
+	assert.NotEmpty(t, rt)
+	assert.Equal(t, user.ID, uid)
+}
+
+func TestAuth_VerifyMFA_ReplayedCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ms := mocks.NewMockMFAStorage(ctrl)
+
+	authTest := newTestAuthWithMFA(ctrl, nil, nil, nil, ms)
+	factor, secret := enabledTOTP(t, authTest, 123)
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+
+	ms.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(models.MFAChallenge{UserID: 123, AppID: 1}, nil)
+	ms.EXPECT().TOTP(gomock.Any(), int64(123)).Return(factor, nil)
+	// код этого шага уже был принят
+	ms.EXPECT().UseTOTPStep(gomock.Any(), int64(123), step).Return(false, nil)
+
+	_, _, _, err = authTest.VerifyMFA(context.Background(), "mfa-token", code)
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestAuth_VerifyMFA_RecoveryCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ms := mocks.NewMockMFAStorage(ctrl)
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 123, Email: "test@test.com"}
+
+	authTest := newTestAuthWithMFA(ctrl, up, ts, ap, ms)
+	factor, _ := enabledTOTP(t, authTest, user.ID)
+
+	ms.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(models.MFAChallenge{UserID: user.ID, AppID: app.ID}, nil)
+	ms.EXPECT().TOTP(gomock.Any(), user.ID).Return(factor, nil)
+	// регистр и дефис не важны
+	ms.EXPECT().ConsumeRecoveryCode(gomock.Any(), user.ID, hashRecoveryCode("abcde-fghij"), gomock.Any()).Return(nil)
+	ms.EXPECT().CompleteMFAChallenge(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	up.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	_, _, _, err := authTest.VerifyMFA(context.Background(), "mfa-token", "ABCDEFGHIJ")
+	require.NoError(t, err)
+}
+
+func TestAuth_VerifyMFA_InvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ms := mocks.NewMockMFAStorage(ctrl)
+	ms.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(models.MFAChallenge{}, storage.ErrMFAChallengeNotFound)
+
+	authTest := newTestAuthWithMFA(ctrl, nil, nil, nil, ms)
+
+	_, _, _, err := authTest.VerifyMFA(context.Background(), "expired-token", "123456")
+	require.ErrorIs(t, err, ErrInvalidMFAToken)
+}
+
+func TestAuth_ConfirmTOTP_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ms := mocks.NewMockMFAStorage(ctrl)
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 123, Email: "test@test.com"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	authTest := newTestAuthWithMFA(ctrl, nil, nil, ap, ms)
+	factor, secret := enabledTOTP(t, authTest, user.ID)
+	factor.Enabled = false
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ms.EXPECT().TOTP(gomock.Any(), user.ID).Return(factor, nil)
+
+	var savedHashes [][]byte
+	ms.EXPECT().EnableTOTP(gomock.Any(), user.ID, step, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, _ int64, hashes [][]byte) error {
+			savedHashes = hashes
+			return nil
+		})
+
+	recoveryCodes, err := authTest.ConfirmTOTP(context.Background(), tokenPair.AccessToken, app.ID, code)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 10)
+	require.Len(t, savedHashes, 10)
+
+	for i, rc := range recoveryCodes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, rc)
+		assert.Equal(t, hashRecoveryCode(rc), savedHashes[i])
+	}
+}
+
+func TestAuth_ConfirmTOTP_WrongCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ms := mocks.NewMockMFAStorage(ctrl)
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 123, Email: "test@test.com"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	authTest := newTestAuthWithMFA(ctrl, nil, nil, ap, ms)
+	factor, _ := enabledTOTP(t, authTest, user.ID)
+	factor.Enabled = false
+
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ms.EXPECT().TOTP(gomock.Any(), user.ID).Return(factor, nil)
+
+	_, err = authTest.ConfirmTOTP(context.Background(), tokenPair.AccessToken, app.ID, "000000")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestAuth_IsAdmin_RequiresMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ms := mocks.NewMockMFAStorage(ctrl)
+
+	up.EXPECT().IsAdmin(gomock.Any(), int64(1)).Return(true, nil).Times(2)
+	ms.EXPECT().TOTP(gomock.Any(), int64(1)).Return(models.TOTP{}, storage.ErrTOTPNotFound)
+	ms.EXPECT().TOTP(gomock.Any(), int64(1)).Return(models.TOTP{UserID: 1, Enabled: true}, nil)
+
+	authTest := newTestAuthWithMFA(ctrl, up, nil, nil, ms)
+
+	isAdmin, err := authTest.IsAdmin(context.Background(), 1)
+	require.NoError(t, err)
+	assert.False(t, isAdmin)
+
+	isAdmin, err = authTest.IsAdmin(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, isAdmin)
+}
+
+func newTestAuthWithKeys(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	ts *mocks.MockTokenStorage,
+	ap *mocks.MockAppProvider,
+	keys KeyProvider,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), nil, nil, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, keys, time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+func mustKey(t *testing.T, alg string) jwt.SigningKey {
+	key, err := jwt.GenerateKey(alg)
+	require.NoError(t, err)
+	return key
+}
+
+// staticKeys — у приложения один активный ключ key
+func staticKeys(ctrl *gomock.Controller, key jwt.SigningKey) *mocks.MockKeyProvider {
+	kp := mocks.NewMockKeyProvider(ctrl)
+	kp.EXPECT().SigningKey(gomock.Any(), gomock.Any()).Return(&key, nil).AnyTimes()
+	kp.EXPECT().VerificationKey(gomock.Any(), gomock.Any(), key.ID).Return(key, nil).AnyTimes()
+	kp.EXPECT().VerificationKey(gomock.Any(), gomock.Any(), gomock.Any()).Return(jwt.SigningKey{}, jwt.ErrKeyNotFound).AnyTimes()
+	kp.EXPECT().PublicKeys(gomock.Any()).Return([]jwt.SigningKey{key}, nil).AnyTimes()
+	kp.EXPECT().AcceptsHMAC(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	return kp
+}
+
+func TestAuth_Login_SignedWithKey(t *testing.T) {
+	for _, alg := range []string{jwt.AlgEdDSA, jwt.AlgRS256} {
+		t.Run(alg, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+			app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+			key := mustKey(t, alg)
+
+			up := mocks.NewMockUserProvider(ctrl)
+			up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+			ap := mocks.NewMockAppProvider(ctrl)
+			ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil).Times(2)
+			ts := mocks.NewMockTokenStorage(ctrl)
+			ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+			authTest := newTestAuthWithKeys(ctrl, up, ts, ap, staticKeys(ctrl, key))
+
+			at, _, _, err := authTest.Login(context.Background(), user.Email, "test", app.ID)
+			require.NoError(t, err)
+
+			// токен проверяется открытым ключом из JWKS, секрет приложения не нужен
+			jwks, err := authTest.JWKS(context.Background())
+			require.NoError(t, err)
+			require.Len(t, jwks, 1)
+			assert.Equal(t, key.ID, jwks[0].Kid)
+			assert.Equal(t, alg, jwks[0].Alg)
+
+			token, err := jwtGo.Parse(at, func(token *jwtGo.Token) (interface{}, error) {
+				assert.Equal(t, key.ID, token.Header["kid"])
+				return key.Public(), nil
+			}, jwtGo.WithValidMethods([]string{alg}))
+			require.NoError(t, err)
+			assert.True(t, token.Valid)
+
+			claims, err := authTest.ValidateToken(context.Background(), at, app.ID)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, claims.UserID)
+		})
+	}
+}
+
+func TestAuth_ValidateToken_SignedForAnotherApp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := mustKey(t, jwt.AlgEdDSA)
+	user := models.User{ID: 50, Email: "test@test.com"}
+	other := models.App{ID: 2, Secret: "other-secret", Name: "other"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, other, &key, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	authTest := newTestAuthWithKeys(ctrl, nil, nil, ap, staticKeys(ctrl, key))
+
+	_, err = authTest.ValidateToken(context.Background(), tokenPair.AccessToken, app.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token was issued for another app")
+}
+
+func TestAuth_ValidateToken_UnknownKeyID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := mustKey(t, jwt.AlgEdDSA)
+	foreign := mustKey(t, jwt.AlgEdDSA)
+	user := models.User{ID: 50, Email: "test@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, &foreign, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	authTest := newTestAuthWithKeys(ctrl, nil, nil, ap, staticKeys(ctrl, key))
+
+	_, err = authTest.ValidateToken(context.Background(), tokenPair.AccessToken, app.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), jwt.ErrKeyNotFound.Error())
+}
+
+func TestAuth_ValidateToken_LegacyHS256(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 50, Email: "test@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	// токены, выпущенные до перехода на асимметричную подпись, действуют, пока у приложения нет ключа
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	authTest := newTestAuthWithKeys(ctrl, nil, nil, ap, legacyKeys(ctrl))
+
+	claims, err := authTest.ValidateToken(context.Background(), tokenPair.AccessToken, app.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+}
+
+func TestAuth_ValidateToken_HS256RejectedWhenAppHasKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 50, Email: "test@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	authTest := newTestAuthWithKeys(ctrl, nil, nil, ap, staticKeys(ctrl, mustKey(t, jwt.AlgEdDSA)))
+
+	_, err = authTest.ValidateToken(context.Background(), tokenPair.AccessToken, app.ID)
+	require.Error(t, err)
+}
+
+func TestAuth_ForgedTokenWithClientSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// OAuth-клиент знает свой секрет и подписывает им токен от имени администратора
+	claims := jwtGo.MapClaims{
+		"typ":    "access",
+		"uid":    int64(1),
+		"email":  "admin@test.com",
+		"app_id": oauthClient.ID,
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	}
+	forged, err := jwtGo.NewWithClaims(jwtGo.SigningMethodHS256, claims).SignedString([]byte(oauthClient.Secret))
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+	authTest := newTestAuthWithKeys(ctrl, nil, nil, ap, legacyKeys(ctrl))
+
+	_, err = authTest.authenticate(context.Background(), forged, oauthClient.ID)
+	require.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestAuth_IssueTokens_OAuthClientAlwaysSignedWithKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := mustKey(t, jwt.AlgEdDSA)
+
+	// при HS256 у клиента нет ключа, и он создаётся при первом выпуске токенов
+	kp := mocks.NewMockKeyProvider(ctrl)
+	kp.EXPECT().SigningKey(gomock.Any(), oauthClient.ID).Return(nil, nil)
+	kp.EXPECT().Rotate(gomock.Any(), oauthClient.ID, jwt.AlgEdDSA).Return(key, nil)
+
+	authTest := newTestAuthWithKeys(ctrl, nil, nil, nil, kp)
+
+	got, err := authTest.signingKey(context.Background(), oauthClient)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, key.ID, got.ID)
+}
+
+func TestAuth_RefreshTokens_SignedWithKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := mustKey(t, jwt.AlgRS256)
+	user := models.User{ID: 50, Email: "test@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, &key, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().RotateRefreshToken(gomock.Any(), user.ID, app.ID, tokenPair.RefreshToken, gomock.Any(), gomock.Any()).Return(int64(2), nil)
+
+	authTest := newTestAuthWithKeys(ctrl, up, ts, ap, staticKeys(ctrl, key))
+
+	at, rt, err := authTest.RefreshTokens(context.Background(), tokenPair.RefreshToken, app.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, at)
+	assert.NotEmpty(t, rt)
+}
+
+func TestAuth_RotateSigningKey_Admin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := mustKey(t, jwt.AlgEdDSA)
+	next := mustKey(t, jwt.AlgEdDSA)
+	admin := models.User{ID: 1, Email: "admin@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	target := models.App{ID: 2, Secret: "other-secret", Name: "other"}
+
+	tokenPair, err := jwt.NewTokenPair(admin, app, &key, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().IsAdmin(gomock.Any(), admin.ID).Return(true, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ap.EXPECT().App(gomock.Any(), target.ID).Return(target, nil)
+
+	kp := staticKeys(ctrl, key)
+	kp.EXPECT().Rotate(gomock.Any(), target.ID, jwt.AlgRS256).Return(next, nil)
+
+	authTest := newTestAuthWithKeys(ctrl, up, nil, ap, kp)
+
+	kid, err := authTest.RotateSigningKey(context.Background(), tokenPair.AccessToken, app.ID, target.ID, jwt.AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, next.ID, kid)
+}
+
+func TestAuth_RotateSigningKey_NotAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := mustKey(t, jwt.AlgEdDSA)
+	user := models.User{ID: 5, Email: "user@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, &key, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().IsAdmin(gomock.Any(), user.ID).Return(false, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	// ротация не вызывается
+	authTest := newTestAuthWithKeys(ctrl, up, nil, ap, staticKeys(ctrl, key))
+
+	_, err = authTest.RotateSigningKey(context.Background(), tokenPair.AccessToken, app.ID, app.ID, "")
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_RotateSigningKey_AppNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := mustKey(t, jwt.AlgEdDSA)
+	admin := models.User{ID: 1, Email: "admin@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(admin, app, &key, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().IsAdmin(gomock.Any(), admin.ID).Return(true, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ap.EXPECT().App(gomock.Any(), 42).Return(models.App{}, storage.ErrAppNotFound)
+
+	authTest := newTestAuthWithKeys(ctrl, up, nil, ap, staticKeys(ctrl, key))
+
+	_, err = authTest.RotateSigningKey(context.Background(), tokenPair.AccessToken, app.ID, 42, "")
+	require.ErrorIs(t, err, ErrAppNotFound)
+}
+
+func TestAuth_Login_StoresClientInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	client := models.ClientInfo{IP: "203.0.113.7", UserAgent: "Mozilla/5.0"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), client).Return(int64(1), nil)
+
+	authTest := newTestAuth(ctrl, up, nil, ts, ap)
+
+	_, _, _, err := authTest.Login(clientinfo.NewContext(context.Background(), client), user.Email, "test", app.ID)
+	require.NoError(t, err)
+}
+
+func TestAuth_ListSessions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	sessions := []models.Session{
+		{ID: 10, AppID: app.ID, AppName: app.Name, Client: models.ClientInfo{IP: "203.0.113.7", UserAgent: "Mozilla/5.0"}},
+		{ID: 11, AppID: app.ID, AppName: app.Name},
+	}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().Sessions(gomock.Any(), user.ID).Return(sessions, nil)
+
+	authTest := newTestAuth(ctrl, nil, nil, ts, ap)
+
+	got, err := authTest.ListSessions(context.Background(), tokenPair.AccessToken, app.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sessions, got)
+}
+
+func TestAuth_ListSessions_InvalidAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	authTest := newTestAuth(ctrl, nil, nil, nil, ap)
+
+	_, err := authTest.ListSessions(context.Background(), "not-a-token", app.ID)
+	require.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestAuth_RevokeSession_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	// чужая или уже завершённая сессия выглядит одинаково
+	ts.EXPECT().RevokeSession(gomock.Any(), user.ID, int64(99)).Return(storage.ErrSessionNotFound)
+
+	authTest := newTestAuth(ctrl, nil, nil, ts, ap)
+
+	err = authTest.RevokeSession(context.Background(), tokenPair.AccessToken, app.ID, 99)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestAuth_RevokeAllSessions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().RevokeRefreshTokens(gomock.Any(), user.ID).Return(nil)
+
+	authTest := newTestAuth(ctrl, nil, nil, ts, ap)
+
+	err = authTest.RevokeAllSessions(context.Background(), tokenPair.AccessToken, app.ID)
+	require.NoError(t, err)
+}
+
+func newTestAuthWithLockouts(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	ts *mocks.MockTokenStorage,
+	ap *mocks.MockAppProvider,
+	ls *mocks.MockLoginLockoutStorage,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), nil, ls, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{
+		EmailThreshold:  3,
+		IPThreshold:     10,
+		LockoutDuration: time.Minute,
+		MaxLockout:      time.Hour,
+		Window:          time.Hour,
+	}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+func TestAuth_Login_Locked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ls := mocks.NewMockLoginLockoutStorage(ctrl)
+	ls.EXPECT().LoginLockedUntil(gomock.Any(), "test@test.com", "203.0.113.7").Return(time.Now().Add(30*time.Second), nil)
+
+	authTest := newTestAuthWithLockouts(ctrl, nil, nil, nil, ls)
+
+	ctx := clientinfo.NewContext(context.Background(), models.ClientInfo{IP: "203.0.113.7"})
+	_, _, _, err := authTest.Login(ctx, " Test@Test.com", "test", 1)
+	require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+
+	var locked *LoginLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.InDelta(t, 30*time.Second, locked.RetryAfter, float64(time.Second))
+}
+
+func TestAuth_Login_WrongPasswordLocksEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 1, Email: "test@test.com", PassHash: mustHash("test")}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+
+	ls := mocks.NewMockLoginLockoutStorage(ctrl)
+	ls.EXPECT().LoginLockedUntil(gomock.Any(), user.Email, "203.0.113.7").Return(time.Time{}, nil)
+	// третья неудача по email достигает порога, по IP — ещё нет
+	ls.EXPECT().RecordLoginFailure(gomock.Any(), models.LockoutKindEmail, user.Email, gomock.Any(), gomock.Any()).Return(3, nil)
+	ls.EXPECT().RecordLoginFailure(gomock.Any(), models.LockoutKindIP, "203.0.113.7", gomock.Any(), gomock.Any()).Return(3, nil)
+	ls.EXPECT().LockLogin(gomock.Any(), models.LockoutKindEmail, user.Email, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _ string, until time.Time) error {
+			assert.WithinDuration(t, time.Now().Add(time.Minute), until, time.Second)
+			return nil
+		})
+
+	authTest := newTestAuthWithLockouts(ctrl, up, nil, nil, ls)
+
+	ctx := clientinfo.NewContext(context.Background(), models.ClientInfo{IP: "203.0.113.7"})
+	_, _, _, err := authTest.Login(ctx, user.Email, "wrong", 1)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuth_Login_SuccessResetsEmailFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 1, Email: "test@test.com", PassHash: mustHash("test")}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	ls := mocks.NewMockLoginLockoutStorage(ctrl)
+	ls.EXPECT().LoginLockedUntil(gomock.Any(), user.Email, "").Return(time.Now().Add(-time.Minute), nil)
+	ls.EXPECT().ResetLoginFailures(gomock.Any(), models.LockoutKindEmail, user.Email).Return(nil)
+
+	authTest := newTestAuthWithLockouts(ctrl, up, ts, ap, ls)
+
+	_, _, _, err := authTest.Login(context.Background(), user.Email, "test", app.ID)
+	require.NoError(t, err)
+}
+
+func TestBruteForceConfig_LockoutDuration(t *testing.T) {
+	cfg := BruteForceConfig{LockoutDuration: time.Minute, MaxLockout: 10 * time.Minute}
+
+	assert.Equal(t, time.Minute, cfg.lockoutDuration(0))
+	assert.Equal(t, 2*time.Minute, cfg.lockoutDuration(1))
+	assert.Equal(t, 8*time.Minute, cfg.lockoutDuration(3))
+	assert.Equal(t, 10*time.Minute, cfg.lockoutDuration(4))
+	assert.Equal(t, 10*time.Minute, cfg.lockoutDuration(100))
+}
+
+func TestAuth_ClearLoginLockout_InvalidKind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authTest := newTestAuthWithLockouts(ctrl, nil, nil, nil, mocks.NewMockLoginLockoutStorage(ctrl))
+
+	err := authTest.ClearLoginLockout(context.Background(), "token", 1, "user", "test@test.com")
+	require.ErrorIs(t, err, ErrInvalidLockoutKind)
+}
+
+func TestAuth_ListLoginLockouts_NotAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 5, Email: "user@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().IsAdmin(gomock.Any(), user.ID).Return(false, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+
+	authTest := newTestAuthWithLockouts(ctrl, up, nil, ap, mocks.NewMockLoginLockoutStorage(ctrl))
+
+	_, err = authTest.ListLoginLockouts(context.Background(), tokenPair.AccessToken, app.ID)
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_Login_RehashesOutdatedHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	outdated, err := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: outdated}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	us := mocks.NewMockUserSaver(ctrl)
+	us.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, passHash []byte) error {
+			cost, err := bcrypt.Cost(passHash)
+			require.NoError(t, err)
+			assert.Equal(t, bcrypt.DefaultCost, cost)
+			assert.NoError(t, bcrypt.CompareHashAndPassword(passHash, []byte("test")))
+			return nil
+		})
+
+	authTest := newTestAuth(ctrl, up, us, ts, ap)
+
+	_, _, _, err = authTest.Login(context.Background(), user.Email, "test", app.ID)
+	require.NoError(t, err)
+}
+
+func TestAuth_Login_RehashFailureDoesNotBlockLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	outdated, err := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: outdated}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	us := mocks.NewMockUserSaver(ctrl)
+	us.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Any()).Return(errors.New("db is down"))
+
+	authTest := newTestAuth(ctrl, up, us, ts, ap)
+
+	at, _, _, err := authTest.Login(context.Background(), user.Email, "test", app.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, at)
+}
+
+func TestAuth_Login_CorruptedHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: []byte("plain-text")}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+
+	authTest := newTestAuth(ctrl, up, nil, nil, nil)
+
+	_, _, _, err := authTest.Login(context.Background(), user.Email, "plain-text", 1)
+	require.ErrorIs(t, err, passhash.ErrUnknownFormat)
+}
+
+func newTestAuthWithRoles(
+	ctrl *gomock.Controller,
+	ap *mocks.MockAppProvider,
+	ms *mocks.MockMFAStorage,
+	rs *mocks.MockRoleStorage,
+	requireMFAForAdmins bool,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, nil, ap, nil, nil, nil, nil, ms, nil, nil, rs, nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{
+		RequiredForAdmins: requireMFAForAdmins,
+	}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+var (
+	adminRole     = models.Role{Name: RoleAdmin, Permissions: []string{PermissionRolesManage, PermissionAppsManage, PermissionUsersDelete, PermissionAuditRead, PermissionUsersBan, PermissionPostCreate, PermissionPostDeleteAny, PermissionChatManage}}
+	moderatorRole = models.Role{Name: RoleModerator, Permissions: []string{PermissionUsersBan, PermissionPostCreate, PermissionPostDeleteAny}}
+	userRole      = models.Role{Name: RoleUser, Permissions: []string{PermissionPostCreate}}
+)
+
+func TestAuth_HasPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), int64(7)).Return([]models.Role{moderatorRole, userRole}, nil).Times(2)
+
+	authTest := newTestAuthWithRoles(ctrl, nil, noMFA(ctrl), rs, false)
+
+	allowed, err := authTest.HasPermission(context.Background(), 7, PermissionPostDeleteAny)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = authTest.HasPermission(context.Background(), 7, PermissionRolesManage)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestAuth_HasPermission_UserNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), int64(404)).Return(nil, storage.ErrUserNotFound)
+
+	authTest := newTestAuthWithRoles(ctrl, nil, noMFA(ctrl), rs, false)
+
+	_, err := authTest.HasPermission(context.Background(), 404, PermissionPostDeleteAny)
+	require.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAuth_HasPermission_AdminRoleRequiresMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), int64(1)).Return([]models.Role{adminRole, userRole}, nil).Times(2)
+
+	ms := mocks.NewMockMFAStorage(ctrl)
+	gomock.InOrder(
+		ms.EXPECT().TOTP(gomock.Any(), int64(1)).Return(models.TOTP{}, storage.ErrTOTPNotFound),
+		ms.EXPECT().TOTP(gomock.Any(), int64(1)).Return(models.TOTP{Enabled: true}, nil),
+	)
+
+	authTest := newTestAuthWithRoles(ctrl, nil, ms, rs, true)
+
+	allowed, err := authTest.HasPermission(context.Background(), 1, PermissionRolesManage)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = authTest.HasPermission(context.Background(), 1, PermissionRolesManage)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestAuth_AssignRole_PermissionDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 5, Email: "user@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	// роль не выдаётся: AssignRole хранилища не вызывается
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), user.ID).Return([]models.Role{moderatorRole}, nil)
+
+	authTest := newTestAuthWithRoles(ctrl, ap, noMFA(ctrl), rs, false)
+
+	err = authTest.AssignRole(context.Background(), tokenPair.AccessToken, app.ID, 9, RoleAdmin)
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_AssignRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	admin := models.User{ID: 1, Email: "admin@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(admin, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil).Times(2)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), admin.ID).Return([]models.Role{adminRole}, nil).Times(2)
+	rs.EXPECT().AssignRole(gomock.Any(), int64(9), RoleModerator).Return(nil)
+	rs.EXPECT().AssignRole(gomock.Any(), int64(9), "owner").Return(storage.ErrRoleNotFound)
+
+	authTest := newTestAuthWithRoles(ctrl, ap, noMFA(ctrl), rs, false)
+
+	require.NoError(t, authTest.AssignRole(context.Background(), tokenPair.AccessToken, app.ID, 9, RoleModerator))
+
+	err = authTest.AssignRole(context.Background(), tokenPair.AccessToken, app.ID, 9, "owner")
+	require.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestAuth_RevokeRole_NotAssigned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	admin := models.User{ID: 1, Email: "admin@test.com"}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	tokenPair, err := jwt.NewTokenPair(admin, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), admin.ID).Return([]models.Role{adminRole}, nil)
+	rs.EXPECT().RevokeRole(gomock.Any(), int64(9), RoleModerator).Return(storage.ErrRoleNotAssigned)
+
+	authTest := newTestAuthWithRoles(ctrl, ap, noMFA(ctrl), rs, false)
+
+	err = authTest.RevokeRole(context.Background(), tokenPair.AccessToken, app.ID, 9, RoleModerator)
+	require.ErrorIs(t, err, ErrRoleNotAssigned)
+}
+
+func TestAuth_Login_RolesInAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 3, Email: "moderator@test.com", PassHash: mustHash("password")}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil).Times(2)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), user.ID).Return([]models.Role{moderatorRole, userRole}, nil)
+
+	authTest := NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), nil, nil, rs, nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+
+	accessToken, _, _, err := authTest.Login(context.Background(), user.Email, "password", app.ID)
+	require.NoError(t, err)
+
+	claims, err := authTest.ValidateToken(context.Background(), accessToken, app.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleModerator, RoleUser}, claims.Roles)
+}
+
+// oauthClientKey — ключ подписи oauthClient в тестах
+var oauthClientKey = func() jwt.SigningKey {
+	key, err := jwt.GenerateKey(jwt.AlgEdDSA)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// oauthKeys — ключи при алгоритме HS256: у приложений нет ключей, а oauthClient получает oauthClientKey
+// при первом выпуске токенов, потому что токены OAuth-клиентов всегда подписываются ключом
+func oauthKeys(ctrl *gomock.Controller) *mocks.MockKeyProvider {
+	kp := mocks.NewMockKeyProvider(ctrl)
+	kp.EXPECT().SigningKey(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	kp.EXPECT().Rotate(gomock.Any(), oauthClient.ID, jwt.AlgEdDSA).Return(oauthClientKey, nil).AnyTimes()
+	kp.EXPECT().VerificationKey(gomock.Any(), oauthClient.ID, oauthClientKey.ID).Return(oauthClientKey, nil).AnyTimes()
+	kp.EXPECT().VerificationKey(gomock.Any(), gomock.Any(), gomock.Any()).Return(jwt.SigningKey{}, jwt.ErrKeyNotFound).AnyTimes()
+	kp.EXPECT().AcceptsHMAC(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	return kp
+}
+
+// buildOAuthRefreshToken — refresh token oauthClient, подписанный его ключом
+func buildOAuthRefreshToken(user models.User, ttl time.Duration) string {
+	tokens, _ := jwt.NewTokenPair(user, oauthClient, &oauthClientKey, time.Minute, ttl)
+	return tokens.RefreshToken
+}
+
+func newTestAuthWithOAuth(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	ap *mocks.MockAppProvider,
+	ts *mocks.MockTokenStorage,
+	oas *mocks.MockOAuthStorage,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), nil, nil, regularUser(ctrl), oas, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, oauthKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{
+		CodeTTL: time.Minute,
+		Issuer:  testIssuer,
+	}, FederationConfig{}, AuditConfig{})
+}
+
+var (
+	frontendApp = models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	oauthClient = models.App{
+		ID:           5,
+		Name:         "client",
+		Secret:       "client-secret",
+		ClientType:   models.ClientTypeConfidential,
+		RedirectURIs: []string{"https://client.example/callback"},
+	}
+)
+
+const testIssuer = "https://sso.example"
+
+// пример из RFC 7636, приложение B
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func authorizationRequest() AuthorizationRequest {
+	return AuthorizationRequest{
+		ClientID:            oauthClient.ID,
+		RedirectURI:         oauthClient.RedirectURIs[0],
+		ResponseType:        "code",
+		Scope:               "forum.read profile",
+		State:               "xyz",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func requireOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var oauthErr *OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, code, oauthErr.Code)
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	assert.True(t, verifyCodeChallenge(testCodeChallenge, testCodeVerifier))
+	assert.False(t, verifyCodeChallenge(testCodeChallenge, testCodeVerifier[1:]+"A"))
+	// короче 43 символов и с недопустимыми символами verifier не принимается
+	assert.False(t, verifyCodeChallenge(testCodeChallenge, "short"))
+	assert.False(t, verifyCodeChallenge(testCodeChallenge, strings.Repeat("a", 42)+"!"))
+}
+
+func TestAuth_CheckAuthorizationRequest(t *testing.T) {
+	notOAuth := models.App{ID: 2, Name: "legacy", Secret: "secret"}
+
+	tests := []struct {
+		name    string
+		app     models.App
+		modify  func(req *AuthorizationRequest)
+		wantErr error
+		code    string
+	}{
+		{name: "not an oauth client", app: notOAuth, modify: func(req *AuthorizationRequest) { req.ClientID = notOAuth.ID }, wantErr: ErrInvalidOAuthClient},
+		{name: "unregistered redirect uri", app: oauthClient, modify: func(req *AuthorizationRequest) { req.RedirectURI = "https://evil.example/callback" }, wantErr: ErrInvalidRedirectURI},
+		{name: "implicit flow", app: oauthClient, modify: func(req *AuthorizationRequest) { req.ResponseType = "token" }, code: OAuthUnsupportedResponseType},
+		{name: "missing pkce", app: oauthClient, modify: func(req *AuthorizationRequest) { req.CodeChallenge = "" }, code: OAuthInvalidRequest},
+		{name: "plain pkce", app: oauthClient, modify: func(req *AuthorizationRequest) { req.CodeChallengeMethod = "plain" }, code: OAuthInvalidRequest},
+		{name: "unknown scope", app: oauthClient, modify: func(req *AuthorizationRequest) { req.Scope = "profile admin" }, code: OAuthInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ap := mocks.NewMockAppProvider(ctrl)
+			ap.EXPECT().App(gomock.Any(), tt.app.ID).Return(tt.app, nil)
+
+			req := authorizationRequest()
+			tt.modify(&req)
+
+			_, _, err := newTestAuthWithOAuth(ctrl, nil, ap, nil, nil).CheckAuthorizationRequest(context.Background(), req)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			requireOAuthError(t, err, tt.code)
+		})
+	}
+}
+
+func TestAuth_CheckAuthorizationRequest_DefaultScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+
+	req := authorizationRequest()
+	req.Scope = ""
+
+	_, scope, err := newTestAuthWithOAuth(ctrl, nil, ap, nil, nil).CheckAuthorizationRequest(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeProfile}, scope)
+}
+
+func TestAuth_Authorize_ConsentRequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	tokenPair, err := jwt.NewTokenPair(user, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	oas := mocks.NewMockOAuthStorage(ctrl)
+	// согласие есть, но только на profile
+	oas.EXPECT().OAuthConsent(gomock.Any(), user.ID, oauthClient.ID).Return(models.OAuthConsent{Scope: []string{ScopeProfile}}, nil)
+
+	authTest := newTestAuthWithOAuth(ctrl, nil, ap, nil, oas)
+
+	result, err := authTest.Authorize(context.Background(), tokenPair.AccessToken, frontendApp.ID, authorizationRequest(), "")
+	require.NoError(t, err)
+	assert.True(t, result.ConsentRequired)
+	assert.Empty(t, result.RedirectTo)
+	assert.Equal(t, oauthClient.Name, result.ClientName)
+	assert.Equal(t, []string{ScopeForumRead, ScopeProfile}, result.Scope)
+}
+
+func TestAuth_Authorize_Approve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	tokenPair, err := jwt.NewTokenPair(user, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+
+	var saved models.AuthorizationCode
+	oas := mocks.NewMockOAuthStorage(ctrl)
+	oas.EXPECT().OAuthConsent(gomock.Any(), user.ID, oauthClient.ID).Return(models.OAuthConsent{Scope: []string{ScopeEmail}}, nil)
+	// прежнее согласие на email сохраняется вместе с новыми scope
+	oas.EXPECT().SaveOAuthConsent(gomock.Any(), user.ID, oauthClient.ID, []string{ScopeEmail, ScopeForumRead, ScopeProfile}).Return(nil)
+	oas.EXPECT().SaveAuthorizationCode(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, code models.AuthorizationCode) error {
+		saved = code
+		return nil
+	})
+
+	authTest := newTestAuthWithOAuth(ctrl, nil, ap, nil, oas)
+
+	req := authorizationRequest()
+	req.Nonce = "n-0S6_WzA2Mj"
+
+	result, err := authTest.Authorize(context.Background(), tokenPair.AccessToken, frontendApp.ID, req, ConsentApprove)
+	require.NoError(t, err)
+	require.False(t, result.ConsentRequired)
+
+	redirect, err := url.Parse(result.RedirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "client.example", redirect.Host)
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+
+	code := redirect.Query().Get("code")
+	require.NotEmpty(t, code)
+	// в хранилище попадает только хэш кода
+	assert.Equal(t, hashOneTimeToken(code), saved.CodeHash)
+	assert.Equal(t, user.ID, saved.UserID)
+	assert.Equal(t, oauthClient.ID, saved.AppID)
+	assert.Equal(t, []string{ScopeForumRead, ScopeProfile}, saved.Scope)
+	assert.Equal(t, testCodeChallenge, saved.CodeChallenge)
+	assert.Equal(t, "n-0S6_WzA2Mj", saved.Nonce)
+}
+
+func TestAuth_Authorize_Deny(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	tokenPair, err := jwt.NewTokenPair(user, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+
+	// ни согласие, ни код не сохраняются
+	authTest := newTestAuthWithOAuth(ctrl, nil, ap, nil, mocks.NewMockOAuthStorage(ctrl))
+
+	result, err := authTest.Authorize(context.Background(), tokenPair.AccessToken, frontendApp.ID, authorizationRequest(), ConsentDeny)
+	require.NoError(t, err)
+
+	redirect, err := url.Parse(result.RedirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, OAuthAccessDenied, redirect.Query().Get("error"))
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+	assert.Empty(t, redirect.Query().Get("code"))
+}
+
+func TestAuth_Authorize_InvalidAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+
+	authTest := newTestAuthWithOAuth(ctrl, nil, ap, nil, nil)
+
+	_, err := authTest.Authorize(context.Background(), "not-a-token", frontendApp.ID, authorizationRequest(), ConsentApprove)
+	require.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func storedCode(userID int64) models.AuthorizationCode {
+	return models.AuthorizationCode{
+		AppID:               oauthClient.ID,
+		UserID:              userID,
+		RedirectURI:         oauthClient.RedirectURIs[0],
+		Scope:               []string{ScopeForumRead, ScopeProfile},
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestAuth_ExchangeAuthorizationCode_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil).Times(2)
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, oauthClient.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	oas := mocks.NewMockOAuthStorage(ctrl)
+	oas.EXPECT().ConsumeAuthorizationCode(gomock.Any(), hashOneTimeToken("the-code"), gomock.Any()).Return(storedCode(user.ID), nil)
+
+	authTest := newTestAuthWithOAuth(ctrl, up, ap, ts, oas)
+
+	tokens, err := authTest.ExchangeAuthorizationCode(context.Background(), oauthClient.ID, oauthClient.Secret, "the-code", oauthClient.RedirectURIs[0], testCodeVerifier)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, time.Minute, tokens.ExpiresIn)
+	assert.Equal(t, []string{ScopeForumRead, ScopeProfile}, tokens.Scope)
+	// без scope openid ID token не выдаётся
+	assert.Empty(t, tokens.IDToken)
+
+	claims, err := authTest.ValidateToken(context.Background(), tokens.AccessToken, oauthClient.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, []string{ScopeForumRead, ScopeProfile}, claims.Scope)
+}
+
+func TestAuth_ExchangeAuthorizationCode_Rejected(t *testing.T) {
+	const userID = int64(7)
+
+	otherClient := storedCode(userID)
+	otherClient.AppID = 6
+
+	otherRedirect := storedCode(userID)
+	otherRedirect.RedirectURI = "https://client.example/other"
+
+	tests := []struct {
+		name     string
+		secret   string
+		verifier string
+		stored   models.AuthorizationCode
+		consume  error
+		code     string
+	}{
+		{name: "wrong client secret", secret: "wrong", verifier: testCodeVerifier, code: OAuthInvalidClient},
+		{name: "wrong verifier", secret: oauthClient.Secret, verifier: strings.Repeat("a", 43), stored: storedCode(userID), code: OAuthInvalidGrant},
+		{name: "used or expired code", secret: oauthClient.Secret, verifier: testCodeVerifier, consume: storage.ErrAuthorizationCodeNotFound, code: OAuthInvalidGrant},
+		{name: "code of another client", secret: oauthClient.Secret, verifier: testCodeVerifier, stored: otherClient, code: OAuthInvalidGrant},
+		{name: "another redirect uri", secret: oauthClient.Secret, verifier: testCodeVerifier, stored: otherRedirect, code: OAuthInvalidGrant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ap := mocks.NewMockAppProvider(ctrl)
+			ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+			oas := mocks.NewMockOAuthStorage(ctrl)
+			if tt.code != OAuthInvalidClient {
+				oas.EXPECT().ConsumeAuthorizationCode(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.stored, tt.consume)
+			}
+
+			authTest := newTestAuthWithOAuth(ctrl, nil, ap, nil, oas)
+
+			_, err := authTest.ExchangeAuthorizationCode(context.Background(), oauthClient.ID, tt.secret, "the-code", oauthClient.RedirectURIs[0], tt.verifier)
+			requireOAuthError(t, err, tt.code)
+		})
+	}
+}
+
+func TestAuth_ExchangeAuthorizationCode_PublicClientWithoutSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	public := oauthClient
+	public.ClientType = models.ClientTypePublic
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), public.ID).Return(public, nil)
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, public.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	oas := mocks.NewMockOAuthStorage(ctrl)
+	oas.EXPECT().ConsumeAuthorizationCode(gomock.Any(), gomock.Any(), gomock.Any()).Return(storedCode(user.ID), nil)
+
+	authTest := newTestAuthWithOAuth(ctrl, up, ap, ts, oas)
+
+	_, err := authTest.ExchangeAuthorizationCode(context.Background(), public.ID, "", "the-code", public.RedirectURIs[0], testCodeVerifier)
+	require.NoError(t, err)
+}
+
+func TestAuth_RefreshOAuthTokens_Scope(t *testing.T) {
+	user := models.User{ID: 7, Email: "user@test.com", Scope: []string{ScopeForumRead, ScopeProfile}}
+
+	tests := []struct {
+		name      string
+		scope     string
+		wantScope []string
+		code      string
+	}{
+		{name: "keeps granted scope", wantScope: []string{ScopeForumRead, ScopeProfile}},
+		{name: "narrows scope", scope: "profile", wantScope: []string{ScopeProfile}},
+		{name: "cannot widen scope", scope: "profile forum.write", code: OAuthInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			refresh := buildOAuthRefreshToken(user, time.Hour)
+
+			ap := mocks.NewMockAppProvider(ctrl)
+			ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+			up := mocks.NewMockUserProvider(ctrl)
+			ts := mocks.NewMockTokenStorage(ctrl)
+			if tt.code == "" {
+				up.EXPECT().User(gomock.Any(), user.Email).Return(models.User{ID: user.ID, Email: user.Email}, nil)
+				ts.EXPECT().RotateRefreshToken(gomock.Any(), user.ID, oauthClient.ID, refresh, gomock.Any(), gomock.Any()).Return(int64(1), nil)
+			}
+
+			authTest := newTestAuthWithOAuth(ctrl, up, ap, ts, nil)
+
+			tokens, err := authTest.RefreshOAuthTokens(context.Background(), oauthClient.ID, oauthClient.Secret, refresh, tt.scope)
+			if tt.code != "" {
+				requireOAuthError(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantScope, tokens.Scope)
+
+			// scope переносится и в новый refresh token
+			claims := jwtGo.MapClaims{}
+			_, _, err = jwtGo.NewParser().ParseUnverified(tokens.RefreshToken, claims)
+			require.NoError(t, err)
+			assert.Equal(t, strings.Join(tt.wantScope, " "), claims["scope"])
+		})
+	}
+}
+
+func TestAuth_RefreshOAuthTokens_InvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+
+	authTest := newTestAuthWithOAuth(ctrl, nil, ap, nil, nil)
+
+	_, err := authTest.RefreshOAuthTokens(context.Background(), oauthClient.ID, oauthClient.Secret, "garbage", "")
+	requireOAuthError(t, err, OAuthInvalidGrant)
+}
+
+func TestAuth_RevokeOAuthConsent_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com"}
+	tokenPair, err := jwt.NewTokenPair(user, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	oas := mocks.NewMockOAuthStorage(ctrl)
+	oas.EXPECT().DeleteOAuthConsent(gomock.Any(), user.ID, oauthClient.ID).Return(storage.ErrConsentNotFound)
+
+	authTest := newTestAuthWithOAuth(ctrl, nil, ap, nil, oas)
+
+	err = authTest.RevokeOAuthConsent(context.Background(), tokenPair.AccessToken, frontendApp.ID, oauthClient.ID)
+	require.ErrorIs(t, err, ErrConsentNotFound)
+}
+
+// parseIDToken проверяет подпись ID token секретом клиента и возвращает его claims
+func parseIDToken(t *testing.T, idToken string) jwtGo.MapClaims {
+	t.Helper()
+
+	claims := jwtGo.MapClaims{}
+	_, err := jwtGo.ParseWithClaims(idToken, claims, func(*jwtGo.Token) (interface{}, error) {
+		return oauthClientKey.Public(), nil
+	})
+	require.NoError(t, err)
+
+	return claims
+}
+
+func TestAuth_ExchangeAuthorizationCode_IDToken(t *testing.T) {
+	user := models.User{ID: 7, Email: "user@test.com", EmailVerified: true}
+
+	tests := []struct {
+		name      string
+		scope     []string
+		wantEmail bool
+	}{
+		{name: "openid only", scope: []string{ScopeOpenID}},
+		{name: "openid with email", scope: []string{ScopeEmail, ScopeOpenID}, wantEmail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			code := storedCode(user.ID)
+			code.Scope = tt.scope
+			code.Nonce = "n-0S6_WzA2Mj"
+
+			ap := mocks.NewMockAppProvider(ctrl)
+			ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+			up := mocks.NewMockUserProvider(ctrl)
+			up.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+			ts := mocks.NewMockTokenStorage(ctrl)
+			ts.EXPECT().SaveToken(gomock.Any(), user.ID, oauthClient.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+			oas := mocks.NewMockOAuthStorage(ctrl)
+			oas.EXPECT().ConsumeAuthorizationCode(gomock.Any(), gomock.Any(), gomock.Any()).Return(code, nil)
+
+			authTest := newTestAuthWithOAuth(ctrl, up, ap, ts, oas)
+
+			tokens, err := authTest.ExchangeAuthorizationCode(context.Background(), oauthClient.ID, oauthClient.Secret, "the-code", oauthClient.RedirectURIs[0], testCodeVerifier)
+			require.NoError(t, err)
+			require.NotEmpty(t, tokens.IDToken)
+
+			claims := parseIDToken(t, tokens.IDToken)
+			assert.Equal(t, testIssuer, claims["iss"])
+			assert.Equal(t, "7", claims["sub"])
+			assert.Equal(t, "5", claims["aud"])
+			assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+			assert.Contains(t, claims, "iat")
+			assert.Contains(t, claims, "exp")
+
+			if tt.wantEmail {
+				assert.Equal(t, user.Email, claims["email"])
+				assert.Equal(t, true, claims["email_verified"])
+			} else {
+				assert.NotContains(t, claims, "email")
+				assert.NotContains(t, claims, "email_verified")
+			}
+		})
+	}
+}
+
+func TestAuth_RefreshOAuthTokens_IDTokenWithoutNonce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 7, Email: "user@test.com", Scope: []string{ScopeOpenID, ScopeProfile}}
+	refresh := buildOAuthRefreshToken(user, time.Hour)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), oauthClient.ID).Return(oauthClient, nil)
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(models.User{ID: user.ID, Email: user.Email}, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().RotateRefreshToken(gomock.Any(), user.ID, oauthClient.ID, refresh, gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	authTest := newTestAuthWithOAuth(ctrl, up, ap, ts, nil)
+
+	tokens, err := authTest.RefreshOAuthTokens(context.Background(), oauthClient.ID, oauthClient.Secret, refresh, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.IDToken)
+
+	claims := parseIDToken(t, tokens.IDToken)
+	assert.Equal(t, "7", claims["sub"])
+	assert.NotContains(t, claims, "nonce")
+}
+
+func TestAuth_UserInfo(t *testing.T) {
+	user := models.User{ID: 7, Email: "user@test.com", EmailVerified: true}
+
+	tests := []struct {
+		name      string
+		app       models.App
+		scope     []string
+		wantEmail bool
+		wantErr   error
+	}{
+		{name: "first-party token", app: frontendApp, wantEmail: true},
+		{name: "openid only", app: oauthClient, scope: []string{ScopeOpenID}},
+		{name: "openid with email", app: oauthClient, scope: []string{ScopeEmail, ScopeOpenID}, wantEmail: true},
+		{name: "without openid", app: oauthClient, scope: []string{ScopeProfile}, wantErr: ErrInsufficientScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			owner := user
+			owner.Scope = tt.scope
+			var key *jwt.SigningKey
+			if tt.app.IsOAuthClient() {
+				key = &oauthClientKey
+			}
+			tokenPair, err := jwt.NewTokenPair(owner, tt.app, key, time.Minute, time.Hour)
+			require.NoError(t, err)
+
+			ap := mocks.NewMockAppProvider(ctrl)
+			ap.EXPECT().App(gomock.Any(), tt.app.ID).Return(tt.app, nil)
+			up := mocks.NewMockUserProvider(ctrl)
+			if tt.wantErr == nil {
+				up.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+			}
+
+			authTest := newTestAuthWithOAuth(ctrl, up, ap, nil, nil)
+
+			info, err := authTest.UserInfo(context.Background(), tokenPair.AccessToken)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "7", info.Subject)
+
+			if tt.wantEmail {
+				assert.Equal(t, user.Email, info.Email)
+				assert.True(t, info.EmailVerified)
+			} else {
+				assert.Empty(t, info.Email)
+			}
+		})
+	}
+}
+
+func TestAuth_UserInfo_InvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authTest := newTestAuthWithOAuth(ctrl, nil, nil, nil, nil)
+
+	_, err := authTest.UserInfo(context.Background(), "garbage")
+	require.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	// токен, подписанный чужим секретом, не принимается, хотя app_id в нём настоящий
+	forged, err := jwt.NewTokenPair(models.User{ID: 7, Email: "user@test.com"}, models.App{ID: frontendApp.ID, Secret: "forged"}, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	authTest = newTestAuthWithOAuth(ctrl, nil, ap, nil, nil)
+
+	_, err = authTest.UserInfo(context.Background(), forged.AccessToken)
+	require.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestAuth_OpenIDConfiguration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := newTestAuthWithOAuth(ctrl, nil, nil, nil, nil).OpenIDConfiguration()
+
+	assert.Equal(t, testIssuer, cfg.Issuer)
+	assert.Equal(t, testIssuer+"/oauth/authorize", cfg.AuthorizationEndpoint)
+	assert.Equal(t, testIssuer+"/oauth/token", cfg.TokenEndpoint)
+	assert.Equal(t, testIssuer+"/userinfo", cfg.UserInfoEndpoint)
+	assert.Equal(t, testIssuer+"/.well-known/jwks.json", cfg.JWKSURI)
+	assert.Contains(t, cfg.ScopesSupported, ScopeOpenID)
+	assert.Equal(t, []string{"code"}, cfg.ResponseTypesSupported)
+	assert.Equal(t, []string{"S256"}, cfg.CodeChallengeMethodsSupported)
+}
+
+const corpProvider = "corp"
+
+func newTestAuthWithFederation(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	ap *mocks.MockAppProvider,
+	ts *mocks.MockTokenStorage,
+	fs *mocks.MockFederationStorage,
+	idp *mocks.MockIdentityProvider,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), nil, nil, regularUser(ctrl), nil, fs, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{
+		StateTTL:  10 * time.Minute,
+		Providers: map[string]IdentityProvider{corpProvider: idp},
+	}, AuditConfig{})
+}
+
+// federationState — сохранённый state входа, который гасит CompleteFederatedLogin
+func federationState(linkUserID int64) models.FederationState {
+	return models.FederationState{
+		Provider:     corpProvider,
+		AppID:        frontendApp.ID,
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		LinkUserID:   linkUserID,
+	}
+}
+
+func TestAuth_StartFederatedLogin_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+
+	var state, nonce, challenge string
+	idp.EXPECT().AuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, s, n, c string) (string, error) {
+			state, nonce, challenge = s, n, c
+			return "https://idp.example/authorize?state=" + s, nil
+		})
+
+	var saved models.FederationState
+	fs.EXPECT().SaveFederationState(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, st models.FederationState) error {
+			saved = st
+			return nil
+		})
+
+	authTest := newTestAuthWithFederation(ctrl, nil, ap, nil, fs, idp)
+
+	redirectTo, err := authTest.StartFederatedLogin(context.Background(), corpProvider, frontendApp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://idp.example/authorize?state="+state, redirectTo)
+
+	// в базе только хеш state, а challenge — S256 от сохранённого verifier
+	assert.Equal(t, hashOneTimeToken(state), saved.StateHash)
+	assert.Equal(t, corpProvider, saved.Provider)
+	assert.Equal(t, frontendApp.ID, saved.AppID)
+	assert.Equal(t, nonce, saved.Nonce)
+	assert.True(t, verifyCodeChallenge(challenge, saved.CodeVerifier))
+	assert.Zero(t, saved.LinkUserID)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), saved.ExpiresAt, time.Minute)
+}
+
+func TestAuth_StartFederatedLogin_UnknownProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+
+	authTest := newTestAuthWithFederation(ctrl, nil, ap, nil, nil, mocks.NewMockIdentityProvider(ctrl))
+
+	_, err := authTest.StartFederatedLogin(context.Background(), "unknown", frontendApp.ID)
+	require.ErrorIs(t, err, ErrUnknownIdentityProvider)
+}
+
+func TestAuth_StartIdentityLink_StoresUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	user := models.User{ID: 42, Email: "user@test.com"}
+	tokenPair, err := jwt.NewTokenPair(user, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	idp.EXPECT().AuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("https://idp.example/authorize", nil)
+	fs.EXPECT().SaveFederationState(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, st models.FederationState) error {
+			assert.Equal(t, user.ID, st.LinkUserID)
+			return nil
+		})
+
+	authTest := newTestAuthWithFederation(ctrl, nil, ap, nil, fs, idp)
+
+	_, err = authTest.StartIdentityLink(context.Background(), tokenPair.AccessToken, frontendApp.ID, corpProvider)
+	require.NoError(t, err)
+}
+
+func TestAuth_CompleteFederatedLogin_LinkedIdentity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	user := models.User{ID: 42, Email: "alice@test.com", EmailVerified: true}
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), hashOneTimeToken("state"), corpProvider, gomock.Any()).Return(federationState(0), nil)
+	idp.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").
+		Return(models.ExternalIdentity{Subject: "alice", Email: "alice@corp.example", EmailVerified: true}, nil)
+	fs.EXPECT().UserIdentity(gomock.Any(), corpProvider, "alice").Return(models.UserIdentity{UserID: user.ID}, nil)
+	up.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, frontendApp.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	authTest := newTestAuthWithFederation(ctrl, up, ap, ts, fs, idp)
+
+	result, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, result.UserID)
+	assert.False(t, result.Linked)
+	assert.NotEmpty(t, result.AccessToken)
+}
+
+func TestAuth_CompleteFederatedLogin_LinksByVerifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	user := models.User{ID: 42, Email: "alice@corp.example", EmailVerified: true}
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).Return(federationState(0), nil)
+	idp.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").
+		Return(models.ExternalIdentity{Subject: "alice", Email: user.Email, EmailVerified: true}, nil)
+	fs.EXPECT().UserIdentity(gomock.Any(), corpProvider, "alice").Return(models.UserIdentity{}, storage.ErrIdentityNotFound)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	fs.EXPECT().SaveUserIdentity(gomock.Any(), models.UserIdentity{UserID: user.ID, Provider: corpProvider, Subject: "alice", Email: user.Email}).Return(nil)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, frontendApp.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	authTest := newTestAuthWithFederation(ctrl, up, ap, ts, fs, idp)
+
+	result, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, result.UserID)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+}
+
+func TestAuth_CompleteFederatedLogin_RefusesUnverifiedEmail(t *testing.T) {
+	tests := []struct {
+		name             string
+		externalVerified bool
+		localVerified    bool
+	}{
+		{name: "not verified by provider", externalVerified: false, localVerified: true},
+		{name: "not verified locally", externalVerified: true, localVerified: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			up := mocks.NewMockUserProvider(ctrl)
+			fs := mocks.NewMockFederationStorage(ctrl)
+			idp := mocks.NewMockIdentityProvider(ctrl)
+
+			user := models.User{ID: 42, Email: "alice@corp.example", EmailVerified: tt.localVerified}
+
+			fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).Return(federationState(0), nil)
+			idp.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(models.ExternalIdentity{Subject: "alice", Email: user.Email, EmailVerified: tt.externalVerified}, nil)
+			fs.EXPECT().UserIdentity(gomock.Any(), corpProvider, "alice").Return(models.UserIdentity{}, storage.ErrIdentityNotFound)
+			up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+			// учётная запись не привязывается и токены не выдаются
+			fs.EXPECT().SaveUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+
+			authTest := newTestAuthWithFederation(ctrl, up, nil, nil, fs, idp)
+
+			_, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+			require.ErrorIs(t, err, ErrFederatedAccountExists)
+		})
+	}
+}
+
+func TestAuth_CompleteFederatedLogin_RegistersNewUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	external := models.ExternalIdentity{Subject: "bob", Email: "bob@corp.example", EmailVerified: true}
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).Return(federationState(0), nil)
+	idp.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(external, nil)
+	fs.EXPECT().UserIdentity(gomock.Any(), corpProvider, external.Subject).Return(models.UserIdentity{}, storage.ErrIdentityNotFound)
+	up.EXPECT().User(gomock.Any(), external.Email).Return(models.User{}, storage.ErrUserNotFound)
+	fs.EXPECT().SaveFederatedUser(gomock.Any(), external.Email, gomock.Any(), true,
+		models.UserIdentity{Provider: corpProvider, Subject: external.Subject, Email: external.Email}).
+		DoAndReturn(func(_ context.Context, _ string, passHash []byte, _ bool, _ models.UserIdentity) (int64, error) {
+			// пароль случайный: войти по паролю нельзя, пока пользователь его не сбросит
+			assert.NotEmpty(t, passHash)
+			return 77, nil
+		})
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil).Times(2)
+	ts.EXPECT().SaveToken(gomock.Any(), int64(77), frontendApp.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	authTest := newTestAuthWithFederation(ctrl, up, ap, ts, fs, idp)
+
+	result, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.NoError(t, err)
+	assert.Equal(t, int64(77), result.UserID)
+
+	claims, err := authTest.ValidateToken(context.Background(), result.AccessToken, frontendApp.ID)
+	require.NoError(t, err)
+	assert.True(t, claims.EmailVerified)
+}
+
+func TestAuth_CompleteFederatedLogin_EmailMissing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).Return(federationState(0), nil)
+	idp.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(models.ExternalIdentity{Subject: "bob"}, nil)
+	fs.EXPECT().UserIdentity(gomock.Any(), corpProvider, "bob").Return(models.UserIdentity{}, storage.ErrIdentityNotFound)
+
+	authTest := newTestAuthWithFederation(ctrl, nil, nil, nil, fs, idp)
+
+	_, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.ErrorIs(t, err, ErrFederatedEmailMissing)
+}
+
+func TestAuth_CompleteFederatedLogin_ExplicitLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	// email провайдера не совпадает с email пользователя и не подтверждён: при явной привязке это не важно
+	external := models.ExternalIdentity{Subject: "alice", Email: "alice@corp.example"}
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).Return(federationState(42), nil)
+	idp.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(external, nil)
+	fs.EXPECT().SaveUserIdentity(gomock.Any(), models.UserIdentity{UserID: 42, Provider: corpProvider, Subject: "alice", Email: external.Email}).Return(nil)
+
+	authTest := newTestAuthWithFederation(ctrl, nil, nil, nil, fs, idp)
+
+	result, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.NoError(t, err)
+	assert.Equal(t, FederatedLoginResult{UserID: 42, Linked: true}, result)
+}
+
+func TestAuth_CompleteFederatedLogin_ExplicitLinkToAnotherUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).Return(federationState(42), nil)
+	idp.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(models.ExternalIdentity{Subject: "alice"}, nil)
+	fs.EXPECT().SaveUserIdentity(gomock.Any(), gomock.Any()).Return(storage.ErrIdentityExists)
+	fs.EXPECT().UserIdentity(gomock.Any(), corpProvider, "alice").Return(models.UserIdentity{UserID: 7}, nil)
+
+	authTest := newTestAuthWithFederation(ctrl, nil, nil, nil, fs, idp)
+
+	_, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.ErrorIs(t, err, ErrIdentityAlreadyLinked)
+}
+
+func TestAuth_CompleteFederatedLogin_InvalidState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).
+		Return(models.FederationState{}, storage.ErrFederationStateNotFound)
+	// код не обменивается, если state не выдавался
+	idp.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithFederation(ctrl, nil, nil, nil, fs, idp)
+
+	_, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.ErrorIs(t, err, ErrInvalidFederationState)
+}
+
+func TestAuth_CompleteFederatedLogin_ExchangeRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fs := mocks.NewMockFederationStorage(ctrl)
+	idp := mocks.NewMockIdentityProvider(ctrl)
+
+	fs.EXPECT().ConsumeFederationState(gomock.Any(), gomock.Any(), corpProvider, gomock.Any()).Return(federationState(0), nil)
+	idp.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(models.ExternalIdentity{}, errors.New("invalid id token: nonce mismatch"))
+
+	authTest := newTestAuthWithFederation(ctrl, nil, nil, nil, fs, idp)
+
+	_, err := authTest.CompleteFederatedLogin(context.Background(), corpProvider, "state", "code")
+	require.ErrorIs(t, err, ErrFederatedLoginFailed)
+}
+
+func newTestAuthWithApps(
+	ctrl *gomock.Controller,
+	ap *mocks.MockAppProvider,
+	as *mocks.MockAppSaver,
+	rs *mocks.MockRoleStorage,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, nil, ap, as, nil, nil, nil, noMFA(ctrl), nil, nil, rs, nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+// adminToken — access token администратора для frontendApp; приложение ищется при его проверке
+func adminToken(t *testing.T, ctrl *gomock.Controller, ap *mocks.MockAppProvider) (string, *mocks.MockRoleStorage) {
+	t.Helper()
+
+	admin := models.User{ID: 1, Email: "admin@test.com"}
+	tokenPair, err := jwt.NewTokenPair(admin, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), admin.ID).Return([]models.Role{adminRole}, nil)
+
+	return tokenPair.AccessToken, rs
+}
+
+func TestAuth_CreateApp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	as := mocks.NewMockAppSaver(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+
+	var saved models.App
+	as.EXPECT().SaveApp(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, app models.App) (int, error) {
+			saved = app
+			return 7, nil
+		})
+	ap.EXPECT().App(gomock.Any(), 7).DoAndReturn(func(context.Context, int) (models.App, error) {
+		app := saved
+		app.ID = 7
+		return app, nil
+	})
+
+	authTest := newTestAuthWithApps(ctrl, ap, as, rs)
+
+	app, err := authTest.CreateApp(context.Background(), token, frontendApp.ID, AppSettings{
+		Name:           "  mobile ",
+		AllowedOrigins: []string{"https://m.example"},
+		AccessTokenTTL: 5 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, app.ID)
+	assert.Equal(t, "mobile", app.Name)
+	// тип клиента по умолчанию — confidential, секрет генерируется сервисом
+	assert.Equal(t, models.ClientTypeConfidential, app.ClientType)
+	assert.Len(t, app.Secret, 43)
+	assert.Equal(t, []string{"https://m.example"}, app.AllowedOrigins)
+	assert.Equal(t, 5*time.Minute, app.AccessTokenTTL)
+}
+
+func TestAuth_CreateApp_InvalidSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings AppSettings
+		field    string
+	}{
+		{name: "empty name", settings: AppSettings{Name: "  "}, field: "name"},
+		{name: "unknown client type", settings: AppSettings{Name: "app", ClientType: "native"}, field: "client_type"},
+		{name: "relative redirect uri", settings: AppSettings{Name: "app", RedirectURIs: []string{"/callback"}}, field: "redirect_uris"},
+		{name: "redirect uri with fragment", settings: AppSettings{Name: "app", RedirectURIs: []string{"https://a.example/cb#x"}}, field: "redirect_uris"},
+		{name: "origin with path", settings: AppSettings{Name: "app", AllowedOrigins: []string{"https://a.example/"}}, field: "allowed_origins"},
+		{name: "origin without scheme", settings: AppSettings{Name: "app", AllowedOrigins: []string{"a.example"}}, field: "allowed_origins"},
+		{name: "negative ttl", settings: AppSettings{Name: "app", AccessTokenTTL: -time.Second}, field: "access_token_ttl"},
+		{name: "fractional ttl", settings: AppSettings{Name: "app", RefreshTokenTTL: 1500 * time.Millisecond}, field: "refresh_token_ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ap := mocks.NewMockAppProvider(ctrl)
+			as := mocks.NewMockAppSaver(ctrl)
+			token, rs := adminToken(t, ctrl, ap)
+			// приложение с неверными настройками не сохраняется
+			as.EXPECT().SaveApp(gomock.Any(), gomock.Any()).Times(0)
+
+			authTest := newTestAuthWithApps(ctrl, ap, as, rs)
+
+			_, err := authTest.CreateApp(context.Background(), token, frontendApp.ID, tt.settings)
+			require.ErrorIs(t, err, ErrInvalidAppSettings)
+
+			var invalid *AppSettingsError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, tt.field, invalid.Field)
+		})
+	}
+}
+
+func TestAuth_CreateApp_PermissionDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 5, Email: "moderator@test.com"}
+	tokenPair, err := jwt.NewTokenPair(user, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), user.ID).Return([]models.Role{moderatorRole}, nil)
+
+	authTest := newTestAuthWithApps(ctrl, ap, mocks.NewMockAppSaver(ctrl), rs)
+
+	_, err = authTest.CreateApp(context.Background(), tokenPair.AccessToken, frontendApp.ID, AppSettings{Name: "app"})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_CreateApp_NameTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	as := mocks.NewMockAppSaver(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	as.EXPECT().SaveApp(gomock.Any(), gomock.Any()).Return(0, storage.ErrAppExists)
+
+	authTest := newTestAuthWithApps(ctrl, ap, as, rs)
+
+	_, err := authTest.CreateApp(context.Background(), token, frontendApp.ID, AppSettings{Name: "test"})
+	require.ErrorIs(t, err, ErrAppExists)
+}
+
+func TestAuth_UpdateApp_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	as := mocks.NewMockAppSaver(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	as.EXPECT().UpdateApp(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, app models.App) error {
+			assert.Equal(t, 404, app.ID)
+			assert.Empty(t, app.Secret)
+			return storage.ErrAppNotFound
+		})
+
+	authTest := newTestAuthWithApps(ctrl, ap, as, rs)
+
+	_, err := authTest.UpdateApp(context.Background(), token, frontendApp.ID, 404, AppSettings{Name: "renamed"})
+	require.ErrorIs(t, err, ErrAppNotFound)
+}
+
+func TestAuth_RotateAppSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	as := mocks.NewMockAppSaver(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+
+	var stored string
+	as.EXPECT().UpdateAppSecret(gomock.Any(), 7, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int, secret string) error {
+			stored = secret
+			return nil
+		})
+
+	authTest := newTestAuthWithApps(ctrl, ap, as, rs)
+
+	secret, err := authTest.RotateAppSecret(context.Background(), token, frontendApp.ID, 7)
+	require.NoError(t, err)
+	assert.NotEmpty(t, secret)
+	assert.Equal(t, stored, secret)
+}
+
+func TestAuth_DisableApp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	as := mocks.NewMockAppSaver(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	as.EXPECT().DisableApp(gomock.Any(), 7).Return(nil)
+
+	authTest := newTestAuthWithApps(ctrl, ap, as, rs)
+
+	require.NoError(t, authTest.DisableApp(context.Background(), token, frontendApp.ID, 7))
+}
+
+func TestAuth_DisableApp_CurrentApp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	as := mocks.NewMockAppSaver(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	as.EXPECT().DisableApp(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithApps(ctrl, ap, as, rs)
+
+	err := authTest.DisableApp(context.Background(), token, frontendApp.ID, frontendApp.ID)
+	require.ErrorIs(t, err, ErrDisableCurrentApp)
+}
+
+func TestAuth_AuthenticateApp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	disabled := frontendApp
+	disabled.ID = 7
+	disabled.Disabled = true
+
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil).Times(3)
+	ap.EXPECT().App(gomock.Any(), disabled.ID).Return(disabled, nil)
+	ap.EXPECT().App(gomock.Any(), 99).Return(models.App{}, storage.ErrAppNotFound)
+
+	authTest := newTestAuthWithApps(ctrl, ap, nil, nil)
+
+	require.NoError(t, authTest.AuthenticateApp(context.Background(), frontendApp.ID, frontendApp.Secret))
+	require.ErrorIs(t, authTest.AuthenticateApp(context.Background(), frontendApp.ID, "wrong"), ErrInvalidCredentials)
+	require.ErrorIs(t, authTest.AuthenticateApp(context.Background(), frontendApp.ID, ""), ErrInvalidCredentials)
+	require.ErrorIs(t, authTest.AuthenticateApp(context.Background(), disabled.ID, disabled.Secret), ErrInvalidCredentials)
+	require.ErrorIs(t, authTest.AuthenticateApp(context.Background(), 99, frontendApp.Secret), ErrInvalidCredentials)
+}
+
+func TestAuth_Login_AppDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+	disabled := frontendApp
+	disabled.Disabled = true
+
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap.EXPECT().App(gomock.Any(), disabled.ID).Return(disabled, nil)
+	ts.EXPECT().SaveToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuth(ctrl, up, nil, ts, ap)
+
+	_, _, _, err := authTest.Login(context.Background(), user.Email, "test", disabled.ID)
+	require.ErrorIs(t, err, ErrAppDisabled)
+}
+
+func TestAuth_ValidateToken_AppDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 123, Email: "test@test.com"}
+	tokenPair, err := jwt.NewTokenPair(user, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	// токен выдан до отключения приложения и ещё не истёк
+	disabled := frontendApp
+	disabled.Disabled = true
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(disabled, nil)
+
+	authTest := newTestAuth(ctrl, nil, nil, nil, ap)
+
+	_, err = authTest.ValidateToken(context.Background(), tokenPair.AccessToken, frontendApp.ID)
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuth_Login_AppTokenTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+	app := frontendApp
+	app.AccessTokenTTL = 5 * time.Minute
+	app.RefreshTokenTTL = 48 * time.Hour
+
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, _ int, _ string, expiresAt time.Time, _ models.ClientInfo) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(48*time.Hour), expiresAt, time.Minute)
+			return 1, nil
+		})
+
+	// глобальные сроки newTestAuth — минута и час; у приложения свои
+	authTest := newTestAuth(ctrl, up, nil, ts, ap)
+
+	at, rt, _, err := authTest.Login(context.Background(), user.Email, "test", app.ID)
+	require.NoError(t, err)
+
+	for token, ttl := range map[string]time.Duration{at: 5 * time.Minute, rt: 48 * time.Hour} {
+		claims := jwtGo.MapClaims{}
+		_, _, err := jwtGo.NewParser().ParseUnverified(token, claims)
+		require.NoError(t, err)
+
+		exp, err := claims.GetExpirationTime()
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(ttl), exp.Time, time.Minute)
+	}
+}
+
+func newTestAuthWithProfile(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	us *mocks.MockUserSaver,
+	ap *mocks.MockAppProvider,
+	ts *mocks.MockTokenStorage,
+	vs *mocks.MockEmailVerificationStorage,
+	m *mocks.MockMailer,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), us, up, ap, nil, ts, nil, vs, noMFA(ctrl), nil, nil, regularUser(ctrl), nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), m, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{
+		TokenTTL:  24 * time.Hour,
+		URL:       "http://localhost:3000/verify-email",
+		ChangeURL: "http://localhost:3000/confirm-email-change",
+	}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+var profileUser = models.User{ID: 7, Email: "user@mail.ru", PassHash: mustHash("current"), EmailVerified: true}
+
+// userToken — access token profileUser для frontendApp; приложение ищется при его проверке
+func userToken(t *testing.T, ap *mocks.MockAppProvider) string {
+	t.Helper()
+
+	tokenPair, err := jwt.NewTokenPair(profileUser, frontendApp, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+
+	return tokenPair.AccessToken
+}
+
+func TestAuth_GetProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+
+	stored := profileUser
+	stored.DisplayName = "Ivan"
+	stored.Username = "ivan"
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(stored, nil)
+
+	authTest := newTestAuthWithProfile(ctrl, up, nil, ap, nil, nil, nil)
+
+	user, err := authTest.GetProfile(context.Background(), token, frontendApp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ivan", user.DisplayName)
+	assert.Equal(t, "ivan", user.Username)
+}
+
+func TestAuth_UpdateProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+
+	var saved models.User
+	us.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, user models.User) error {
+			saved = user
+			return nil
+		})
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).DoAndReturn(func(context.Context, int64) (models.User, error) {
+		user := profileUser
+		user.DisplayName, user.Username, user.AvatarURL, user.Bio = saved.DisplayName, saved.Username, saved.AvatarURL, saved.Bio
+		return user, nil
+	})
+
+	authTest := newTestAuthWithProfile(ctrl, up, us, ap, nil, nil, nil)
+
+	user, err := authTest.UpdateProfile(context.Background(), token, frontendApp.ID, Profile{
+		DisplayName: "  Иван Петров ",
+		Username:    "Ivan_Petrov",
+		AvatarURL:   "https://cdn.example/avatar.png",
+		Bio:         "гоняю на велосипеде",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, profileUser.ID, saved.ID)
+	assert.Equal(t, "Иван Петров", user.DisplayName)
+	// имя пользователя уникально без учёта регистра и хранится в нижнем регистре
+	assert.Equal(t, "ivan_petrov", user.Username)
+	assert.Equal(t, "https://cdn.example/avatar.png", user.AvatarURL)
+	assert.Equal(t, "гоняю на велосипеде", user.Bio)
+}
+
+func TestAuth_UpdateProfile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		field   string
+	}{
+		{name: "long display name", profile: Profile{DisplayName: strings.Repeat("я", maxDisplayNameLength+1)}, field: "display_name"},
+		{name: "control characters", profile: Profile{DisplayName: "Ivan\nPetrov"}, field: "display_name"},
+		{name: "short username", profile: Profile{Username: "iv"}, field: "username"},
+		{name: "username with spaces", profile: Profile{Username: "ivan petrov"}, field: "username"},
+		{name: "relative avatar url", profile: Profile{AvatarURL: "/avatar.png"}, field: "avatar_url"},
+		{name: "avatar url scheme", profile: Profile{AvatarURL: "javascript:alert(1)"}, field: "avatar_url"},
+		{name: "long bio", profile: Profile{Bio: strings.Repeat("a", maxBioLength+1)}, field: "bio"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			us := mocks.NewMockUserSaver(ctrl)
+			ap := mocks.NewMockAppProvider(ctrl)
+			token := userToken(t, ap)
+			us.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).Times(0)
+
+			authTest := newTestAuthWithProfile(ctrl, nil, us, ap, nil, nil, nil)
+
+			_, err := authTest.UpdateProfile(context.Background(), token, frontendApp.ID, tt.profile)
+			require.ErrorIs(t, err, ErrInvalidProfile)
+
+			var invalid *ProfileError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, tt.field, invalid.Field)
+		})
+	}
+}
+
+func TestAuth_UpdateProfile_UsernameTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+	us.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).Return(storage.ErrUsernameExists)
+
+	authTest := newTestAuthWithProfile(ctrl, nil, us, ap, nil, nil, nil)
+
+	_, err := authTest.UpdateProfile(context.Background(), token, frontendApp.ID, Profile{Username: "ivan"})
+	require.ErrorIs(t, err, ErrUsernameTaken)
+}
+
+func TestAuth_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	token := userToken(t, ap)
+
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+	us.EXPECT().UpdatePassword(gomock.Any(), profileUser.ID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, passHash []byte) error {
+			assert.NoError(t, bcrypt.CompareHashAndPassword(passHash, []byte("newPassword")))
+			return nil
+		})
+	// сначала отзываются все сессии, затем вызывающему выдаётся новая
+	gomock.InOrder(
+		ts.EXPECT().RevokeRefreshTokens(gomock.Any(), profileUser.ID).Return(nil),
+		ts.EXPECT().SaveToken(gomock.Any(), profileUser.ID, frontendApp.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil),
+	)
+	ap.EXPECT().App(gomock.Any(), frontendApp.ID).Return(frontendApp, nil)
+
+	authTest := newTestAuthWithProfile(ctrl, up, us, ap, ts, nil, nil)
+
+	accessToken, refreshToken, err := authTest.ChangePassword(context.Background(), token, frontendApp.ID, "current", "newPassword")
+	require.NoError(t, err)
+	assert.NotEmpty(t, accessToken)
+	assert.NotEmpty(t, refreshToken)
+}
+
+func TestAuth_ChangePassword_WrongCurrentPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	token := userToken(t, ap)
+
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+	us.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	ts.EXPECT().RevokeRefreshTokens(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithProfile(ctrl, up, us, ap, ts, nil, nil)
+
+	_, _, err := authTest.ChangePassword(context.Background(), token, frontendApp.ID, "wrong", "newPassword")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuth_ChangePassword_WeakPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+	us.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithProfile(ctrl, up, us, ap, nil, nil, nil)
+	authTest.passwordPolicy = strictPolicy()
+
+	_, _, err := authTest.ChangePassword(context.Background(), token, frontendApp.ID, "current", "user2024")
+
+	var weak *PasswordPolicyError
+	require.ErrorAs(t, err, &weak)
+	assert.Equal(t, passpolicy.RuleEmailSimilar, weak.Violations[0].Rule)
+}
+
+func TestAuth_ChangeEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	vs := mocks.NewMockEmailVerificationStorage(ctrl)
+	m := mocks.NewMockMailer(ctrl)
+	token := userToken(t, ap)
+
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+	up.EXPECT().User(gomock.Any(), "new@mail.ru").Return(models.User{}, storage.ErrUserNotFound)
+
+	var tokenHash []byte
+	vs.EXPECT().SaveEmailChangeToken(gomock.Any(), profileUser.ID, "new@mail.ru", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, _ string, hash []byte, _ time.Time) error {
+			tokenHash = hash
+			return nil
+		})
+	m.EXPECT().Send(gomock.Any(), "new@mail.ru", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _, body string) error {
+			link := body[strings.Index(body, "http://localhost:3000/confirm-email-change"):]
+			u, err := url.Parse(strings.Fields(link)[0])
+			require.NoError(t, err)
+			assert.Equal(t, tokenHash, hashOneTimeToken(u.Query().Get("token")))
+			return nil
+		})
+	// прежний адрес получает только уведомление, без ссылки
+	m.EXPECT().Send(gomock.Any(), profileUser.Email, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _, body string) error {
+			assert.NotContains(t, body, "confirm-email-change")
+			return nil
+		})
+
+	authTest := newTestAuthWithProfile(ctrl, up, nil, ap, nil, vs, m)
+
+	require.NoError(t, authTest.ChangeEmail(context.Background(), token, frontendApp.ID, "current", "new@mail.ru"))
+}
+
+func TestAuth_ChangeEmail_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		newEmail string
+		taken    bool
+		want     error
+	}{
+		{name: "wrong password", password: "wrong", newEmail: "new@mail.ru", want: ErrInvalidCredentials},
+		{name: "same email", password: "current", newEmail: "USER@mail.ru", want: ErrEmailUnchanged},
+		{name: "email taken", password: "current", newEmail: "taken@mail.ru", taken: true, want: ErrEmailTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			up := mocks.NewMockUserProvider(ctrl)
+			ap := mocks.NewMockAppProvider(ctrl)
+			vs := mocks.NewMockEmailVerificationStorage(ctrl)
+			token := userToken(t, ap)
+
+			up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+			if tt.taken {
+				up.EXPECT().User(gomock.Any(), tt.newEmail).Return(models.User{ID: 8, Email: tt.newEmail}, nil)
+			}
+			vs.EXPECT().SaveEmailChangeToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			authTest := newTestAuthWithProfile(ctrl, up, nil, ap, nil, vs, mocks.NewMockMailer(ctrl))
+
+			err := authTest.ChangeEmail(context.Background(), token, frontendApp.ID, tt.password, tt.newEmail)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestAuth_ConfirmEmailChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vs := mocks.NewMockEmailVerificationStorage(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+
+	vs.EXPECT().ConfirmEmailChange(gomock.Any(), hashOneTimeToken("change-token"), gomock.Any()).Return(profileUser.ID, nil)
+	// refresh токены содержат прежний email и после смены не продлеваются
+	ts.EXPECT().RevokeRefreshTokens(gomock.Any(), profileUser.ID).Return(nil)
+
+	authTest := newTestAuthWithProfile(ctrl, nil, nil, nil, ts, vs, nil)
+
+	require.NoError(t, authTest.ConfirmEmailChange(context.Background(), "change-token"))
+}
+
+func TestAuth_ConfirmEmailChange_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		storageErr error
+		want       error
+	}{
+		{name: "invalid token", storageErr: storage.ErrVerificationTokenNotFound, want: ErrInvalidVerifyToken},
+		{name: "email taken meanwhile", storageErr: storage.ErrUserAlreadyExists, want: ErrEmailTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			vs := mocks.NewMockEmailVerificationStorage(ctrl)
+			ts := mocks.NewMockTokenStorage(ctrl)
+
+			vs.EXPECT().ConfirmEmailChange(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), tt.storageErr)
+			ts.EXPECT().RevokeRefreshTokens(gomock.Any(), gomock.Any()).Times(0)
+
+			authTest := newTestAuthWithProfile(ctrl, nil, nil, nil, ts, vs, nil)
+
+			require.ErrorIs(t, authTest.ConfirmEmailChange(context.Background(), "change-token"), tt.want)
+		})
+	}
+}
+
+func TestAuth_DeleteAccount_Self(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+	us.EXPECT().DeleteUser(gomock.Any(), profileUser.ID).Return(int64(42), nil)
+
+	authTest := newTestAuthWithProfile(ctrl, up, us, ap, nil, nil, nil)
+
+	require.NoError(t, authTest.DeleteAccount(context.Background(), token, frontendApp.ID, "current", 0))
+}
+
+func TestAuth_DeleteAccount_WrongPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+	us.EXPECT().DeleteUser(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithProfile(ctrl, up, us, ap, nil, nil, nil)
+
+	// свой id в запросе не освобождает от подтверждения паролем
+	err := authTest.DeleteAccount(context.Background(), token, frontendApp.ID, "wrong", profileUser.ID)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuth_DeleteAccount_ByAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+
+	us.EXPECT().DeleteUser(gomock.Any(), int64(7)).Return(int64(42), nil)
+
+	authTest := newTestAuthWithProfile(ctrl, nil, us, ap, nil, nil, nil)
+	authTest.roles = rs
+
+	require.NoError(t, authTest.DeleteAccount(context.Background(), token, frontendApp.ID, "", 7))
+}
+
+func TestAuth_DeleteAccount_ByAdmin_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+
+	us.EXPECT().DeleteUser(gomock.Any(), int64(7)).Return(int64(0), storage.ErrUserNotFound)
+
+	authTest := newTestAuthWithProfile(ctrl, nil, us, ap, nil, nil, nil)
+	authTest.roles = rs
+
+	err := authTest.DeleteAccount(context.Background(), token, frontendApp.ID, "", 7)
+	require.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAuth_DeleteAccount_OtherUserDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	us := mocks.NewMockUserSaver(ctrl)
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+
+	us.EXPECT().DeleteUser(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithProfile(ctrl, nil, us, ap, nil, nil, nil)
+
+	err := authTest.DeleteAccount(context.Background(), token, frontendApp.ID, "current", 8)
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_AccountErasures_Limit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "default", limit: 0, want: 100},
+		{name: "as requested", limit: 10, want: 10},
+		{name: "capped", limit: 5000, want: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			up := mocks.NewMockUserProvider(ctrl)
+			erasures := []models.AccountErasure{{ID: 6, UserID: 7, CreatedAt: time.Now()}}
+			up.EXPECT().AccountErasures(gomock.Any(), int64(5), tt.want).Return(erasures, nil)
+
+			authTest := newTestAuthWithProfile(ctrl, up, nil, nil, nil, nil, nil)
+
+			got, err := authTest.AccountErasures(context.Background(), 5, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, erasures, got)
+		})
+	}
+}
+
+func TestAuth_ExportUserData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+
+	records := []models.RefreshTokenRecord{
+		{ID: 1, AppID: frontendApp.ID, SessionID: 3, RotatedAt: time.Now()},
+		{ID: 2, AppID: frontendApp.ID, SessionID: 3},
+	}
+	up.EXPECT().UserByID(gomock.Any(), profileUser.ID).Return(profileUser, nil)
+	ts.EXPECT().RefreshTokenRecords(gomock.Any(), profileUser.ID).Return(records, nil)
+
+	authTest := newTestAuthWithProfile(ctrl, up, nil, nil, ts, nil, nil)
+
+	user, got, err := authTest.ExportUserData(context.Background(), profileUser.ID)
+	require.NoError(t, err)
+	assert.Equal(t, profileUser.Email, user.Email)
+	assert.Equal(t, records, got)
+}
+
+func TestAuth_ExportUserData_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	up := mocks.NewMockUserProvider(ctrl)
+	ts := mocks.NewMockTokenStorage(ctrl)
+
+	up.EXPECT().UserByID(gomock.Any(), int64(99)).Return(models.User{}, storage.ErrUserNotFound)
+	ts.EXPECT().RefreshTokenRecords(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithProfile(ctrl, up, nil, nil, ts, nil, nil)
+
+	_, _, err := authTest.ExportUserData(context.Background(), 99)
+	require.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func newTestAuthWithAudit(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	ts *mocks.MockTokenStorage,
+	ap *mocks.MockAppProvider,
+	es *mocks.MockSecurityEventStorage,
+	rs *mocks.MockRoleStorage,
+	audit AuditConfig,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), es, nil, rs, nil, nil, notBanned(ctrl), currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, audit)
+}
+
+func TestAuth_Login_AuditsSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	client := models.ClientInfo{IP: "203.0.113.7", UserAgent: "Mozilla/5.0"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), client).Return(int64(1), nil)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+	es.EXPECT().SaveSecurityEvent(gomock.Any(), models.SecurityEvent{
+		UserID:  user.ID,
+		AppID:   app.ID,
+		Type:    models.SecurityEventLogin,
+		Outcome: models.AuditOutcomeSuccess,
+		Client:  client,
+		Details: map[string]any{"method": "password"},
+	}).Return(nil)
+
+	authTest := newTestAuthWithAudit(ctrl, up, ts, ap, es, regularUser(ctrl), AuditConfig{Enabled: true})
+
+	_, _, _, err := authTest.Login(clientinfo.NewContext(context.Background(), client), user.Email, "test", app.ID)
+	require.NoError(t, err)
+}
+
+func TestAuth_Login_AuditsUnknownEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := models.ClientInfo{IP: "203.0.113.7", UserAgent: "curl/8.0"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), "kaban@mail.ru").Return(models.User{}, storage.ErrUserNotFound)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+	es.EXPECT().SaveSecurityEvent(gomock.Any(), models.SecurityEvent{
+		AppID:   1,
+		Type:    models.SecurityEventLogin,
+		Outcome: models.AuditOutcomeFailure,
+		Client:  client,
+		Details: map[string]any{"email": "kaban@mail.ru", "reason": "invalid_credentials"},
+	}).Return(nil)
+
+	authTest := newTestAuthWithAudit(ctrl, up, nil, nil, es, regularUser(ctrl), AuditConfig{Enabled: true})
+
+	_, _, _, err := authTest.Login(clientinfo.NewContext(context.Background(), client), "kaban@mail.ru", "test", 1)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuth_Login_AuditErrorIsHidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), user.ID, app.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+	es.EXPECT().SaveSecurityEvent(gomock.Any(), gomock.Any()).Return(errors.New("db is down"))
+
+	authTest := newTestAuthWithAudit(ctrl, up, ts, ap, es, regularUser(ctrl), AuditConfig{Enabled: true})
+
+	at, rt, _, err := authTest.Login(context.Background(), user.Email, "test", app.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, at)
+	assert.NotEmpty(t, rt)
+}
+
+func TestAuth_AssignRole_AuditsDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), profileUser.ID).Return([]models.Role{userRole}, nil)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+	es.EXPECT().SaveSecurityEvent(gomock.Any(), models.SecurityEvent{
+		UserID:  9,
+		AppID:   frontendApp.ID,
+		Type:    models.SecurityEventRoleAssigned,
+		Outcome: models.AuditOutcomeFailure,
+		Details: map[string]any{"role": RoleAdmin, "actor_id": profileUser.ID, "reason": "permission_denied"},
+	}).Return(nil)
+
+	authTest := newTestAuthWithAudit(ctrl, nil, nil, ap, es, rs, AuditConfig{Enabled: true})
+
+	err := authTest.AssignRole(context.Background(), token, frontendApp.ID, 9, RoleAdmin)
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_ListAuditEvents_Pages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+
+	since := time.Now().Add(-time.Hour)
+	es.EXPECT().SecurityEvents(gomock.Any(), models.SecurityEventFilter{
+		UserID:   7,
+		Outcome:  models.AuditOutcomeFailure,
+		Since:    since,
+		BeforeID: 100,
+		Limit:    3,
+	}).Return([]models.SecurityEvent{{ID: 99}, {ID: 97}, {ID: 96}}, nil)
+
+	authTest := newTestAuthWithAudit(ctrl, nil, nil, ap, es, rs, AuditConfig{Enabled: true})
+
+	filter := models.SecurityEventFilter{UserID: 7, Outcome: models.AuditOutcomeFailure, Since: since}
+	events, next, err := authTest.ListAuditEvents(context.Background(), token, frontendApp.ID, filter, 2, "100")
+	require.NoError(t, err)
+	assert.Equal(t, []models.SecurityEvent{{ID: 99}, {ID: 97}}, events)
+	assert.Equal(t, "97", next)
+}
+
+func TestAuth_ListAuditEvents_LastPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+	es.EXPECT().SecurityEvents(gomock.Any(), models.SecurityEventFilter{Limit: defaultAuditPageSize + 1}).
+		Return([]models.SecurityEvent{{ID: 2}, {ID: 1}}, nil)
+
+	authTest := newTestAuthWithAudit(ctrl, nil, nil, ap, es, rs, AuditConfig{})
+
+	events, next, err := authTest.ListAuditEvents(context.Background(), token, frontendApp.ID, models.SecurityEventFilter{}, 0, "")
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Empty(t, next)
+}
+
+func TestAuth_ListAuditEvents_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// запрос отклоняется до проверки токена и обращения к журналу
+	authTest := newTestAuthWithAudit(ctrl, nil, nil, nil, nil, nil, AuditConfig{Enabled: true})
+
+	now := time.Now()
+	_, _, err := authTest.ListAuditEvents(context.Background(), "token", frontendApp.ID, models.SecurityEventFilter{Outcome: "partial"}, 10, "")
+	require.ErrorIs(t, err, ErrInvalidAuditFilter)
+
+	_, _, err = authTest.ListAuditEvents(context.Background(), "token", frontendApp.ID, models.SecurityEventFilter{Since: now, Until: now.Add(-time.Hour)}, 10, "")
+	require.ErrorIs(t, err, ErrInvalidAuditFilter)
+
+	_, _, err = authTest.ListAuditEvents(context.Background(), "token", frontendApp.ID, models.SecurityEventFilter{}, 10, "abc")
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestAuth_ListAuditEvents_PermissionDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), profileUser.ID).Return([]models.Role{userRole}, nil)
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+	es.EXPECT().SecurityEvents(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithAudit(ctrl, nil, nil, ap, es, rs, AuditConfig{Enabled: true})
+
+	_, _, err := authTest.ListAuditEvents(context.Background(), token, frontendApp.ID, models.SecurityEventFilter{}, 10, "")
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_SweepAuditEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	es := mocks.NewMockSecurityEventStorage(ctrl)
+	es.EXPECT().DeleteSecurityEventsBefore(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-30*24*time.Hour), before, time.Minute)
+			return 3, nil
+		})
+
+	authTest := newTestAuthWithAudit(ctrl, nil, nil, nil, es, nil, AuditConfig{Enabled: true, Retention: 30 * 24 * time.Hour})
+
+	removed, err := authTest.SweepAuditEvents(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+
+	// без срока хранения события не удаляются
+	forever := newTestAuthWithAudit(ctrl, nil, nil, nil, es, nil, AuditConfig{Enabled: true})
+
+	removed, err = forever.SweepAuditEvents(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+func newTestAuthWithBans(
+	ctrl *gomock.Controller,
+	up *mocks.MockUserProvider,
+	ts *mocks.MockTokenStorage,
+	ap *mocks.MockAppProvider,
+	rs *mocks.MockRoleStorage,
+	bs *mocks.MockBanStorage,
+) *Auth {
+	return NewAuth(utils.New(config.Load("../../../config/local.yaml").Env), nil, up, ap, nil, ts, nil, nil, noMFA(ctrl), nil, nil, rs, nil, nil, bs, currentHasher(), defaultPolicy(), nil, nil, legacyKeys(ctrl), time.Minute, time.Hour, PasswordResetConfig{}, EmailVerificationConfig{}, MFAConfig{}, BruteForceConfig{}, OAuthConfig{}, FederationConfig{}, AuditConfig{})
+}
+
+func TestAuth_BanUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	rs.EXPECT().UserRoles(gomock.Any(), int64(9)).Return([]models.Role{userRole}, nil)
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	bs := notBanned(ctrl)
+	bs.EXPECT().SaveBan(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ban models.Ban) error {
+			assert.Equal(t, int64(9), ban.UserID)
+			assert.Equal(t, models.BanKindBan, ban.Kind)
+			assert.Equal(t, "spam", ban.Reason)
+			assert.Equal(t, expiresAt, ban.ExpiresAt)
+			assert.Equal(t, int64(1), ban.BannedBy)
+			return nil
+		})
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, rs, bs)
+
+	ban, err := authTest.BanUser(context.Background(), token, frontendApp.ID, 9, "spam", expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ban.BannedBy)
+	assert.False(t, ban.CreatedAt.IsZero())
+}
+
+func TestAuth_BanUser_ModeratorCannotBanModerator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), profileUser.ID).Return([]models.Role{moderatorRole}, nil).Times(2)
+	rs.EXPECT().UserRoles(gomock.Any(), int64(9)).Return([]models.Role{moderatorRole}, nil)
+	bs := notBanned(ctrl)
+	bs.EXPECT().SaveBan(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, rs, bs)
+
+	_, err := authTest.BanUser(context.Background(), token, frontendApp.ID, 9, "abuse", time.Time{})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_BanUser_PermissionDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token := userToken(t, ap)
+	rs := mocks.NewMockRoleStorage(ctrl)
+	rs.EXPECT().UserRoles(gomock.Any(), profileUser.ID).Return([]models.Role{userRole}, nil)
+	bs := notBanned(ctrl)
+	bs.EXPECT().SaveBan(gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, rs, bs)
+
+	_, err := authTest.BanUser(context.Background(), token, frontendApp.ID, 9, "spam", time.Time{})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuth_BanUser_Self(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	bs := notBanned(ctrl)
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, rs, bs)
+
+	_, err := authTest.BanUser(context.Background(), token, frontendApp.ID, 1, "test", time.Time{})
+	require.ErrorIs(t, err, ErrCannotBanSelf)
+}
+
+func TestAuth_BanUser_InvalidExpiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// срок проверяется до access token
+	authTest := newTestAuthWithBans(ctrl, nil, nil, nil, nil, mocks.NewMockBanStorage(ctrl))
+
+	_, err := authTest.BanUser(context.Background(), "token", frontendApp.ID, 9, "spam", time.Now().Add(-time.Minute))
+	require.ErrorIs(t, err, ErrInvalidBanExpiry)
+
+	// приостановка без срока невозможна
+	_, err = authTest.SuspendUser(context.Background(), "token", frontendApp.ID, 9, "spam", time.Time{})
+	require.ErrorIs(t, err, ErrInvalidBanExpiry)
+}
+
+func TestAuth_SuspendUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	rs.EXPECT().UserRoles(gomock.Any(), int64(9)).Return([]models.Role{userRole}, nil)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	bs := notBanned(ctrl)
+	bs.EXPECT().SaveBan(gomock.Any(), gomock.Any()).Return(nil)
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, rs, bs)
+
+	ban, err := authTest.SuspendUser(context.Background(), token, frontendApp.ID, 9, "flood", expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, models.BanKindSuspension, ban.Kind)
+	assert.Equal(t, expiresAt, ban.ExpiresAt)
+}
+
+func TestAuth_UnbanUser_NotBanned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	token, rs := adminToken(t, ctrl, ap)
+	bs := notBanned(ctrl)
+	bs.EXPECT().DeleteBan(gomock.Any(), int64(9)).Return(storage.ErrBanNotFound)
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, rs, bs)
+
+	err := authTest.UnbanUser(context.Background(), token, frontendApp.ID, 9)
+	require.ErrorIs(t, err, ErrUserNotBanned)
+}
+
+func TestAuth_Login_Banned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+	until := time.Now().Add(time.Hour)
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().SaveToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	bs := mocks.NewMockBanStorage(ctrl)
+	bs.EXPECT().ActiveBan(gomock.Any(), user.ID, gomock.Any()).
+		Return(models.Ban{UserID: user.ID, Kind: models.BanKindSuspension, Reason: "flood", ExpiresAt: until}, nil)
+
+	authTest := newTestAuthWithBans(ctrl, up, ts, nil, nil, bs)
+
+	_, _, _, err := authTest.Login(context.Background(), user.Email, "test", frontendApp.ID)
+	require.ErrorIs(t, err, ErrUserBanned)
+
+	var banned *UserBannedError
+	require.ErrorAs(t, err, &banned)
+	assert.Equal(t, models.BanKindSuspension, banned.Kind)
+	assert.Equal(t, until, banned.ExpiresAt)
+}
+
+func TestAuth_Login_BannedWrongPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 123, Email: "test@test.com", PassHash: mustHash("test")}
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	// бан не раскрывается, пока пароль не подтверждён
+	bs := mocks.NewMockBanStorage(ctrl)
+	bs.EXPECT().ActiveBan(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	authTest := newTestAuthWithBans(ctrl, up, nil, nil, nil, bs)
+
+	_, _, _, err := authTest.Login(context.Background(), user.Email, "wrong", frontendApp.ID)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuth_RefreshTokens_Banned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := models.User{ID: 1, Email: "test@test.com", PassHash: mustHash("test")}
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+
+	refresh := buildRefreshToken(user, app, time.Hour)
+
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().User(gomock.Any(), user.Email).Return(user, nil)
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	ts := mocks.NewMockTokenStorage(ctrl)
+	ts.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	bs := mocks.NewMockBanStorage(ctrl)
+	bs.EXPECT().ActiveBan(gomock.Any(), user.ID, gomock.Any()).Return(models.Ban{UserID: user.ID, Kind: models.BanKindBan}, nil)
+
+	authTest := newTestAuthWithBans(ctrl, up, ts, ap, nil, bs)
+
+	_, _, err := authTest.RefreshTokens(context.Background(), refresh, app.ID)
+	require.ErrorIs(t, err, ErrUserBanned)
+}
+
+func TestAuth_ValidateToken_Banned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 50, Email: "test@test.com"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil).Times(2)
+	bs := mocks.NewMockBanStorage(ctrl)
+	bs.EXPECT().ActiveBan(gomock.Any(), user.ID, gomock.Any()).Return(models.Ban{UserID: user.ID, Kind: models.BanKindBan}, nil)
+	bs.EXPECT().ActiveBan(gomock.Any(), user.ID, gomock.Any()).Return(models.Ban{}, errors.New("connection refused"))
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, nil, bs)
+
+	// токен ещё не истёк, но уже не принимается
+	_, err = authTest.ValidateToken(context.Background(), tokenPair.AccessToken, app.ID)
+	var banned *UserBannedError
+	require.ErrorAs(t, err, &banned)
+	assert.True(t, banned.ExpiresAt.IsZero())
+
+	_, err = authTest.ValidateToken(context.Background(), tokenPair.AccessToken, app.ID)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestAuth_ValidateToken_DeletedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := models.App{ID: 1, Secret: "test-secret", Name: "test"}
+	user := models.User{ID: 50, Email: "test@test.com"}
+
+	tokenPair, err := jwt.NewTokenPair(user, app, nil, time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().App(gomock.Any(), app.ID).Return(app, nil)
+	bs := mocks.NewMockBanStorage(ctrl)
+	bs.EXPECT().ActiveBan(gomock.Any(), user.ID, gomock.Any()).Return(models.Ban{}, storage.ErrUserNotFound)
+
+	authTest := newTestAuthWithBans(ctrl, nil, nil, ap, nil, bs)
+
+	// аккаунт удалён, а токен ещё не истёк
+	_, err = authTest.ValidateToken(context.Background(), tokenPair.AccessToken, app.ID)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuth_ActiveBans_IncludesDeletedAccounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deletedAt := time.Now().Add(-time.Minute)
+
+	ap := mocks.NewMockAppProvider(ctrl)
+	ap.EXPECT().Apps(gomock.Any()).Return([]models.App{{ID: 1}, {ID: 2, AccessTokenTTL: 30 * time.Minute}}, nil)
+	bs := mocks.NewMockBanStorage(ctrl)
+	bs.EXPECT().ActiveBans(gomock.Any(), gomock.Any()).Return([]models.Ban{{UserID: 9, Kind: models.BanKindBan}}, nil)
+	up := mocks.NewMockUserProvider(ctrl)
+	up.EXPECT().AccountErasuresSince(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, since time.Time) ([]models.AccountErasure, error) {
+			// окно — наибольший срок жизни access token среди приложений
+			assert.WithinDuration(t, time.Now().Add(-30*time.Minute), since, 5*time.Second)
+			return []models.AccountErasure{{ID: 3, UserID: 11, CreatedAt: deletedAt}}, nil
+		})
+
+	authTest := newTestAuthWithBans(ctrl, up, nil, ap, nil, bs)
+
+	bans, err := authTest.ActiveBans(context.Background())
+	require.NoError(t, err)
+	require.Len(t, bans, 2)
+	assert.Equal(t, int64(11), bans[1].UserID)
+	assert.Equal(t, models.BanKindDeleted, bans[1].Kind)
+	assert.Equal(t, deletedAt.Add(30*time.Minute), bans[1].ExpiresAt)
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrUserBanned — пользователь забанен или приостановлен; из сервиса возвращается как *UserBannedError
+	ErrUserBanned = errors.New("user is banned")
+	// ErrUserNotBanned — у пользователя нет блокировки
+	ErrUserNotBanned = errors.New("user is not banned")
+	// ErrInvalidBanExpiry — срок блокировки уже прошёл или не задан для приостановки
+	ErrInvalidBanExpiry = errors.New("ban expiry must be in the future")
+	// ErrCannotBanSelf — попытка заблокировать самого себя
+	ErrCannotBanSelf = errors.New("cannot ban yourself")
+)
+
+// UserBannedError возвращается из Login, RefreshTokens, ValidateToken и других выдач токенов,
+// пока у пользователя действует бан или приостановка
+type UserBannedError struct {
+	Kind string
+	// ExpiresAt — окончание блокировки; нулевое значение — бессрочно
+	ExpiresAt time.Time
+}
+
+func (e *UserBannedError) Error() string {
+	if e.ExpiresAt.IsZero() {
+		return fmt.Sprintf("%s (%s)", ErrUserBanned, e.Kind)
+	}
+	return fmt.Sprintf("%s (%s) until %s", ErrUserBanned, e.Kind, e.ExpiresAt.Format(time.RFC3339))
+}
+
+func (e *UserBannedError) Unwrap() error {
+	return ErrUserBanned
+}
+
+// BanUser банит пользователя userID с причиной reason. Нулевой expiresAt — бессрочный бан.
+// Требует права auth.users.ban; пользователя с этим правом может забанить только тот, кто
+// управляет ролями. Выданные токены не отзываются, но перестают приниматься сразу.
+func (auth *Auth) BanUser(ctx context.Context, accessToken string, appID int, userID int64, reason string, expiresAt time.Time) (models.Ban, error) {
+	const op = "auth.BanUser"
+
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return models.Ban{}, fmt.Errorf("%s: %w", op, ErrInvalidBanExpiry)
+	}
+
+	ban, err := auth.saveBan(ctx, accessToken, appID, models.Ban{
+		UserID:    userID,
+		Kind:      models.BanKindBan,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return models.Ban{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ban, nil
+}
+
+// SuspendUser приостанавливает пользователя userID до expiresAt. Требует права auth.users.ban.
+func (auth *Auth) SuspendUser(ctx context.Context, accessToken string, appID int, userID int64, reason string, expiresAt time.Time) (models.Ban, error) {
+	const op = "auth.SuspendUser"
+
+	if !expiresAt.After(time.Now()) {
+		return models.Ban{}, fmt.Errorf("%s: %w", op, ErrInvalidBanExpiry)
+	}
+
+	ban, err := auth.saveBan(ctx, accessToken, appID, models.Ban{
+		UserID:    userID,
+		Kind:      models.BanKindSuspension,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return models.Ban{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ban, nil
+}
+
+// UnbanUser снимает с пользователя userID бан или приостановку. Требует права auth.users.ban.
+func (auth *Auth) UnbanUser(ctx context.Context, accessToken string, appID int, userID int64) error {
+	const op = "auth.UnbanUser"
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionUsersBan)
+	if err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			auth.auditFailure(ctx, models.SecurityEventUserUnbanned, userID, appID, err, map[string]any{"actor_id": claims.UserID})
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", userID))
+
+	if err := auth.bans.DeleteBan(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrBanNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrUserNotBanned)
+		}
+		log.Error("failed to delete ban", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user unbanned", slog.Int64("unbanned_by", claims.UserID))
+	auth.auditSuccess(ctx, models.SecurityEventUserUnbanned, userID, appID, map[string]any{"actor_id": claims.UserID})
+	return nil
+}
+
+// ActiveBans возвращает действующие баны и приостановки. Вызывается другими сервисами,
+// которые проверяют access токены сами. Удалённые аккаунты добавляются как блокировки вида
+// models.BanKindDeleted, пока у них могут оставаться неистёкшие access токены.
+func (auth *Auth) ActiveBans(ctx context.Context) ([]models.Ban, error) {
+	const op = "auth.ActiveBans"
+
+	log := auth.log.With(slog.String("op", op))
+	now := time.Now()
+
+	bans, err := auth.bans.ActiveBans(ctx, now)
+	if err != nil {
+		log.Error("failed to list active bans", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessTTL, err := auth.maxAccessTokenTTL(ctx)
+	if err != nil {
+		log.Error("failed to get apps", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	erasures, err := auth.userProvider.AccountErasuresSince(ctx, now.Add(-accessTTL))
+	if err != nil {
+		log.Error("failed to list recent account erasures", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, erasure := range erasures {
+		bans = append(bans, models.Ban{
+			UserID:    erasure.UserID,
+			Kind:      models.BanKindDeleted,
+			Reason:    "account deleted",
+			ExpiresAt: erasure.CreatedAt.Add(accessTTL),
+			CreatedAt: erasure.CreatedAt,
+		})
+	}
+
+	return bans, nil
+}
+
+// maxAccessTokenTTL возвращает наибольший срок жизни access token среди всех приложений
+func (auth *Auth) maxAccessTokenTTL(ctx context.Context) (time.Duration, error) {
+	apps, err := auth.appProvider.Apps(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	maxTTL := auth.accessTokenTTL
+	for _, app := range apps {
+		accessTTL, _ := auth.tokenTTLs(app)
+		maxTTL = max(maxTTL, accessTTL)
+	}
+
+	return maxTTL, nil
+}
+
+// saveBan проверяет права владельца access token и сохраняет блокировку ban
+func (auth *Auth) saveBan(ctx context.Context, accessToken string, appID int, ban models.Ban) (models.Ban, error) {
+	details := map[string]any{"kind": ban.Kind, "reason": ban.Reason}
+	if !ban.ExpiresAt.IsZero() {
+		details["expires_at"] = ban.ExpiresAt.Unix()
+	}
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionUsersBan)
+	if err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			details["actor_id"] = claims.UserID
+			auth.auditFailure(ctx, models.SecurityEventUserBanned, ban.UserID, appID, err, details)
+		}
+		return models.Ban{}, err
+	}
+	details["actor_id"] = claims.UserID
+
+	log := auth.log.With(slog.Int64("user_id", ban.UserID), slog.String("kind", ban.Kind))
+
+	if ban.UserID == claims.UserID {
+		return models.Ban{}, ErrCannotBanSelf
+	}
+
+	// модератор не может заблокировать другого модератора или администратора
+	targetCanBan, err := auth.HasPermission(ctx, ban.UserID, PermissionUsersBan)
+	if err != nil {
+		return models.Ban{}, err
+	}
+	if targetCanBan {
+		canManageRoles, err := auth.HasPermission(ctx, claims.UserID, PermissionRolesManage)
+		if err != nil {
+			return models.Ban{}, err
+		}
+		if !canManageRoles {
+			log.Warn("ban of a moderator denied", slog.Int64("banned_by", claims.UserID))
+			auth.auditFailure(ctx, models.SecurityEventUserBanned, ban.UserID, appID, ErrPermissionDenied, details)
+			return models.Ban{}, ErrPermissionDenied
+		}
+	}
+
+	ban.BannedBy = claims.UserID
+	ban.CreatedAt = time.Now()
+
+	if err := auth.bans.SaveBan(ctx, ban); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.Ban{}, ErrUserNotFound
+		}
+		log.Error("failed to save ban", sl.Err(err))
+		return models.Ban{}, err
+	}
+
+	log.Info("user banned", slog.Int64("banned_by", claims.UserID), slog.Time("expires_at", ban.ExpiresAt))
+	auth.auditSuccess(ctx, models.SecurityEventUserBanned, ban.UserID, appID, details)
+	return ban, nil
+}
+
+// checkBan возвращает *UserBannedError, если у пользователя действует бан или приостановка,
+// и ErrUserNotFound, если аккаунт уже удалён
+func (auth *Auth) checkBan(ctx context.Context, userID int64) error {
+	ban, err := auth.bans.ActiveBan(ctx, userID, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrBanNotFound) {
+			return nil
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		auth.log.Error("failed to check user ban", slog.Int64("user_id", userID), sl.Err(err))
+		return err
+	}
+
+	return &UserBannedError{Kind: ban.Kind, ExpiresAt: ban.ExpiresAt}
+}
@@ -0,0 +1,334 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"time"
+)
+
+// FederationConfig — вход через внешних провайдеров OpenID Connect
+type FederationConfig struct {
+	// StateTTL — сколько ждать возвращения пользователя от провайдера
+	StateTTL time.Duration
+	// Providers — настроенные провайдеры по имени, которое используется в адресах /federation/{provider}/
+	Providers map[string]IdentityProvider
+}
+
+var (
+	// ErrUnknownIdentityProvider — провайдер с таким именем не настроен
+	ErrUnknownIdentityProvider = errors.New("unknown identity provider")
+	// ErrInvalidFederationState — state не выдавался, уже использован или истёк
+	ErrInvalidFederationState = errors.New("invalid or expired federation state")
+	// ErrFederatedLoginFailed — провайдер не выдал ID token или токен не прошёл проверку
+	ErrFederatedLoginFailed = errors.New("identity provider login failed")
+	// ErrFederatedEmailMissing — провайдер не сообщил email, и создать по нему пользователя нельзя
+	ErrFederatedEmailMissing = errors.New("identity provider did not return an email")
+	// ErrFederatedAccountExists — пользователь с таким email уже есть, но email не подтверждён
+	// провайдером или у нас; учётную запись провайдера нужно привязать явно после входа
+	ErrFederatedAccountExists = errors.New("account with this email already exists, link the provider explicitly")
+	// ErrIdentityAlreadyLinked — учётная запись провайдера привязана к другому пользователю
+	// или у пользователя уже есть другая учётная запись этого провайдера
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked")
+)
+
+// FederatedLoginResult — итог возвращения пользователя от провайдера
+type FederatedLoginResult struct {
+	UserID       int64
+	AccessToken  string
+	RefreshToken string
+	// Linked — учётная запись провайдера привязана к вошедшему пользователю; токены в этом случае не выдаются
+	Linked bool
+}
+
+// StartFederatedLogin начинает вход в приложение appID через провайдера и возвращает адрес, на который
+// нужно отправить браузер пользователя
+func (auth *Auth) StartFederatedLogin(ctx context.Context, provider string, appID int) (string, error) {
+	const op = "auth.StartFederatedLogin"
+
+	if _, err := auth.activeApp(ctx, appID); err != nil {
+		if !errors.Is(err, ErrAppNotFound) && !errors.Is(err, ErrAppDisabled) {
+			auth.log.Error("failed to get app", slog.String("op", op), sl.Err(err))
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	redirectTo, err := auth.startFederation(ctx, provider, appID, 0)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return redirectTo, nil
+}
+
+// StartIdentityLink начинает привязку учётной записи провайдера к владельцу accessToken.
+// После возвращения от провайдера учётная запись привязывается независимо от её email.
+func (auth *Auth) StartIdentityLink(ctx context.Context, accessToken string, appID int, provider string) (string, error) {
+	const op = "auth.StartIdentityLink"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	redirectTo, err := auth.startFederation(ctx, provider, appID, claims.UserID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return redirectTo, nil
+}
+
+// CompleteFederatedLogin обрабатывает возвращение пользователя от провайдера: гасит state, обменивает код
+// на ID token и находит пользователя. Учётная запись провайдера, которую ещё не видели, привязывается
+// к пользователю с тем же email, только если email подтверждён и провайдером, и у нас; если такого
+// пользователя нет, он создаётся. Как и Login, при включённом TOTP возвращает *MFAChallengeError.
+func (auth *Auth) CompleteFederatedLogin(ctx context.Context, provider, state, code string) (FederatedLoginResult, error) {
+	const op = "auth.CompleteFederatedLogin"
+
+	idp, ok := auth.federation.Providers[provider]
+	if !ok {
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, ErrUnknownIdentityProvider)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.String("provider", provider))
+
+	stored, err := auth.federationStorage.ConsumeFederationState(ctx, hashOneTimeToken(state), provider, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrFederationStateNotFound) {
+			return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, ErrInvalidFederationState)
+		}
+		log.Error("failed to consume federation state", sl.Err(err))
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	external, err := idp.Exchange(ctx, code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		log.Warn("identity provider login rejected", sl.Err(err))
+		auth.auditFailure(ctx, models.SecurityEventLogin, stored.LinkUserID, stored.AppID, ErrFederatedLoginFailed, map[string]any{"method": "federated", "provider": provider})
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w: %v", op, ErrFederatedLoginFailed, err)
+	}
+
+	if stored.LinkUserID != 0 {
+		if err := auth.linkIdentity(ctx, stored.LinkUserID, provider, external); err != nil {
+			return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		log.Info("identity linked", slog.Int64("user_id", stored.LinkUserID))
+		return FederatedLoginResult{UserID: stored.LinkUserID, Linked: true}, nil
+	}
+
+	user, err := auth.federatedUser(ctx, provider, external)
+	if err != nil {
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("user_id", user.ID))
+
+	if err := auth.checkBan(ctx, user.ID); err != nil {
+		if errors.Is(err, ErrUserBanned) {
+			log.Info("user is banned")
+			auth.auditFailure(ctx, models.SecurityEventLogin, user.ID, stored.AppID, err, map[string]any{"method": "federated", "provider": provider})
+		}
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if auth.emailVerification.RequiredForLogin && !user.EmailVerified {
+		log.Info("email is not verified")
+		auth.auditFailure(ctx, models.SecurityEventLogin, user.ID, stored.AppID, ErrEmailNotVerified, map[string]any{"method": "federated", "provider": provider})
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
+
+	app, err := auth.activeApp(ctx, stored.AppID)
+	if err != nil {
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mfaEnabled, err := auth.mfaEnabled(ctx, user.ID)
+	if err != nil {
+		log.Error("failed to check mfa", sl.Err(err))
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if mfaEnabled {
+		challenge, err := auth.newMFAChallenge(ctx, user.ID, app.ID)
+		if err != nil {
+			log.Error("failed to create mfa challenge", sl.Err(err))
+			return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("identity provider login accepted, waiting for mfa code")
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, challenge)
+	}
+
+	tokenPair, err := auth.issueTokens(ctx, user, app)
+	if err != nil {
+		return FederatedLoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("successfully logged in through identity provider")
+	auth.auditSuccess(ctx, models.SecurityEventLogin, user.ID, app.ID, map[string]any{"method": "federated", "provider": provider})
+
+	return FederatedLoginResult{
+		UserID:       user.ID,
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+	}, nil
+}
+
+// startFederation сохраняет state, nonce и PKCE входа и возвращает адрес входа у провайдера
+func (auth *Auth) startFederation(ctx context.Context, provider string, appID int, linkUserID int64) (string, error) {
+	idp, ok := auth.federation.Providers[provider]
+	if !ok {
+		return "", ErrUnknownIdentityProvider
+	}
+
+	log := auth.log.With(slog.String("provider", provider), slog.Int("app_id", appID))
+
+	var values [3]string
+	for i := range values {
+		value, err := newOneTimeToken()
+		if err != nil {
+			log.Error("failed to generate federation state", sl.Err(err))
+			return "", err
+		}
+		values[i] = value
+	}
+	state, nonce, codeVerifier := values[0], values[1], values[2]
+
+	sum := sha256.Sum256([]byte(codeVerifier))
+	redirectTo, err := idp.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(sum[:]))
+	if err != nil {
+		log.Error("failed to build identity provider login url", sl.Err(err))
+		return "", err
+	}
+
+	err = auth.federationStorage.SaveFederationState(ctx, models.FederationState{
+		StateHash:    hashOneTimeToken(state),
+		Provider:     provider,
+		AppID:        appID,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(auth.federation.StateTTL),
+	})
+	if err != nil {
+		log.Error("failed to save federation state", sl.Err(err))
+		return "", err
+	}
+
+	return redirectTo, nil
+}
+
+// federatedUser находит пользователя по учётной записи провайдера, при необходимости привязывая её
+// к пользователю с тем же email или создавая нового пользователя
+func (auth *Auth) federatedUser(ctx context.Context, provider string, external models.ExternalIdentity) (models.User, error) {
+	log := auth.log.With(slog.String("provider", provider))
+
+	identity, err := auth.federationStorage.UserIdentity(ctx, provider, external.Subject)
+	if err == nil {
+		user, err := auth.userProvider.UserByID(ctx, identity.UserID)
+		if err != nil {
+			log.Error("failed to get linked user", slog.Int64("user_id", identity.UserID), sl.Err(err))
+			return models.User{}, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, storage.ErrIdentityNotFound) {
+		log.Error("failed to get user identity", sl.Err(err))
+		return models.User{}, err
+	}
+
+	if external.Email == "" {
+		return models.User{}, ErrFederatedEmailMissing
+	}
+
+	newIdentity := models.UserIdentity{Provider: provider, Subject: external.Subject, Email: external.Email}
+
+	user, err := auth.userProvider.User(ctx, external.Email)
+	switch {
+	case err == nil:
+		// без подтверждения с обеих сторон email мог зарегистрировать кто угодно: пользователь
+		// должен войти сам и привязать провайдера явно
+		if !external.EmailVerified || !user.EmailVerified {
+			log.Info("refusing to link identity by unverified email", slog.Int64("user_id", user.ID))
+			return models.User{}, ErrFederatedAccountExists
+		}
+
+		newIdentity.UserID = user.ID
+		if err := auth.federationStorage.SaveUserIdentity(ctx, newIdentity); err != nil {
+			if errors.Is(err, storage.ErrIdentityExists) {
+				return models.User{}, ErrIdentityAlreadyLinked
+			}
+			log.Error("failed to link identity", sl.Err(err))
+			return models.User{}, err
+		}
+
+		log.Info("identity linked by verified email", slog.Int64("user_id", user.ID))
+		return user, nil
+
+	case errors.Is(err, storage.ErrUserNotFound):
+		// пароля у такого пользователя нет; задать его можно через сброс пароля
+		password, err := newOneTimeToken()
+		if err != nil {
+			return models.User{}, err
+		}
+		passHash, err := auth.passwordHasher.Hash(password)
+		if err != nil {
+			log.Error("failed to generate hash password", sl.Err(err))
+			return models.User{}, err
+		}
+
+		id, err := auth.federationStorage.SaveFederatedUser(ctx, external.Email, passHash, external.EmailVerified, newIdentity)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrUserAlreadyExists):
+				return models.User{}, ErrFederatedAccountExists
+			case errors.Is(err, storage.ErrIdentityExists):
+				return models.User{}, ErrIdentityAlreadyLinked
+			}
+			log.Error("failed to save federated user", sl.Err(err))
+			return models.User{}, err
+		}
+
+		log.Info("user registered through identity provider", slog.Int64("user_id", id))
+		return models.User{ID: id, Email: external.Email, EmailVerified: external.EmailVerified}, nil
+
+	default:
+		log.Error("failed to get user", sl.Err(err))
+		return models.User{}, err
+	}
+}
+
+// linkIdentity явно привязывает учётную запись провайдера к пользователю userID.
+// Повторная привязка той же учётной записи к тому же пользователю ошибкой не считается.
+func (auth *Auth) linkIdentity(ctx context.Context, userID int64, provider string, external models.ExternalIdentity) error {
+	err := auth.federationStorage.SaveUserIdentity(ctx, models.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  external.Subject,
+		Email:    external.Email,
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, storage.ErrIdentityExists) {
+		auth.log.Error("failed to link identity", sl.Err(err))
+		return err
+	}
+
+	identity, err := auth.federationStorage.UserIdentity(ctx, provider, external.Subject)
+	if err == nil && identity.UserID == userID {
+		return nil
+	}
+	if err != nil && !errors.Is(err, storage.ErrIdentityNotFound) {
+		auth.log.Error("failed to get user identity", sl.Err(err))
+		return err
+	}
+
+	return ErrIdentityAlreadyLinked
+}
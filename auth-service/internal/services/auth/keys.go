@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	jwtGo "github.com/golang-jwt/jwt/v5"
+	"log/slog"
+)
+
+// JWKS возвращает открытые ключи, которыми можно проверить выпущенные токены
+func (auth *Auth) JWKS(ctx context.Context) ([]jwt.JWK, error) {
+	const op = "auth.JWKS"
+
+	keys, err := auth.keys.PublicKeys(ctx)
+	if err != nil {
+		auth.log.Error("failed to get public keys", slog.String("op", op), slog.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jwks := make([]jwt.JWK, 0, len(keys))
+	for _, key := range keys {
+		jwks = append(jwks, jwt.PublicJWK(key))
+	}
+
+	return jwks, nil
+}
+
+// RotateSigningKey выпускает новый ключ подписи приложения targetAppID. Доступно только администратору.
+func (auth *Auth) RotateSigningKey(ctx context.Context, accessToken string, appID int, targetAppID int, algorithm string) (string, error) {
+	const op = "auth.RotateSigningKey"
+
+	log := auth.log.With(slog.String("op", op), slog.Int("target_app_id", targetAppID))
+
+	claims, err := auth.requireAdmin(ctx, accessToken, appID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := auth.appProvider.App(ctx, targetAppID); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return "", fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err := auth.keys.Rotate(ctx, targetAppID, algorithm)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("signing key rotated by admin", slog.Int64("user_id", claims.UserID), slog.String("kid", key.ID))
+	return key.ID, nil
+}
+
+// signingKey возвращает ключ подписи токенов приложения или nil, если токены подписываются его секретом.
+// Секрет OAuth-клиента знает сам клиент, поэтому его токены всегда подписываются ключом:
+// при алгоритме HS256 ключ EdDSA создаётся при первом выпуске токенов.
+func (auth *Auth) signingKey(ctx context.Context, app models.App) (*jwt.SigningKey, error) {
+	key, err := auth.keys.SigningKey(ctx, app.ID)
+	if err != nil || key != nil || !app.IsOAuthClient() {
+		return key, err
+	}
+
+	created, err := auth.keys.Rotate(ctx, app.ID, jwt.AlgEdDSA)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// keyFunc выбирает ключ проверки подписи. Токены с kid проверяются опубликованным ключом приложения.
+// Токены без kid подписаны секретом приложения; секрет выдаётся OAuth-клиентам как client_secret,
+// поэтому такие токены не принимаются для OAuth-клиентов и для приложений с ключом подписи.
+func (auth *Auth) keyFunc(ctx context.Context, app models.App) jwtGo.Keyfunc {
+	return func(token *jwtGo.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if _, ok := token.Method.(*jwtGo.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			if app.IsOAuthClient() {
+				return nil, errors.New("tokens without kid are not accepted for oauth clients")
+			}
+
+			accepted, err := auth.keys.AcceptsHMAC(ctx, app.ID)
+			if err != nil {
+				return nil, err
+			}
+			if !accepted {
+				return nil, errors.New("tokens without kid are not accepted for this app")
+			}
+
+			return []byte(app.Secret), nil
+		}
+
+		key, err := auth.keys.VerificationKey(ctx, app.ID, kid)
+		if err != nil {
+			return nil, fmt.Errorf("kid %q: %w", kid, err)
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return key.Public(), nil
+	}
+}
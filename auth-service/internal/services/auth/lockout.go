@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/clientinfo"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// LoginLockedError возвращается из Login, когда вход по email или IP временно заблокирован
+// из-за неудачных попыток. Пароль в этом случае не проверяется.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyLoginAttempts, e.RetryAfter)
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// ListLoginLockouts возвращает действующие блокировки входа. Доступно только администратору.
+func (auth *Auth) ListLoginLockouts(ctx context.Context, accessToken string, appID int) ([]models.LoginLockout, error) {
+	const op = "auth.ListLoginLockouts"
+
+	if _, err := auth.requireAdmin(ctx, accessToken, appID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lockouts, err := auth.lockouts.ActiveLoginLockouts(ctx, time.Now())
+	if err != nil {
+		auth.log.Error("failed to list login lockouts", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockouts, nil
+}
+
+// ClearLoginLockout снимает блокировку входа по email или IP. Доступно только администратору.
+func (auth *Auth) ClearLoginLockout(ctx context.Context, accessToken string, appID int, kind, key string) error {
+	const op = "auth.ClearLoginLockout"
+
+	if kind != models.LockoutKindEmail && kind != models.LockoutKindIP {
+		return fmt.Errorf("%s: %w", op, ErrInvalidLockoutKind)
+	}
+	if kind == models.LockoutKindEmail {
+		key = normalizeEmail(key)
+	}
+
+	claims, err := auth.requireAdmin(ctx, accessToken, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.String("kind", kind), slog.String("key", key))
+
+	if err := auth.lockouts.ClearLoginLockout(ctx, kind, key); err != nil {
+		if errors.Is(err, storage.ErrLockoutNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrLockoutNotFound)
+		}
+		log.Error("failed to clear login lockout", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("login lockout cleared by admin", slog.Int64("user_id", claims.UserID))
+	return nil
+}
+
+// checkLoginLockout возвращает *LoginLockedError, если вход по email или IP клиента заблокирован
+func (auth *Auth) checkLoginLockout(ctx context.Context, email string) error {
+	if !auth.bruteForce.enabled() {
+		return nil
+	}
+
+	lockedUntil, err := auth.lockouts.LoginLockedUntil(ctx, normalizeEmail(email), clientinfo.FromContext(ctx).IP)
+	if err != nil {
+		return err
+	}
+
+	if retryAfter := time.Until(lockedUntil); retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// recordLoginFailure учитывает неудачную попытку входа. Достигнув порога, ключ блокируется
+// на LockoutDuration, и каждая следующая неудача после окончания блокировки удваивает срок
+// вплоть до MaxLockout. Ошибки хранилища только логируются: они не должны мешать ответу.
+func (auth *Auth) recordLoginFailure(ctx context.Context, email string) {
+	const op = "auth.recordLoginFailure"
+
+	if !auth.bruteForce.enabled() {
+		return
+	}
+
+	now := time.Now()
+	keys := []struct {
+		kind, key string
+		threshold int
+	}{
+		{models.LockoutKindEmail, normalizeEmail(email), auth.bruteForce.EmailThreshold},
+		{models.LockoutKindIP, clientinfo.FromContext(ctx).IP, auth.bruteForce.IPThreshold},
+	}
+
+	for _, k := range keys {
+		if k.threshold <= 0 || k.key == "" {
+			continue
+		}
+
+		log := auth.log.With(slog.String("op", op), slog.String("kind", k.kind), slog.String("key", k.key))
+
+		failures, err := auth.lockouts.RecordLoginFailure(ctx, k.kind, k.key, now, now.Add(-auth.bruteForce.Window))
+		if err != nil {
+			log.Error("failed to record login failure", sl.Err(err))
+			continue
+		}
+		if failures < k.threshold {
+			continue
+		}
+
+		duration := auth.bruteForce.lockoutDuration(failures - k.threshold)
+		if err := auth.lockouts.LockLogin(ctx, k.kind, k.key, now.Add(duration)); err != nil {
+			log.Error("failed to lock login", sl.Err(err))
+			continue
+		}
+
+		log.Warn("login locked after failed attempts", slog.Int("failures", failures), slog.Duration("duration", duration))
+	}
+}
+
+// resetLoginFailures обнуляет счётчик email после верного пароля. Счётчик IP не сбрасывается,
+// иначе перебор с одного адреса можно было бы прерывать входом в собственный аккаунт.
+func (auth *Auth) resetLoginFailures(ctx context.Context, email string) {
+	if !auth.bruteForce.enabled() || auth.bruteForce.EmailThreshold <= 0 {
+		return
+	}
+
+	if err := auth.lockouts.ResetLoginFailures(ctx, models.LockoutKindEmail, normalizeEmail(email)); err != nil {
+		auth.log.Error("failed to reset login failures", sl.Err(err))
+	}
+}
+
+func (c BruteForceConfig) enabled() bool {
+	return c.EmailThreshold > 0 || c.IPThreshold > 0
+}
+
+// lockoutDuration — срок блокировки после extra неудач сверх порога
+func (c BruteForceConfig) lockoutDuration(extra int) time.Duration {
+	duration := c.LockoutDuration
+	for i := 0; i < extra && duration < c.MaxLockout; i++ {
+		duration *= 2
+	}
+	if c.MaxLockout > 0 && duration > c.MaxLockout {
+		duration = c.MaxLockout
+	}
+	return duration
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
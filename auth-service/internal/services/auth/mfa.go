@@ -0,0 +1,393 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/totp"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// количество одноразовых кодов восстановления, выдаваемых при включении TOTP
+const recoveryCodesCount = 10
+
+// MFAChallengeError возвращается из Login, когда пароль верный, но у пользователя включён
+// второй фактор. Token нужно передать в VerifyMFA вместе с кодом.
+type MFAChallengeError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *MFAChallengeError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFAChallengeError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// EnrollTOTP начинает подключение TOTP: генерирует секрет и возвращает его вместе с otpauth URI.
+// Второй фактор включается только после ConfirmTOTP с первым кодом из приложения.
+func (auth *Auth) EnrollTOTP(ctx context.Context, accessToken string, appID int) (string, string, error) {
+	const op = "auth.EnrollTOTP"
+
+	log := auth.log.With(slog.String("op", op))
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", claims.UserID))
+	log.Info("enrolling totp")
+
+	enabled, err := auth.mfaEnabled(ctx, claims.UserID)
+	if err != nil {
+		log.Error("failed to check mfa", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if enabled {
+		return "", "", fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("failed to generate totp secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	encrypted, err := auth.secretCipher.Seal([]byte(secret))
+	if err != nil {
+		log.Error("failed to encrypt totp secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.mfaStorage.SaveTOTPSecret(ctx, claims.UserID, encrypted); err != nil {
+		if errors.Is(err, storage.ErrTOTPAlreadyEnabled) {
+			return "", "", fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+		}
+
+		log.Error("failed to save totp secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enrollment started")
+	return secret, totp.URI(auth.mfa.Issuer, claims.Email, secret), nil
+}
+
+// ConfirmTOTP включает TOTP по первому коду из приложения и возвращает коды восстановления.
+// Коды показываются один раз, в хранилище попадают только их хэши.
+func (auth *Auth) ConfirmTOTP(ctx context.Context, accessToken string, appID int, code string) ([]string, error) {
+	const op = "auth.ConfirmTOTP"
+
+	log := auth.log.With(slog.String("op", op))
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", claims.UserID))
+	log.Info("confirming totp")
+
+	factor, err := auth.mfaStorage.TOTP(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrMFANotEnabled)
+		}
+
+		log.Error("failed to get totp", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if factor.Enabled {
+		return nil, fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+	}
+
+	step, err := auth.validateTOTPCode(factor, code)
+	if err != nil {
+		log.Warn("invalid totp code", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Error("failed to generate recovery codes", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.mfaStorage.EnableTOTP(ctx, claims.UserID, step, hashes); err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			// подтверждение уже выполнено параллельным запросом или код использован повторно
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidMFACode)
+		}
+
+		log.Error("failed to enable totp", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enabled")
+	return codes, nil
+}
+
+// DisableTOTP выключает второй фактор. Нужен действующий код TOTP или код восстановления.
+func (auth *Auth) DisableTOTP(ctx context.Context, accessToken string, appID int, code string) error {
+	const op = "auth.DisableTOTP"
+
+	log := auth.log.With(slog.String("op", op))
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", claims.UserID))
+	log.Info("disabling totp")
+
+	factor, err := auth.mfaStorage.TOTP(ctx, claims.UserID)
+	if err != nil && !errors.Is(err, storage.ErrTOTPNotFound) {
+		log.Error("failed to get totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err != nil || !factor.Enabled {
+		return fmt.Errorf("%s: %w", op, ErrMFANotEnabled)
+	}
+
+	if err := auth.checkMFACode(ctx, factor, code); err != nil {
+		log.Warn("invalid mfa code", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.mfaStorage.DeleteTOTP(ctx, claims.UserID); err != nil {
+		log.Error("failed to delete totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp disabled")
+	return nil
+}
+
+// VerifyMFA завершает вход, начатый Login: проверяет код TOTP или код восстановления
+// по MFA-токену и выпускает пару токенов.
+func (auth *Auth) VerifyMFA(ctx context.Context, mfaToken string, code string) (string, string, int64, error) {
+	const op = "auth.VerifyMFA"
+
+	log := auth.log.With(slog.String("op", op))
+	log.Info("verifying mfa code")
+
+	tokenHash := hashOneTimeToken(mfaToken)
+
+	challenge, err := auth.mfaStorage.AttemptMFAChallenge(ctx, tokenHash, time.Now(), auth.mfa.MaxAttempts)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeNotFound) {
+			log.Warn("invalid mfa token", sl.Err(err))
+			return "", "", 0, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+		}
+
+		log.Error("failed to get mfa challenge", sl.Err(err))
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", challenge.UserID))
+
+	factor, err := auth.mfaStorage.TOTP(ctx, challenge.UserID)
+	if err != nil && !errors.Is(err, storage.ErrTOTPNotFound) {
+		log.Error("failed to get totp", sl.Err(err))
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err != nil || !factor.Enabled {
+		// второй фактор выключили, пока пользователь вводил код
+		return "", "", 0, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+	}
+
+	if err := auth.checkMFACode(ctx, factor, code); err != nil {
+		log.Warn("invalid mfa code", sl.Err(err))
+		auth.auditFailure(ctx, models.SecurityEventLogin, challenge.UserID, challenge.AppID, err, map[string]any{"method": "mfa"})
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.mfaStorage.CompleteMFAChallenge(ctx, tokenHash, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeNotFound) {
+			return "", "", 0, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+		}
+
+		log.Error("failed to complete mfa challenge", sl.Err(err))
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := auth.userProvider.UserByID(ctx, challenge.UserID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// пользователя могли забанить, пока он вводил код
+	if err := auth.checkBan(ctx, user.ID); err != nil {
+		if errors.Is(err, ErrUserBanned) {
+			auth.auditFailure(ctx, models.SecurityEventLogin, user.ID, challenge.AppID, err, map[string]any{"method": "mfa"})
+		}
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := auth.activeApp(ctx, challenge.AppID)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokenPair, err := auth.issueTokens(ctx, user, app)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("successfully logged in with mfa")
+	auth.auditSuccess(ctx, models.SecurityEventLogin, user.ID, app.ID, map[string]any{"method": "mfa"})
+	return tokenPair.AccessToken, tokenPair.RefreshToken, user.ID, nil
+}
+
+// authenticate проверяет access token для RPC, которые пользователь вызывает от своего имени
+func (auth *Auth) authenticate(ctx context.Context, accessToken string, appID int) (models.AccessClaims, error) {
+	claims, err := auth.ValidateToken(ctx, accessToken, appID)
+	if err != nil {
+		return models.AccessClaims{}, fmt.Errorf("%w: %v", ErrInvalidAccessToken, err)
+	}
+	return claims, nil
+}
+
+// requireAdmin проверяет access token и права администратора его владельца
+func (auth *Auth) requireAdmin(ctx context.Context, accessToken string, appID int) (models.AccessClaims, error) {
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return models.AccessClaims{}, err
+	}
+
+	isAdmin, err := auth.IsAdmin(ctx, claims.UserID)
+	if err != nil {
+		return models.AccessClaims{}, err
+	}
+	if !isAdmin {
+		auth.log.Warn("admin action denied", slog.Int64("user_id", claims.UserID))
+		return models.AccessClaims{}, ErrPermissionDenied
+	}
+
+	return claims, nil
+}
+
+func (auth *Auth) mfaEnabled(ctx context.Context, userID int64) (bool, error) {
+	factor, err := auth.mfaStorage.TOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return factor.Enabled, nil
+}
+
+func (auth *Auth) newMFAChallenge(ctx context.Context, userID int64, appID int) (*MFAChallengeError, error) {
+	token, err := newOneTimeToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(auth.mfa.ChallengeTTL)
+	if err := auth.mfaStorage.SaveMFAChallenge(ctx, userID, appID, hashOneTimeToken(token), expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &MFAChallengeError{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// checkMFACode принимает либо код TOTP, либо одноразовый код восстановления
+func (auth *Auth) checkMFACode(ctx context.Context, factor models.TOTP, code string) error {
+	code = strings.TrimSpace(code)
+
+	if !isTOTPCode(code) {
+		err := auth.mfaStorage.ConsumeRecoveryCode(ctx, factor.UserID, hashRecoveryCode(code), time.Now())
+		if err != nil {
+			if errors.Is(err, storage.ErrRecoveryCodeNotFound) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+		return nil
+	}
+
+	step, err := auth.validateTOTPCode(factor, code)
+	if err != nil {
+		return err
+	}
+
+	// код, уже принятый однажды, повторно не засчитывается
+	fresh, err := auth.mfaStorage.UseTOTPStep(ctx, factor.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (auth *Auth) validateTOTPCode(factor models.TOTP, code string) (int64, error) {
+	secret, err := auth.secretCipher.Open(factor.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(string(secret), strings.TrimSpace(code), time.Now())
+	if !ok || step <= factor.LastUsedStep {
+		return 0, ErrInvalidMFACode
+	}
+
+	return step, nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes возвращает коды восстановления вида xxxxx-xxxxx и их хэши
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([][]byte, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode не зависит от регистра и дефисов, чтобы код можно было ввести как удобно
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return sum[:]
+}
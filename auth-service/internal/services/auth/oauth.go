@@ -0,0 +1,543 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope, которые может запросить OAuth-клиент
+const (
+	ScopeOpenID     = "openid"
+	ScopeProfile    = "profile"
+	ScopeEmail      = "email"
+	ScopeForumRead  = "forum.read"
+	ScopeForumWrite = "forum.write"
+)
+
+// OAuthScopes — поддерживаемые scope и их описание для страницы согласия
+var OAuthScopes = map[string]string{
+	ScopeOpenID:     "sign you in with your account",
+	ScopeProfile:    "basic profile information",
+	ScopeEmail:      "email address",
+	ScopeForumRead:  "read forum topics and comments",
+	ScopeForumWrite: "create topics and comments on your behalf",
+}
+
+// Коды ошибок OAuth 2.0 (RFC 6749, разделы 4.1.2.1 и 5.2)
+const (
+	OAuthInvalidRequest           = "invalid_request"
+	OAuthInvalidClient            = "invalid_client"
+	OAuthInvalidGrant             = "invalid_grant"
+	OAuthInvalidScope             = "invalid_scope"
+	OAuthAccessDenied             = "access_denied"
+	OAuthUnsupportedResponseType  = "unsupported_response_type"
+	OAuthUnsupportedGrantType     = "unsupported_grant_type"
+	OAuthServerError              = "server_error"
+	codeChallengeMethodS256       = "S256"
+	responseTypeCode              = "code"
+	minCodeVerifierLen            = 43
+	maxCodeVerifierLen            = 128
+	codeVerifierAllowedCharacters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~"
+)
+
+// Решения пользователя на странице согласия
+const (
+	ConsentApprove = "approve"
+	ConsentDeny    = "deny"
+)
+
+// OAuthConfig — параметры сервера авторизации OAuth 2.0
+type OAuthConfig struct {
+	// CodeTTL — время жизни кода авторизации
+	CodeTTL time.Duration
+	// Issuer — идентификатор сервера авторизации в ID token и OpenID discovery, без завершающего /
+	Issuer string
+}
+
+var (
+	// ErrInvalidOAuthClient — client_id не указан, не найден или приложение не зарегистрировано как OAuth-клиент.
+	// Об этой ошибке нельзя сообщить клиенту редиректом.
+	ErrInvalidOAuthClient = errors.New("invalid oauth client")
+	// ErrInvalidRedirectURI — redirect_uri не зарегистрирован у клиента; редирект на него запрещён
+	ErrInvalidRedirectURI = errors.New("redirect_uri is not registered for the client")
+	// ErrInvalidConsentDecision — страница согласия прислала неизвестное решение
+	ErrInvalidConsentDecision = errors.New("decision must be approve or deny")
+)
+
+// OAuthError — ошибка протокола OAuth 2.0, которая передаётся клиенту с кодом из RFC 6749
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// AuthorizationRequest — параметры запроса к /oauth/authorize
+type AuthorizationRequest struct {
+	ClientID     int
+	RedirectURI  string
+	ResponseType string
+	// Scope — запрошенные scope через пробел
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce — параметр OpenID Connect, который клиент получит обратно в ID token
+	Nonce string
+}
+
+// ErrorRedirect возвращает адрес клиента с ошибкой err в query-параметрах
+func (r AuthorizationRequest) ErrorRedirect(err *OAuthError) string {
+	params := url.Values{}
+	params.Set("error", err.Code)
+	params.Set("error_description", err.Description)
+	return r.redirect(params)
+}
+
+func (r AuthorizationRequest) redirect(params url.Values) string {
+	if r.State != "" {
+		params.Set("state", r.State)
+	}
+
+	u, err := url.Parse(r.RedirectURI)
+	if err != nil {
+		return r.RedirectURI
+	}
+
+	q := u.Query()
+	for name, values := range params {
+		q[name] = values
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// AuthorizationResult — итог POST /oauth/authorize
+type AuthorizationResult struct {
+	// RedirectTo — адрес клиента с кодом или ошибкой; пуст, если нужно согласие пользователя
+	RedirectTo string
+	// ConsentRequired — пользователь ещё не давал согласия на эти scope, ему нужно показать страницу согласия
+	ConsentRequired bool
+	ClientName      string
+	Scope           []string
+}
+
+// OAuthTokens — ответ /oauth/token
+type OAuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	// IDToken выдаётся, только если клиенту доступен scope openid
+	IDToken   string
+	ExpiresIn time.Duration
+	Scope     []string
+}
+
+// CheckAuthorizationRequest проверяет клиента, redirect_uri, PKCE и scope запроса авторизации.
+// Возвращает клиента и нормализованный список scope. Ошибки ErrInvalidOAuthClient и ErrInvalidRedirectURI
+// показываются пользователю; *OAuthError передаётся клиенту через ErrorRedirect.
+func (auth *Auth) CheckAuthorizationRequest(ctx context.Context, req AuthorizationRequest) (models.App, []string, error) {
+	const op = "auth.CheckAuthorizationRequest"
+
+	app, err := auth.appProvider.App(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.App{}, nil, fmt.Errorf("%s: %w", op, ErrInvalidOAuthClient)
+		}
+		auth.log.Error("failed to get oauth client", slog.String("op", op), sl.Err(err))
+		return models.App{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !app.IsOAuthClient() || app.Disabled {
+		return models.App{}, nil, fmt.Errorf("%s: %w", op, ErrInvalidOAuthClient)
+	}
+
+	// redirect_uri сравнивается целиком, без нормализации
+	if !slices.Contains(app.RedirectURIs, req.RedirectURI) {
+		return models.App{}, nil, fmt.Errorf("%s: %w", op, ErrInvalidRedirectURI)
+	}
+
+	if req.ResponseType != responseTypeCode {
+		return models.App{}, nil, &OAuthError{Code: OAuthUnsupportedResponseType, Description: "response_type must be code"}
+	}
+
+	// PKCE обязателен для всех клиентов, метод plain не поддерживается
+	if req.CodeChallenge == "" {
+		return models.App{}, nil, &OAuthError{Code: OAuthInvalidRequest, Description: "code_challenge is required"}
+	}
+	if req.CodeChallengeMethod != codeChallengeMethodS256 {
+		return models.App{}, nil, &OAuthError{Code: OAuthInvalidRequest, Description: "code_challenge_method must be S256"}
+	}
+	if challenge, err := base64.RawURLEncoding.DecodeString(req.CodeChallenge); err != nil || len(challenge) != sha256.Size {
+		return models.App{}, nil, &OAuthError{Code: OAuthInvalidRequest, Description: "code_challenge must be a base64url-encoded SHA-256 hash"}
+	}
+
+	scope, err := requestedScope(req.Scope)
+	if err != nil {
+		return models.App{}, nil, err
+	}
+
+	return app, scope, nil
+}
+
+// Authorize выдаёт клиенту код авторизации от имени владельца accessToken (токена фронтенда appID).
+// Пустой decision выдаёт код, только если пользователь уже согласился на все запрошенные scope,
+// иначе возвращает ConsentRequired. ConsentApprove записывает согласие, ConsentDeny возвращает клиенту access_denied.
+func (auth *Auth) Authorize(ctx context.Context, accessToken string, appID int, req AuthorizationRequest, decision string) (AuthorizationResult, error) {
+	const op = "auth.Authorize"
+
+	client, scope, err := auth.CheckAuthorizationRequest(ctx, req)
+	if err != nil {
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) {
+			return AuthorizationResult{RedirectTo: req.ErrorRedirect(oauthErr)}, nil
+		}
+		return AuthorizationResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return AuthorizationResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", claims.UserID), slog.Int("client_id", client.ID))
+
+	switch decision {
+	case ConsentDeny:
+		log.Info("user denied oauth access")
+		return AuthorizationResult{
+			RedirectTo: req.ErrorRedirect(&OAuthError{Code: OAuthAccessDenied, Description: "the user denied the request"}),
+		}, nil
+
+	case ConsentApprove:
+		granted, err := auth.consentedScope(ctx, claims.UserID, client.ID)
+		if err != nil {
+			return AuthorizationResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		granted = mergeScope(granted, scope)
+		if err := auth.oauthStorage.SaveOAuthConsent(ctx, claims.UserID, client.ID, granted); err != nil {
+			log.Error("failed to save oauth consent", sl.Err(err))
+			return AuthorizationResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		log.Info("oauth consent granted", slog.Any("scope", granted))
+
+	case "":
+		granted, err := auth.consentedScope(ctx, claims.UserID, client.ID)
+		if err != nil {
+			return AuthorizationResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if !scopeCovers(granted, scope) {
+			return AuthorizationResult{ConsentRequired: true, ClientName: client.Name, Scope: scope}, nil
+		}
+
+	default:
+		return AuthorizationResult{}, fmt.Errorf("%s: %w", op, ErrInvalidConsentDecision)
+	}
+
+	code, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate authorization code", sl.Err(err))
+		return AuthorizationResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = auth.oauthStorage.SaveAuthorizationCode(ctx, models.AuthorizationCode{
+		CodeHash:            hashOneTimeToken(code),
+		AppID:               client.ID,
+		UserID:              claims.UserID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+		ExpiresAt:           time.Now().Add(auth.oauth.CodeTTL),
+	})
+	if err != nil {
+		log.Error("failed to save authorization code", sl.Err(err))
+		return AuthorizationResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("authorization code issued")
+
+	return AuthorizationResult{
+		RedirectTo: req.redirect(url.Values{"code": {code}}),
+		ClientName: client.Name,
+		Scope:      scope,
+	}, nil
+}
+
+// ExchangeAuthorizationCode обменивает код авторизации на токены (grant_type=authorization_code).
+// Код одноразовый: повторный обмен, чужой клиент, другой redirect_uri или неверный code_verifier дают invalid_grant.
+func (auth *Auth) ExchangeAuthorizationCode(ctx context.Context, clientID int, clientSecret, code, redirectURI, codeVerifier string) (OAuthTokens, error) {
+	const op = "auth.ExchangeAuthorizationCode"
+
+	client, err := auth.oauthClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if code == "" || codeVerifier == "" {
+		return OAuthTokens{}, &OAuthError{Code: OAuthInvalidRequest, Description: "code and code_verifier are required"}
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int("client_id", client.ID))
+
+	stored, err := auth.oauthStorage.ConsumeAuthorizationCode(ctx, hashOneTimeToken(code), time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrAuthorizationCodeNotFound) {
+			return OAuthTokens{}, &OAuthError{Code: OAuthInvalidGrant, Description: "authorization code is invalid, expired or already used"}
+		}
+		log.Error("failed to consume authorization code", sl.Err(err))
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.AppID != client.ID {
+		log.Warn("authorization code presented by another client", slog.Int("code_client_id", stored.AppID))
+		return OAuthTokens{}, &OAuthError{Code: OAuthInvalidGrant, Description: "authorization code was issued to another client"}
+	}
+	if stored.RedirectURI != redirectURI {
+		return OAuthTokens{}, &OAuthError{Code: OAuthInvalidGrant, Description: "redirect_uri does not match the authorization request"}
+	}
+	if !verifyCodeChallenge(stored.CodeChallenge, codeVerifier) {
+		log.Warn("pkce verification failed")
+		return OAuthTokens{}, &OAuthError{Code: OAuthInvalidGrant, Description: "code_verifier does not match code_challenge"}
+	}
+
+	user, err := auth.userProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return OAuthTokens{}, &OAuthError{Code: OAuthInvalidGrant, Description: "resource owner no longer exists"}
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.Scope = stored.Scope
+
+	if err := auth.checkBan(ctx, user.ID); err != nil {
+		if errors.Is(err, ErrUserBanned) {
+			return OAuthTokens{}, &OAuthError{Code: OAuthInvalidGrant, Description: "resource owner is banned"}
+		}
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokenPair, err := auth.issueTokens(ctx, user, client)
+	if err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	idToken, err := auth.issueIDToken(ctx, user, client, stored.Nonce)
+	if err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("authorization code exchanged", slog.Int64("user_id", user.ID))
+
+	accessTTL, _ := auth.tokenTTLs(client)
+
+	return OAuthTokens{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		IDToken:      idToken,
+		ExpiresIn:    accessTTL,
+		Scope:        stored.Scope,
+	}, nil
+}
+
+// RefreshOAuthTokens обменивает refresh token клиента на новую пару (grant_type=refresh_token).
+// Непустой scope сужает выданный ранее набор.
+func (auth *Auth) RefreshOAuthTokens(ctx context.Context, clientID int, clientSecret, refreshToken, scope string) (OAuthTokens, error) {
+	const op = "auth.RefreshOAuthTokens"
+
+	client, err := auth.oauthClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if refreshToken == "" {
+		return OAuthTokens{}, &OAuthError{Code: OAuthInvalidRequest, Description: "refresh_token is required"}
+	}
+
+	var narrowScope []string
+	if scope != "" {
+		if narrowScope, err = requestedScope(scope); err != nil {
+			return OAuthTokens{}, err
+		}
+	}
+
+	tokenPair, user, err := auth.rotateTokens(ctx, refreshToken, client, narrowScope)
+	if err != nil {
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) {
+			return OAuthTokens{}, oauthErr
+		}
+		auth.log.Info("oauth refresh rejected", slog.String("op", op), slog.Int("client_id", client.ID), sl.Err(err))
+		return OAuthTokens{}, &OAuthError{Code: OAuthInvalidGrant, Description: "refresh token is invalid or expired"}
+	}
+
+	// ID token при обновлении выдаётся без nonce (OpenID Connect Core 1.0, раздел 12.2)
+	idToken, err := auth.issueIDToken(ctx, user, client, "")
+	if err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessTTL, _ := auth.tokenTTLs(client)
+
+	return OAuthTokens{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		IDToken:      idToken,
+		ExpiresIn:    accessTTL,
+		Scope:        user.Scope,
+	}, nil
+}
+
+// ListOAuthConsents возвращает клиентов, которым владелец accessToken дал доступ
+func (auth *Auth) ListOAuthConsents(ctx context.Context, accessToken string, appID int) ([]models.OAuthConsent, error) {
+	const op = "auth.ListOAuthConsents"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	consents, err := auth.oauthStorage.OAuthConsents(ctx, claims.UserID)
+	if err != nil {
+		auth.log.Error("failed to list oauth consents", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consents, nil
+}
+
+// RevokeOAuthConsent отзывает согласие, данное клиенту clientID, вместе с выданными ему refresh токенами.
+// При следующем запросе авторизации клиенту снова понадобится согласие пользователя.
+func (auth *Auth) RevokeOAuthConsent(ctx context.Context, accessToken string, appID int, clientID int) error {
+	const op = "auth.RevokeOAuthConsent"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", claims.UserID), slog.Int("client_id", clientID))
+
+	if err := auth.oauthStorage.DeleteOAuthConsent(ctx, claims.UserID, clientID); err != nil {
+		if errors.Is(err, storage.ErrConsentNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrConsentNotFound)
+		}
+		log.Error("failed to revoke oauth consent", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("oauth consent revoked")
+	return nil
+}
+
+// oauthClient находит OAuth-клиента и проверяет секрет конфиденциального клиента.
+// Публичный клиент секрета не имеет — его подлинность подтверждает PKCE.
+func (auth *Auth) oauthClient(ctx context.Context, clientID int, clientSecret string) (models.App, error) {
+	invalidClient := &OAuthError{Code: OAuthInvalidClient, Description: "client authentication failed"}
+
+	app, err := auth.appProvider.App(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.App{}, invalidClient
+		}
+		auth.log.Error("failed to get oauth client", sl.Err(err))
+		return models.App{}, err
+	}
+	if !app.IsOAuthClient() || app.Disabled {
+		return models.App{}, invalidClient
+	}
+
+	if app.ClientType != models.ClientTypePublic &&
+		subtle.ConstantTimeCompare([]byte(clientSecret), []byte(app.Secret)) != 1 {
+		auth.log.Warn("oauth client authentication failed", slog.Int("client_id", clientID))
+		return models.App{}, invalidClient
+	}
+
+	return app, nil
+}
+
+// consentedScope возвращает scope, на которые пользователь уже согласился для клиента
+func (auth *Auth) consentedScope(ctx context.Context, userID int64, clientID int) ([]string, error) {
+	consent, err := auth.oauthStorage.OAuthConsent(ctx, userID, clientID)
+	if err != nil {
+		if errors.Is(err, storage.ErrConsentNotFound) {
+			return nil, nil
+		}
+		auth.log.Error("failed to get oauth consent", sl.Err(err))
+		return nil, err
+	}
+
+	return consent.Scope, nil
+}
+
+// requestedScope разбирает scope из запроса клиента. Пустой scope означает profile.
+func requestedScope(raw string) ([]string, error) {
+	scope := parseScope(raw)
+	if len(scope) == 0 {
+		return []string{ScopeProfile}, nil
+	}
+
+	for _, s := range scope {
+		if _, ok := OAuthScopes[s]; !ok {
+			return nil, &OAuthError{Code: OAuthInvalidScope, Description: fmt.Sprintf("unknown scope %q", s)}
+		}
+	}
+
+	return scope, nil
+}
+
+// parseScope разбирает список scope через пробел, убирая повторы; результат отсортирован
+func parseScope(raw string) []string {
+	scope := strings.Fields(raw)
+	if len(scope) == 0 {
+		return nil
+	}
+
+	slices.Sort(scope)
+	return slices.Compact(scope)
+}
+
+func mergeScope(a, b []string) []string {
+	return parseScope(strings.Join(append(slices.Clone(a), b...), " "))
+}
+
+// scopeCovers сообщает, входят ли все scope из requested в granted
+func scopeCovers(granted, requested []string) bool {
+	for _, s := range requested {
+		if !slices.Contains(granted, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyCodeChallenge проверяет code_verifier по сохранённому code_challenge методом S256 (RFC 7636)
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if len(verifier) < minCodeVerifierLen || len(verifier) > maxCodeVerifierLen {
+		return false
+	}
+	if strings.Trim(verifier, codeVerifierAllowedCharacters) != "" {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	jwtGo "github.com/golang-jwt/jwt/v5"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// ErrInsufficientScope — токен OAuth-клиента выдан без scope openid и не даёт доступа к /userinfo
+var ErrInsufficientScope = errors.New("access token does not grant the openid scope")
+
+// OpenIDConfiguration — метаданные провайдера OpenID (OpenID Connect Discovery 1.0, раздел 3)
+type OpenIDConfiguration struct {
+	Issuer                            string
+	AuthorizationEndpoint             string
+	TokenEndpoint                     string
+	UserInfoEndpoint                  string
+	JWKSURI                           string
+	ScopesSupported                   []string
+	ResponseTypesSupported            []string
+	GrantTypesSupported               []string
+	SubjectTypesSupported             []string
+	IDTokenSigningAlgValuesSupported  []string
+	TokenEndpointAuthMethodsSupported []string
+	CodeChallengeMethodsSupported     []string
+	ClaimsSupported                   []string
+}
+
+// UserInfo — ответ /userinfo. Email пуст, если токен не даёт scope email.
+type UserInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// OpenIDConfiguration возвращает документ /.well-known/openid-configuration.
+// Адреса эндпоинтов строятся от Issuer: все они обслуживаются HTTP-шлюзом сервиса.
+func (auth *Auth) OpenIDConfiguration() OpenIDConfiguration {
+	scopes := make([]string, 0, len(OAuthScopes))
+	for s := range OAuthScopes {
+		scopes = append(scopes, s)
+	}
+	sort.Strings(scopes)
+
+	issuer := auth.oauth.Issuer
+
+	return OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   scopes,
+		ResponseTypesSupported:            []string{responseTypeCode},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{jwt.AlgRS256, jwt.AlgEdDSA, jwt.AlgHS256},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{codeChallengeMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "email", "email_verified"},
+	}
+}
+
+// UserInfo возвращает сведения о владельце accessToken (OpenID Connect Core 1.0, раздел 5.3).
+// Приложение, для которого выпущен токен, берётся из его claim app_id: подпись всё равно
+// проверяется ключом этого приложения. Токену OAuth-клиента нужен scope openid, email
+// отдаётся только со scope email; токены первых приложений scope не имеют и видят всё.
+func (auth *Auth) UserInfo(ctx context.Context, accessToken string) (UserInfo, error) {
+	const op = "auth.UserInfo"
+
+	appID, err := tokenAppID(accessToken)
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("%s: %w: %v", op, ErrInvalidAccessToken, err)
+	}
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	oauthToken := len(claims.Scope) > 0
+	if oauthToken && !slices.Contains(claims.Scope, ScopeOpenID) {
+		return UserInfo{}, fmt.Errorf("%s: %w", op, ErrInsufficientScope)
+	}
+
+	// email мог измениться после выпуска токена, поэтому данные берутся из хранилища
+	user, err := auth.userProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return UserInfo{}, fmt.Errorf("%s: %w", op, ErrInvalidAccessToken)
+		}
+		auth.log.Error("failed to get user", slog.String("op", op), sl.Err(err))
+		return UserInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info := UserInfo{Subject: strconv.FormatInt(user.ID, 10)}
+	if !oauthToken || slices.Contains(claims.Scope, ScopeEmail) {
+		info.Email = user.Email
+		info.EmailVerified = user.EmailVerified
+	}
+
+	return info, nil
+}
+
+// issueIDToken выпускает ID token клиенту, если user.Scope содержит openid; иначе возвращает пустую строку
+func (auth *Auth) issueIDToken(ctx context.Context, user models.User, client models.App, nonce string) (string, error) {
+	if !slices.Contains(user.Scope, ScopeOpenID) {
+		return "", nil
+	}
+
+	key, err := auth.signingKey(ctx, client)
+	if err != nil {
+		auth.log.Error("failed to get signing key", sl.Err(err))
+		return "", err
+	}
+
+	idToken := jwt.IDToken{
+		Issuer:   auth.oauth.Issuer,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Audience: strconv.Itoa(client.ID),
+		Nonce:    nonce,
+	}
+	if slices.Contains(user.Scope, ScopeEmail) {
+		idToken.Email = user.Email
+		idToken.EmailVerified = user.EmailVerified
+	}
+
+	accessTTL, _ := auth.tokenTTLs(client)
+
+	token, err := jwt.NewIDToken(idToken, client, key, accessTTL)
+	if err != nil {
+		auth.log.Error("failed to generate id token", sl.Err(err))
+		return "", err
+	}
+
+	return token, nil
+}
+
+// tokenAppID читает claim app_id без проверки подписи, чтобы выбрать ключ для проверки
+func tokenAppID(accessToken string) (int, error) {
+	token, _, err := jwtGo.NewParser().ParseUnverified(accessToken, jwtGo.MapClaims{})
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(jwtGo.MapClaims)
+	if !ok {
+		return 0, errors.New("invalid token claims")
+	}
+
+	appID, ok := claims["app_id"].(float64)
+	if !ok {
+		return 0, errors.New("app_id claim is missing or invalid")
+	}
+
+	return int(appID), nil
+}
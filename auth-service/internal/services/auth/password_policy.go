@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/lib/passpolicy"
+	"strings"
+)
+
+// PasswordPolicyError возвращается, когда новый пароль не проходит политику паролей.
+// Violations перечисляет все нарушенные правила, а не только первое.
+type PasswordPolicyError struct {
+	Violations []passpolicy.Violation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(rules, ", "))
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// checkPassword проверяет новый пароль пользователя с адресом email
+func (auth *Auth) checkPassword(password, email string) error {
+	if violations := auth.passwordPolicy.Check(password, email); len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxDisplayNameLength = 64
+	maxBioLength         = 500
+	maxAvatarURLLength   = 2048
+)
+
+// usernamePattern — имя пользователя после приведения к нижнему регистру
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,32}$`)
+
+var (
+	// ErrInvalidProfile — профиль не прошёл проверку, подробности в *ProfileError
+	ErrInvalidProfile = errors.New("invalid profile")
+	ErrUsernameTaken  = errors.New("username already taken")
+	ErrEmailTaken     = errors.New("email already in use")
+	ErrEmailUnchanged = errors.New("new email matches the current one")
+)
+
+// Profile — поля профиля, которые пользователь меняет сам
+type Profile struct {
+	DisplayName string
+	// Username — уникальное имя без учёта регистра; пустое снимает имя пользователя
+	Username  string
+	AvatarURL string
+	Bio       string
+}
+
+// ProfileError — поле Field профиля недопустимо
+type ProfileError struct {
+	Field       string
+	Description string
+}
+
+func (e *ProfileError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidProfile, e.Field, e.Description)
+}
+
+func (e *ProfileError) Unwrap() error {
+	return ErrInvalidProfile
+}
+
+// GetProfile возвращает профиль владельца access token
+func (auth *Auth) GetProfile(ctx context.Context, accessToken string, appID int) (models.User, error) {
+	const op = "auth.GetProfile"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := auth.userProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		auth.log.Error("failed to get user", slog.String("op", op), sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// UpdateProfile заменяет профиль владельца access token целиком и возвращает его новое состояние
+func (auth *Auth) UpdateProfile(ctx context.Context, accessToken string, appID int, profile Profile) (models.User, error) {
+	const op = "auth.UpdateProfile"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	profile, err = normalizeProfile(profile)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", claims.UserID))
+
+	err = auth.userSaver.UpdateProfile(ctx, models.User{
+		ID:          claims.UserID,
+		DisplayName: profile.DisplayName,
+		Username:    profile.Username,
+		AvatarURL:   profile.AvatarURL,
+		Bio:         profile.Bio,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUsernameExists):
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUsernameTaken)
+		case errors.Is(err, storage.ErrUserNotFound):
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to update profile", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := auth.userProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		log.Error("failed to get updated user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("profile updated")
+	return user, nil
+}
+
+// ChangePassword меняет пароль владельца access token после проверки текущего. Все сессии
+// пользователя отзываются, а вызывающему выдаётся новая пара токенов, чтобы он остался в системе.
+// Неверный текущий пароль учитывается защитой от перебора так же, как неудачный вход.
+func (auth *Auth) ChangePassword(ctx context.Context, accessToken string, appID int, currentPassword, newPassword string) (string, string, error) {
+	const op = "auth.ChangePassword"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", claims.UserID))
+
+	user, err := auth.userProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.verifyCurrentPassword(ctx, user, currentPassword); err != nil {
+		auth.auditFailure(ctx, models.SecurityEventPasswordChange, user.ID, appID, err, map[string]any{"method": "change"})
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.checkPassword(newPassword, user.Email); err != nil {
+		log.Info("password rejected by policy", sl.Err(err))
+		auth.auditFailure(ctx, models.SecurityEventPasswordChange, user.ID, appID, err, map[string]any{"method": "change"})
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := auth.passwordHasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate hash password", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.userSaver.UpdatePassword(ctx, user.ID, passHash); err != nil {
+		log.Error("failed to update password", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.revokeAllSessions(ctx, user.ID); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	auth.auditSuccess(ctx, models.SecurityEventPasswordChange, user.ID, appID, map[string]any{"method": "change"})
+
+	app, err := auth.activeApp(ctx, appID)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokenPair, err := auth.issueTokens(ctx, user, app)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password changed")
+	return tokenPair.AccessToken, tokenPair.RefreshToken, nil
+}
+
+// ChangeEmail отправляет ссылку подтверждения на новый адрес владельца access token.
+// Адрес меняется только после ConfirmEmailChange; прежний адрес получает уведомление о запросе.
+func (auth *Auth) ChangeEmail(ctx context.Context, accessToken string, appID int, password, newEmail string) error {
+	const op = "auth.ChangeEmail"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", claims.UserID))
+
+	user, err := auth.userProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.verifyCurrentPassword(ctx, user, password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if strings.EqualFold(newEmail, user.Email) {
+		return fmt.Errorf("%s: %w", op, ErrEmailUnchanged)
+	}
+
+	// занятость адреса проверяется и при подтверждении: до него адрес может успеть занять другой пользователь
+	_, err = auth.userProvider.User(ctx, newEmail)
+	switch {
+	case err == nil:
+		return fmt.Errorf("%s: %w", op, ErrEmailTaken)
+	case !errors.Is(err, storage.ErrUserNotFound):
+		log.Error("failed to check new email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate email change token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	expiresAt := time.Now().Add(auth.emailVerification.TokenTTL)
+	if err := auth.verificationStorage.SaveEmailChangeToken(ctx, user.ID, newEmail, hashOneTimeToken(token), expiresAt); err != nil {
+		log.Error("failed to save email change token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	body := fmt.Sprintf(
+		"Someone asked to use this address for their account.\n\n"+
+			"To confirm the change, open %s\n\n"+
+			"The link expires at %s. If you did not request it, ignore this email.\n",
+		tokenLink(auth.emailVerification.ChangeURL, token), expiresAt.UTC().Format(time.RFC1123),
+	)
+	if err := auth.mailer.Send(ctx, newEmail, "Confirm your new email", body); err != nil {
+		log.Error("failed to send email change confirmation", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// уведомление только предупреждает владельца; смена не должна от него зависеть
+	notice := "Someone requested to change the email address of your account.\n\n" +
+		"If it was not you, change your password and revoke your sessions.\n"
+	if err := auth.mailer.Send(ctx, user.Email, "Email change requested", notice); err != nil {
+		log.Error("failed to send email change notice", sl.Err(err))
+	}
+
+	log.Info("email change requested")
+	return nil
+}
+
+// ConfirmEmailChange записывает новый адрес по токену из письма. Токен одноразовый.
+// Все сессии пользователя отзываются: refresh токены содержат прежний email.
+func (auth *Auth) ConfirmEmailChange(ctx context.Context, token string) error {
+	const op = "auth.ConfirmEmailChange"
+
+	log := auth.log.With(slog.String("op", op))
+	log.Info("confirming email change")
+
+	userID, err := auth.verificationStorage.ConfirmEmailChange(ctx, hashOneTimeToken(token), time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrVerificationTokenNotFound):
+			log.Warn("invalid email change token", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidVerifyToken)
+		case errors.Is(err, storage.ErrUserAlreadyExists):
+			return fmt.Errorf("%s: %w", op, ErrEmailTaken)
+		}
+		log.Error("failed to confirm email change", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.revokeAllSessions(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email changed", slog.Int64("user_id", userID))
+	return nil
+}
+
+// verifyCurrentPassword проверяет пароль пользователя, который уже вошёл, с учётом блокировок входа
+func (auth *Auth) verifyCurrentPassword(ctx context.Context, user models.User, password string) error {
+	if err := auth.checkLoginLockout(ctx, user.Email); err != nil {
+		return err
+	}
+
+	ok, _, err := auth.passwordHasher.Verify(user.PassHash, password)
+	if err != nil {
+		auth.log.Error("failed to verify password hash", sl.Err(err))
+		return err
+	}
+	if !ok {
+		auth.recordLoginFailure(ctx, user.Email)
+		return ErrInvalidCredentials
+	}
+
+	auth.resetLoginFailures(ctx, user.Email)
+	return nil
+}
+
+// normalizeProfile обрезает пробелы, приводит имя пользователя к нижнему регистру и проверяет поля
+func normalizeProfile(profile Profile) (Profile, error) {
+	profile.DisplayName = strings.TrimSpace(profile.DisplayName)
+	profile.Username = strings.ToLower(strings.TrimSpace(profile.Username))
+	profile.AvatarURL = strings.TrimSpace(profile.AvatarURL)
+	profile.Bio = strings.TrimSpace(profile.Bio)
+
+	if utf8.RuneCountInString(profile.DisplayName) > maxDisplayNameLength {
+		return Profile{}, &ProfileError{Field: "display_name", Description: fmt.Sprintf("must be at most %d characters", maxDisplayNameLength)}
+	}
+	if strings.IndexFunc(profile.DisplayName, unicode.IsControl) >= 0 {
+		return Profile{}, &ProfileError{Field: "display_name", Description: "must not contain control characters"}
+	}
+
+	if profile.Username != "" && !usernamePattern.MatchString(profile.Username) {
+		return Profile{}, &ProfileError{Field: "username", Description: "must be 3-32 latin letters, digits or underscores"}
+	}
+
+	if profile.AvatarURL != "" {
+		if len(profile.AvatarURL) > maxAvatarURLLength {
+			return Profile{}, &ProfileError{Field: "avatar_url", Description: fmt.Sprintf("must be at most %d bytes", maxAvatarURLLength)}
+		}
+		u, err := url.Parse(profile.AvatarURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return Profile{}, &ProfileError{Field: "avatar_url", Description: "must be an absolute http or https URL"}
+		}
+	}
+
+	if utf8.RuneCountInString(profile.Bio) > maxBioLength {
+		return Profile{}, &ProfileError{Field: "bio", Description: fmt.Sprintf("must be at most %d characters", maxBioLength)}
+	}
+
+	return profile, nil
+}
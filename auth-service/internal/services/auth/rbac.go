@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"slices"
+)
+
+// Роли из миграции 11_init_rbac
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+	RoleReadOnly  = "read_only"
+)
+
+// Права, которые проверяют сервисы. Полный список и привязка к ролям — в миграциях.
+const (
+	PermissionRolesManage   = "auth.roles.manage"
+	PermissionAppsManage    = "auth.apps.manage"
+	PermissionUsersDelete   = "auth.users.delete"
+	PermissionAuditRead     = "auth.audit.read"
+	PermissionUsersBan      = "auth.users.ban"
+	PermissionPostCreate    = "forum.post.create"
+	PermissionPostDeleteAny = "forum.post.delete_any"
+	PermissionChatManage    = "forum.chat.manage"
+)
+
+// HasPermission проверяет, даёт ли какая-нибудь из ролей пользователя право permission
+func (auth *Auth) HasPermission(ctx context.Context, userID int64, permission string) (bool, error) {
+	const op = "auth.HasPermission"
+
+	roles, err := auth.effectiveRoles(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, role := range roles {
+		if slices.Contains(role.Permissions, permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AssignRole выдаёт пользователю userID роль role. Требует права auth.roles.manage.
+func (auth *Auth) AssignRole(ctx context.Context, accessToken string, appID int, userID int64, role string) error {
+	const op = "auth.AssignRole"
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionRolesManage)
+	if err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			auth.auditFailure(ctx, models.SecurityEventRoleAssigned, userID, appID, err, map[string]any{"role": role, "actor_id": claims.UserID})
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", userID), slog.String("role", role))
+
+	if err := auth.roles.AssignRole(ctx, userID, role); err != nil {
+		if mapped := roleStorageError(err); mapped != nil {
+			return fmt.Errorf("%s: %w", op, mapped)
+		}
+		log.Error("failed to assign role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role assigned", slog.Int64("granted_by", claims.UserID))
+	auth.auditSuccess(ctx, models.SecurityEventRoleAssigned, userID, appID, map[string]any{"role": role, "actor_id": claims.UserID})
+	return nil
+}
+
+// RevokeRole отзывает у пользователя userID роль role. Требует права auth.roles.manage.
+// Уже выданные access токены сохраняют роль в claims до истечения своего срока.
+func (auth *Auth) RevokeRole(ctx context.Context, accessToken string, appID int, userID int64, role string) error {
+	const op = "auth.RevokeRole"
+
+	claims, err := auth.requirePermission(ctx, accessToken, appID, PermissionRolesManage)
+	if err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			auth.auditFailure(ctx, models.SecurityEventRoleRevoked, userID, appID, err, map[string]any{"role": role, "actor_id": claims.UserID})
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", userID), slog.String("role", role))
+
+	if err := auth.roles.RevokeRole(ctx, userID, role); err != nil {
+		if mapped := roleStorageError(err); mapped != nil {
+			return fmt.Errorf("%s: %w", op, mapped)
+		}
+		log.Error("failed to revoke role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role revoked", slog.Int64("revoked_by", claims.UserID))
+	auth.auditSuccess(ctx, models.SecurityEventRoleRevoked, userID, appID, map[string]any{"role": role, "actor_id": claims.UserID})
+	return nil
+}
+
+// requirePermission проверяет access token и право permission его владельца.
+// При ErrPermissionDenied claims всё равно заполнены, чтобы отказ можно было записать в журнал аудита.
+func (auth *Auth) requirePermission(ctx context.Context, accessToken string, appID int, permission string) (models.AccessClaims, error) {
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return models.AccessClaims{}, err
+	}
+
+	allowed, err := auth.HasPermission(ctx, claims.UserID, permission)
+	if err != nil {
+		return models.AccessClaims{}, err
+	}
+	if !allowed {
+		auth.log.Warn("action denied", slog.Int64("user_id", claims.UserID), slog.String("permission", permission))
+		return claims, ErrPermissionDenied
+	}
+
+	return claims, nil
+}
+
+// effectiveRoles возвращает роли пользователя, которые действуют сейчас: при MFAConfig.RequiredForAdmins
+// роль admin не учитывается, пока у пользователя не включён TOTP
+func (auth *Auth) effectiveRoles(ctx context.Context, userID int64) ([]models.Role, error) {
+	roles, err := auth.roles.UserRoles(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		auth.log.Error("failed to get user roles", slog.Int64("user_id", userID), sl.Err(err))
+		return nil, err
+	}
+
+	isAdmin := slices.ContainsFunc(roles, func(role models.Role) bool { return role.Name == RoleAdmin })
+	if !isAdmin || !auth.mfa.RequiredForAdmins {
+		return roles, nil
+	}
+
+	mfaEnabled, err := auth.mfaEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		return roles, nil
+	}
+
+	auth.log.Warn("admin role is ignored until mfa is enabled", slog.Int64("user_id", userID))
+
+	effective := make([]models.Role, 0, len(roles))
+	for _, role := range roles {
+		if role.Name != RoleAdmin {
+			effective = append(effective, role)
+		}
+	}
+
+	return effective, nil
+}
+
+// withRoles добавляет к пользователю названия действующих ролей для claims access token
+func (auth *Auth) withRoles(ctx context.Context, user models.User) (models.User, error) {
+	roles, err := auth.effectiveRoles(ctx, user.ID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Roles = make([]string, 0, len(roles))
+	for _, role := range roles {
+		user.Roles = append(user.Roles, role.Name)
+	}
+
+	return user, nil
+}
+
+// roleStorageError переводит ошибки хранилища ролей в ошибки сервиса; для остальных возвращает nil
+func roleStorageError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, storage.ErrRoleNotFound):
+		return ErrRoleNotFound
+	case errors.Is(err, storage.ErrRoleNotAssigned):
+		return ErrRoleNotAssigned
+	default:
+		return nil
+	}
+}
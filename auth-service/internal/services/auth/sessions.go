@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+)
+
+// ListSessions возвращает активные сессии владельца access token во всех приложениях
+func (auth *Auth) ListSessions(ctx context.Context, accessToken string, appID int) ([]models.Session, error) {
+	const op = "auth.ListSessions"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessions, err := auth.tokenStorage.Sessions(ctx, claims.UserID)
+	if err != nil {
+		auth.log.Error("failed to list sessions", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию sessionID владельца access token
+func (auth *Auth) RevokeSession(ctx context.Context, accessToken string, appID int, sessionID int64) error {
+	const op = "auth.RevokeSession"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := auth.log.With(slog.String("op", op), slog.Int64("user_id", claims.UserID), slog.Int64("session_id", sessionID))
+
+	if err := auth.tokenStorage.RevokeSession(ctx, claims.UserID, sessionID); err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrSessionNotFound)
+		}
+		log.Error("failed to revoke session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("session revoked")
+	return nil
+}
+
+// RevokeAllSessions завершает все сессии владельца access token, включая текущую
+func (auth *Auth) RevokeAllSessions(ctx context.Context, accessToken string, appID int) error {
+	const op = "auth.RevokeAllSessions"
+
+	claims, err := auth.authenticate(ctx, accessToken, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := auth.revokeAllSessions(ctx, claims.UserID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// revokeAllSessions отзывает все refresh токены пользователя. Вызывается также при смене пароля.
+// Уже выданные access токены действуют до истечения своего срока.
+func (auth *Auth) revokeAllSessions(ctx context.Context, userID int64) error {
+	log := auth.log.With(slog.Int64("user_id", userID))
+
+	if err := auth.tokenStorage.RevokeRefreshTokens(ctx, userID); err != nil {
+		log.Error("failed to revoke sessions", sl.Err(err))
+		return err
+	}
+
+	log.Info("all sessions revoked")
+	return nil
+}
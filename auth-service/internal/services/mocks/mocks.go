@@ -10,6 +10,8 @@ import (
 	time "time"
 
 	models "github.com/14kear/forum-project/auth-service/internal/domain/models"
+	jwt "github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	passpolicy "github.com/14kear/forum-project/auth-service/internal/lib/passpolicy"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -65,160 +67,1579 @@ func (mr *MockTokenStorageMockRecorder) IsRefreshTokenValid(ctx, userID, appID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRefreshTokenValid", reflect.TypeOf((*MockTokenStorage)(nil).IsRefreshTokenValid), ctx, userID, appID, token)
 }
 
-// RevokeRefreshToken mocks base method.
-func (m *MockTokenStorage) RevokeRefreshToken(ctx context.Context, userID int64, appID int, token string) error {
+// RefreshTokenRecords mocks base method.
+func (m *MockTokenStorage) RefreshTokenRecords(ctx context.Context, userID int64) ([]models.RefreshTokenRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, userID, appID, token)
+	ret := m.ctrl.Call(m, "RefreshTokenRecords", ctx, userID)
+	ret0, _ := ret[0].([]models.RefreshTokenRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokenRecords indicates an expected call of RefreshTokenRecords.
+func (mr *MockTokenStorageMockRecorder) RefreshTokenRecords(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokenRecords", reflect.TypeOf((*MockTokenStorage)(nil).RefreshTokenRecords), ctx, userID)
+}
+
+// RevokeRefreshTokens mocks base method.
+func (m *MockTokenStorage) RevokeRefreshTokens(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokens indicates an expected call of RevokeRefreshTokens.
+func (mr *MockTokenStorageMockRecorder) RevokeRefreshTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokens", reflect.TypeOf((*MockTokenStorage)(nil).RevokeRefreshTokens), ctx, userID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockTokenStorage) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
-func (mr *MockTokenStorageMockRecorder) RevokeRefreshToken(ctx, userID, appID, token interface{}) *gomock.Call {
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockTokenStorageMockRecorder) RevokeSession(ctx, userID, sessionID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockTokenStorage)(nil).RevokeRefreshToken), ctx, userID, appID, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockTokenStorage)(nil).RevokeSession), ctx, userID, sessionID)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockTokenStorage) RotateRefreshToken(ctx context.Context, userID int64, appID int, oldToken, newToken string, expiresAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, userID, appID, oldToken, newToken, expiresAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockTokenStorageMockRecorder) RotateRefreshToken(ctx, userID, appID, oldToken, newToken, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockTokenStorage)(nil).RotateRefreshToken), ctx, userID, appID, oldToken, newToken, expiresAt)
 }
 
 // SaveToken mocks base method.
-func (m *MockTokenStorage) SaveToken(ctx context.Context, userID int64, appID int, token string, expiresAt time.Time) (int64, error) {
+func (m *MockTokenStorage) SaveToken(ctx context.Context, userID int64, appID int, token string, expiresAt time.Time, client models.ClientInfo) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveToken", ctx, userID, appID, token, expiresAt)
+	ret := m.ctrl.Call(m, "SaveToken", ctx, userID, appID, token, expiresAt, client)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SaveToken indicates an expected call of SaveToken.
-func (mr *MockTokenStorageMockRecorder) SaveToken(ctx, userID, appID, token, expiresAt interface{}) *gomock.Call {
+func (mr *MockTokenStorageMockRecorder) SaveToken(ctx, userID, appID, token, expiresAt, client interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveToken", reflect.TypeOf((*MockTokenStorage)(nil).SaveToken), ctx, userID, appID, token, expiresAt)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveToken", reflect.TypeOf((*MockTokenStorage)(nil).SaveToken), ctx, userID, appID, token, expiresAt, client)
 }
 
-// MockUserSaver is a mock of UserSaver interface.
-type MockUserSaver struct {
+// Sessions mocks base method.
+func (m *MockTokenStorage) Sessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sessions", ctx, userID)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sessions indicates an expected call of Sessions.
+func (mr *MockTokenStorageMockRecorder) Sessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sessions", reflect.TypeOf((*MockTokenStorage)(nil).Sessions), ctx, userID)
+}
+
+// MockLoginLockoutStorage is a mock of LoginLockoutStorage interface.
+type MockLoginLockoutStorage struct {
 	ctrl     *gomock.Controller
-	recorder *MockUserSaverMockRecorder
+	recorder *MockLoginLockoutStorageMockRecorder
 }
 
-// MockUserSaverMockRecorder is the mock recorder for MockUserSaver.
-type MockUserSaverMockRecorder struct {
-	mock *MockUserSaver
+// MockLoginLockoutStorageMockRecorder is the mock recorder for MockLoginLockoutStorage.
+type MockLoginLockoutStorageMockRecorder struct {
+	mock *MockLoginLockoutStorage
 }
 
-// NewMockUserSaver creates a new mock instance.
-func NewMockUserSaver(ctrl *gomock.Controller) *MockUserSaver {
-	mock := &MockUserSaver{ctrl: ctrl}
-	mock.recorder = &MockUserSaverMockRecorder{mock}
+// NewMockLoginLockoutStorage creates a new mock instance.
+func NewMockLoginLockoutStorage(ctrl *gomock.Controller) *MockLoginLockoutStorage {
+	mock := &MockLoginLockoutStorage{ctrl: ctrl}
+	mock.recorder = &MockLoginLockoutStorageMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockUserSaver) EXPECT() *MockUserSaverMockRecorder {
+func (m *MockLoginLockoutStorage) EXPECT() *MockLoginLockoutStorageMockRecorder {
 	return m.recorder
 }
 
-// SaveUser mocks base method.
-func (m *MockUserSaver) SaveUser(ctx context.Context, email string, passHash []byte) (int64, error) {
+// ActiveLoginLockouts mocks base method.
+func (m *MockLoginLockoutStorage) ActiveLoginLockouts(ctx context.Context, now time.Time) ([]models.LoginLockout, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveUser", ctx, email, passHash)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "ActiveLoginLockouts", ctx, now)
+	ret0, _ := ret[0].([]models.LoginLockout)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// SaveUser indicates an expected call of SaveUser.
-func (mr *MockUserSaverMockRecorder) SaveUser(ctx, email, passHash interface{}) *gomock.Call {
+// ActiveLoginLockouts indicates an expected call of ActiveLoginLockouts.
+func (mr *MockLoginLockoutStorageMockRecorder) ActiveLoginLockouts(ctx, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockUserSaver)(nil).SaveUser), ctx, email, passHash)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActiveLoginLockouts", reflect.TypeOf((*MockLoginLockoutStorage)(nil).ActiveLoginLockouts), ctx, now)
 }
 
-// MockUserProvider is a mock of UserProvider interface.
-type MockUserProvider struct {
+// ClearLoginLockout mocks base method.
+func (m *MockLoginLockoutStorage) ClearLoginLockout(ctx context.Context, kind, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginLockout", ctx, kind, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLoginLockout indicates an expected call of ClearLoginLockout.
+func (mr *MockLoginLockoutStorageMockRecorder) ClearLoginLockout(ctx, kind, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginLockout", reflect.TypeOf((*MockLoginLockoutStorage)(nil).ClearLoginLockout), ctx, kind, key)
+}
+
+// LockLogin mocks base method.
+func (m *MockLoginLockoutStorage) LockLogin(ctx context.Context, kind, key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, kind, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockLoginLockoutStorageMockRecorder) LockLogin(ctx, kind, key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockLoginLockoutStorage)(nil).LockLogin), ctx, kind, key, until)
+}
+
+// LoginLockedUntil mocks base method.
+func (m *MockLoginLockoutStorage) LoginLockedUntil(ctx context.Context, email, ip string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginLockedUntil", ctx, email, ip)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginLockedUntil indicates an expected call of LoginLockedUntil.
+func (mr *MockLoginLockoutStorageMockRecorder) LoginLockedUntil(ctx, email, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginLockedUntil", reflect.TypeOf((*MockLoginLockoutStorage)(nil).LoginLockedUntil), ctx, email, ip)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockLoginLockoutStorage) RecordLoginFailure(ctx context.Context, kind, key string, now, windowStart time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, kind, key, now, windowStart)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockLoginLockoutStorageMockRecorder) RecordLoginFailure(ctx, kind, key, now, windowStart interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockLoginLockoutStorage)(nil).RecordLoginFailure), ctx, kind, key, now, windowStart)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockLoginLockoutStorage) ResetLoginFailures(ctx context.Context, kind, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", ctx, kind, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockLoginLockoutStorageMockRecorder) ResetLoginFailures(ctx, kind, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockLoginLockoutStorage)(nil).ResetLoginFailures), ctx, kind, key)
+}
+
+// MockRoleStorage is a mock of RoleStorage interface.
+type MockRoleStorage struct {
 	ctrl     *gomock.Controller
-	recorder *MockUserProviderMockRecorder
+	recorder *MockRoleStorageMockRecorder
 }
 
-// MockUserProviderMockRecorder is the mock recorder for MockUserProvider.
-type MockUserProviderMockRecorder struct {
-	mock *MockUserProvider
+// MockRoleStorageMockRecorder is the mock recorder for MockRoleStorage.
+type MockRoleStorageMockRecorder struct {
+	mock *MockRoleStorage
 }
 
-// NewMockUserProvider creates a new mock instance.
-func NewMockUserProvider(ctrl *gomock.Controller) *MockUserProvider {
-	mock := &MockUserProvider{ctrl: ctrl}
-	mock.recorder = &MockUserProviderMockRecorder{mock}
+// NewMockRoleStorage creates a new mock instance.
+func NewMockRoleStorage(ctrl *gomock.Controller) *MockRoleStorage {
+	mock := &MockRoleStorage{ctrl: ctrl}
+	mock.recorder = &MockRoleStorageMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockUserProvider) EXPECT() *MockUserProviderMockRecorder {
+func (m *MockRoleStorage) EXPECT() *MockRoleStorageMockRecorder {
 	return m.recorder
 }
 
-// IsAdmin mocks base method.
-func (m *MockUserProvider) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+// AssignRole mocks base method.
+func (m *MockRoleStorage) AssignRole(ctx context.Context, userID int64, role string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAdmin", ctx, userID)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "AssignRole", ctx, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockRoleStorageMockRecorder) AssignRole(ctx, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockRoleStorage)(nil).AssignRole), ctx, userID, role)
+}
+
+// RevokeRole mocks base method.
+func (m *MockRoleStorage) RevokeRole(ctx context.Context, userID int64, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockRoleStorageMockRecorder) RevokeRole(ctx, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockRoleStorage)(nil).RevokeRole), ctx, userID, role)
+}
+
+// UserRoles mocks base method.
+func (m *MockRoleStorage) UserRoles(ctx context.Context, userID int64) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserRoles", ctx, userID)
+	ret0, _ := ret[0].([]models.Role)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsAdmin indicates an expected call of IsAdmin.
-func (mr *MockUserProviderMockRecorder) IsAdmin(ctx, userID interface{}) *gomock.Call {
+// UserRoles indicates an expected call of UserRoles.
+func (mr *MockRoleStorageMockRecorder) UserRoles(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockUserProvider)(nil).IsAdmin), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserRoles", reflect.TypeOf((*MockRoleStorage)(nil).UserRoles), ctx, userID)
 }
 
-// User mocks base method.
-func (m *MockUserProvider) User(ctx context.Context, email string) (models.User, error) {
+// MockOAuthStorage is a mock of OAuthStorage interface.
+type MockOAuthStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthStorageMockRecorder
+}
+
+// MockOAuthStorageMockRecorder is the mock recorder for MockOAuthStorage.
+type MockOAuthStorageMockRecorder struct {
+	mock *MockOAuthStorage
+}
+
+// NewMockOAuthStorage creates a new mock instance.
+func NewMockOAuthStorage(ctrl *gomock.Controller) *MockOAuthStorage {
+	mock := &MockOAuthStorage{ctrl: ctrl}
+	mock.recorder = &MockOAuthStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthStorage) EXPECT() *MockOAuthStorageMockRecorder {
+	return m.recorder
+}
+
+// ConsumeAuthorizationCode mocks base method.
+func (m *MockOAuthStorage) ConsumeAuthorizationCode(ctx context.Context, codeHash []byte, now time.Time) (models.AuthorizationCode, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "User", ctx, email)
-	ret0, _ := ret[0].(models.User)
+	ret := m.ctrl.Call(m, "ConsumeAuthorizationCode", ctx, codeHash, now)
+	ret0, _ := ret[0].(models.AuthorizationCode)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// User indicates an expected call of User.
-func (mr *MockUserProviderMockRecorder) User(ctx, email interface{}) *gomock.Call {
+// ConsumeAuthorizationCode indicates an expected call of ConsumeAuthorizationCode.
+func (mr *MockOAuthStorageMockRecorder) ConsumeAuthorizationCode(ctx, codeHash, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "User", reflect.TypeOf((*MockUserProvider)(nil).User), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeAuthorizationCode", reflect.TypeOf((*MockOAuthStorage)(nil).ConsumeAuthorizationCode), ctx, codeHash, now)
 }
 
-// MockAppProvider is a mock of AppProvider interface.
-type MockAppProvider struct {
+// DeleteOAuthConsent mocks base method.
+func (m *MockOAuthStorage) DeleteOAuthConsent(ctx context.Context, userID int64, appID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOAuthConsent", ctx, userID, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOAuthConsent indicates an expected call of DeleteOAuthConsent.
+func (mr *MockOAuthStorageMockRecorder) DeleteOAuthConsent(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOAuthConsent", reflect.TypeOf((*MockOAuthStorage)(nil).DeleteOAuthConsent), ctx, userID, appID)
+}
+
+// OAuthConsent mocks base method.
+func (m *MockOAuthStorage) OAuthConsent(ctx context.Context, userID int64, appID int) (models.OAuthConsent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OAuthConsent", ctx, userID, appID)
+	ret0, _ := ret[0].(models.OAuthConsent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OAuthConsent indicates an expected call of OAuthConsent.
+func (mr *MockOAuthStorageMockRecorder) OAuthConsent(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OAuthConsent", reflect.TypeOf((*MockOAuthStorage)(nil).OAuthConsent), ctx, userID, appID)
+}
+
+// OAuthConsents mocks base method.
+func (m *MockOAuthStorage) OAuthConsents(ctx context.Context, userID int64) ([]models.OAuthConsent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OAuthConsents", ctx, userID)
+	ret0, _ := ret[0].([]models.OAuthConsent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OAuthConsents indicates an expected call of OAuthConsents.
+func (mr *MockOAuthStorageMockRecorder) OAuthConsents(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OAuthConsents", reflect.TypeOf((*MockOAuthStorage)(nil).OAuthConsents), ctx, userID)
+}
+
+// SaveAuthorizationCode mocks base method.
+func (m *MockOAuthStorage) SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAuthorizationCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAuthorizationCode indicates an expected call of SaveAuthorizationCode.
+func (mr *MockOAuthStorageMockRecorder) SaveAuthorizationCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAuthorizationCode", reflect.TypeOf((*MockOAuthStorage)(nil).SaveAuthorizationCode), ctx, code)
+}
+
+// SaveOAuthConsent mocks base method.
+func (m *MockOAuthStorage) SaveOAuthConsent(ctx context.Context, userID int64, appID int, scope []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOAuthConsent", ctx, userID, appID, scope)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveOAuthConsent indicates an expected call of SaveOAuthConsent.
+func (mr *MockOAuthStorageMockRecorder) SaveOAuthConsent(ctx, userID, appID, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOAuthConsent", reflect.TypeOf((*MockOAuthStorage)(nil).SaveOAuthConsent), ctx, userID, appID, scope)
+}
+
+// MockFederationStorage is a mock of FederationStorage interface.
+type MockFederationStorage struct {
 	ctrl     *gomock.Controller
-	recorder *MockAppProviderMockRecorder
+	recorder *MockFederationStorageMockRecorder
 }
 
-// MockAppProviderMockRecorder is the mock recorder for MockAppProvider.
-type MockAppProviderMockRecorder struct {
-	mock *MockAppProvider
+// MockFederationStorageMockRecorder is the mock recorder for MockFederationStorage.
+type MockFederationStorageMockRecorder struct {
+	mock *MockFederationStorage
 }
 
-// NewMockAppProvider creates a new mock instance.
-func NewMockAppProvider(ctrl *gomock.Controller) *MockAppProvider {
-	mock := &MockAppProvider{ctrl: ctrl}
-	mock.recorder = &MockAppProviderMockRecorder{mock}
+// NewMockFederationStorage creates a new mock instance.
+func NewMockFederationStorage(ctrl *gomock.Controller) *MockFederationStorage {
+	mock := &MockFederationStorage{ctrl: ctrl}
+	mock.recorder = &MockFederationStorageMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockAppProvider) EXPECT() *MockAppProviderMockRecorder {
+func (m *MockFederationStorage) EXPECT() *MockFederationStorageMockRecorder {
 	return m.recorder
 }
 
-// App mocks base method.
-func (m *MockAppProvider) App(ctx context.Context, appID int) (models.App, error) {
+// ConsumeFederationState mocks base method.
+func (m *MockFederationStorage) ConsumeFederationState(ctx context.Context, stateHash []byte, provider string, now time.Time) (models.FederationState, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "App", ctx, appID)
-	ret0, _ := ret[0].(models.App)
+	ret := m.ctrl.Call(m, "ConsumeFederationState", ctx, stateHash, provider, now)
+	ret0, _ := ret[0].(models.FederationState)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// App indicates an expected call of App.
-func (mr *MockAppProviderMockRecorder) App(ctx, appID interface{}) *gomock.Call {
+// ConsumeFederationState indicates an expected call of ConsumeFederationState.
+func (mr *MockFederationStorageMockRecorder) ConsumeFederationState(ctx, stateHash, provider, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "App", reflect.TypeOf((*MockAppProvider)(nil).App), ctx, appID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeFederationState", reflect.TypeOf((*MockFederationStorage)(nil).ConsumeFederationState), ctx, stateHash, provider, now)
+}
+
+// SaveFederatedUser mocks base method.
+func (m *MockFederationStorage) SaveFederatedUser(ctx context.Context, email string, passHash []byte, emailVerified bool, identity models.UserIdentity) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFederatedUser", ctx, email, passHash, emailVerified, identity)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveFederatedUser indicates an expected call of SaveFederatedUser.
+func (mr *MockFederationStorageMockRecorder) SaveFederatedUser(ctx, email, passHash, emailVerified, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFederatedUser", reflect.TypeOf((*MockFederationStorage)(nil).SaveFederatedUser), ctx, email, passHash, emailVerified, identity)
+}
+
+// SaveFederationState mocks base method.
+func (m *MockFederationStorage) SaveFederationState(ctx context.Context, state models.FederationState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFederationState", ctx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFederationState indicates an expected call of SaveFederationState.
+func (mr *MockFederationStorageMockRecorder) SaveFederationState(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFederationState", reflect.TypeOf((*MockFederationStorage)(nil).SaveFederationState), ctx, state)
+}
+
+// SaveUserIdentity mocks base method.
+func (m *MockFederationStorage) SaveUserIdentity(ctx context.Context, identity models.UserIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUserIdentity", ctx, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUserIdentity indicates an expected call of SaveUserIdentity.
+func (mr *MockFederationStorageMockRecorder) SaveUserIdentity(ctx, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUserIdentity", reflect.TypeOf((*MockFederationStorage)(nil).SaveUserIdentity), ctx, identity)
+}
+
+// UserIdentity mocks base method.
+func (m *MockFederationStorage) UserIdentity(ctx context.Context, provider, subject string) (models.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserIdentity", ctx, provider, subject)
+	ret0, _ := ret[0].(models.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserIdentity indicates an expected call of UserIdentity.
+func (mr *MockFederationStorageMockRecorder) UserIdentity(ctx, provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserIdentity", reflect.TypeOf((*MockFederationStorage)(nil).UserIdentity), ctx, provider, subject)
+}
+
+// MockIdentityProvider is a mock of IdentityProvider interface.
+type MockIdentityProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityProviderMockRecorder
+}
+
+// MockIdentityProviderMockRecorder is the mock recorder for MockIdentityProvider.
+type MockIdentityProviderMockRecorder struct {
+	mock *MockIdentityProvider
+}
+
+// NewMockIdentityProvider creates a new mock instance.
+func NewMockIdentityProvider(ctrl *gomock.Controller) *MockIdentityProvider {
+	mock := &MockIdentityProvider{ctrl: ctrl}
+	mock.recorder = &MockIdentityProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityProvider) EXPECT() *MockIdentityProviderMockRecorder {
+	return m.recorder
+}
+
+// AuthCodeURL mocks base method.
+func (m *MockIdentityProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthCodeURL", ctx, state, nonce, codeChallenge)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthCodeURL indicates an expected call of AuthCodeURL.
+func (mr *MockIdentityProviderMockRecorder) AuthCodeURL(ctx, state, nonce, codeChallenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthCodeURL", reflect.TypeOf((*MockIdentityProvider)(nil).AuthCodeURL), ctx, state, nonce, codeChallenge)
+}
+
+// Exchange mocks base method.
+func (m *MockIdentityProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (models.ExternalIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", ctx, code, codeVerifier, nonce)
+	ret0, _ := ret[0].(models.ExternalIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockIdentityProviderMockRecorder) Exchange(ctx, code, codeVerifier, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockIdentityProvider)(nil).Exchange), ctx, code, codeVerifier, nonce)
+}
+
+// MockSecurityEventStorage is a mock of SecurityEventStorage interface.
+type MockSecurityEventStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecurityEventStorageMockRecorder
+}
+
+// MockSecurityEventStorageMockRecorder is the mock recorder for MockSecurityEventStorage.
+type MockSecurityEventStorageMockRecorder struct {
+	mock *MockSecurityEventStorage
+}
+
+// NewMockSecurityEventStorage creates a new mock instance.
+func NewMockSecurityEventStorage(ctrl *gomock.Controller) *MockSecurityEventStorage {
+	mock := &MockSecurityEventStorage{ctrl: ctrl}
+	mock.recorder = &MockSecurityEventStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecurityEventStorage) EXPECT() *MockSecurityEventStorageMockRecorder {
+	return m.recorder
+}
+
+// DeleteSecurityEventsBefore mocks base method.
+func (m *MockSecurityEventStorage) DeleteSecurityEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecurityEventsBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSecurityEventsBefore indicates an expected call of DeleteSecurityEventsBefore.
+func (mr *MockSecurityEventStorageMockRecorder) DeleteSecurityEventsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecurityEventsBefore", reflect.TypeOf((*MockSecurityEventStorage)(nil).DeleteSecurityEventsBefore), ctx, before)
+}
+
+// SaveSecurityEvent mocks base method.
+func (m *MockSecurityEventStorage) SaveSecurityEvent(ctx context.Context, event models.SecurityEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSecurityEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSecurityEvent indicates an expected call of SaveSecurityEvent.
+func (mr *MockSecurityEventStorageMockRecorder) SaveSecurityEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSecurityEvent", reflect.TypeOf((*MockSecurityEventStorage)(nil).SaveSecurityEvent), ctx, event)
+}
+
+// SecurityEvents mocks base method.
+func (m *MockSecurityEventStorage) SecurityEvents(ctx context.Context, filter models.SecurityEventFilter) ([]models.SecurityEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SecurityEvents", ctx, filter)
+	ret0, _ := ret[0].([]models.SecurityEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SecurityEvents indicates an expected call of SecurityEvents.
+func (mr *MockSecurityEventStorageMockRecorder) SecurityEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecurityEvents", reflect.TypeOf((*MockSecurityEventStorage)(nil).SecurityEvents), ctx, filter)
+}
+
+// MockBanStorage is a mock of BanStorage interface.
+type MockBanStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockBanStorageMockRecorder
+}
+
+// MockBanStorageMockRecorder is the mock recorder for MockBanStorage.
+type MockBanStorageMockRecorder struct {
+	mock *MockBanStorage
+}
+
+// NewMockBanStorage creates a new mock instance.
+func NewMockBanStorage(ctrl *gomock.Controller) *MockBanStorage {
+	mock := &MockBanStorage{ctrl: ctrl}
+	mock.recorder = &MockBanStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBanStorage) EXPECT() *MockBanStorageMockRecorder {
+	return m.recorder
+}
+
+// ActiveBan mocks base method.
+func (m *MockBanStorage) ActiveBan(ctx context.Context, userID int64, now time.Time) (models.Ban, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActiveBan", ctx, userID, now)
+	ret0, _ := ret[0].(models.Ban)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActiveBan indicates an expected call of ActiveBan.
+func (mr *MockBanStorageMockRecorder) ActiveBan(ctx, userID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActiveBan", reflect.TypeOf((*MockBanStorage)(nil).ActiveBan), ctx, userID, now)
+}
+
+// ActiveBans mocks base method.
+func (m *MockBanStorage) ActiveBans(ctx context.Context, now time.Time) ([]models.Ban, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActiveBans", ctx, now)
+	ret0, _ := ret[0].([]models.Ban)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActiveBans indicates an expected call of ActiveBans.
+func (mr *MockBanStorageMockRecorder) ActiveBans(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActiveBans", reflect.TypeOf((*MockBanStorage)(nil).ActiveBans), ctx, now)
+}
+
+// DeleteBan mocks base method.
+func (m *MockBanStorage) DeleteBan(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBan", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBan indicates an expected call of DeleteBan.
+func (mr *MockBanStorageMockRecorder) DeleteBan(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBan", reflect.TypeOf((*MockBanStorage)(nil).DeleteBan), ctx, userID)
+}
+
+// SaveBan mocks base method.
+func (m *MockBanStorage) SaveBan(ctx context.Context, ban models.Ban) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBan", ctx, ban)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBan indicates an expected call of SaveBan.
+func (mr *MockBanStorageMockRecorder) SaveBan(ctx, ban interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBan", reflect.TypeOf((*MockBanStorage)(nil).SaveBan), ctx, ban)
+}
+
+// MockUserSaver is a mock of UserSaver interface.
+type MockUserSaver struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserSaverMockRecorder
+}
+
+// MockUserSaverMockRecorder is the mock recorder for MockUserSaver.
+type MockUserSaverMockRecorder struct {
+	mock *MockUserSaver
+}
+
+// NewMockUserSaver creates a new mock instance.
+func NewMockUserSaver(ctrl *gomock.Controller) *MockUserSaver {
+	mock := &MockUserSaver{ctrl: ctrl}
+	mock.recorder = &MockUserSaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserSaver) EXPECT() *MockUserSaverMockRecorder {
+	return m.recorder
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserSaver) DeleteUser(ctx context.Context, userID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserSaverMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserSaver)(nil).DeleteUser), ctx, userID)
+}
+
+// SaveUser mocks base method.
+func (m *MockUserSaver) SaveUser(ctx context.Context, email string, passHash []byte) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUser", ctx, email, passHash)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveUser indicates an expected call of SaveUser.
+func (mr *MockUserSaverMockRecorder) SaveUser(ctx, email, passHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockUserSaver)(nil).SaveUser), ctx, email, passHash)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserSaver) UpdatePassword(ctx context.Context, userID int64, passHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, passHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserSaverMockRecorder) UpdatePassword(ctx, userID, passHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserSaver)(nil).UpdatePassword), ctx, userID, passHash)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserSaver) UpdateProfile(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserSaverMockRecorder) UpdateProfile(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserSaver)(nil).UpdateProfile), ctx, user)
+}
+
+// MockUserProvider is a mock of UserProvider interface.
+type MockUserProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserProviderMockRecorder
+}
+
+// MockUserProviderMockRecorder is the mock recorder for MockUserProvider.
+type MockUserProviderMockRecorder struct {
+	mock *MockUserProvider
+}
+
+// NewMockUserProvider creates a new mock instance.
+func NewMockUserProvider(ctrl *gomock.Controller) *MockUserProvider {
+	mock := &MockUserProvider{ctrl: ctrl}
+	mock.recorder = &MockUserProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserProvider) EXPECT() *MockUserProviderMockRecorder {
+	return m.recorder
+}
+
+// AccountErasures mocks base method.
+func (m *MockUserProvider) AccountErasures(ctx context.Context, afterID int64, limit int) ([]models.AccountErasure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountErasures", ctx, afterID, limit)
+	ret0, _ := ret[0].([]models.AccountErasure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountErasures indicates an expected call of AccountErasures.
+func (mr *MockUserProviderMockRecorder) AccountErasures(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountErasures", reflect.TypeOf((*MockUserProvider)(nil).AccountErasures), ctx, afterID, limit)
+}
+
+// AccountErasuresSince mocks base method.
+func (m *MockUserProvider) AccountErasuresSince(ctx context.Context, since time.Time) ([]models.AccountErasure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountErasuresSince", ctx, since)
+	ret0, _ := ret[0].([]models.AccountErasure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountErasuresSince indicates an expected call of AccountErasuresSince.
+func (mr *MockUserProviderMockRecorder) AccountErasuresSince(ctx, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountErasuresSince", reflect.TypeOf((*MockUserProvider)(nil).AccountErasuresSince), ctx, since)
+}
+
+// IsAdmin mocks base method.
+func (m *MockUserProvider) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAdmin", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAdmin indicates an expected call of IsAdmin.
+func (mr *MockUserProviderMockRecorder) IsAdmin(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockUserProvider)(nil).IsAdmin), ctx, userID)
+}
+
+// User mocks base method.
+func (m *MockUserProvider) User(ctx context.Context, email string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "User", ctx, email)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// User indicates an expected call of User.
+func (mr *MockUserProviderMockRecorder) User(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "User", reflect.TypeOf((*MockUserProvider)(nil).User), ctx, email)
+}
+
+// UserByID mocks base method.
+func (m *MockUserProvider) UserByID(ctx context.Context, userID int64) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserByID", ctx, userID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserByID indicates an expected call of UserByID.
+func (mr *MockUserProviderMockRecorder) UserByID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByID", reflect.TypeOf((*MockUserProvider)(nil).UserByID), ctx, userID)
+}
+
+// MockAppProvider is a mock of AppProvider interface.
+type MockAppProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppProviderMockRecorder
+}
+
+// MockAppProviderMockRecorder is the mock recorder for MockAppProvider.
+type MockAppProviderMockRecorder struct {
+	mock *MockAppProvider
+}
+
+// NewMockAppProvider creates a new mock instance.
+func NewMockAppProvider(ctrl *gomock.Controller) *MockAppProvider {
+	mock := &MockAppProvider{ctrl: ctrl}
+	mock.recorder = &MockAppProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAppProvider) EXPECT() *MockAppProviderMockRecorder {
+	return m.recorder
+}
+
+// App mocks base method.
+func (m *MockAppProvider) App(ctx context.Context, appID int) (models.App, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "App", ctx, appID)
+	ret0, _ := ret[0].(models.App)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// App indicates an expected call of App.
+func (mr *MockAppProviderMockRecorder) App(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "App", reflect.TypeOf((*MockAppProvider)(nil).App), ctx, appID)
+}
+
+// AppAllowsOrigin mocks base method.
+func (m *MockAppProvider) AppAllowsOrigin(ctx context.Context, origin string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppAllowsOrigin", ctx, origin)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppAllowsOrigin indicates an expected call of AppAllowsOrigin.
+func (mr *MockAppProviderMockRecorder) AppAllowsOrigin(ctx, origin interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppAllowsOrigin", reflect.TypeOf((*MockAppProvider)(nil).AppAllowsOrigin), ctx, origin)
+}
+
+// Apps mocks base method.
+func (m *MockAppProvider) Apps(ctx context.Context) ([]models.App, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Apps", ctx)
+	ret0, _ := ret[0].([]models.App)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Apps indicates an expected call of Apps.
+func (mr *MockAppProviderMockRecorder) Apps(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apps", reflect.TypeOf((*MockAppProvider)(nil).Apps), ctx)
+}
+
+// MockAppSaver is a mock of AppSaver interface.
+type MockAppSaver struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppSaverMockRecorder
+}
+
+// MockAppSaverMockRecorder is the mock recorder for MockAppSaver.
+type MockAppSaverMockRecorder struct {
+	mock *MockAppSaver
+}
+
+// NewMockAppSaver creates a new mock instance.
+func NewMockAppSaver(ctrl *gomock.Controller) *MockAppSaver {
+	mock := &MockAppSaver{ctrl: ctrl}
+	mock.recorder = &MockAppSaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAppSaver) EXPECT() *MockAppSaverMockRecorder {
+	return m.recorder
+}
+
+// DisableApp mocks base method.
+func (m *MockAppSaver) DisableApp(ctx context.Context, appID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableApp", ctx, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableApp indicates an expected call of DisableApp.
+func (mr *MockAppSaverMockRecorder) DisableApp(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableApp", reflect.TypeOf((*MockAppSaver)(nil).DisableApp), ctx, appID)
+}
+
+// SaveApp mocks base method.
+func (m *MockAppSaver) SaveApp(ctx context.Context, app models.App) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveApp", ctx, app)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveApp indicates an expected call of SaveApp.
+func (mr *MockAppSaverMockRecorder) SaveApp(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveApp", reflect.TypeOf((*MockAppSaver)(nil).SaveApp), ctx, app)
+}
+
+// UpdateApp mocks base method.
+func (m *MockAppSaver) UpdateApp(ctx context.Context, app models.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApp", ctx, app)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateApp indicates an expected call of UpdateApp.
+func (mr *MockAppSaverMockRecorder) UpdateApp(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApp", reflect.TypeOf((*MockAppSaver)(nil).UpdateApp), ctx, app)
+}
+
+// UpdateAppSecret mocks base method.
+func (m *MockAppSaver) UpdateAppSecret(ctx context.Context, appID int, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppSecret", ctx, appID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppSecret indicates an expected call of UpdateAppSecret.
+func (mr *MockAppSaverMockRecorder) UpdateAppSecret(ctx, appID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppSecret", reflect.TypeOf((*MockAppSaver)(nil).UpdateAppSecret), ctx, appID, secret)
+}
+
+// MockPasswordResetStorage is a mock of PasswordResetStorage interface.
+type MockPasswordResetStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetStorageMockRecorder
+}
+
+// MockPasswordResetStorageMockRecorder is the mock recorder for MockPasswordResetStorage.
+type MockPasswordResetStorageMockRecorder struct {
+	mock *MockPasswordResetStorage
+}
+
+// NewMockPasswordResetStorage creates a new mock instance.
+func NewMockPasswordResetStorage(ctrl *gomock.Controller) *MockPasswordResetStorage {
+	mock := &MockPasswordResetStorage{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetStorage) EXPECT() *MockPasswordResetStorageMockRecorder {
+	return m.recorder
+}
+
+// ConsumePasswordResetToken mocks base method.
+func (m *MockPasswordResetStorage) ConsumePasswordResetToken(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasswordResetToken", ctx, tokenHash, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasswordResetToken indicates an expected call of ConsumePasswordResetToken.
+func (mr *MockPasswordResetStorageMockRecorder) ConsumePasswordResetToken(ctx, tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasswordResetToken", reflect.TypeOf((*MockPasswordResetStorage)(nil).ConsumePasswordResetToken), ctx, tokenHash, now)
+}
+
+// PasswordResetTokenUser mocks base method.
+func (m *MockPasswordResetStorage) PasswordResetTokenUser(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PasswordResetTokenUser", ctx, tokenHash, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PasswordResetTokenUser indicates an expected call of PasswordResetTokenUser.
+func (mr *MockPasswordResetStorageMockRecorder) PasswordResetTokenUser(ctx, tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PasswordResetTokenUser", reflect.TypeOf((*MockPasswordResetStorage)(nil).PasswordResetTokenUser), ctx, tokenHash, now)
+}
+
+// SavePasswordResetToken mocks base method.
+func (m *MockPasswordResetStorage) SavePasswordResetToken(ctx context.Context, userID int64, tokenHash []byte, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePasswordResetToken", ctx, userID, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePasswordResetToken indicates an expected call of SavePasswordResetToken.
+func (mr *MockPasswordResetStorageMockRecorder) SavePasswordResetToken(ctx, userID, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePasswordResetToken", reflect.TypeOf((*MockPasswordResetStorage)(nil).SavePasswordResetToken), ctx, userID, tokenHash, expiresAt)
+}
+
+// MockEmailVerificationStorage is a mock of EmailVerificationStorage interface.
+type MockEmailVerificationStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerificationStorageMockRecorder
+}
+
+// MockEmailVerificationStorageMockRecorder is the mock recorder for MockEmailVerificationStorage.
+type MockEmailVerificationStorageMockRecorder struct {
+	mock *MockEmailVerificationStorage
+}
+
+// NewMockEmailVerificationStorage creates a new mock instance.
+func NewMockEmailVerificationStorage(ctrl *gomock.Controller) *MockEmailVerificationStorage {
+	mock := &MockEmailVerificationStorage{ctrl: ctrl}
+	mock.recorder = &MockEmailVerificationStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerificationStorage) EXPECT() *MockEmailVerificationStorageMockRecorder {
+	return m.recorder
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockEmailVerificationStorage) ConfirmEmailChange(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, tokenHash, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockEmailVerificationStorageMockRecorder) ConfirmEmailChange(ctx, tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockEmailVerificationStorage)(nil).ConfirmEmailChange), ctx, tokenHash, now)
+}
+
+// SaveEmailChangeToken mocks base method.
+func (m *MockEmailVerificationStorage) SaveEmailChangeToken(ctx context.Context, userID int64, newEmail string, tokenHash []byte, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEmailChangeToken", ctx, userID, newEmail, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEmailChangeToken indicates an expected call of SaveEmailChangeToken.
+func (mr *MockEmailVerificationStorageMockRecorder) SaveEmailChangeToken(ctx, userID, newEmail, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEmailChangeToken", reflect.TypeOf((*MockEmailVerificationStorage)(nil).SaveEmailChangeToken), ctx, userID, newEmail, tokenHash, expiresAt)
+}
+
+// SaveEmailVerificationToken mocks base method.
+func (m *MockEmailVerificationStorage) SaveEmailVerificationToken(ctx context.Context, userID int64, tokenHash []byte, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEmailVerificationToken", ctx, userID, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEmailVerificationToken indicates an expected call of SaveEmailVerificationToken.
+func (mr *MockEmailVerificationStorageMockRecorder) SaveEmailVerificationToken(ctx, userID, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEmailVerificationToken", reflect.TypeOf((*MockEmailVerificationStorage)(nil).SaveEmailVerificationToken), ctx, userID, tokenHash, expiresAt)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockEmailVerificationStorage) VerifyEmail(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, tokenHash, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockEmailVerificationStorageMockRecorder) VerifyEmail(ctx, tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockEmailVerificationStorage)(nil).VerifyEmail), ctx, tokenHash, now)
+}
+
+// MockMFAStorage is a mock of MFAStorage interface.
+type MockMFAStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAStorageMockRecorder
+}
+
+// MockMFAStorageMockRecorder is the mock recorder for MockMFAStorage.
+type MockMFAStorageMockRecorder struct {
+	mock *MockMFAStorage
+}
+
+// NewMockMFAStorage creates a new mock instance.
+func NewMockMFAStorage(ctrl *gomock.Controller) *MockMFAStorage {
+	mock := &MockMFAStorage{ctrl: ctrl}
+	mock.recorder = &MockMFAStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAStorage) EXPECT() *MockMFAStorageMockRecorder {
+	return m.recorder
+}
+
+// AttemptMFAChallenge mocks base method.
+func (m *MockMFAStorage) AttemptMFAChallenge(ctx context.Context, tokenHash []byte, now time.Time, maxAttempts int) (models.MFAChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttemptMFAChallenge", ctx, tokenHash, now, maxAttempts)
+	ret0, _ := ret[0].(models.MFAChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttemptMFAChallenge indicates an expected call of AttemptMFAChallenge.
+func (mr *MockMFAStorageMockRecorder) AttemptMFAChallenge(ctx, tokenHash, now, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttemptMFAChallenge", reflect.TypeOf((*MockMFAStorage)(nil).AttemptMFAChallenge), ctx, tokenHash, now, maxAttempts)
+}
+
+// CompleteMFAChallenge mocks base method.
+func (m *MockMFAStorage) CompleteMFAChallenge(ctx context.Context, tokenHash []byte, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteMFAChallenge", ctx, tokenHash, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteMFAChallenge indicates an expected call of CompleteMFAChallenge.
+func (mr *MockMFAStorageMockRecorder) CompleteMFAChallenge(ctx, tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMFAChallenge", reflect.TypeOf((*MockMFAStorage)(nil).CompleteMFAChallenge), ctx, tokenHash, now)
+}
+
+// ConsumeRecoveryCode mocks base method.
+func (m *MockMFAStorage) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash []byte, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRecoveryCode", ctx, userID, codeHash, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeRecoveryCode indicates an expected call of ConsumeRecoveryCode.
+func (mr *MockMFAStorageMockRecorder) ConsumeRecoveryCode(ctx, userID, codeHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRecoveryCode", reflect.TypeOf((*MockMFAStorage)(nil).ConsumeRecoveryCode), ctx, userID, codeHash, now)
+}
+
+// DeleteTOTP mocks base method.
+func (m *MockMFAStorage) DeleteTOTP(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTP", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTOTP indicates an expected call of DeleteTOTP.
+func (mr *MockMFAStorageMockRecorder) DeleteTOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTP", reflect.TypeOf((*MockMFAStorage)(nil).DeleteTOTP), ctx, userID)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockMFAStorage) EnableTOTP(ctx context.Context, userID, step int64, recoveryCodeHashes [][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, userID, step, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockMFAStorageMockRecorder) EnableTOTP(ctx, userID, step, recoveryCodeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockMFAStorage)(nil).EnableTOTP), ctx, userID, step, recoveryCodeHashes)
+}
+
+// SaveMFAChallenge mocks base method.
+func (m *MockMFAStorage) SaveMFAChallenge(ctx context.Context, userID int64, appID int, tokenHash []byte, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMFAChallenge", ctx, userID, appID, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMFAChallenge indicates an expected call of SaveMFAChallenge.
+func (mr *MockMFAStorageMockRecorder) SaveMFAChallenge(ctx, userID, appID, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMFAChallenge", reflect.TypeOf((*MockMFAStorage)(nil).SaveMFAChallenge), ctx, userID, appID, tokenHash, expiresAt)
+}
+
+// SaveTOTPSecret mocks base method.
+func (m *MockMFAStorage) SaveTOTPSecret(ctx context.Context, userID int64, secret []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTOTPSecret", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTOTPSecret indicates an expected call of SaveTOTPSecret.
+func (mr *MockMFAStorageMockRecorder) SaveTOTPSecret(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTPSecret", reflect.TypeOf((*MockMFAStorage)(nil).SaveTOTPSecret), ctx, userID, secret)
+}
+
+// TOTP mocks base method.
+func (m *MockMFAStorage) TOTP(ctx context.Context, userID int64) (models.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TOTP", ctx, userID)
+	ret0, _ := ret[0].(models.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TOTP indicates an expected call of TOTP.
+func (mr *MockMFAStorageMockRecorder) TOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TOTP", reflect.TypeOf((*MockMFAStorage)(nil).TOTP), ctx, userID)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockMFAStorage) UseTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockMFAStorageMockRecorder) UseTOTPStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockMFAStorage)(nil).UseTOTPStep), ctx, userID, step)
+}
+
+// MockSecretCipher is a mock of SecretCipher interface.
+type MockSecretCipher struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecretCipherMockRecorder
+}
+
+// MockSecretCipherMockRecorder is the mock recorder for MockSecretCipher.
+type MockSecretCipherMockRecorder struct {
+	mock *MockSecretCipher
+}
+
+// NewMockSecretCipher creates a new mock instance.
+func NewMockSecretCipher(ctrl *gomock.Controller) *MockSecretCipher {
+	mock := &MockSecretCipher{ctrl: ctrl}
+	mock.recorder = &MockSecretCipherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecretCipher) EXPECT() *MockSecretCipherMockRecorder {
+	return m.recorder
+}
+
+// Open mocks base method.
+func (m *MockSecretCipher) Open(ciphertext []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ciphertext)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockSecretCipherMockRecorder) Open(ciphertext interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockSecretCipher)(nil).Open), ciphertext)
+}
+
+// Seal mocks base method.
+func (m *MockSecretCipher) Seal(plaintext []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Seal", plaintext)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Seal indicates an expected call of Seal.
+func (mr *MockSecretCipherMockRecorder) Seal(plaintext interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seal", reflect.TypeOf((*MockSecretCipher)(nil).Seal), plaintext)
+}
+
+// MockKeyProvider is a mock of KeyProvider interface.
+type MockKeyProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyProviderMockRecorder
+}
+
+// MockKeyProviderMockRecorder is the mock recorder for MockKeyProvider.
+type MockKeyProviderMockRecorder struct {
+	mock *MockKeyProvider
+}
+
+// NewMockKeyProvider creates a new mock instance.
+func NewMockKeyProvider(ctrl *gomock.Controller) *MockKeyProvider {
+	mock := &MockKeyProvider{ctrl: ctrl}
+	mock.recorder = &MockKeyProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyProvider) EXPECT() *MockKeyProviderMockRecorder {
+	return m.recorder
+}
+
+// AcceptsHMAC mocks base method.
+func (m *MockKeyProvider) AcceptsHMAC(ctx context.Context, appID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptsHMAC", ctx, appID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptsHMAC indicates an expected call of AcceptsHMAC.
+func (mr *MockKeyProviderMockRecorder) AcceptsHMAC(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptsHMAC", reflect.TypeOf((*MockKeyProvider)(nil).AcceptsHMAC), ctx, appID)
+}
+
+// PublicKeys mocks base method.
+func (m *MockKeyProvider) PublicKeys(ctx context.Context) ([]jwt.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublicKeys", ctx)
+	ret0, _ := ret[0].([]jwt.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublicKeys indicates an expected call of PublicKeys.
+func (mr *MockKeyProviderMockRecorder) PublicKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublicKeys", reflect.TypeOf((*MockKeyProvider)(nil).PublicKeys), ctx)
+}
+
+// Rotate mocks base method.
+func (m *MockKeyProvider) Rotate(ctx context.Context, appID int, algorithm string) (jwt.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", ctx, appID, algorithm)
+	ret0, _ := ret[0].(jwt.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockKeyProviderMockRecorder) Rotate(ctx, appID, algorithm interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockKeyProvider)(nil).Rotate), ctx, appID, algorithm)
+}
+
+// SigningKey mocks base method.
+func (m *MockKeyProvider) SigningKey(ctx context.Context, appID int) (*jwt.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SigningKey", ctx, appID)
+	ret0, _ := ret[0].(*jwt.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SigningKey indicates an expected call of SigningKey.
+func (mr *MockKeyProviderMockRecorder) SigningKey(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SigningKey", reflect.TypeOf((*MockKeyProvider)(nil).SigningKey), ctx, appID)
+}
+
+// VerificationKey mocks base method.
+func (m *MockKeyProvider) VerificationKey(ctx context.Context, appID int, kid string) (jwt.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerificationKey", ctx, appID, kid)
+	ret0, _ := ret[0].(jwt.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerificationKey indicates an expected call of VerificationKey.
+func (mr *MockKeyProviderMockRecorder) VerificationKey(ctx, appID, kid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerificationKey", reflect.TypeOf((*MockKeyProvider)(nil).VerificationKey), ctx, appID, kid)
+}
+
+// MockPasswordHasher is a mock of PasswordHasher interface.
+type MockPasswordHasher struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordHasherMockRecorder
+}
+
+// MockPasswordHasherMockRecorder is the mock recorder for MockPasswordHasher.
+type MockPasswordHasherMockRecorder struct {
+	mock *MockPasswordHasher
+}
+
+// NewMockPasswordHasher creates a new mock instance.
+func NewMockPasswordHasher(ctrl *gomock.Controller) *MockPasswordHasher {
+	mock := &MockPasswordHasher{ctrl: ctrl}
+	mock.recorder = &MockPasswordHasherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordHasher) EXPECT() *MockPasswordHasherMockRecorder {
+	return m.recorder
+}
+
+// Hash mocks base method.
+func (m *MockPasswordHasher) Hash(password string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hash", password)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hash indicates an expected call of Hash.
+func (mr *MockPasswordHasherMockRecorder) Hash(password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockPasswordHasher)(nil).Hash), password)
+}
+
+// Verify mocks base method.
+func (m *MockPasswordHasher) Verify(hash []byte, password string) (bool, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", hash, password)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockPasswordHasherMockRecorder) Verify(hash, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockPasswordHasher)(nil).Verify), hash, password)
+}
+
+// MockPasswordPolicy is a mock of PasswordPolicy interface.
+type MockPasswordPolicy struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordPolicyMockRecorder
+}
+
+// MockPasswordPolicyMockRecorder is the mock recorder for MockPasswordPolicy.
+type MockPasswordPolicyMockRecorder struct {
+	mock *MockPasswordPolicy
+}
+
+// NewMockPasswordPolicy creates a new mock instance.
+func NewMockPasswordPolicy(ctrl *gomock.Controller) *MockPasswordPolicy {
+	mock := &MockPasswordPolicy{ctrl: ctrl}
+	mock.recorder = &MockPasswordPolicyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordPolicy) EXPECT() *MockPasswordPolicyMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockPasswordPolicy) Check(password, email string) []passpolicy.Violation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", password, email)
+	ret0, _ := ret[0].([]passpolicy.Violation)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockPasswordPolicyMockRecorder) Check(password, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockPasswordPolicy)(nil).Check), password, email)
+}
+
+// MockMailer is a mock of Mailer interface.
+type MockMailer struct {
+	ctrl     *gomock.Controller
+	recorder *MockMailerMockRecorder
+}
+
+// MockMailerMockRecorder is the mock recorder for MockMailer.
+type MockMailerMockRecorder struct {
+	mock *MockMailer
+}
+
+// NewMockMailer creates a new mock instance.
+func NewMockMailer(ctrl *gomock.Controller) *MockMailer {
+	mock := &MockMailer{ctrl: ctrl}
+	mock.recorder = &MockMailerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMailer) EXPECT() *MockMailerMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, to, subject, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockMailerMockRecorder) Send(ctx, to, subject, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockMailer)(nil).Send), ctx, to, subject, body)
 }
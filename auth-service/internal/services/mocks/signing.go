@@ -0,0 +1,164 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/signing/signing.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/14kear/forum-project/auth-service/internal/domain/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockKeyStorage is a mock of KeyStorage interface.
+type MockKeyStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyStorageMockRecorder
+}
+
+// MockKeyStorageMockRecorder is the mock recorder for MockKeyStorage.
+type MockKeyStorageMockRecorder struct {
+	mock *MockKeyStorage
+}
+
+// NewMockKeyStorage creates a new mock instance.
+func NewMockKeyStorage(ctrl *gomock.Controller) *MockKeyStorage {
+	mock := &MockKeyStorage{ctrl: ctrl}
+	mock.recorder = &MockKeyStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyStorage) EXPECT() *MockKeyStorageMockRecorder {
+	return m.recorder
+}
+
+// ActiveSigningKey mocks base method.
+func (m *MockKeyStorage) ActiveSigningKey(ctx context.Context, appID int) (models.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActiveSigningKey", ctx, appID)
+	ret0, _ := ret[0].(models.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActiveSigningKey indicates an expected call of ActiveSigningKey.
+func (mr *MockKeyStorageMockRecorder) ActiveSigningKey(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActiveSigningKey", reflect.TypeOf((*MockKeyStorage)(nil).ActiveSigningKey), ctx, appID)
+}
+
+// PublishedSigningKeys mocks base method.
+func (m *MockKeyStorage) PublishedSigningKeys(ctx context.Context, retireBefore time.Time) ([]models.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishedSigningKeys", ctx, retireBefore)
+	ret0, _ := ret[0].([]models.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishedSigningKeys indicates an expected call of PublishedSigningKeys.
+func (mr *MockKeyStorageMockRecorder) PublishedSigningKeys(ctx, retireBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishedSigningKeys", reflect.TypeOf((*MockKeyStorage)(nil).PublishedSigningKeys), ctx, retireBefore)
+}
+
+// RotateSigningKey mocks base method.
+func (m *MockKeyStorage) RotateSigningKey(ctx context.Context, key models.SigningKey, retireBefore time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSigningKey", ctx, key, retireBefore)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSigningKey indicates an expected call of RotateSigningKey.
+func (mr *MockKeyStorageMockRecorder) RotateSigningKey(ctx, key, retireBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningKey", reflect.TypeOf((*MockKeyStorage)(nil).RotateSigningKey), ctx, key, retireBefore)
+}
+
+// SaveSigningKey mocks base method.
+func (m *MockKeyStorage) SaveSigningKey(ctx context.Context, key models.SigningKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSigningKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSigningKey indicates an expected call of SaveSigningKey.
+func (mr *MockKeyStorageMockRecorder) SaveSigningKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSigningKey", reflect.TypeOf((*MockKeyStorage)(nil).SaveSigningKey), ctx, key)
+}
+
+// SigningKey mocks base method.
+func (m *MockKeyStorage) SigningKey(ctx context.Context, kid string) (models.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SigningKey", ctx, kid)
+	ret0, _ := ret[0].(models.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SigningKey indicates an expected call of SigningKey.
+func (mr *MockKeyStorageMockRecorder) SigningKey(ctx, kid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SigningKey", reflect.TypeOf((*MockKeyStorage)(nil).SigningKey), ctx, kid)
+}
+
+// MockCipher is a mock of Cipher interface.
+type MockCipher struct {
+	ctrl     *gomock.Controller
+	recorder *MockCipherMockRecorder
+}
+
+// MockCipherMockRecorder is the mock recorder for MockCipher.
+type MockCipherMockRecorder struct {
+	mock *MockCipher
+}
+
+// NewMockCipher creates a new mock instance.
+func NewMockCipher(ctrl *gomock.Controller) *MockCipher {
+	mock := &MockCipher{ctrl: ctrl}
+	mock.recorder = &MockCipherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCipher) EXPECT() *MockCipherMockRecorder {
+	return m.recorder
+}
+
+// Open mocks base method.
+func (m *MockCipher) Open(ciphertext []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ciphertext)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockCipherMockRecorder) Open(ciphertext interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockCipher)(nil).Open), ciphertext)
+}
+
+// Seal mocks base method.
+func (m *MockCipher) Seal(plaintext []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Seal", plaintext)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Seal indicates an expected call of Seal.
+func (mr *MockCipherMockRecorder) Seal(plaintext interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seal", reflect.TypeOf((*MockCipher)(nil).Seal), plaintext)
+}
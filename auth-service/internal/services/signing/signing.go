@@ -0,0 +1,321 @@
+package signing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/14kear/sso-prettyslog/slogpretty/errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Keys хранит ключи подписи приложений в базе и ротирует их. Расшифрованные ключи
+// кэшируются на CacheTTL, поэтому ротация на другом экземпляре сервиса вступает в силу
+// здесь с задержкой не больше CacheTTL.
+type Keys struct {
+	log     *slog.Logger
+	storage KeyStorage
+	cipher  Cipher
+	cfg     Config
+
+	mu     sync.Mutex
+	active map[int]cachedKey
+	byKID  map[string]cachedKey
+	now    func() time.Time
+}
+
+// Config — параметры ключей подписи
+type Config struct {
+	// Algorithm — алгоритм новых ключей: RS256 или EdDSA. HS256 означает, что ключи
+	// не создаются автоматически и приложения без ключа подписывают токены своим секретом.
+	Algorithm string
+	// RetireAfter — сколько уходящий ключ принимается после ротации. Должен быть не меньше
+	// времени жизни refresh token, иначе ротация разлогинит пользователей.
+	RetireAfter time.Duration
+	// CacheTTL — сколько расшифрованный ключ живёт в памяти
+	CacheTTL time.Duration
+	// LegacyHMACUntil — до какого момента при асимметричном Algorithm ещё принимаются токены
+	// без kid, подписанные секретом приложения (выпущенные до перехода на ключи)
+	LegacyHMACUntil time.Time
+}
+
+// KeyStorage хранит зашифрованные ключи подписи
+type KeyStorage interface {
+	ActiveSigningKey(ctx context.Context, appID int) (models.SigningKey, error)
+	SigningKey(ctx context.Context, kid string) (models.SigningKey, error)
+	// PublishedSigningKeys возвращает активные ключи и уходящие, ставшие уходящими не раньше retireBefore
+	PublishedSigningKeys(ctx context.Context, retireBefore time.Time) ([]models.SigningKey, error)
+	SaveSigningKey(ctx context.Context, key models.SigningKey) error
+	RotateSigningKey(ctx context.Context, key models.SigningKey, retireBefore time.Time) (retired []string, err error)
+}
+
+// Cipher шифрует закрытые ключи перед записью в хранилище
+type Cipher interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(ciphertext []byte) ([]byte, error)
+}
+
+type cachedKey struct {
+	key        jwt.SigningKey
+	appID      int
+	state      string
+	retiringAt time.Time
+	loadedAt   time.Time
+}
+
+var ErrUnsupportedAlgorithm = errors.New("algorithm must be RS256 or EdDSA")
+
+func New(log *slog.Logger, storage KeyStorage, cipher Cipher, cfg Config) *Keys {
+	return &Keys{
+		log:     log,
+		storage: storage,
+		cipher:  cipher,
+		cfg:     cfg,
+		active:  map[int]cachedKey{},
+		byKID:   map[string]cachedKey{},
+		now:     time.Now,
+	}
+}
+
+// SigningKey возвращает активный ключ приложения. Если ключа ещё нет, он создаётся;
+// при алгоритме HS256 вместо этого возвращается nil и токены подписываются секретом приложения.
+func (k *Keys) SigningKey(ctx context.Context, appID int) (*jwt.SigningKey, error) {
+	const op = "signing.SigningKey"
+
+	if cached, ok := k.cachedActive(appID); ok {
+		return &cached.key, nil
+	}
+
+	stored, err := k.storage.ActiveSigningKey(ctx, appID)
+	if errors.Is(err, storage.ErrSigningKeyNotFound) {
+		if k.cfg.Algorithm == jwt.AlgHS256 {
+			return nil, nil
+		}
+		stored, err = k.createFirstKey(ctx, appID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cached, err := k.remember(stored)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &cached.key, nil
+}
+
+// AcceptsHMAC сообщает, принимаются ли токены приложения без kid, подписанные его секретом.
+// Секрет знает и само приложение, поэтому такие токены принимаются, только пока у приложения
+// нет ключа подписи, и только при алгоритме HS256 или до LegacyHMACUntil.
+func (k *Keys) AcceptsHMAC(ctx context.Context, appID int) (bool, error) {
+	const op = "signing.AcceptsHMAC"
+
+	if k.cfg.Algorithm != jwt.AlgHS256 && !k.now().Before(k.cfg.LegacyHMACUntil) {
+		return false, nil
+	}
+
+	if _, ok := k.cachedActive(appID); ok {
+		return false, nil
+	}
+
+	_, err := k.storage.ActiveSigningKey(ctx, appID)
+	if errors.Is(err, storage.ErrSigningKeyNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return false, nil
+}
+
+// VerificationKey возвращает активный или уходящий ключ приложения по kid. Уходящий ключ
+// принимается RetireAfter с момента ротации, даже если его ещё не вывели из оборота.
+func (k *Keys) VerificationKey(ctx context.Context, appID int, kid string) (jwt.SigningKey, error) {
+	const op = "signing.VerificationKey"
+
+	cached, ok := k.cachedByKID(kid)
+	if !ok {
+		stored, err := k.storage.SigningKey(ctx, kid)
+		if err != nil {
+			if errors.Is(err, storage.ErrSigningKeyNotFound) {
+				return jwt.SigningKey{}, fmt.Errorf("%s: %w", op, jwt.ErrKeyNotFound)
+			}
+			return jwt.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		cached, err = k.remember(stored)
+		if err != nil {
+			return jwt.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if cached.appID != appID || cached.state == models.SigningKeyRetired || k.expired(cached) {
+		return jwt.SigningKey{}, fmt.Errorf("%s: %w", op, jwt.ErrKeyNotFound)
+	}
+
+	return cached.key, nil
+}
+
+// PublicKeys возвращает активные и ещё принимаемые уходящие ключи всех приложений для JWKS
+func (k *Keys) PublicKeys(ctx context.Context) ([]jwt.SigningKey, error) {
+	const op = "signing.PublicKeys"
+
+	stored, err := k.storage.PublishedSigningKeys(ctx, k.now().Add(-k.cfg.RetireAfter))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := make([]jwt.SigningKey, 0, len(stored))
+	for _, s := range stored {
+		cached, err := k.remember(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, cached.key)
+	}
+
+	return keys, nil
+}
+
+// Rotate выпускает новый активный ключ приложения. Прежний ключ становится уходящим и
+// принимается ещё RetireAfter; ключи, ушедшие раньше, выводятся из оборота.
+// Пустой algorithm означает алгоритм из конфига.
+func (k *Keys) Rotate(ctx context.Context, appID int, algorithm string) (jwt.SigningKey, error) {
+	const op = "signing.Rotate"
+
+	log := k.log.With(slog.String("op", op), slog.Int("app_id", appID))
+
+	if algorithm == "" {
+		algorithm = k.cfg.Algorithm
+	}
+
+	key, stored, err := k.newKey(appID, algorithm)
+	if err != nil {
+		return jwt.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	retired, err := k.storage.RotateSigningKey(ctx, stored, k.now().Add(-k.cfg.RetireAfter))
+	if err != nil {
+		log.Error("failed to rotate signing key", sl.Err(err))
+		return jwt.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	k.mu.Lock()
+	delete(k.active, appID)
+	for _, kid := range retired {
+		delete(k.byKID, kid)
+	}
+	k.mu.Unlock()
+
+	log.Info("signing key rotated", slog.String("kid", key.ID), slog.Any("retired", retired))
+	return key, nil
+}
+
+func (k *Keys) createFirstKey(ctx context.Context, appID int) (models.SigningKey, error) {
+	key, stored, err := k.newKey(appID, k.cfg.Algorithm)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	err = k.storage.SaveSigningKey(ctx, stored)
+	if errors.Is(err, storage.ErrSigningKeyExists) {
+		// ключ одновременно создал другой экземпляр сервиса — используем его
+		return k.storage.ActiveSigningKey(ctx, appID)
+	}
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	k.log.Info("created signing key", slog.Int("app_id", appID), slog.String("kid", key.ID))
+	return stored, nil
+}
+
+func (k *Keys) newKey(appID int, algorithm string) (jwt.SigningKey, models.SigningKey, error) {
+	if algorithm != jwt.AlgRS256 && algorithm != jwt.AlgEdDSA {
+		return jwt.SigningKey{}, models.SigningKey{}, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	key, err := jwt.GenerateKey(algorithm)
+	if err != nil {
+		return jwt.SigningKey{}, models.SigningKey{}, err
+	}
+
+	pemKey, err := jwt.MarshalPrivateKeyPEM(key.Private)
+	if err != nil {
+		return jwt.SigningKey{}, models.SigningKey{}, err
+	}
+
+	encrypted, err := k.cipher.Seal(pemKey)
+	if err != nil {
+		return jwt.SigningKey{}, models.SigningKey{}, err
+	}
+
+	return key, models.SigningKey{
+		KID:        key.ID,
+		AppID:      appID,
+		Algorithm:  algorithm,
+		PrivateKey: encrypted,
+		State:      models.SigningKeyActive,
+	}, nil
+}
+
+func (k *Keys) remember(stored models.SigningKey) (cachedKey, error) {
+	pemKey, err := k.cipher.Open(stored.PrivateKey)
+	if err != nil {
+		return cachedKey{}, fmt.Errorf("kid %q: %w", stored.KID, err)
+	}
+
+	private, err := jwt.ParsePrivateKeyPEM(pemKey)
+	if err != nil {
+		return cachedKey{}, fmt.Errorf("kid %q: %w", stored.KID, err)
+	}
+
+	key, err := jwt.NewSigningKey(stored.Algorithm, private, stored.KID)
+	if err != nil {
+		return cachedKey{}, fmt.Errorf("kid %q: %w", stored.KID, err)
+	}
+
+	cached := cachedKey{key: key, appID: stored.AppID, state: stored.State, retiringAt: stored.RetiringAt, loadedAt: k.now()}
+
+	k.mu.Lock()
+	k.byKID[key.ID] = cached
+	if stored.State == models.SigningKeyActive {
+		k.active[stored.AppID] = cached
+	}
+	k.mu.Unlock()
+
+	return cached, nil
+}
+
+func (k *Keys) cachedActive(appID int) (cachedKey, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.fresh(k.active[appID])
+}
+
+func (k *Keys) cachedByKID(kid string) (cachedKey, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.fresh(k.byKID[kid])
+}
+
+// expired сообщает, что уходящий ключ пережил RetireAfter и больше не должен приниматься
+func (k *Keys) expired(cached cachedKey) bool {
+	return cached.state == models.SigningKeyRetiring && !cached.retiringAt.IsZero() &&
+		!k.now().Before(cached.retiringAt.Add(k.cfg.RetireAfter))
+}
+
+func (k *Keys) fresh(cached cachedKey) (cachedKey, bool) {
+	if cached.key.ID == "" || k.now().Sub(cached.loadedAt) >= k.cfg.CacheTTL {
+		return cachedKey{}, false
+	}
+	return cached, true
+}
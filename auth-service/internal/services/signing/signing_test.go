@@ -0,0 +1,231 @@
+package signing
+
+import (
+	"context"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/jwt"
+	"github.com/14kear/forum-project/auth-service/internal/lib/secretbox"
+	"github.com/14kear/forum-project/auth-service/internal/services/mocks"
+	"github.com/14kear/forum-project/auth-service/internal/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestKeys(t *testing.T, ks *mocks.MockKeyStorage, algorithm string) *Keys {
+	box, err := secretbox.New(make([]byte, secretbox.KeySize))
+	require.NoError(t, err)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, ks, box, Config{Algorithm: algorithm, RetireAfter: time.Hour, CacheTTL: time.Minute})
+}
+
+// storedKey создаёт ключ в том виде, в каком он лежит в базе
+func storedKey(t *testing.T, k *Keys, appID int, state string) (jwt.SigningKey, models.SigningKey) {
+	key, stored, err := k.newKey(appID, jwt.AlgEdDSA)
+	require.NoError(t, err)
+	stored.State = state
+	return key, stored
+}
+
+func TestKeys_SigningKey_CreatesFirstKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ks := mocks.NewMockKeyStorage(ctrl)
+	ks.EXPECT().ActiveSigningKey(gomock.Any(), 1).Return(models.SigningKey{}, storage.ErrSigningKeyNotFound)
+
+	var saved models.SigningKey
+	ks.EXPECT().SaveSigningKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key models.SigningKey) error {
+		saved = key
+		return nil
+	})
+
+	k := newTestKeys(t, ks, jwt.AlgEdDSA)
+
+	key, err := k.SigningKey(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, saved.KID, key.ID)
+	assert.Equal(t, 1, saved.AppID)
+
+	// закрытый ключ в хранилище зашифрован
+	assert.NotContains(t, string(saved.PrivateKey), "PRIVATE KEY")
+
+	// повторный вызов обслуживается из кэша
+	again, err := k.SigningKey(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, again.ID)
+}
+
+func TestKeys_SigningKey_ConcurrentCreation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ks := mocks.NewMockKeyStorage(ctrl)
+	k := newTestKeys(t, ks, jwt.AlgEdDSA)
+	existing, stored := storedKey(t, k, 1, models.SigningKeyActive)
+
+	gomock.InOrder(
+		ks.EXPECT().ActiveSigningKey(gomock.Any(), 1).Return(models.SigningKey{}, storage.ErrSigningKeyNotFound),
+		ks.EXPECT().SaveSigningKey(gomock.Any(), gomock.Any()).Return(storage.ErrSigningKeyExists),
+		ks.EXPECT().ActiveSigningKey(gomock.Any(), 1).Return(stored, nil),
+	)
+
+	key, err := k.SigningKey(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, key.ID)
+}
+
+func TestKeys_SigningKey_HS256WithoutKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ks := mocks.NewMockKeyStorage(ctrl)
+	ks.EXPECT().ActiveSigningKey(gomock.Any(), 1).Return(models.SigningKey{}, storage.ErrSigningKeyNotFound)
+
+	key, err := newTestKeys(t, ks, jwt.AlgHS256).SigningKey(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Nil(t, key)
+}
+
+func TestKeys_AcceptsHMAC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ks := mocks.NewMockKeyStorage(ctrl)
+	hs := newTestKeys(t, ks, jwt.AlgHS256)
+	_, stored := storedKey(t, hs, 2, models.SigningKeyActive)
+	ks.EXPECT().ActiveSigningKey(gomock.Any(), 1).Return(models.SigningKey{}, storage.ErrSigningKeyNotFound).Times(2)
+	ks.EXPECT().ActiveSigningKey(gomock.Any(), 2).Return(stored, nil)
+
+	// HS256: секрет принимается только у приложений без ключа
+	accepted, err := hs.AcceptsHMAC(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, accepted)
+
+	accepted, err = hs.AcceptsHMAC(context.Background(), 2)
+	require.NoError(t, err)
+	assert.False(t, accepted)
+
+	// EdDSA: только до LegacyHMACUntil
+	ed := newTestKeys(t, ks, jwt.AlgEdDSA)
+	accepted, err = ed.AcceptsHMAC(context.Background(), 1)
+	require.NoError(t, err)
+	assert.False(t, accepted)
+
+	ed.cfg.LegacyHMACUntil = time.Now().Add(time.Hour)
+	accepted, err = ed.AcceptsHMAC(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, accepted)
+}
+
+func TestKeys_VerificationKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ks := mocks.NewMockKeyStorage(ctrl)
+	k := newTestKeys(t, ks, jwt.AlgEdDSA)
+
+	now := time.Now()
+	k.now = func() time.Time { return now }
+
+	retiring, retiringStored := storedKey(t, k, 1, models.SigningKeyRetiring)
+	retiringStored.RetiringAt = now.Add(-30 * time.Minute)
+	expired, expiredStored := storedKey(t, k, 1, models.SigningKeyRetiring)
+	expiredStored.RetiringAt = now.Add(-2 * time.Hour)
+	retired, retiredStored := storedKey(t, k, 1, models.SigningKeyRetired)
+
+	ks.EXPECT().SigningKey(gomock.Any(), retiring.ID).Return(retiringStored, nil)
+	ks.EXPECT().SigningKey(gomock.Any(), expired.ID).Return(expiredStored, nil)
+	ks.EXPECT().SigningKey(gomock.Any(), retired.ID).Return(retiredStored, nil)
+	ks.EXPECT().SigningKey(gomock.Any(), "unknown").Return(models.SigningKey{}, storage.ErrSigningKeyNotFound)
+
+	// уходящий ключ ещё принимается
+	key, err := k.VerificationKey(context.Background(), 1, retiring.ID)
+	require.NoError(t, err)
+	assert.Equal(t, retiring.ID, key.ID)
+
+	// но только для своего приложения
+	_, err = k.VerificationKey(context.Background(), 2, retiring.ID)
+	assert.ErrorIs(t, err, jwt.ErrKeyNotFound)
+
+	// уходящий дольше RetireAfter ключ не принимается, даже если его ещё не вывели из оборота
+	_, err = k.VerificationKey(context.Background(), 1, expired.ID)
+	assert.ErrorIs(t, err, jwt.ErrKeyNotFound)
+
+	_, err = k.VerificationKey(context.Background(), 1, retired.ID)
+	assert.ErrorIs(t, err, jwt.ErrKeyNotFound)
+
+	_, err = k.VerificationKey(context.Background(), 1, "unknown")
+	assert.ErrorIs(t, err, jwt.ErrKeyNotFound)
+}
+
+func TestKeys_PublicKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ks := mocks.NewMockKeyStorage(ctrl)
+	k := newTestKeys(t, ks, jwt.AlgEdDSA)
+
+	now := time.Now()
+	k.now = func() time.Time { return now }
+
+	active, activeStored := storedKey(t, k, 1, models.SigningKeyActive)
+	ks.EXPECT().PublishedSigningKeys(gomock.Any(), now.Add(-time.Hour)).Return([]models.SigningKey{activeStored}, nil)
+
+	keys, err := k.PublicKeys(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, active.ID, keys[0].ID)
+}
+
+func TestKeys_Rotate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ks := mocks.NewMockKeyStorage(ctrl)
+	k := newTestKeys(t, ks, jwt.AlgEdDSA)
+
+	now := time.Now()
+	k.now = func() time.Time { return now }
+
+	old, oldStored := storedKey(t, k, 1, models.SigningKeyActive)
+	ks.EXPECT().ActiveSigningKey(gomock.Any(), 1).Return(oldStored, nil)
+
+	key, err := k.SigningKey(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, old.ID, key.ID)
+
+	var rotated models.SigningKey
+	ks.EXPECT().RotateSigningKey(gomock.Any(), gomock.Any(), now.Add(-time.Hour)).DoAndReturn(
+		func(_ context.Context, key models.SigningKey, _ time.Time) ([]string, error) {
+			rotated = key
+			return nil, nil
+		})
+
+	next, err := k.Rotate(context.Background(), 1, jwt.AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, jwt.AlgRS256, next.Algorithm)
+	assert.Equal(t, next.ID, rotated.KID)
+
+	// после ротации активный ключ перечитывается из хранилища, а не берётся из кэша
+	rotated.State = models.SigningKeyActive
+	ks.EXPECT().ActiveSigningKey(gomock.Any(), 1).Return(rotated, nil)
+
+	key, err = k.SigningKey(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, next.ID, key.ID)
+}
+
+func TestKeys_Rotate_UnsupportedAlgorithm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, err := newTestKeys(t, mocks.NewMockKeyStorage(ctrl), jwt.AlgHS256).Rotate(context.Background(), 1, "")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/14kear/forum-project/auth-service/internal/domain/models"
@@ -35,7 +36,15 @@ func New(postgresURL string) (*Storage, error) {
 func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (int64, error) {
 	const op = "storage.postgres.SaveUser"
 
-	stmt, err := s.db.Prepare("INSERT INTO users(email, pass_hash) VALUES($1, $2) RETURNING id")
+	// новый пользователь сразу получает роль user
+	stmt, err := s.db.Prepare(`
+		WITH new_user AS (
+			INSERT INTO users(email, pass_hash) VALUES($1, $2) RETURNING id
+		), default_role AS (
+			INSERT INTO user_roles(user_id, role_id)
+			SELECT new_user.id, roles.id FROM new_user, roles WHERE roles.name = 'user'
+		)
+		SELECT id FROM new_user`)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -57,16 +66,13 @@ func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (
 func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
 	const op = "storage.postgres.User"
 
-	stmt, err := s.db.Prepare("SELECT id, email, pass_hash FROM users WHERE email = $1")
+	stmt, err := s.db.Prepare("SELECT " + userColumns + " FROM users WHERE email = $1")
 	if err != nil {
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx, email)
-
-	var user models.User
-	err = row.Scan(&user.ID, &user.Email, &user.PassHash)
+	user, err := scanUser(stmt.QueryRowContext(ctx, email))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
@@ -76,10 +82,58 @@ func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
 	return user, nil
 }
 
+// userColumns — столбцы users в порядке, который ожидает scanUser
+const userColumns = `id, email, pass_hash, email_verified, display_name, COALESCE(username, ''), avatar_url, bio,
+	created_at, updated_at`
+
+func scanUser(row interface{ Scan(dest ...any) error }) (models.User, error) {
+	var user models.User
+	err := row.Scan(&user.ID, &user.Email, &user.PassHash, &user.EmailVerified, &user.DisplayName, &user.Username,
+		&user.AvatarURL, &user.Bio, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// UpdateProfile заменяет профиль пользователя user.ID; пустой Username снимает имя пользователя
+func (s *Storage) UpdateProfile(ctx context.Context, user models.User) error {
+	const op = "storage.postgres.UpdateProfile"
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET display_name = $2, username = NULLIF($3, ''), avatar_url = $4, bio = $5, updated_at = now()
+		WHERE id = $1`,
+		user.ID, user.DisplayName, user.Username, user.AvatarURL, user.Bio)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, storage.ErrUsernameExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
 func (s *Storage) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	const op = "storage.postgres.IsAdmin"
 
-	stmt, err := s.db.Prepare("SELECT is_admin FROM users WHERE id = $1")
+	stmt, err := s.db.Prepare(`
+		SELECT EXISTS (
+			SELECT 1 FROM user_roles ur
+			JOIN roles r ON r.id = ur.role_id
+			WHERE ur.user_id = u.id AND r.name = 'admin'
+		)
+		FROM users u WHERE u.id = $1`)
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
@@ -101,16 +155,13 @@ func (s *Storage) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 func (s *Storage) App(ctx context.Context, appID int) (models.App, error) {
 	const op = "storage.postgres.App"
 
-	stmt, err := s.db.Prepare("SELECT id, name, secret FROM apps WHERE id = $1")
+	stmt, err := s.db.Prepare("SELECT " + appColumns + " FROM apps WHERE id = $1")
 	if err != nil {
 		return models.App{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx, appID)
-
-	var app models.App
-	err = row.Scan(&app.ID, &app.Name, &app.Secret)
+	app, err := scanApp(stmt.QueryRowContext(ctx, appID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
@@ -120,17 +171,37 @@ func (s *Storage) App(ctx context.Context, appID int) (models.App, error) {
 	return app, nil
 }
 
-func (s *Storage) SaveToken(ctx context.Context, userID int64, appID int, token string, expiresAt time.Time) (int64, error) {
+// appColumns — столбцы apps в порядке, который ожидает scanApp
+const appColumns = `id, name, secret, client_type, redirect_uris, access_token_ttl_seconds, refresh_token_ttl_seconds,
+	allowed_origins, disabled, created_at, updated_at`
+
+func scanApp(row interface{ Scan(dest ...any) error }) (models.App, error) {
+	var app models.App
+	var accessTTL, refreshTTL int64
+	err := row.Scan(&app.ID, &app.Name, &app.Secret, &app.ClientType, pq.Array(&app.RedirectURIs), &accessTTL, &refreshTTL,
+		pq.Array(&app.AllowedOrigins), &app.Disabled, &app.CreatedAt, &app.UpdatedAt)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	app.AccessTokenTTL = time.Duration(accessTTL) * time.Second
+	app.RefreshTokenTTL = time.Duration(refreshTTL) * time.Second
+
+	return app, nil
+}
+
+// SaveToken сохраняет refresh token, выданный при входе, — он начинает новую сессию
+func (s *Storage) SaveToken(ctx context.Context, userID int64, appID int, token string, expiresAt time.Time, client models.ClientInfo) (int64, error) {
 	const op = "storage.postgres.SaveToken"
 
-	stmt, err := s.db.Prepare("INSERT INTO refresh_tokens(user_id, app_id, token, expires_at) VALUES($1, $2, $3, $4) RETURNING id")
+	stmt, err := s.db.Prepare("INSERT INTO refresh_tokens(user_id, app_id, token, expires_at, ip, user_agent) VALUES($1, $2, $3, $4, $5, $6) RETURNING id")
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
 	var id int64
-	err = stmt.QueryRowContext(ctx, userID, appID, token, expiresAt).Scan(&id)
+	err = stmt.QueryRowContext(ctx, userID, appID, token, expiresAt, client.IP, client.UserAgent).Scan(&id)
 	if err != nil {
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // Код ошибки для уникальности
@@ -152,6 +223,7 @@ func (s *Storage) IsRefreshTokenValid(ctx context.Context, userID int64, appID i
 			FROM refresh_tokens 
 			WHERE token = $1 
 			AND revoked = FALSE 
+			AND rotated_at IS NULL
 			AND expires_at > NOW() 
 			AND user_id = $2 
 			AND app_id = $3
@@ -171,6 +243,179 @@ func (s *Storage) IsRefreshTokenValid(ctx context.Context, userID int64, appID i
 	return isValid, nil
 }
 
+// RotateRefreshToken заменяет refresh token oldToken на newToken в той же семье.
+// Использованный токен остаётся в таблице с отметкой rotated_at: если его предъявят снова,
+// вся семья отзывается и возвращается ErrRefreshTokenReused вместе с идентификатором семьи.
+func (s *Storage) RotateRefreshToken(ctx context.Context, userID int64, appID int, oldToken, newToken string, expiresAt time.Time) (int64, error) {
+	const op = "storage.postgres.RotateRefreshToken"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var (
+		id, familyID int64
+		revoked      bool
+		expired      bool
+		rotatedAt    sql.NullTime
+		client       models.ClientInfo
+		sessionStart time.Time
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, family_id, revoked, expires_at <= now(), rotated_at, ip, user_agent, session_created_at
+		FROM refresh_tokens
+		WHERE token = $1 AND user_id = $2 AND app_id = $3
+		FOR UPDATE`, oldToken, userID, appID).Scan(&id, &familyID, &revoked, &expired, &rotatedAt, &client.IP, &client.UserAgent, &sessionStart)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rotatedAt.Valid {
+		if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = TRUE WHERE family_id = $1 AND revoked = FALSE", familyID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return familyID, fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenReused)
+	}
+
+	if revoked || expired {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1", id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens(user_id, app_id, token, expires_at, family_id, parent_id, ip, user_agent, session_created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		userID, appID, newToken, expiresAt, familyID, id, client.IP, client.UserAgent, sessionStart); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrTokenAlreadyExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return familyID, nil
+}
+
+// SaveSecurityEvent записывает событие безопасности
+func (s *Storage) SaveSecurityEvent(ctx context.Context, event models.SecurityEvent) error {
+	const op = "storage.postgres.SaveSecurityEvent"
+
+	eventDetails := event.Details
+	if eventDetails == nil {
+		eventDetails = map[string]any{}
+	}
+	details, err := json.Marshal(eventDetails)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var userID sql.NullInt64
+	if event.UserID != 0 {
+		userID = sql.NullInt64{Int64: event.UserID, Valid: true}
+	}
+	var appID sql.NullInt32
+	if event.AppID != 0 {
+		appID = sql.NullInt32{Int32: int32(event.AppID), Valid: true}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO security_events(user_id, app_id, type, outcome, ip, user_agent, details)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`,
+		userID, appID, event.Type, event.Outcome, event.Client.IP, event.Client.UserAgent, details)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SecurityEvents возвращает события журнала аудита по фильтру от новых к старым
+func (s *Storage) SecurityEvents(ctx context.Context, filter models.SecurityEventFilter) ([]models.SecurityEvent, error) {
+	const op = "storage.postgres.SecurityEvents"
+
+	var since, until sql.NullTime
+	if !filter.Since.IsZero() {
+		since = sql.NullTime{Time: filter.Since, Valid: true}
+	}
+	if !filter.Until.IsZero() {
+		until = sql.NullTime{Time: filter.Until, Valid: true}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, app_id, type, outcome, ip, user_agent, details, created_at
+		FROM security_events
+		WHERE ($1::bigint = 0 OR user_id = $1)
+		  AND ($2::int = 0 OR app_id = $2)
+		  AND ($3::text = '' OR type = $3)
+		  AND ($4::text = '' OR outcome = $4)
+		  AND ($5::timestamptz IS NULL OR created_at >= $5)
+		  AND ($6::timestamptz IS NULL OR created_at < $6)
+		  AND ($7::bigint = 0 OR id < $7)
+		ORDER BY id DESC
+		LIMIT $8`,
+		filter.UserID, filter.AppID, filter.Type, filter.Outcome, since, until, filter.BeforeID, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []models.SecurityEvent
+	for rows.Next() {
+		var (
+			event   models.SecurityEvent
+			userID  sql.NullInt64
+			appID   sql.NullInt32
+			details []byte
+		)
+		if err := rows.Scan(&event.ID, &userID, &appID, &event.Type, &event.Outcome, &event.Client.IP, &event.Client.UserAgent, &details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		event.UserID = userID.Int64
+		event.AppID = int(appID.Int32)
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// DeleteSecurityEventsBefore удаляет события журнала аудита старше before и возвращает их число
+func (s *Storage) DeleteSecurityEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteSecurityEventsBefore"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM security_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return removed, nil
+}
+
 func (s *Storage) DeleteRefreshToken(ctx context.Context, userID int64, appID int, token string) error {
 	const op = "storage.postgres.DeleteExpiredTokens"
 
@@ -197,3 +442,1665 @@ func (s *Storage) DeleteRefreshToken(ctx context.Context, userID int64, appID in
 
 	return nil
 }
+
+func (s *Storage) UpdatePassword(ctx context.Context, userID int64, passHash []byte) error {
+	const op = "storage.postgres.UpdatePassword"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET pass_hash = $1 WHERE id = $2", passHash, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// Sessions возвращает активные сессии пользователя — действующие токены, которые ещё не заменены при ротации
+func (s *Storage) Sessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	const op = "storage.postgres.Sessions"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT rt.family_id, rt.app_id, COALESCE(a.name, ''), rt.ip, rt.user_agent,
+		       rt.session_created_at, rt.created_at, rt.expires_at
+		FROM refresh_tokens rt
+		LEFT JOIN apps a ON a.id = rt.app_id
+		WHERE rt.user_id = $1
+		  AND rt.revoked = FALSE
+		  AND rt.rotated_at IS NULL
+		  AND rt.expires_at > now()
+		ORDER BY rt.created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(
+			&session.ID, &session.AppID, &session.AppName, &session.Client.IP, &session.Client.UserAgent,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession отзывает все токены сессии (семьи) sessionID пользователя userID
+func (s *Storage) RevokeSession(ctx context.Context, userID int64, sessionID int64) error {
+	const op = "storage.postgres.RevokeSession"
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked = TRUE
+		WHERE user_id = $1 AND family_id = $2 AND revoked = FALSE`, userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokens отзывает все refresh токены пользователя во всех приложениях
+func (s *Storage) RevokeRefreshTokens(ctx context.Context, userID int64) error {
+	const op = "storage.postgres.RevokeRefreshTokens"
+
+	_, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND revoked = FALSE", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SavePasswordResetToken(ctx context.Context, userID int64, tokenHash []byte, expiresAt time.Time) error {
+	const op = "storage.postgres.SavePasswordResetToken"
+
+	stmt, err := s.db.Prepare("INSERT INTO password_reset_tokens(user_id, token_hash, expires_at) VALUES($1, $2, $3)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumePasswordResetToken помечает токен использованным и возвращает ID его владельца.
+// Токен, который уже использован или истёк к моменту now, не принимается.
+func (s *Storage) PasswordResetTokenUser(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	const op = "storage.postgres.PasswordResetTokenUser"
+
+	stmt, err := s.db.Prepare(`
+		SELECT user_id FROM password_reset_tokens
+		WHERE token_hash = $1
+		AND used_at IS NULL
+		AND expires_at > $2`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var userID int64
+	err = stmt.QueryRowContext(ctx, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrResetTokenNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func (s *Storage) ConsumePasswordResetToken(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	const op = "storage.postgres.ConsumePasswordResetToken"
+
+	stmt, err := s.db.Prepare(`
+		UPDATE password_reset_tokens
+		SET used_at = $2
+		WHERE token_hash = $1
+		AND used_at IS NULL
+		AND expires_at > $2
+		RETURNING user_id`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var userID int64
+	err = stmt.QueryRowContext(ctx, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrResetTokenNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func (s *Storage) SaveEmailVerificationToken(ctx context.Context, userID int64, tokenHash []byte, expiresAt time.Time) error {
+	const op = "storage.postgres.SaveEmailVerificationToken"
+
+	stmt, err := s.db.Prepare("INSERT INTO email_verification_tokens(user_id, token_hash, expires_at) VALUES($1, $2, $3)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// VerifyEmail погашает токен подтверждения и отмечает email его владельца подтверждённым.
+// Обе операции выполняются одним запросом, поэтому токен нельзя использовать повторно.
+func (s *Storage) VerifyEmail(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	const op = "storage.postgres.VerifyEmail"
+
+	stmt, err := s.db.Prepare(`
+		WITH token AS (
+			UPDATE email_verification_tokens
+			SET used_at = $2
+			WHERE token_hash = $1
+			AND new_email IS NULL
+			AND used_at IS NULL
+			AND expires_at > $2
+			RETURNING user_id
+		)
+		UPDATE users
+		SET email_verified = TRUE
+		FROM token
+		WHERE users.id = token.user_id
+		RETURNING users.id`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var userID int64
+	err = stmt.QueryRowContext(ctx, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrVerificationTokenNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// SaveEmailChangeToken сохраняет токен подтверждения нового адреса newEmail пользователя userID
+func (s *Storage) SaveEmailChangeToken(ctx context.Context, userID int64, newEmail string, tokenHash []byte, expiresAt time.Time) error {
+	const op = "storage.postgres.SaveEmailChangeToken"
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO email_verification_tokens(user_id, token_hash, expires_at, new_email) VALUES($1, $2, $3, $4)`,
+		userID, tokenHash, expiresAt, newEmail)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange погашает токен смены email и записывает новый адрес как подтверждённый.
+// Если адрес успел занять другой пользователь, токен остаётся непогашенным.
+func (s *Storage) ConfirmEmailChange(ctx context.Context, tokenHash []byte, now time.Time) (int64, error) {
+	const op = "storage.postgres.ConfirmEmailChange"
+
+	var userID int64
+	err := s.db.QueryRowContext(ctx, `
+		WITH token AS (
+			UPDATE email_verification_tokens
+			SET used_at = $2
+			WHERE token_hash = $1
+			AND new_email IS NOT NULL
+			AND used_at IS NULL
+			AND expires_at > $2
+			RETURNING user_id, new_email
+		)
+		UPDATE users
+		SET email = token.new_email, email_verified = TRUE, updated_at = $2
+		FROM token
+		WHERE users.id = token.user_id
+		RETURNING users.id`, tokenHash, now).Scan(&userID)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrVerificationTokenNotFound)
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserAlreadyExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func (s *Storage) UserByID(ctx context.Context, userID int64) (models.User, error) {
+	const op = "storage.postgres.UserByID"
+
+	stmt, err := s.db.Prepare("SELECT " + userColumns + " FROM users WHERE id = $1")
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	user, err := scanUser(stmt.QueryRowContext(ctx, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+// SaveTOTPSecret сохраняет новый неподтверждённый секрет, заменяя предыдущий незавершённый.
+// Включённый второй фактор не перезаписывается.
+func (s *Storage) SaveTOTPSecret(ctx context.Context, userID int64, secret []byte) error {
+	const op = "storage.postgres.SaveTOTPSecret"
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO user_totp(user_id, secret_encrypted) VALUES($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0, created_at = now()
+		WHERE user_totp.enabled = FALSE`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, userID, secret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPAlreadyEnabled)
+	}
+
+	return nil
+}
+
+func (s *Storage) TOTP(ctx context.Context, userID int64) (models.TOTP, error) {
+	const op = "storage.postgres.TOTP"
+
+	stmt, err := s.db.Prepare("SELECT user_id, secret_encrypted, enabled, last_used_step FROM user_totp WHERE user_id = $1")
+	if err != nil {
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var totp models.TOTP
+	err = stmt.QueryRowContext(ctx, userID).Scan(&totp.UserID, &totp.Secret, &totp.Enabled, &totp.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.TOTP{}, fmt.Errorf("%s: %w", op, storage.ErrTOTPNotFound)
+		}
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return totp, nil
+}
+
+// EnableTOTP включает второй фактор, подтверждённый кодом шага step, и заменяет коды восстановления
+func (s *Storage) EnableTOTP(ctx context.Context, userID int64, step int64, recoveryCodeHashes [][]byte) error {
+	const op = "storage.postgres.EnableTOTP"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_totp
+		SET enabled = TRUE, enabled_at = now(), last_used_step = $2
+		WHERE user_id = $1
+		AND enabled = FALSE
+		AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO mfa_recovery_codes(user_id, code_hash) VALUES($1, $2)", userID, hash); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteTOTP выключает второй фактор вместе с кодами восстановления
+func (s *Storage) DeleteTOTP(ctx context.Context, userID int64) error {
+	const op = "storage.postgres.DeleteTOTP"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep запоминает шаг принятого кода. Возвращает false, если код этого
+// или более позднего шага уже был использован.
+func (s *Storage) UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	const op = "storage.postgres.UseTOTPStep"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2", userID, step)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (s *Storage) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash []byte, now time.Time) error {
+	const op = "storage.postgres.ConsumeRecoveryCode"
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE mfa_recovery_codes
+		SET used_at = $3
+		WHERE user_id = $1
+		AND code_hash = $2
+		AND used_at IS NULL`, userID, codeHash, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRecoveryCodeNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveMFAChallenge(ctx context.Context, userID int64, appID int, tokenHash []byte, expiresAt time.Time) error {
+	const op = "storage.postgres.SaveMFAChallenge"
+
+	stmt, err := s.db.Prepare("INSERT INTO mfa_challenges(user_id, app_id, token_hash, expires_at) VALUES($1, $2, $3, $4)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, userID, appID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AttemptMFAChallenge засчитывает попытку ввода кода и возвращает challenge.
+// Истёкший, завершённый или исчерпавший maxAttempts попыток challenge не принимается.
+func (s *Storage) AttemptMFAChallenge(ctx context.Context, tokenHash []byte, now time.Time, maxAttempts int) (models.MFAChallenge, error) {
+	const op = "storage.postgres.AttemptMFAChallenge"
+
+	stmt, err := s.db.Prepare(`
+		UPDATE mfa_challenges
+		SET attempts = attempts + 1
+		WHERE token_hash = $1
+		AND used_at IS NULL
+		AND expires_at > $2
+		AND attempts < $3
+		RETURNING user_id, app_id`)
+	if err != nil {
+		return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var challenge models.MFAChallenge
+	err = stmt.QueryRowContext(ctx, tokenHash, now, maxAttempts).Scan(&challenge.UserID, &challenge.AppID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+		}
+		return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challenge, nil
+}
+
+// CompleteMFAChallenge помечает challenge использованным, чтобы по нему нельзя было войти ещё раз
+func (s *Storage) CompleteMFAChallenge(ctx context.Context, tokenHash []byte, now time.Time) error {
+	const op = "storage.postgres.CompleteMFAChallenge"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE mfa_challenges SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL", tokenHash, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+	}
+
+	return nil
+}
+
+// AppIDs возвращает идентификаторы всех приложений
+func (s *Storage) AppIDs(ctx context.Context) ([]int, error) {
+	const op = "storage.postgres.AppIDs"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM apps ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
+
+const signingKeyColumns = "kid, app_id, algorithm, private_key_encrypted, state, created_at, retiring_at"
+
+func scanSigningKey(row interface{ Scan(dest ...any) error }) (models.SigningKey, error) {
+	var (
+		key        models.SigningKey
+		retiringAt sql.NullTime
+	)
+	err := row.Scan(&key.KID, &key.AppID, &key.Algorithm, &key.PrivateKey, &key.State, &key.CreatedAt, &retiringAt)
+	key.RetiringAt = retiringAt.Time
+	return key, err
+}
+
+// ActiveSigningKey возвращает ключ, которым приложение подписывает новые токены
+func (s *Storage) ActiveSigningKey(ctx context.Context, appID int) (models.SigningKey, error) {
+	const op = "storage.postgres.ActiveSigningKey"
+
+	stmt, err := s.db.Prepare("SELECT " + signingKeyColumns + " FROM signing_keys WHERE app_id = $1 AND state = 'active'")
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	key, err := scanSigningKey(stmt.QueryRowContext(ctx, appID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SigningKey{}, fmt.Errorf("%s: %w", op, storage.ErrSigningKeyNotFound)
+		}
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// SigningKey ищет ключ по kid в любом состоянии
+func (s *Storage) SigningKey(ctx context.Context, kid string) (models.SigningKey, error) {
+	const op = "storage.postgres.SigningKey"
+
+	stmt, err := s.db.Prepare("SELECT " + signingKeyColumns + " FROM signing_keys WHERE kid = $1")
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	key, err := scanSigningKey(stmt.QueryRowContext(ctx, kid))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SigningKey{}, fmt.Errorf("%s: %w", op, storage.ErrSigningKeyNotFound)
+		}
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// PublishedSigningKeys возвращает активные и уходящие ключи всех включённых приложений.
+// Уходящие ключи, ставшие уходящими раньше retireBefore, не публикуются, даже если ещё не
+// выведены из оборота. Ключи отключённого приложения не публикуются, чтобы его токены
+// перестали проверяться и в других сервисах.
+func (s *Storage) PublishedSigningKeys(ctx context.Context, retireBefore time.Time) ([]models.SigningKey, error) {
+	const op = "storage.postgres.PublishedSigningKeys"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+signingKeyColumns+` FROM signing_keys
+		WHERE (state = 'active' OR (state = 'retiring' AND retiring_at >= $1))
+		  AND app_id IN (SELECT id FROM apps WHERE NOT disabled)
+		ORDER BY app_id, created_at DESC`, retireBefore)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		key, err := scanSigningKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// SaveSigningKey добавляет первый активный ключ приложения. Если активный ключ уже есть
+// (например, его только что создал другой экземпляр сервиса), возвращает ErrSigningKeyExists.
+func (s *Storage) SaveSigningKey(ctx context.Context, key models.SigningKey) error {
+	const op = "storage.postgres.SaveSigningKey"
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO signing_keys(kid, app_id, algorithm, private_key_encrypted, state)
+		VALUES($1, $2, $3, $4, 'active')
+		ON CONFLICT (app_id) WHERE state = 'active' DO NOTHING`,
+		key.KID, key.AppID, key.Algorithm, key.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSigningKeyExists)
+	}
+
+	return nil
+}
+
+// RotateSigningKey делает key активным ключом приложения: прежний активный ключ становится
+// уходящим, а ключи, ушедшие раньше retireBefore, выводятся из оборота.
+// Возвращает kid выведенных ключей.
+func (s *Storage) RotateSigningKey(ctx context.Context, key models.SigningKey, retireBefore time.Time) ([]string, error) {
+	const op = "storage.postgres.RotateSigningKey"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE signing_keys
+		SET state = 'retired', retired_at = now()
+		WHERE app_id = $1
+		AND state = 'retiring'
+		AND retiring_at < $2
+		RETURNING kid`, key.AppID, retireBefore)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var retired []string
+	for rows.Next() {
+		var kid string
+		if err := rows.Scan(&kid); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		retired = append(retired, kid)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE signing_keys
+		SET state = 'retiring', retiring_at = now()
+		WHERE app_id = $1 AND state = 'active'`, key.AppID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO signing_keys(kid, app_id, algorithm, private_key_encrypted, state)
+		VALUES($1, $2, $3, $4, 'active')`,
+		key.KID, key.AppID, key.Algorithm, key.PrivateKey); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSigningKeyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return retired, nil
+}
+
+// LoginLockedUntil возвращает самую позднюю блокировку входа для email и ip.
+// Нулевое время означает, что вход не заблокирован.
+func (s *Storage) LoginLockedUntil(ctx context.Context, email, ip string) (time.Time, error) {
+	const op = "storage.postgres.LoginLockedUntil"
+
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT max(locked_until) FROM login_lockouts
+		WHERE (kind = $1 AND key = $2) OR (kind = $3 AND key = $4)`,
+		models.LockoutKindEmail, email, models.LockoutKindIP, ip).Scan(&lockedUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockedUntil.Time, nil
+}
+
+// RecordLoginFailure увеличивает счётчик неудачных попыток и возвращает его новое значение.
+// Если предыдущая неудача была раньше windowStart, счёт начинается заново.
+func (s *Storage) RecordLoginFailure(ctx context.Context, kind, key string, now, windowStart time.Time) (int, error) {
+	const op = "storage.postgres.RecordLoginFailure"
+
+	var failures int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO login_lockouts(kind, key, failures, last_failure_at)
+		VALUES($1, $2, 1, $3)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			failures = CASE WHEN login_lockouts.last_failure_at < $4 THEN 1 ELSE login_lockouts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`, kind, key, now, windowStart).Scan(&failures)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return failures, nil
+}
+
+// LockLogin запрещает вход по ключу до until
+func (s *Storage) LockLogin(ctx context.Context, kind, key string, until time.Time) error {
+	const op = "storage.postgres.LockLogin"
+
+	_, err := s.db.ExecContext(ctx, "UPDATE login_lockouts SET locked_until = $3 WHERE kind = $1 AND key = $2", kind, key, until)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResetLoginFailures сбрасывает счётчик неудачных попыток после успешного входа
+func (s *Storage) ResetLoginFailures(ctx context.Context, kind, key string) error {
+	const op = "storage.postgres.ResetLoginFailures"
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM login_lockouts WHERE kind = $1 AND key = $2", kind, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ActiveLoginLockouts возвращает блокировки входа, действующие на момент now
+func (s *Storage) ActiveLoginLockouts(ctx context.Context, now time.Time) ([]models.LoginLockout, error) {
+	const op = "storage.postgres.ActiveLoginLockouts"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT kind, key, failures, last_failure_at, locked_until
+		FROM login_lockouts
+		WHERE locked_until > $1
+		ORDER BY locked_until DESC`, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var lockouts []models.LoginLockout
+	for rows.Next() {
+		var lockout models.LoginLockout
+		if err := rows.Scan(&lockout.Kind, &lockout.Key, &lockout.Failures, &lockout.LastFailureAt, &lockout.LockedUntil); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		lockouts = append(lockouts, lockout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockouts, nil
+}
+
+// ClearLoginLockout снимает блокировку и обнуляет счётчик неудачных попыток
+func (s *Storage) ClearLoginLockout(ctx context.Context, kind, key string) error {
+	const op = "storage.postgres.ClearLoginLockout"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM login_lockouts WHERE kind = $1 AND key = $2", kind, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrLockoutNotFound)
+	}
+
+	return nil
+}
+
+// UserRoles возвращает роли пользователя вместе с их правами
+func (s *Storage) UserRoles(ctx context.Context, userID int64) ([]models.Role, error) {
+	const op = "storage.postgres.UserRoles"
+
+	// LEFT JOIN от users отличает пользователя без ролей от несуществующего
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.name, p.name
+		FROM users u
+		LEFT JOIN user_roles ur ON ur.user_id = u.id
+		LEFT JOIN roles r ON r.id = ur.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE u.id = $1
+		ORDER BY r.name, p.name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var (
+		roles []models.Role
+		found bool
+	)
+	for rows.Next() {
+		found = true
+
+		var roleName, permission sql.NullString
+		if err := rows.Scan(&roleName, &permission); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !roleName.Valid {
+			continue
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].Name != roleName.String {
+			roles = append(roles, models.Role{Name: roleName.String})
+		}
+		if permission.Valid {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, permission.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return roles, nil
+}
+
+// AssignRole выдаёт пользователю роль. Повторная выдача не считается ошибкой.
+func (s *Storage) AssignRole(ctx context.Context, userID int64, role string) error {
+	const op = "storage.postgres.AssignRole"
+
+	roleID, err := s.roleID(ctx, role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO user_roles(user_id, role_id) VALUES($1, $2)
+		ON CONFLICT DO NOTHING`, userID, roleID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeRole отзывает у пользователя роль
+func (s *Storage) RevokeRole(ctx context.Context, userID int64, role string) error {
+	const op = "storage.postgres.RevokeRole"
+
+	roleID, err := s.roleID(ctx, role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2", userID, roleID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRoleNotAssigned)
+	}
+
+	return nil
+}
+
+func (s *Storage) roleID(ctx context.Context, role string) (int, error) {
+	var id int
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM roles WHERE name = $1", role).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrRoleNotFound
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// SaveOAuthClient регистрирует новое приложение как OAuth-клиент и возвращает его ID
+func (s *Storage) SaveOAuthClient(ctx context.Context, app models.App) (int, error) {
+	const op = "storage.postgres.SaveOAuthClient"
+
+	var id int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO apps(name, secret, client_type, redirect_uris)
+		VALUES($1, $2, $3, $4)
+		RETURNING id`,
+		app.Name, app.Secret, app.ClientType, pq.Array(app.RedirectURIs)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateOAuthClient меняет тип клиента и список redirect URI существующего приложения
+func (s *Storage) UpdateOAuthClient(ctx context.Context, appID int, clientType string, redirectURIs []string) error {
+	const op = "storage.postgres.UpdateOAuthClient"
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE apps SET client_type = $2, redirect_uris = $3 WHERE id = $1`,
+		appID, clientType, pq.Array(redirectURIs))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	const op = "storage.postgres.SaveAuthorizationCode"
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO oauth_authorization_codes(
+			code_hash, app_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, nonce, expires_at
+		) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		code.CodeHash, code.AppID, code.UserID, code.RedirectURI, pq.Array(code.Scope),
+		code.CodeChallenge, code.CodeChallengeMethod, code.Nonce, code.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeAuthorizationCode погашает код авторизации и возвращает его параметры.
+// Код, который уже обменян или истёк к моменту now, не принимается.
+func (s *Storage) ConsumeAuthorizationCode(ctx context.Context, codeHash []byte, now time.Time) (models.AuthorizationCode, error) {
+	const op = "storage.postgres.ConsumeAuthorizationCode"
+
+	code := models.AuthorizationCode{CodeHash: codeHash}
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE oauth_authorization_codes
+		SET used_at = $2
+		WHERE code_hash = $1
+		AND used_at IS NULL
+		AND expires_at > $2
+		RETURNING app_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, nonce, expires_at`,
+		codeHash, now).Scan(
+		&code.AppID, &code.UserID, &code.RedirectURI, pq.Array(&code.Scope),
+		&code.CodeChallenge, &code.CodeChallengeMethod, &code.Nonce, &code.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, storage.ErrAuthorizationCodeNotFound)
+		}
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
+
+func (s *Storage) OAuthConsent(ctx context.Context, userID int64, appID int) (models.OAuthConsent, error) {
+	const op = "storage.postgres.OAuthConsent"
+
+	consent := models.OAuthConsent{UserID: userID, AppID: appID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT a.name, c.scope, c.granted_at
+		FROM oauth_consents c
+		JOIN apps a ON a.id = c.app_id
+		WHERE c.user_id = $1 AND c.app_id = $2`, userID, appID).Scan(
+		&consent.AppName, pq.Array(&consent.Scope), &consent.GrantedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.OAuthConsent{}, fmt.Errorf("%s: %w", op, storage.ErrConsentNotFound)
+		}
+		return models.OAuthConsent{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consent, nil
+}
+
+// OAuthConsents возвращает все согласия пользователя, последние выданные — первыми
+func (s *Storage) OAuthConsents(ctx context.Context, userID int64) ([]models.OAuthConsent, error) {
+	const op = "storage.postgres.OAuthConsents"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.app_id, a.name, c.scope, c.granted_at
+		FROM oauth_consents c
+		JOIN apps a ON a.id = c.app_id
+		WHERE c.user_id = $1
+		ORDER BY c.granted_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var consents []models.OAuthConsent
+	for rows.Next() {
+		consent := models.OAuthConsent{UserID: userID}
+		if err := rows.Scan(&consent.AppID, &consent.AppName, pq.Array(&consent.Scope), &consent.GrantedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		consents = append(consents, consent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consents, nil
+}
+
+// SaveOAuthConsent записывает согласие пользователя, заменяя прежний набор scope
+func (s *Storage) SaveOAuthConsent(ctx context.Context, userID int64, appID int, scope []string) error {
+	const op = "storage.postgres.SaveOAuthConsent"
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO oauth_consents(user_id, app_id, scope, granted_at)
+		VALUES($1, $2, $3, now())
+		ON CONFLICT (user_id, app_id) DO UPDATE SET scope = EXCLUDED.scope, granted_at = EXCLUDED.granted_at`,
+		userID, appID, pq.Array(scope))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteOAuthConsent отзывает согласие пользователя и refresh токены, выданные клиенту
+func (s *Storage) DeleteOAuthConsent(ctx context.Context, userID int64, appID int) error {
+	const op = "storage.postgres.DeleteOAuthConsent"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM oauth_consents WHERE user_id = $1 AND app_id = $2", userID, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrConsentNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked = TRUE
+		WHERE user_id = $1 AND app_id = $2 AND revoked = FALSE`, userID, appID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveFederationState(ctx context.Context, state models.FederationState) error {
+	const op = "storage.postgres.SaveFederationState"
+
+	var linkUserID sql.NullInt64
+	if state.LinkUserID != 0 {
+		linkUserID = sql.NullInt64{Int64: state.LinkUserID, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO federation_states(state_hash, provider, app_id, nonce, code_verifier, link_user_id, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`,
+		state.StateHash, state.Provider, state.AppID, state.Nonce, state.CodeVerifier, linkUserID, state.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeFederationState погашает state входа через провайдера provider и возвращает его параметры.
+// Использованный, истёкший или выданный для другого провайдера state не принимается.
+func (s *Storage) ConsumeFederationState(ctx context.Context, stateHash []byte, provider string, now time.Time) (models.FederationState, error) {
+	const op = "storage.postgres.ConsumeFederationState"
+
+	state := models.FederationState{StateHash: stateHash, Provider: provider}
+	var linkUserID sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE federation_states
+		SET used_at = $3
+		WHERE state_hash = $1
+		AND provider = $2
+		AND used_at IS NULL
+		AND expires_at > $3
+		RETURNING app_id, nonce, code_verifier, link_user_id, expires_at`,
+		stateHash, provider, now).Scan(
+		&state.AppID, &state.Nonce, &state.CodeVerifier, &linkUserID, &state.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.FederationState{}, fmt.Errorf("%s: %w", op, storage.ErrFederationStateNotFound)
+		}
+		return models.FederationState{}, fmt.Errorf("%s: %w", op, err)
+	}
+	state.LinkUserID = linkUserID.Int64
+
+	return state, nil
+}
+
+func (s *Storage) UserIdentity(ctx context.Context, provider, subject string) (models.UserIdentity, error) {
+	const op = "storage.postgres.UserIdentity"
+
+	identity := models.UserIdentity{Provider: provider, Subject: subject}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, email, created_at FROM user_identities
+		WHERE provider = $1 AND subject = $2`, provider, subject).Scan(
+		&identity.UserID, &identity.Email, &identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UserIdentity{}, fmt.Errorf("%s: %w", op, storage.ErrIdentityNotFound)
+		}
+		return models.UserIdentity{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identity, nil
+}
+
+// SaveUserIdentity привязывает учётную запись провайдера к пользователю. Если она уже привязана
+// или у пользователя уже есть учётная запись этого провайдера, возвращает storage.ErrIdentityExists.
+func (s *Storage) SaveUserIdentity(ctx context.Context, identity models.UserIdentity) error {
+	const op = "storage.postgres.SaveUserIdentity"
+
+	if err := saveUserIdentity(ctx, s.db, identity); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveFederatedUser создаёт пользователя, вошедшего через провайдера, вместе с привязкой его учётной записи.
+// Email провайдера, подтверждённый им, сразу считается подтверждённым.
+func (s *Storage) SaveFederatedUser(ctx context.Context, email string, passHash []byte, emailVerified bool, identity models.UserIdentity) (int64, error) {
+	const op = "storage.postgres.SaveFederatedUser"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		WITH new_user AS (
+			INSERT INTO users(email, pass_hash, email_verified) VALUES($1, $2, $3) RETURNING id
+		), default_role AS (
+			INSERT INTO user_roles(user_id, role_id)
+			SELECT new_user.id, roles.id FROM new_user, roles WHERE roles.name = 'user'
+		)
+		SELECT id FROM new_user`, email, passHash, emailVerified).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserAlreadyExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	identity.UserID = id
+	if err := saveUserIdentity(ctx, tx, identity); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// saveUserIdentity выполняется и в транзакции, и без неё: подходят и *sql.DB, и *sql.Tx
+func saveUserIdentity(ctx context.Context, db interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, identity models.UserIdentity) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO user_identities(user_id, provider, subject, email) VALUES($1, $2, $3, $4)`,
+		identity.UserID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return storage.ErrIdentityExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SaveApp создаёт приложение и возвращает его ID
+func (s *Storage) SaveApp(ctx context.Context, app models.App) (int, error) {
+	const op = "storage.postgres.SaveApp"
+
+	var id int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO apps(name, secret, client_type, redirect_uris, access_token_ttl_seconds, refresh_token_ttl_seconds, allowed_origins)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		app.Name, app.Secret, app.ClientType, pq.Array(app.RedirectURIs),
+		int64(app.AccessTokenTTL/time.Second), int64(app.RefreshTokenTTL/time.Second), pq.Array(app.AllowedOrigins)).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrAppExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// Apps возвращает все приложения, включая отключённые, по возрастанию ID
+func (s *Storage) Apps(ctx context.Context) ([]models.App, error) {
+	const op = "storage.postgres.Apps"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+appColumns+" FROM apps ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var apps []models.App
+	for rows.Next() {
+		app, err := scanApp(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+// UpdateApp меняет название и настройки приложения. Секрет и признак отключения не меняются.
+func (s *Storage) UpdateApp(ctx context.Context, app models.App) error {
+	const op = "storage.postgres.UpdateApp"
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE apps
+		SET name = $2, client_type = $3, redirect_uris = $4, access_token_ttl_seconds = $5,
+			refresh_token_ttl_seconds = $6, allowed_origins = $7, updated_at = NOW()
+		WHERE id = $1`,
+		app.ID, app.Name, app.ClientType, pq.Array(app.RedirectURIs),
+		int64(app.AccessTokenTTL/time.Second), int64(app.RefreshTokenTTL/time.Second), pq.Array(app.AllowedOrigins))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, storage.ErrAppExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return appAffected(op, res)
+}
+
+// UpdateAppSecret заменяет секрет приложения
+func (s *Storage) UpdateAppSecret(ctx context.Context, appID int, secret string) error {
+	const op = "storage.postgres.UpdateAppSecret"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE apps SET secret = $2, updated_at = NOW() WHERE id = $1", appID, secret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return appAffected(op, res)
+}
+
+// DisableApp отключает приложение и отзывает все refresh токены, выданные для него
+func (s *Storage) DisableApp(ctx context.Context, appID int) error {
+	const op = "storage.postgres.DisableApp"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE apps SET disabled = TRUE, updated_at = NOW() WHERE id = $1", appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := appAffected(op, res); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = TRUE WHERE app_id = $1 AND revoked = FALSE", appID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AppAllowsOrigin сообщает, разрешён ли origin хотя бы одному включённому приложению
+func (s *Storage) AppAllowsOrigin(ctx context.Context, origin string) (bool, error) {
+	const op = "storage.postgres.AppAllowsOrigin"
+
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM apps WHERE NOT disabled AND $1 = ANY(allowed_origins))`, origin).Scan(&allowed)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return allowed, nil
+}
+
+func appAffected(op string, res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	return nil
+}
+
+// DeleteUser удаляет пользователя вместе со всеми его данными (сессии, роли, второй фактор и т.д.
+// удаляются каскадно) и записывает событие удаления аккаунта. Возвращает id события.
+func (s *Storage) DeleteUser(ctx context.Context, userID int64) (int64, error) {
+	const op = "storage.postgres.DeleteUser"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRowContext(ctx, "DELETE FROM users WHERE id = $1 RETURNING email", userID).Scan(&email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// счётчик неудачных входов хранит email в ключе и на users не ссылается
+	_, err = tx.ExecContext(ctx, "DELETE FROM login_lockouts WHERE kind = 'email' AND key = lower(trim($1))", email)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var eventID int64
+	err = tx.QueryRowContext(ctx, "INSERT INTO account_erasures(user_id) VALUES($1) RETURNING id", userID).Scan(&eventID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return eventID, nil
+}
+
+// AccountErasures возвращает не более limit событий удаления аккаунтов с id больше afterID по возрастанию id
+func (s *Storage) AccountErasures(ctx context.Context, afterID int64, limit int) ([]models.AccountErasure, error) {
+	const op = "storage.postgres.AccountErasures"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, created_at FROM account_erasures
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var erasures []models.AccountErasure
+	for rows.Next() {
+		var erasure models.AccountErasure
+		if err := rows.Scan(&erasure.ID, &erasure.UserID, &erasure.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		erasures = append(erasures, erasure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return erasures, nil
+}
+
+// AccountErasuresSince возвращает события удаления аккаунтов, записанные не раньше since
+func (s *Storage) AccountErasuresSince(ctx context.Context, since time.Time) ([]models.AccountErasure, error) {
+	const op = "storage.postgres.AccountErasuresSince"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, created_at FROM account_erasures
+		WHERE created_at >= $1
+		ORDER BY id`, since)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var erasures []models.AccountErasure
+	for rows.Next() {
+		var erasure models.AccountErasure
+		if err := rows.Scan(&erasure.ID, &erasure.UserID, &erasure.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		erasures = append(erasures, erasure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return erasures, nil
+}
+
+func (s *Storage) RefreshTokenRecords(ctx context.Context, userID int64) ([]models.RefreshTokenRecord, error) {
+	const op = "storage.postgres.RefreshTokenRecords"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, app_id, family_id, ip, user_agent, session_created_at, created_at, expires_at,
+		       rotated_at, COALESCE(revoked, FALSE)
+		FROM refresh_tokens
+		WHERE user_id = $1
+		ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var records []models.RefreshTokenRecord
+	for rows.Next() {
+		var (
+			record    models.RefreshTokenRecord
+			rotatedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&record.ID, &record.AppID, &record.SessionID, &record.Client.IP, &record.Client.UserAgent,
+			&record.SessionCreatedAt, &record.CreatedAt, &record.ExpiresAt, &rotatedAt, &record.Revoked,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		record.RotatedAt = rotatedAt.Time
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// SaveBan блокирует пользователя; действующая блокировка заменяется новой
+func (s *Storage) SaveBan(ctx context.Context, ban models.Ban) error {
+	const op = "storage.postgres.SaveBan"
+
+	var expiresAt sql.NullTime
+	if !ban.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: ban.ExpiresAt, Valid: true}
+	}
+
+	var bannedBy sql.NullInt64
+	if ban.BannedBy != 0 {
+		bannedBy = sql.NullInt64{Int64: ban.BannedBy, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_bans(user_id, kind, reason, expires_at, banned_by, created_at)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET kind = EXCLUDED.kind,
+		    reason = EXCLUDED.reason,
+		    expires_at = EXCLUDED.expires_at,
+		    banned_by = EXCLUDED.banned_by,
+		    created_at = EXCLUDED.created_at`,
+		ban.UserID, ban.Kind, ban.Reason, expiresAt, bannedBy, ban.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteBan снимает блокировку пользователя, в том числе уже истёкшую
+func (s *Storage) DeleteBan(ctx context.Context, userID int64) error {
+	const op = "storage.postgres.DeleteBan"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM user_bans WHERE user_id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrBanNotFound)
+	}
+
+	return nil
+}
+
+// ActiveBan возвращает блокировку пользователя, действующую в момент now. Пользователь
+// ищется тем же запросом: если его нет (аккаунт удалён), возвращается storage.ErrUserNotFound.
+func (s *Storage) ActiveBan(ctx context.Context, userID int64, now time.Time) (models.Ban, error) {
+	const op = "storage.postgres.ActiveBan"
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT b.kind, b.reason, b.expires_at, b.banned_by, b.created_at
+		FROM users u
+		LEFT JOIN user_bans b ON b.user_id = u.id AND (b.expires_at IS NULL OR b.expires_at > $2)
+		WHERE u.id = $1`, userID, now)
+
+	var (
+		kind, reason sql.NullString
+		expiresAt    sql.NullTime
+		bannedBy     sql.NullInt64
+		createdAt    sql.NullTime
+	)
+	if err := row.Scan(&kind, &reason, &expiresAt, &bannedBy, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Ban{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.Ban{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !kind.Valid {
+		return models.Ban{}, fmt.Errorf("%s: %w", op, storage.ErrBanNotFound)
+	}
+
+	return models.Ban{
+		UserID:    userID,
+		Kind:      kind.String,
+		Reason:    reason.String,
+		ExpiresAt: expiresAt.Time,
+		BannedBy:  bannedBy.Int64,
+		CreatedAt: createdAt.Time,
+	}, nil
+}
+
+// ActiveBans возвращает все блокировки, действующие в момент now
+func (s *Storage) ActiveBans(ctx context.Context, now time.Time) ([]models.Ban, error) {
+	const op = "storage.postgres.ActiveBans"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, kind, reason, expires_at, banned_by, created_at
+		FROM user_bans
+		WHERE expires_at IS NULL OR expires_at > $1
+		ORDER BY user_id`, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var bans []models.Ban
+	for rows.Next() {
+		ban, err := scanBan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		bans = append(bans, ban)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bans, nil
+}
+
+func scanBan(row interface{ Scan(dest ...any) error }) (models.Ban, error) {
+	var (
+		ban       models.Ban
+		expiresAt sql.NullTime
+		bannedBy  sql.NullInt64
+	)
+	if err := row.Scan(&ban.UserID, &ban.Kind, &ban.Reason, &expiresAt, &bannedBy, &ban.CreatedAt); err != nil {
+		return models.Ban{}, err
+	}
+	ban.ExpiresAt = expiresAt.Time
+	ban.BannedBy = bannedBy.Int64
+
+	return ban, nil
+}
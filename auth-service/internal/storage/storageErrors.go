@@ -3,9 +3,30 @@ package storage
 import "errors"
 
 var (
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrAppNotFound        = errors.New("app not found")
-	ErrTokenAlreadyExists = errors.New("token already exist")
-	ErrTokenNotFound      = errors.New("token not found")
+	ErrUserNotFound              = errors.New("user not found")
+	ErrUserAlreadyExists         = errors.New("user already exists")
+	ErrUsernameExists            = errors.New("username already taken")
+	ErrAppNotFound               = errors.New("app not found")
+	ErrAppExists                 = errors.New("app already exists")
+	ErrTokenAlreadyExists        = errors.New("token already exist")
+	ErrTokenNotFound             = errors.New("token not found")
+	ErrResetTokenNotFound        = errors.New("reset token not found")
+	ErrVerificationTokenNotFound = errors.New("verification token not found")
+	ErrTOTPNotFound              = errors.New("totp not found")
+	ErrTOTPAlreadyEnabled        = errors.New("totp already enabled")
+	ErrRecoveryCodeNotFound      = errors.New("recovery code not found")
+	ErrMFAChallengeNotFound      = errors.New("mfa challenge not found")
+	ErrSigningKeyNotFound        = errors.New("signing key not found")
+	ErrSigningKeyExists          = errors.New("active signing key already exists")
+	ErrRefreshTokenReused        = errors.New("refresh token reused")
+	ErrSessionNotFound           = errors.New("session not found")
+	ErrLockoutNotFound           = errors.New("login lockout not found")
+	ErrRoleNotFound              = errors.New("role not found")
+	ErrRoleNotAssigned           = errors.New("role is not assigned to user")
+	ErrAuthorizationCodeNotFound = errors.New("authorization code not found")
+	ErrConsentNotFound           = errors.New("oauth consent not found")
+	ErrFederationStateNotFound   = errors.New("federation state not found")
+	ErrIdentityNotFound          = errors.New("user identity not found")
+	ErrIdentityExists            = errors.New("user identity already exists")
+	ErrBanNotFound               = errors.New("user ban not found")
 )
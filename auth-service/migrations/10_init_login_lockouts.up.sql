@@ -0,0 +1,11 @@
+-- Неудачные попытки входа по email и по IP. Строка с locked_until в будущем — активная блокировка.
+CREATE TABLE IF NOT EXISTS login_lockouts (
+    kind TEXT NOT NULL CHECK (kind IN ('email', 'ip')),
+    key TEXT NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (kind, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_lockouts_locked_until ON login_lockouts(locked_until);
@@ -0,0 +1,63 @@
+-- Роли и права вместо флага users.is_admin. Набор прав роли задаётся здесь же, миграциями.
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access, including role management'),
+    ('moderator', 'Moderates forum content'),
+    ('user', 'Regular registered user'),
+    ('read_only', 'Can read but not post')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('auth.roles.manage', 'Assign and revoke user roles'),
+    ('forum.post.create', 'Create topics, comments and chat messages'),
+    ('forum.post.delete_any', 'Delete topics and comments of other users')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON (r.name, p.name) IN (
+    ('admin', 'auth.roles.manage'),
+    ('admin', 'forum.post.create'),
+    ('admin', 'forum.post.delete_any'),
+    ('moderator', 'forum.post.create'),
+    ('moderator', 'forum.post.delete_any'),
+    ('user', 'forum.post.create')
+)
+ON CONFLICT DO NOTHING;
+
+-- существующие пользователи получают роль user, администраторы — ещё и admin
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u JOIN roles r ON r.name = 'user'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u JOIN roles r ON r.name = 'admin'
+WHERE u.is_admin
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users DROP COLUMN is_admin;
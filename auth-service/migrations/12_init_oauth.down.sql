@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+
+ALTER TABLE apps
+    DROP COLUMN redirect_uris,
+    DROP COLUMN client_type;
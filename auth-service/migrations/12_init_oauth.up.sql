@@ -0,0 +1,30 @@
+-- Приложения становятся OAuth-клиентами: у клиента есть тип и разрешённые redirect URI.
+-- Приложение без redirect URI в OAuth не участвует.
+ALTER TABLE apps
+    ADD COLUMN client_type TEXT NOT NULL DEFAULT 'confidential' CHECK (client_type IN ('confidential', 'public')),
+    ADD COLUMN redirect_uris TEXT[] NOT NULL DEFAULT '{}';
+
+-- Коды авторизации хранятся в виде хэша и гасятся при обмене на токены
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash BYTEA PRIMARY KEY,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT[] NOT NULL DEFAULT '{}',
+    code_challenge TEXT NOT NULL,
+    code_challenge_method TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
+
+-- Согласия пользователя на доступ клиента к scope
+CREATE TABLE IF NOT EXISTS oauth_consents (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    scope TEXT[] NOT NULL DEFAULT '{}',
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, app_id)
+);
@@ -0,0 +1,3 @@
+-- nonce из запроса авторизации OpenID Connect переносится в ID token, выданный по коду
+ALTER TABLE oauth_authorization_codes
+    ADD COLUMN nonce TEXT NOT NULL DEFAULT '';
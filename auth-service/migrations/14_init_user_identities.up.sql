@@ -0,0 +1,26 @@
+-- Учётные записи внешних провайдеров OpenID Connect, привязанные к пользователям.
+-- У пользователя не больше одной учётной записи каждого провайдера.
+CREATE TABLE IF NOT EXISTS user_identities (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+-- Незавершённые входы через провайдера: state хранится в виде хэша и гасится при возврате пользователя
+CREATE TABLE IF NOT EXISTS federation_states (
+    state_hash BYTEA PRIMARY KEY,
+    provider TEXT NOT NULL,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    link_user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_federation_states_expires_at ON federation_states(expires_at);
@@ -0,0 +1,9 @@
+DELETE FROM permissions WHERE name = 'auth.apps.manage';
+
+ALTER TABLE apps
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at,
+    DROP COLUMN disabled,
+    DROP COLUMN allowed_origins,
+    DROP COLUMN refresh_token_ttl_seconds,
+    DROP COLUMN access_token_ttl_seconds;
@@ -0,0 +1,22 @@
+-- Настройки приложения, которые раньше были общими для всех: сроки жизни токенов
+-- (0 — значение из конфига), разрешённые origin для CORS и признак отключения.
+ALTER TABLE apps
+    ADD COLUMN access_token_ttl_seconds INT NOT NULL DEFAULT 0 CHECK (access_token_ttl_seconds >= 0),
+    ADD COLUMN refresh_token_ttl_seconds INT NOT NULL DEFAULT 0 CHECK (refresh_token_ttl_seconds >= 0),
+    ADD COLUMN allowed_origins TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+INSERT INTO permissions (name, description) VALUES
+    ('auth.apps.manage', 'Create, update and disable apps, rotate app secrets')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON r.name = 'admin' AND p.name = 'auth.apps.manage'
+ON CONFLICT DO NOTHING;
+
+-- приложения раньше добавлялись вручную с явным id; последовательность должна их обогнать
+SELECT setval(pg_get_serial_sequence('apps', 'id'), GREATEST((SELECT MAX(id) FROM apps), 1));
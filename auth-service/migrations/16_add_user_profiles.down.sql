@@ -0,0 +1,12 @@
+DELETE FROM email_verification_tokens WHERE new_email IS NOT NULL;
+ALTER TABLE email_verification_tokens DROP COLUMN IF EXISTS new_email;
+
+DROP INDEX IF EXISTS idx_users_username;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS bio,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS username,
+    DROP COLUMN IF EXISTS display_name;
@@ -0,0 +1,13 @@
+-- у пользователей, созданных до появления профиля, created_at совпадает с моментом миграции
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS username TEXT,
+    ADD COLUMN IF NOT EXISTS avatar_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(username);
+
+-- токен с new_email подтверждает смену адреса, без него — адрес, указанный при регистрации
+ALTER TABLE email_verification_tokens ADD COLUMN IF NOT EXISTS new_email TEXT;
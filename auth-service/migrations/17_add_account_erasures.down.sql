@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'auth.users.delete';
+
+DROP TABLE IF EXISTS account_erasures;
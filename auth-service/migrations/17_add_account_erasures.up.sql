@@ -0,0 +1,17 @@
+-- События удаления аккаунтов. Другие сервисы читают их по возрастанию id и удаляют у себя
+-- данные пользователя; поэтому строка не ссылается на users и переживает удаление.
+CREATE TABLE IF NOT EXISTS account_erasures (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('auth.users.delete', 'Delete accounts of other users')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON r.name = 'admin' AND p.name = 'auth.users.delete'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+DELETE FROM permissions WHERE name = 'auth.audit.read';
+
+DROP TRIGGER IF EXISTS security_events_no_update ON security_events;
+DROP FUNCTION IF EXISTS security_events_append_only();
+
+DROP INDEX IF EXISTS idx_security_events_type;
+DROP INDEX IF EXISTS idx_security_events_created_at;
+
+ALTER TABLE security_events
+    DROP COLUMN IF EXISTS outcome,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS ip;
+
+-- события удалённых пользователей не могут снова ссылаться на users
+DELETE FROM security_events e WHERE e.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = e.user_id);
+
+ALTER TABLE security_events
+    ADD CONSTRAINT security_events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
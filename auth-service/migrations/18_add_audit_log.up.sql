@@ -0,0 +1,34 @@
+-- Журнал аудита: security_events становится журналом всех событий безопасности (вход, регистрация,
+-- обновление токенов, смена ролей и пароля). Журнал только дополняется; старые записи удаляет
+-- очистка по сроку хранения. Записи переживают удаление пользователя, чтобы по ним можно было
+-- расследовать инциденты.
+ALTER TABLE security_events DROP CONSTRAINT IF EXISTS security_events_user_id_fkey;
+
+ALTER TABLE security_events
+    ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS outcome TEXT NOT NULL DEFAULT 'success' CHECK (outcome IN ('success', 'failure'));
+
+CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_security_events_type ON security_events(type);
+
+CREATE OR REPLACE FUNCTION security_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'security_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS security_events_no_update ON security_events;
+CREATE TRIGGER security_events_no_update
+    BEFORE UPDATE ON security_events
+    FOR EACH ROW EXECUTE FUNCTION security_events_append_only();
+
+INSERT INTO permissions (name, description) VALUES
+    ('auth.audit.read', 'Read the security audit log')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON r.name = 'admin' AND p.name = 'auth.audit.read'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'auth.users.ban';
+
+DROP TABLE IF EXISTS user_bans;
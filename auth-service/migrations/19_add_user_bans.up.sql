@@ -0,0 +1,21 @@
+-- Блокировки пользователей: бессрочный или временный бан (ban) и временная приостановка
+-- (suspension). Пока блокировка действует, пользователь не может войти и обновить токены,
+-- а его access токены не принимаются. Истёкшие блокировки не удаляются и просто не действуют.
+CREATE TABLE IF NOT EXISTS user_bans (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('ban', 'suspension')),
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    banned_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('auth.users.ban', 'Ban, suspend and unban users')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON r.name IN ('admin', 'moderator') AND p.name = 'auth.users.ban'
+ON CONFLICT DO NOTHING;
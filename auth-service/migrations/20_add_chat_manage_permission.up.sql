@@ -0,0 +1,9 @@
+INSERT INTO permissions (name, description) VALUES
+    ('forum.chat.manage', 'Change chat settings: slow mode, retention and sweeps')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON r.name = 'admin' AND p.name = 'forum.chat.manage'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,17 @@
+-- Ключи подписи токенов по приложениям. Новые токены подписываются активным ключом,
+-- уходящий (retiring) ещё принимается при проверке, пока не истекут выпущенные им токены.
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id SERIAL PRIMARY KEY,
+    kid TEXT NOT NULL UNIQUE,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    algorithm TEXT NOT NULL,
+    -- закрытый ключ в PEM, зашифрованный ключом из конфига
+    private_key_encrypted BYTEA NOT NULL,
+    state TEXT NOT NULL DEFAULT 'active' CHECK (state IN ('active', 'retiring', 'retired')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    retiring_at TIMESTAMPTZ,
+    retired_at TIMESTAMPTZ
+);
+
+-- у приложения может быть только один активный ключ
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_active ON signing_keys(app_id) WHERE state = 'active';
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS security_events;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS rotated_at,
+    DROP COLUMN IF EXISTS parent_id,
+    DROP COLUMN IF EXISTS family_id;
+
+DROP SEQUENCE IF EXISTS refresh_token_families_seq;
@@ -0,0 +1,22 @@
+-- Ротация refresh token: использованный токен не удаляется, а помечается rotated_at,
+-- новые токены наследуют family_id. Повторное предъявление использованного токена
+-- означает утечку, и вся семья отзывается.
+CREATE SEQUENCE IF NOT EXISTS refresh_token_families_seq;
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS family_id BIGINT NOT NULL DEFAULT nextval('refresh_token_families_seq'),
+    ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    app_id INT,
+    type TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events(user_id);
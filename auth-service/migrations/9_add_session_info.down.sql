@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS session_created_at,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS ip;
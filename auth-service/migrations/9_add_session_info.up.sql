@@ -0,0 +1,12 @@
+-- Сессия — семья refresh токенов. Данные клиента записываются при входе
+-- и переносятся на каждый следующий токен семьи.
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS session_created_at TIMESTAMPTZ;
+
+UPDATE refresh_tokens SET session_created_at = created_at WHERE session_created_at IS NULL;
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN session_created_at SET DEFAULT now(),
+    ALTER COLUMN session_created_at SET NOT NULL;
@@ -37,9 +37,22 @@ func New(t *testing.T) *Suite {
 	application := app.NewApp(
 		log,
 		cfg.GRPC.Port,
+		cfg.GRPC.TrustedProxies,
+		cfg.GRPC.InternalAppIDs,
 		cfg.StoragePath,
 		cfg.AccessTokenTTL,
 		cfg.RefreshTokenTTL,
+		cfg.PasswordReset,
+		cfg.EmailVerification,
+		cfg.MFA,
+		cfg.Signing,
+		cfg.BruteForce,
+		cfg.PasswordHash,
+		cfg.PasswordPolicy,
+		cfg.OAuth,
+		cfg.Federation,
+		cfg.Audit,
+		cfg.Mailer,
 	)
 
 	go func() {
@@ -63,7 +76,7 @@ func New(t *testing.T) *Suite {
 
 	t.Cleanup(func() {
 		conn.Close()
-		application.GRPCServer.Stop()
+		application.Stop()
 		cancel()
 	})
 
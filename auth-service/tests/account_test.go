@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serviceContext добавляет учётные данные тестового приложения, без которых внутренние RPC
+// (ListAccountErasures, ExportUserData, ListActiveBans) не отвечают
+func serviceContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-app-id", strconv.Itoa(appID), "x-app-secret", appSecret)
+}
+
+func TestAccount_DeleteSelf(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	// события до удаления не интересны, читаем только новые
+	var afterID int64
+	for {
+		before, err := st.AuthClient.ListAccountErasures(serviceContext(ctx), &ssov1.ListAccountErasuresRequest{AfterId: afterID, Limit: 1000})
+		require.NoError(t, err)
+		if len(before.GetErasures()) == 0 {
+			break
+		}
+		afterID = before.GetErasures()[len(before.GetErasures())-1].GetId()
+	}
+
+	_, err := st.AuthClient.DeleteAccount(ctx, &ssov1.DeleteAccountRequest{
+		AccessToken: login.GetAccessToken(), AppId: appID, Password: "wrong-" + password,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.DeleteAccount(ctx, &ssov1.DeleteAccountRequest{
+		AccessToken: login.GetAccessToken(), AppId: appID, Password: password,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: login.GetRefreshToken(), AppId: appID})
+	require.Error(t, err)
+
+	// выданный до удаления access token ещё не истёк, но уже не принимается
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// сервисы, проверяющие токены сами, узнают об удалении из списка блокировок
+	bans, err := st.AuthClient.ListActiveBans(serviceContext(ctx), &ssov1.ListActiveBansRequest{})
+	require.NoError(t, err)
+	var deleted bool
+	for _, ban := range bans.GetBans() {
+		if ban.GetUserId() == login.GetUserId() {
+			deleted = ban.GetKind() == "deleted"
+		}
+	}
+	assert.True(t, deleted)
+
+	erasures, err := st.AuthClient.ListAccountErasures(serviceContext(ctx), &ssov1.ListAccountErasuresRequest{AfterId: afterID})
+	require.NoError(t, err)
+	var userIDs []int64
+	for _, erasure := range erasures.GetErasures() {
+		assert.Greater(t, erasure.GetId(), afterID)
+		userIDs = append(userIDs, erasure.GetUserId())
+	}
+	assert.Contains(t, userIDs, login.GetUserId())
+
+	// адрес снова свободен для регистрации
+	registerAndLogin(t, ctx, st, email, password)
+}
+
+func TestAccount_DeleteByAdmin(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	user := registerAndLogin(t, ctx, st, email, password)
+	other := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.DeleteAccount(ctx, &ssov1.DeleteAccountRequest{
+		AccessToken: other.GetAccessToken(), AppId: appID, UserId: user.GetUserId(),
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.DeleteAccount(ctx, &ssov1.DeleteAccountRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, UserId: user.GetUserId(),
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.DeleteAccount(ctx, &ssov1.DeleteAccountRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, UserId: user.GetUserId(),
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAccount_ExportUserData(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	_, err := st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: login.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+
+	export, err := st.AuthClient.ExportUserData(serviceContext(ctx), &ssov1.ExportUserDataRequest{UserId: login.GetUserId()})
+	require.NoError(t, err)
+	assert.Equal(t, email, export.GetProfile().GetEmail())
+
+	// исходный токен заменён новым, оба остаются в выгрузке
+	require.Len(t, export.GetRefreshTokens(), 2)
+	assert.NotZero(t, export.GetRefreshTokens()[0].GetRotatedAt())
+	assert.Zero(t, export.GetRefreshTokens()[1].GetRotatedAt())
+	assert.Equal(t, export.GetRefreshTokens()[0].GetSessionId(), export.GetRefreshTokens()[1].GetSessionId())
+
+	_, err = st.AuthClient.ExportUserData(serviceContext(ctx), &ssov1.ExportUserDataRequest{UserId: 1 << 40})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAccount_InternalRPCs_RequireServiceCredentials(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.ExportUserData(ctx, &ssov1.ExportUserDataRequest{UserId: login.GetUserId()})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.ListActiveBans(ctx, &ssov1.ListActiveBansRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	wrongSecret := metadata.AppendToOutgoingContext(ctx, "x-app-id", strconv.Itoa(appID), "x-app-secret", "wrong-"+appSecret)
+	_, err = st.AuthClient.ListAccountErasures(wrongSecret, &ssov1.ListAccountErasuresRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.ListAccountErasures(serviceContext(ctx), &ssov1.ListAccountErasuresRequest{Limit: 1})
+	require.NoError(t, err)
+
+	// у OAuth-клиента есть свой секрет, но он не сервис системы
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+	client := createApp(t, ctx, st, admin.GetAccessToken(), &ssov1.AppSettings{RedirectUris: []string{"https://client.example/callback"}})
+
+	clientCtx := metadata.AppendToOutgoingContext(ctx, "x-app-id", strconv.Itoa(int(client.GetApp().GetId())), "x-app-secret", client.GetSecret())
+	_, err = st.AuthClient.ExportUserData(clientCtx, &ssov1.ExportUserDataRequest{UserId: login.GetUserId()})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// createApp создаёт приложение от имени администратора с уникальным именем
+func createApp(t *testing.T, ctx context.Context, st *suite.Suite, adminToken string, settings *ssov1.AppSettings) *ssov1.CreateAppResponse {
+	t.Helper()
+
+	settings.Name = "app " + gofakeit.Email()
+	resp, err := st.AuthClient.CreateApp(ctx, &ssov1.CreateAppRequest{AccessToken: adminToken, AppId: appID, Settings: settings})
+	require.NoError(t, err)
+
+	return resp
+}
+
+func TestApps_CreateLoginAndDisable(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	created := createApp(t, ctx, st, admin.GetAccessToken(), &ssov1.AppSettings{
+		AllowedOrigins:        []string{"https://mobile.example"},
+		AccessTokenTtlSeconds: 300,
+	})
+	assert.NotEmpty(t, created.GetSecret())
+	assert.Equal(t, "confidential", created.GetApp().GetSettings().GetClientType())
+	assert.False(t, created.GetApp().GetDisabled())
+	newAppID := created.GetApp().GetId()
+
+	list, err := st.AuthClient.ListApps(ctx, &ssov1.ListAppsRequest{AccessToken: admin.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	var ids []int32
+	for _, app := range list.GetApps() {
+		ids = append(ids, app.GetId())
+	}
+	assert.Contains(t, ids, newAppID)
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	registerAndLogin(t, ctx, st, email, password)
+
+	login, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: newAppID})
+	require.NoError(t, err)
+
+	// срок жизни access token берётся из настроек приложения, а не из конфига
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: login.GetAccessToken(), AppId: newAppID})
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(login.GetAccessToken(), claims)
+	require.NoError(t, err)
+	exp, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), exp.Time, 5*time.Second)
+
+	_, err = st.AuthClient.DisableApp(ctx, &ssov1.DisableAppRequest{AccessToken: admin.GetAccessToken(), AppId: appID, TargetAppId: newAppID})
+	require.NoError(t, err)
+
+	// ранее выданные токены перестают приниматься сразу после отключения
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: login.GetAccessToken(), AppId: newAppID})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: login.GetRefreshToken(), AppId: newAppID})
+	require.Error(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: newAppID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestApps_UpdateAndRotateSecret(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	created := createApp(t, ctx, st, admin.GetAccessToken(), &ssov1.AppSettings{})
+
+	settings := created.GetApp().GetSettings()
+	settings.AllowedOrigins = []string{"https://updated.example"}
+	settings.RefreshTokenTtlSeconds = 3600
+
+	updated, err := st.AuthClient.UpdateApp(ctx, &ssov1.UpdateAppRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, TargetAppId: created.GetApp().GetId(), Settings: settings,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://updated.example"}, updated.GetApp().GetSettings().GetAllowedOrigins())
+	assert.Equal(t, int64(3600), updated.GetApp().GetSettings().GetRefreshTokenTtlSeconds())
+
+	rotated, err := st.AuthClient.RotateAppSecret(ctx, &ssov1.RotateAppSecretRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, TargetAppId: created.GetApp().GetId(),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, rotated.GetSecret())
+	assert.NotEqual(t, created.GetSecret(), rotated.GetSecret())
+
+	_, err = st.AuthClient.RotateAppSecret(ctx, &ssov1.RotateAppSecretRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, TargetAppId: 1 << 30,
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestApps_Errors(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+	user := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.ListApps(ctx, &ssov1.ListAppsRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.CreateApp(ctx, &ssov1.CreateAppRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID,
+		Settings: &ssov1.AppSettings{Name: "app " + gofakeit.Email(), AllowedOrigins: []string{"https://a.example/path"}},
+	})
+	grpcStatus, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, grpcStatus.Code())
+	require.Len(t, grpcStatus.Details(), 1)
+	badRequest, ok := grpcStatus.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	assert.Equal(t, "settings.allowed_origins", badRequest.GetFieldViolations()[0].GetField())
+
+	created := createApp(t, ctx, st, admin.GetAccessToken(), &ssov1.AppSettings{})
+	_, err = st.AuthClient.CreateApp(ctx, &ssov1.CreateAppRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID,
+		Settings: &ssov1.AppSettings{Name: created.GetApp().GetSettings().GetName()},
+	})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = st.AuthClient.DisableApp(ctx, &ssov1.DisableAppRequest{AccessToken: admin.GetAccessToken(), AppId: appID, TargetAppId: appID})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAudit_RecordsAuthenticationEvents(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	clientCtx, ip := fromIP(ctx)
+
+	_, err := st.AuthClient.Register(clientCtx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(clientCtx, &ssov1.LoginRequest{Email: email, Password: "wrong-" + password, AppId: appID})
+	require.Error(t, err)
+
+	login, err := st.AuthClient.Login(clientCtx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	refreshed, err := st.AuthClient.RefreshTokens(clientCtx, &ssov1.RefreshTokenRequest{RefreshToken: login.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Logout(clientCtx, &ssov1.LogoutRequest{RefreshToken: refreshed.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+
+	resp, err := st.AuthClient.ListAuditEvents(ctx, &ssov1.ListAuditEventsRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		Filter:      &ssov1.AuditEventFilter{UserId: login.GetUserId()},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetNextPageToken())
+
+	// события от новых к старым
+	var got []string
+	for _, event := range resp.GetEvents() {
+		got = append(got, event.GetType()+"/"+event.GetOutcome())
+		assert.Equal(t, login.GetUserId(), event.GetUserId())
+		assert.Equal(t, ip, event.GetIp())
+	}
+	assert.Equal(t, []string{
+		"logout/success",
+		"refresh/success",
+		"login/success",
+		"login/failure",
+		"register/success",
+	}, got)
+
+	var details map[string]any
+	require.NoError(t, json.Unmarshal([]byte(resp.GetEvents()[3].GetDetails()), &details))
+	assert.Equal(t, "invalid_credentials", details["reason"])
+
+	failures, err := st.AuthClient.ListAuditEvents(ctx, &ssov1.ListAuditEventsRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		Filter:      &ssov1.AuditEventFilter{UserId: login.GetUserId(), Type: "login", Outcome: "failure"},
+	})
+	require.NoError(t, err)
+	require.Len(t, failures.GetEvents(), 1)
+	assert.Equal(t, int32(appID), failures.GetEvents()[0].GetAppId())
+}
+
+func TestAudit_Pagination(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	user := registerAndLogin(t, ctx, st, email, password)
+	for range 2 {
+		_, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+		require.NoError(t, err)
+	}
+
+	var (
+		ids       []int64
+		pageToken string
+		pages     int
+	)
+	for {
+		resp, err := st.AuthClient.ListAuditEvents(ctx, &ssov1.ListAuditEventsRequest{
+			AccessToken: admin.GetAccessToken(),
+			AppId:       appID,
+			Filter:      &ssov1.AuditEventFilter{UserId: user.GetUserId()},
+			PageSize:    2,
+			PageToken:   pageToken,
+		})
+		require.NoError(t, err)
+		pages++
+
+		for _, event := range resp.GetEvents() {
+			if len(ids) > 0 {
+				assert.Less(t, event.GetId(), ids[len(ids)-1])
+			}
+			ids = append(ids, event.GetId())
+		}
+
+		pageToken = resp.GetNextPageToken()
+		if pageToken == "" {
+			break
+		}
+	}
+
+	// регистрация и три входа
+	assert.Len(t, ids, 4)
+	assert.Equal(t, 2, pages)
+}
+
+func TestAudit_RequiresPermission(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	user := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.ListAuditEvents(ctx, &ssov1.ListAuditEventsRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	_, err = st.AuthClient.ListAuditEvents(ctx, &ssov1.ListAuditEventsRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		Filter:      &ssov1.AuditEventFilter{Outcome: "maybe"},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.ListAuditEvents(ctx, &ssov1.ListAuditEventsRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		PageToken:   "not-a-token",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
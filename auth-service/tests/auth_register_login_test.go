@@ -45,9 +45,10 @@ func TestRegisterLogin_Login_HappyPath(t *testing.T) {
 	require.NotEmpty(t, accessToken)
 	require.NotEmpty(t, refreshToken)
 
-	accessTokenParsed, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(appSecret), nil
-	})
+	// токены подписаны ключом сервиса, открытая часть которого опубликована в JWKS
+	keyFunc := jwksKeyFunc(t, ctx, st)
+
+	accessTokenParsed, err := jwt.Parse(accessToken, keyFunc)
 	require.NoError(t, err)
 
 	accessClaims, ok := accessTokenParsed.Claims.(jwt.MapClaims)
@@ -63,9 +64,7 @@ func TestRegisterLogin_Login_HappyPath(t *testing.T) {
 	assert.InDelta(t, loginTime.Add(st.Cfg.AccessTokenTTL).Unix(), accessClaims["exp"].(float64), deltaSeconds)
 
 	// проверка refresh token`a
-	refreshTokenParsed, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(appSecret), nil
-	})
+	refreshTokenParsed, err := jwt.Parse(refreshToken, keyFunc)
 	require.NoError(t, err)
 
 	refreshClaims, ok := refreshTokenParsed.Claims.(jwt.MapClaims)
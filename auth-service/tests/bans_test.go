@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBans_BanBlocksLoginRefreshAndAccessToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	user := registerAndLogin(t, ctx, st, email, password)
+
+	_, err := st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+
+	banned, err := st.AuthClient.BanUser(ctx, &ssov1.BanUserRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		UserId:      user.GetUserId(),
+		Reason:      "spam",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ban", banned.GetBan().GetKind())
+	assert.Zero(t, banned.GetBan().GetExpiresAt())
+	assert.Equal(t, admin.GetUserId(), banned.GetBan().GetBannedBy())
+
+	// выданный до бана access token не принимается, хотя ещё не истёк
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: user.GetRefreshToken(), AppId: appID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "user is banned", status.Convert(err).Message())
+
+	active, err := st.AuthClient.ListActiveBans(serviceContext(ctx), &ssov1.ListActiveBansRequest{})
+	require.NoError(t, err)
+	var found bool
+	for _, ban := range active.GetBans() {
+		if ban.GetUserId() == user.GetUserId() {
+			found = true
+			assert.Equal(t, "spam", ban.GetReason())
+		}
+	}
+	assert.True(t, found)
+
+	_, err = st.AuthClient.UnbanUser(ctx, &ssov1.UnbanUserRequest{AccessToken: admin.GetAccessToken(), AppId: appID, UserId: user.GetUserId()})
+	require.NoError(t, err)
+
+	// сессии не отзывались: после снятия бана старые токены снова действуют
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: user.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+}
+
+func TestBans_Suspension(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	user := registerAndLogin(t, ctx, st, email, password)
+
+	_, err := st.AuthClient.SuspendUser(ctx, &ssov1.SuspendUserRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		UserId:      user.GetUserId(),
+		Reason:      "flood",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	suspended, err := st.AuthClient.SuspendUser(ctx, &ssov1.SuspendUserRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		UserId:      user.GetUserId(),
+		Reason:      "flood",
+		ExpiresAt:   until.Unix(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "suspension", suspended.GetBan().GetKind())
+	assert.Equal(t, until.Unix(), suspended.GetBan().GetExpiresAt())
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "user is suspended until "+until.UTC().Format(time.RFC3339), status.Convert(err).Message())
+
+	// неверный пароль не раскрывает приостановку
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: "wrong-" + password, AppId: appID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBans_RequiresPermission(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	user := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	other := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.BanUser(ctx, &ssov1.BanUserRequest{AccessToken: user.GetAccessToken(), AppId: appID, UserId: other.GetUserId(), Reason: "spam"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	_, err = st.AuthClient.BanUser(ctx, &ssov1.BanUserRequest{AccessToken: admin.GetAccessToken(), AppId: appID, UserId: other.GetUserId()})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.BanUser(ctx, &ssov1.BanUserRequest{AccessToken: admin.GetAccessToken(), AppId: appID, UserId: admin.GetUserId(), Reason: "test"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = st.AuthClient.UnbanUser(ctx, &ssov1.UnbanUserRequest{AccessToken: admin.GetAccessToken(), AppId: appID, UserId: other.GetUserId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// модератор банит обычных пользователей, но не администраторов
+	_, err = st.AuthClient.AssignRole(ctx, &ssov1.AssignRoleRequest{AccessToken: admin.GetAccessToken(), AppId: appID, UserId: user.GetUserId(), Role: "moderator"})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.BanUser(ctx, &ssov1.BanUserRequest{AccessToken: user.GetAccessToken(), AppId: appID, UserId: admin.GetUserId(), Reason: "revenge"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.BanUser(ctx, &ssov1.BanUserRequest{AccessToken: user.GetAccessToken(), AppId: appID, UserId: other.GetUserId(), Reason: "spam"})
+	require.NoError(t, err)
+}
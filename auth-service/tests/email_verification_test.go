@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmailVerification_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	validated, err := st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.False(t, validated.GetEmailVerified())
+
+	// письмо с подтверждением отправляется при регистрации
+	token := readMailToken(t, st)
+
+	_, err = st.AuthClient.VerifyEmail(ctx, &ssov1.VerifyEmailRequest{Token: token})
+	require.NoError(t, err)
+
+	// новый access token уже содержит подтверждённый email
+	login, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	validated, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.True(t, validated.GetEmailVerified())
+
+	// токен одноразовый
+	_, err = st.AuthClient.VerifyEmail(ctx, &ssov1.VerifyEmailRequest{Token: token})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// для подтверждённого email письмо повторно не отправляется
+	before, err := filepath.Glob(filepath.Join(st.MailDir, "*.eml"))
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ResendVerification(ctx, &ssov1.ResendVerificationRequest{Email: email})
+	require.NoError(t, err)
+
+	after, err := filepath.Glob(filepath.Join(st.MailDir, "*.eml"))
+	require.NoError(t, err)
+	assert.Len(t, after, len(before))
+}
+
+func TestEmailVerification_Resend(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	registerAndLogin(t, ctx, st, email, randomFakePassword())
+
+	first := readMailToken(t, st)
+
+	_, err := st.AuthClient.ResendVerification(ctx, &ssov1.ResendVerificationRequest{Email: email})
+	require.NoError(t, err)
+
+	second := readMailToken(t, st)
+	require.NotEqual(t, first, second)
+
+	_, err = st.AuthClient.VerifyEmail(ctx, &ssov1.VerifyEmailRequest{Token: second})
+	require.NoError(t, err)
+}
+
+func TestEmailVerification_UnknownEmail_SameResponse(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.AuthClient.ResendVerification(ctx, &ssov1.ResendVerificationRequest{Email: gofakeit.Email()})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	files, err := filepath.Glob(filepath.Join(st.MailDir, "*.eml"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestEmailVerification_FailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.VerifyEmail(ctx, &ssov1.VerifyEmailRequest{Token: ""})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "token is required")
+
+	_, err = st.AuthClient.VerifyEmail(ctx, &ssov1.VerifyEmailRequest{Token: "some-token"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "invalid or expired verification token")
+
+	_, err = st.AuthClient.ResendVerification(ctx, &ssov1.ResendVerificationRequest{Email: ""})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRegister_InvalidEmail(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	for _, email := range []string{"not-an-email", "user@", "Name <user@example.com>"} {
+		_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: randomFakePassword()})
+		require.Error(t, err, email)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, err.Error(), "email is invalid")
+	}
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/internal/config"
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/lib/oidc/oidctest"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const corpProvider = "corp"
+
+// federationEnv — сервис с подключённым тестовым провайдером и HTTP-сервер /federation/
+type federationEnv struct {
+	ctx    context.Context
+	st     *suite.Suite
+	idp    *oidctest.Provider
+	server *httptest.Server
+	client *http.Client
+}
+
+// newFederationEnv поднимает тестового провайдера и сервис, который его знает как corp.
+// Адрес сервера /federation/ нужен заранее: из него собирается redirect_uri, зарегистрированный у провайдера.
+func newFederationEnv(t *testing.T) federationEnv {
+	t.Helper()
+
+	idp := oidctest.NewProvider(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, st := suite.NewWithConfig(t, func(cfg *config.Config) {
+		cfg.OAuth.Issuer = "http://" + listener.Addr().String()
+		cfg.Federation.Providers = []config.IdentityProviderConfig{{
+			Name:         corpProvider,
+			Issuer:       idp.Issuer,
+			ClientID:     oidctest.ClientID,
+			ClientSecret: oidctest.ClientSecret,
+			Scopes:       []string{"email"},
+		}}
+	})
+
+	server := httptest.NewUnstartedServer(st.App.FederationHandler)
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	return federationEnv{ctx: ctx, st: st, idp: idp, server: server, client: client}
+}
+
+// follow выполняет запрос и возвращает адрес, на который сервер отправил браузер
+func (env federationEnv) follow(t *testing.T, req *http.Request) *url.URL {
+	t.Helper()
+
+	resp, err := env.client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode, req.URL.String())
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	return location
+}
+
+func (env federationEnv) get(t *testing.T, target string) *url.URL {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	require.NoError(t, err)
+
+	return env.follow(t, req)
+}
+
+// finish проходит вход у провайдера и callback, возвращая параметры из фрагмента страницы результата
+func (env federationEnv) finish(t *testing.T, idpURL string) url.Values {
+	t.Helper()
+
+	callback := env.get(t, idpURL)
+	require.Equal(t, "/federation/"+corpProvider+"/callback", callback.Path)
+
+	result := env.get(t, callback.String())
+	assert.Equal(t, env.st.Cfg.Federation.ResultURL, (&url.URL{Scheme: result.Scheme, Host: result.Host, Path: result.Path}).String())
+
+	params, err := url.ParseQuery(result.Fragment)
+	require.NoError(t, err)
+
+	return params
+}
+
+// login проходит федеративный вход в приложение appID
+func (env federationEnv) login(t *testing.T) url.Values {
+	t.Helper()
+
+	idpURL := env.get(t, env.server.URL+"/federation/"+corpProvider+"/login?app_id="+strconv.Itoa(appID))
+	require.Equal(t, env.idp.Issuer+"/authorize", (&url.URL{Scheme: idpURL.Scheme, Host: idpURL.Host, Path: idpURL.Path}).String())
+
+	return env.finish(t, idpURL.String())
+}
+
+func TestFederation_RegistersAndLogsIn(t *testing.T) {
+	env := newFederationEnv(t)
+
+	external := models.ExternalIdentity{Subject: gofakeit.Email(), Email: gofakeit.Email(), EmailVerified: true}
+	env.idp.SetUser(external)
+
+	first := env.login(t)
+	require.Empty(t, first.Get("error"))
+	require.NotEmpty(t, first.Get("access_token"))
+	require.NotEmpty(t, first.Get("refresh_token"))
+
+	validated, err := env.st.AuthClient.ValidateToken(env.ctx, &ssov1.ValidateTokenRequest{
+		AccessToken: first.Get("access_token"),
+		AppId:       appID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, external.Email, validated.GetEmail())
+	assert.Equal(t, first.Get("user_id"), strconv.FormatInt(validated.GetUserId(), 10))
+
+	// повторный вход находит того же пользователя по учётной записи провайдера
+	second := env.login(t)
+	assert.Equal(t, first.Get("user_id"), second.Get("user_id"))
+}
+
+func TestFederation_ExistingAccountRequiresExplicitLink(t *testing.T) {
+	env := newFederationEnv(t)
+
+	email := gofakeit.Email()
+	local := registerAndLogin(t, env.ctx, env.st, email, randomFakePassword())
+
+	// email провайдера подтверждён, но у нас — нет: автоматически не привязываем
+	env.idp.SetUser(models.ExternalIdentity{Subject: gofakeit.Email(), Email: email, EmailVerified: true})
+
+	refused := env.login(t)
+	assert.Equal(t, "account_exists", refused.Get("error"))
+	assert.Empty(t, refused.Get("access_token"))
+
+	// пользователь входит сам и привязывает провайдера явно
+	req, err := http.NewRequest(http.MethodPost, env.server.URL+"/federation/"+corpProvider+"/link?app_id="+strconv.Itoa(appID), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+local.GetAccessToken())
+
+	resp, err := env.client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var link struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&link))
+
+	linked := env.finish(t, link.RedirectTo)
+	assert.Equal(t, corpProvider, linked.Get("linked"))
+	assert.Empty(t, linked.Get("access_token"))
+
+	loggedIn := env.login(t)
+	require.Empty(t, loggedIn.Get("error"))
+	assert.Equal(t, strconv.FormatInt(local.GetUserId(), 10), loggedIn.Get("user_id"))
+}
+
+func TestFederation_InvalidState(t *testing.T) {
+	env := newFederationEnv(t)
+
+	result := env.get(t, env.server.URL+"/federation/"+corpProvider+"/callback?state=forged&code=code-1")
+
+	params, err := url.ParseQuery(result.Fragment)
+	require.NoError(t, err)
+	assert.Equal(t, "invalid_state", params.Get("error"))
+}
+
+func TestFederation_UnknownProvider(t *testing.T) {
+	env := newFederationEnv(t)
+
+	resp, err := env.client.Get(env.server.URL + "/federation/unknown/login?app_id=" + strconv.Itoa(appID))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// jwksKeyFunc проверяет подпись токена открытым ключом из GetJWKS, найденным по kid
+func jwksKeyFunc(t *testing.T, ctx context.Context, st *suite.Suite) jwt.Keyfunc {
+	t.Helper()
+
+	resp, err := st.AuthClient.GetJWKS(ctx, &ssov1.GetJWKSRequest{})
+	require.NoError(t, err)
+
+	keys := make(map[string]interface{}, len(resp.GetKeys()))
+	for _, jwk := range resp.GetKeys() {
+		keys[jwk.GetKid()] = publicKey(t, jwk)
+	}
+
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys[kid]
+		if !ok {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return key, nil
+	}
+}
+
+func publicKey(t *testing.T, jwk *ssov1.JWK) interface{} {
+	t.Helper()
+
+	decode := func(value string) []byte {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		require.NoError(t, err)
+		return data
+	}
+
+	switch jwk.GetKty() {
+	case "OKP":
+		require.Equal(t, "Ed25519", jwk.GetCrv())
+		return ed25519.PublicKey(decode(jwk.GetX()))
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(jwk.GetN())),
+			E: int(new(big.Int).SetBytes(decode(jwk.GetE())).Int64()),
+		}
+	default:
+		t.Fatalf("unexpected key type %q", jwk.GetKty())
+		return nil
+	}
+}
+
+func TestJWKS_PublishesSigningKey(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.AuthClient.GetJWKS(ctx, &ssov1.GetJWKSRequest{})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.GetKeys())
+
+	for _, jwk := range resp.GetKeys() {
+		assert.NotEmpty(t, jwk.GetKid())
+		assert.Equal(t, "sig", jwk.GetUse())
+		assert.Equal(t, st.Cfg.Signing.Algorithm, jwk.GetAlg())
+	}
+
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	token, err := jwt.Parse(login.GetAccessToken(), jwksKeyFunc(t, ctx, st), jwt.WithValidMethods([]string{st.Cfg.Signing.Algorithm}))
+	require.NoError(t, err)
+	assert.True(t, token.Valid)
+}
+
+func TestJWKS_HS256TokenRejectedWhenAppHasKey(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	validated, err := st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+
+	// после входа у приложения есть ключ подписи, и токен, подписанный его секретом, не принимается:
+	// иначе его мог бы подделать любой, кто знает секрет
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"uid":    validated.GetUserId(),
+		"email":  validated.GetEmail(),
+		"app_id": appID,
+		"typ":    "access",
+		"exp":    time.Now().Add(st.Cfg.AccessTokenTTL).Unix(),
+	})
+	at, err := legacy.SignedString([]byte(appSecret))
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: at, AppId: appID})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
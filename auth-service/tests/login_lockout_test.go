@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fromIP имитирует запрос через grpc-gateway от клиента со случайным адресом,
+// чтобы счётчики по IP не пересекались с другими тестами
+func fromIP(ctx context.Context) (context.Context, string) {
+	ip := fmt.Sprintf("10.%d.%d.%d", rand.Intn(256), rand.Intn(256), 1+rand.Intn(254))
+	return metadata.AppendToOutgoingContext(ctx, "x-forwarded-for", ip), ip
+}
+
+func requireLocked(t *testing.T, err error, header metadata.MD) {
+	t.Helper()
+
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	retryAfter := header.Get("retry-after")
+	require.Len(t, retryAfter, 1)
+	seconds, err := strconv.Atoi(retryAfter[0])
+	require.NoError(t, err)
+	assert.Positive(t, seconds)
+}
+
+func TestLoginLockout_Email(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+
+	ipCtx, _ := fromIP(ctx)
+
+	for i := 0; i < st.Cfg.BruteForce.EmailThreshold; i++ {
+		_, err := st.AuthClient.Login(ipCtx, &ssov1.LoginRequest{Email: email, Password: "wrong-" + password, AppId: appID})
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	// во время блокировки не проходит даже верный пароль, в том числе с другого адреса
+	otherCtx, _ := fromIP(ctx)
+	var header metadata.MD
+	_, err = st.AuthClient.Login(otherCtx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID}, grpc.Header(&header))
+	requireLocked(t, err, header)
+
+	// администратор видит блокировку и снимает её
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	list, err := st.AuthClient.ListLoginLockouts(ctx, &ssov1.ListLoginLockoutsRequest{AccessToken: admin.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+
+	var found *ssov1.LoginLockout
+	for _, lockout := range list.GetLockouts() {
+		if lockout.GetKind() == "email" && lockout.GetKey() == email {
+			found = lockout
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, int32(st.Cfg.BruteForce.EmailThreshold), found.GetFailures())
+	assert.Greater(t, found.GetLockedUntil(), found.GetLastFailureAt())
+
+	_, err = st.AuthClient.ClearLoginLockout(ctx, &ssov1.ClearLoginLockoutRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		Kind:        "email",
+		Key:         email,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(otherCtx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+}
+
+func TestLoginLockout_IP(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	ipCtx, ip := fromIP(ctx)
+
+	// перебор разных аккаунтов с одного адреса
+	for i := 0; i < st.Cfg.BruteForce.IPThreshold; i++ {
+		_, err := st.AuthClient.Login(ipCtx, &ssov1.LoginRequest{Email: gofakeit.Email(), Password: randomFakePassword(), AppId: appID})
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = st.AuthClient.Login(ipCtx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID}, grpc.Header(&header))
+	requireLocked(t, err, header)
+
+	// с другого адреса вход работает
+	otherCtx, _ := fromIP(ctx)
+	_, err = st.AuthClient.Login(otherCtx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	_, err = st.AuthClient.ClearLoginLockout(ctx, &ssov1.ClearLoginLockoutRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		Kind:        "ip",
+		Key:         ip,
+	})
+	require.NoError(t, err)
+}
+
+func TestLoginLockout_SpoofedForwardedFor(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	// клиент подставляет в x-forwarded-for новый адрес при каждой попытке,
+	// а grpc-gateway дописывает после него настоящий
+	_, ip := fromIP(ctx)
+	spoofed := func() context.Context {
+		spoof, _ := fromIP(ctx)
+		return metadata.AppendToOutgoingContext(spoof, "x-forwarded-for", ip)
+	}
+
+	for i := 0; i < st.Cfg.BruteForce.IPThreshold; i++ {
+		_, err := st.AuthClient.Login(spoofed(), &ssov1.LoginRequest{Email: gofakeit.Email(), Password: randomFakePassword(), AppId: appID})
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = st.AuthClient.Login(spoofed(), &ssov1.LoginRequest{Email: email, Password: password, AppId: appID}, grpc.Header(&header))
+	requireLocked(t, err, header)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	_, err = st.AuthClient.ClearLoginLockout(ctx, &ssov1.ClearLoginLockoutRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+		Kind:        "ip",
+		Key:         ip,
+	})
+	require.NoError(t, err)
+}
+
+func TestLoginLockout_AdminFailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	user := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.ListLoginLockouts(ctx, &ssov1.ListLoginLockoutsRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	makeAdmin(t, st, user.GetUserId())
+
+	_, err = st.AuthClient.ClearLoginLockout(ctx, &ssov1.ClearLoginLockoutRequest{
+		AccessToken: user.GetAccessToken(),
+		AppId:       appID,
+		Kind:        "user",
+		Key:         "someone",
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.ClearLoginLockout(ctx, &ssov1.ClearLoginLockoutRequest{
+		AccessToken: user.GetAccessToken(),
+		AppId:       appID,
+		Kind:        "email",
+		Key:         gofakeit.Email(),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
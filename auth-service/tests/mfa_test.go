@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"github.com/14kear/forum-project/auth-service/internal/lib/totp"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMFA_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	enroll, err := st.AuthClient.EnrollTOTP(ctx, &ssov1.EnrollTOTPRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	require.NotEmpty(t, enroll.GetSecret())
+
+	uri, err := url.Parse(enroll.GetOtpauthUri())
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, enroll.GetSecret(), uri.Query().Get("secret"))
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(enroll.GetSecret(), step)
+	require.NoError(t, err)
+
+	confirm, err := st.AuthClient.ConfirmTOTP(ctx, &ssov1.ConfirmTOTPRequest{AccessToken: login.GetAccessToken(), AppId: appID, Code: code})
+	require.NoError(t, err)
+	require.Len(t, confirm.GetRecoveryCodes(), 10)
+
+	// после включения TOTP пароль выдаёт только MFA-токен
+	first, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+	assert.True(t, first.GetMfaRequired())
+	assert.NotEmpty(t, first.GetMfaToken())
+	assert.Empty(t, first.GetAccessToken())
+	assert.Empty(t, first.GetRefreshToken())
+
+	// неверный код не завершает вход
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: first.GetMfaToken(), Code: "000000"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// код, которым подтверждали TOTP, повторно не принимается
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: first.GetMfaToken(), Code: code})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// код следующего шага укладывается в допустимое расхождение часов
+	next, err := totp.Code(enroll.GetSecret(), step+1)
+	require.NoError(t, err)
+
+	verified, err := st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: first.GetMfaToken(), Code: next})
+	require.NoError(t, err)
+	assert.NotEmpty(t, verified.GetAccessToken())
+	assert.NotEmpty(t, verified.GetRefreshToken())
+
+	// MFA-токен одноразовый
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: first.GetMfaToken(), Code: confirm.GetRecoveryCodes()[0]})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// вход по коду восстановления, каждый код одноразовый
+	second, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: second.GetMfaToken(), Code: confirm.GetRecoveryCodes()[0]})
+	require.NoError(t, err)
+
+	third, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: third.GetMfaToken(), Code: confirm.GetRecoveryCodes()[0]})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// после отключения вход снова одношаговый
+	_, err = st.AuthClient.DisableTOTP(ctx, &ssov1.DisableTOTPRequest{
+		AccessToken: verified.GetAccessToken(),
+		AppId:       appID,
+		Code:        confirm.GetRecoveryCodes()[1],
+	})
+	require.NoError(t, err)
+
+	plain, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+	assert.False(t, plain.GetMfaRequired())
+	assert.NotEmpty(t, plain.GetAccessToken())
+}
+
+func TestMFA_ChallengeAttemptsLimited(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	enroll, err := st.AuthClient.EnrollTOTP(ctx, &ssov1.EnrollTOTPRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+
+	code, err := totp.Code(enroll.GetSecret(), totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	confirm, err := st.AuthClient.ConfirmTOTP(ctx, &ssov1.ConfirmTOTPRequest{AccessToken: login.GetAccessToken(), AppId: appID, Code: code})
+	require.NoError(t, err)
+
+	challenge, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	for i := 0; i < st.Cfg.MFA.MaxAttempts; i++ {
+		_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: challenge.GetMfaToken(), Code: "000000"})
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	// попытки исчерпаны: даже верный код не принимается, нужно начать вход заново
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: challenge.GetMfaToken(), Code: confirm.GetRecoveryCodes()[0]})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestMFA_FailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.EnrollTOTP(ctx, &ssov1.EnrollTOTPRequest{AccessToken: "bad-token", AppId: appID})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// без EnrollTOTP подтверждать нечего
+	_, err = st.AuthClient.ConfirmTOTP(ctx, &ssov1.ConfirmTOTPRequest{AccessToken: login.GetAccessToken(), AppId: appID, Code: "123456"})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = st.AuthClient.DisableTOTP(ctx, &ssov1.DisableTOTPRequest{AccessToken: login.GetAccessToken(), AppId: appID, Code: "123456"})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{MfaToken: "", Code: "123456"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
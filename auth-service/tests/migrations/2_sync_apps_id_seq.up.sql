@@ -0,0 +1,2 @@
+-- тестовое приложение вставлено с явным id, последовательность должна его обогнать
+SELECT setval(pg_get_serial_sequence('apps', 'id'), (SELECT MAX(id) FROM apps));
@@ -0,0 +1,362 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/internal/storage/postgres"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const clientRedirectURI = "https://client.example/callback"
+
+type oauthClient struct {
+	id     int
+	secret string
+}
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	Scope        string `json:"scope"`
+	Error        string `json:"error"`
+}
+
+type oauthAuthorizeResponse struct {
+	RedirectTo      string   `json:"redirect_to"`
+	ConsentRequired bool     `json:"consent_required"`
+	ClientName      string   `json:"client_name"`
+	Scope           []string `json:"scope"`
+	Error           string   `json:"error"`
+}
+
+// registerOAuthClient регистрирует клиента так же, как cmd/oauth-clients
+func registerOAuthClient(t *testing.T, st *suite.Suite, clientType string) oauthClient {
+	t.Helper()
+
+	db, err := postgres.New(st.Cfg.StoragePath)
+	require.NoError(t, err)
+
+	client := oauthClient{secret: gofakeit.Password(true, true, true, false, false, 32)}
+	client.id, err = db.SaveOAuthClient(context.Background(), models.App{
+		Name:         "oauth-client " + gofakeit.Email(),
+		Secret:       client.secret,
+		ClientType:   clientType,
+		RedirectURIs: []string{clientRedirectURI},
+	})
+	require.NoError(t, err)
+
+	return client
+}
+
+// newOAuthServer поднимает HTTP-обработчик /oauth/ приложения; клиент не следует редиректам
+func newOAuthServer(t *testing.T, st *suite.Suite) (*httptest.Server, *http.Client) {
+	t.Helper()
+
+	server := httptest.NewServer(st.App.OAuthHandler)
+	t.Cleanup(server.Close)
+
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	return server, client
+}
+
+func newPKCE(t *testing.T) (verifier, challenge string) {
+	t.Helper()
+
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func authorizeParams(clientID int, challenge string) url.Values {
+	return url.Values{
+		"client_id":             {strconv.Itoa(clientID)},
+		"redirect_uri":          {clientRedirectURI},
+		"response_type":         {"code"},
+		"scope":                 {"profile forum.read"},
+		"state":                 {"state-123"},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+// postAuthorize отправляет решение страницы согласия от имени пользователя с accessToken
+func postAuthorize(t *testing.T, server *httptest.Server, client *http.Client, accessToken string, params url.Values, decision string) (int, oauthAuthorizeResponse) {
+	t.Helper()
+
+	form := url.Values{"app_id": {strconv.Itoa(appID)}, "decision": {decision}}
+	for name, values := range params {
+		form[name] = values
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/oauth/authorize", strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body oauthAuthorizeResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return resp.StatusCode, body
+}
+
+func postToken(t *testing.T, server *httptest.Server, client *http.Client, oc oauthClient, form url.Values) (int, oauthTokenResponse) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/oauth/token", strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(strconv.Itoa(oc.id), oc.secret)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+
+	var body oauthTokenResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return resp.StatusCode, body
+}
+
+func codeFrom(t *testing.T, redirectTo string) string {
+	t.Helper()
+
+	u, err := url.Parse(redirectTo)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(redirectTo, clientRedirectURI), redirectTo)
+	assert.Equal(t, "state-123", u.Query().Get("state"))
+
+	code := u.Query().Get("code")
+	require.NotEmpty(t, code, redirectTo)
+	return code
+}
+
+func TestOAuth_AuthorizationCodeFlow(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypeConfidential)
+	server, client := newOAuthServer(t, st)
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	verifier, challenge := newPKCE(t)
+	params := authorizeParams(oc.id, challenge)
+
+	// браузер отправляется на страницу согласия с исходными параметрами
+	resp, err := client.Get(server.URL + "/oauth/authorize?" + params.Encode())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Location"), st.Cfg.OAuth.ConsentURL+"?"))
+
+	// согласия ещё нет
+	status, authorized := postAuthorize(t, server, client, login.GetAccessToken(), params, "")
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, authorized.ConsentRequired)
+	assert.Equal(t, []string{"forum.read", "profile"}, authorized.Scope)
+
+	status, authorized = postAuthorize(t, server, client, login.GetAccessToken(), params, "approve")
+	require.Equal(t, http.StatusOK, status)
+	code := codeFrom(t, authorized.RedirectTo)
+
+	status, tokens := postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {clientRedirectURI},
+		"code_verifier": {verifier},
+	})
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, int64(st.Cfg.AccessTokenTTL.Seconds()), tokens.ExpiresIn)
+	assert.Equal(t, "forum.read profile", tokens.Scope)
+
+	claims, err := st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: tokens.AccessToken, AppId: int32(oc.id)})
+	require.NoError(t, err)
+	assert.Equal(t, login.GetUserId(), claims.GetUserId())
+	assert.Equal(t, []string{"forum.read", "profile"}, claims.GetScopes())
+
+	// код одноразовый
+	status, replay := postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {clientRedirectURI},
+		"code_verifier": {verifier},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_grant", replay.Error)
+
+	// согласие запомнено: повторный запрос сразу получает код
+	_, challenge = newPKCE(t)
+	status, authorized = postAuthorize(t, server, client, login.GetAccessToken(), authorizeParams(oc.id, challenge), "")
+	require.Equal(t, http.StatusOK, status)
+	assert.False(t, authorized.ConsentRequired)
+	codeFrom(t, authorized.RedirectTo)
+
+	// refresh сохраняет scope и может его сузить
+	status, refreshed := postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokens.RefreshToken},
+		"scope":         {"profile"},
+	})
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "profile", refreshed.Scope)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+}
+
+func TestOAuth_RevokeConsent(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypeConfidential)
+	server, client := newOAuthServer(t, st)
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	verifier, challenge := newPKCE(t)
+	params := authorizeParams(oc.id, challenge)
+
+	_, authorized := postAuthorize(t, server, client, login.GetAccessToken(), params, "approve")
+	_, tokens := postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {codeFrom(t, authorized.RedirectTo)},
+		"redirect_uri":  {clientRedirectURI},
+		"code_verifier": {verifier},
+	})
+	require.NotEmpty(t, tokens.RefreshToken)
+
+	consents, err := st.AuthClient.ListOAuthConsents(ctx, &ssov1.ListOAuthConsentsRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	require.Len(t, consents.GetConsents(), 1)
+	assert.Equal(t, int32(oc.id), consents.GetConsents()[0].GetClientId())
+	assert.Equal(t, []string{"forum.read", "profile"}, consents.GetConsents()[0].GetScopes())
+
+	_, err = st.AuthClient.RevokeOAuthConsent(ctx, &ssov1.RevokeOAuthConsentRequest{AccessToken: login.GetAccessToken(), AppId: appID, ClientId: int32(oc.id)})
+	require.NoError(t, err)
+
+	// refresh токены клиента отозваны, а новый запрос снова требует согласия
+	status, refreshed := postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokens.RefreshToken},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_grant", refreshed.Error)
+
+	_, authorized = postAuthorize(t, server, client, login.GetAccessToken(), params, "")
+	assert.True(t, authorized.ConsentRequired)
+}
+
+func TestOAuth_DenyRedirectsWithAccessDenied(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypePublic)
+	server, client := newOAuthServer(t, st)
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, challenge := newPKCE(t)
+
+	status, authorized := postAuthorize(t, server, client, login.GetAccessToken(), authorizeParams(oc.id, challenge), "deny")
+	require.Equal(t, http.StatusOK, status)
+
+	u, err := url.Parse(authorized.RedirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "access_denied", u.Query().Get("error"))
+	assert.Empty(t, u.Query().Get("code"))
+}
+
+func TestOAuth_AuthorizeRejectsUnregisteredRedirectURI(t *testing.T) {
+	_, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypePublic)
+	server, client := newOAuthServer(t, st)
+
+	_, challenge := newPKCE(t)
+	params := authorizeParams(oc.id, challenge)
+	params.Set("redirect_uri", "https://evil.example/callback")
+
+	resp, err := client.Get(server.URL + "/oauth/authorize?" + params.Encode())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// на непроверенный адрес не перенаправляем
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Location"))
+}
+
+func TestOAuth_AuthorizeRequiresPKCE(t *testing.T) {
+	_, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypePublic)
+	server, client := newOAuthServer(t, st)
+
+	params := authorizeParams(oc.id, "")
+	params.Del("code_challenge")
+
+	resp, err := client.Get(server.URL + "/oauth/authorize?" + params.Encode())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(location.String(), clientRedirectURI))
+	assert.Equal(t, "invalid_request", location.Query().Get("error"))
+	assert.Equal(t, "state-123", location.Query().Get("state"))
+}
+
+func TestOAuth_TokenRejectsWrongVerifierAndSecret(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypeConfidential)
+	server, client := newOAuthServer(t, st)
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, challenge := newPKCE(t)
+	_, authorized := postAuthorize(t, server, client, login.GetAccessToken(), authorizeParams(oc.id, challenge), "approve")
+	code := codeFrom(t, authorized.RedirectTo)
+
+	wrongVerifier, _ := newPKCE(t)
+
+	status, body := postToken(t, server, client, oauthClient{id: oc.id, secret: "wrong"}, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {clientRedirectURI},
+		"code_verifier": {wrongVerifier},
+	})
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "invalid_client", body.Error)
+
+	status, body = postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {clientRedirectURI},
+		"code_verifier": {wrongVerifier},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_grant", body.Error)
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/internal/domain/models"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGatewayServer поднимает grpc-gateway поверх gRPC-клиента, как это делает cmd/auth
+func newGatewayServer(t *testing.T, ctx context.Context, st *suite.Suite) *httptest.Server {
+	t.Helper()
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, ssov1.RegisterAuthHandlerClient(ctx, mux, st.AuthClient))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// getJSON выполняет GET и разбирает JSON-ответ в map
+func getJSON(t *testing.T, target, accessToken string) (int, map[string]any) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	require.NoError(t, err)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return resp.StatusCode, body
+}
+
+// oidcTokens проходит поток авторизации с указанными scope и nonce и возвращает ответ /oauth/token
+func oidcTokens(t *testing.T, st *suite.Suite, oc oauthClient, accessToken, scope, nonce string) oauthTokenResponse {
+	t.Helper()
+
+	server, client := newOAuthServer(t, st)
+
+	verifier, challenge := newPKCE(t)
+	params := authorizeParams(oc.id, challenge)
+	params.Set("scope", scope)
+	params.Set("nonce", nonce)
+
+	status, authorized := postAuthorize(t, server, client, accessToken, params, "approve")
+	require.Equal(t, http.StatusOK, status)
+
+	status, tokens := postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {codeFrom(t, authorized.RedirectTo)},
+		"redirect_uri":  {clientRedirectURI},
+		"code_verifier": {verifier},
+	})
+	require.Equal(t, http.StatusOK, status, tokens.Error)
+
+	return tokens
+}
+
+func TestOIDC_Discovery(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	gateway := newGatewayServer(t, ctx, st)
+
+	status, doc := getJSON(t, gateway.URL+"/.well-known/openid-configuration", "")
+	require.Equal(t, http.StatusOK, status)
+
+	issuer := st.Cfg.OAuth.Issuer
+	assert.Equal(t, issuer, doc["issuer"])
+	assert.Equal(t, issuer+"/oauth/authorize", doc["authorization_endpoint"])
+	assert.Equal(t, issuer+"/oauth/token", doc["token_endpoint"])
+	assert.Equal(t, issuer+"/userinfo", doc["userinfo_endpoint"])
+	assert.Equal(t, issuer+"/.well-known/jwks.json", doc["jwks_uri"])
+	assert.Contains(t, doc["scopes_supported"], "openid")
+	assert.Equal(t, []any{"code"}, doc["response_types_supported"])
+	assert.Equal(t, []any{"S256"}, doc["code_challenge_methods_supported"])
+	assert.Contains(t, doc["id_token_signing_alg_values_supported"], st.Cfg.Signing.Algorithm)
+}
+
+func TestOIDC_IDTokenAndUserInfo(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypeConfidential)
+	email := gofakeit.Email()
+	login := registerAndLogin(t, ctx, st, email, randomFakePassword())
+
+	tokens := oidcTokens(t, st, oc, login.GetAccessToken(), "openid email", "nonce-123")
+	require.NotEmpty(t, tokens.IDToken)
+	assert.Equal(t, "email openid", tokens.Scope)
+
+	// ID token проверяется по опубликованным ключам, как это делают OIDC-библиотеки
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokens.IDToken, claims, jwksKeyFunc(t, ctx, st),
+		jwt.WithValidMethods([]string{st.Cfg.Signing.Algorithm}),
+		jwt.WithIssuer(st.Cfg.OAuth.Issuer),
+		jwt.WithAudience(strconv.Itoa(oc.id)),
+		jwt.WithIssuedAt(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(login.GetUserId(), 10), claims["sub"])
+	assert.Equal(t, "nonce-123", claims["nonce"])
+	assert.Equal(t, email, claims["email"])
+	assert.Equal(t, false, claims["email_verified"])
+
+	gateway := newGatewayServer(t, ctx, st)
+
+	status, info := getJSON(t, gateway.URL+"/userinfo", tokens.AccessToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, claims["sub"], info["sub"])
+	assert.Equal(t, email, info["email"])
+	assert.Equal(t, false, info["email_verified"])
+
+	// при обновлении ID token выдаётся снова, но без nonce
+	server, client := newOAuthServer(t, st)
+	status, refreshed := postToken(t, server, client, oc, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokens.RefreshToken},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, refreshed.IDToken)
+
+	refreshedClaims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(refreshed.IDToken, refreshedClaims, jwksKeyFunc(t, ctx, st))
+	require.NoError(t, err)
+	assert.Equal(t, claims["sub"], refreshedClaims["sub"])
+	assert.NotContains(t, refreshedClaims, "nonce")
+}
+
+func TestOIDC_UserInfoRespectsScope(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	oc := registerOAuthClient(t, st, models.ClientTypeConfidential)
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	gateway := newGatewayServer(t, ctx, st)
+
+	// без scope email отдаётся только sub
+	tokens := oidcTokens(t, st, oc, login.GetAccessToken(), "openid", "nonce-1")
+	status, info := getJSON(t, gateway.URL+"/userinfo", tokens.AccessToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, strconv.FormatInt(login.GetUserId(), 10), info["sub"])
+	assert.NotContains(t, info, "email")
+
+	// без scope openid токен клиента не даёт доступа к /userinfo, и ID token не выдаётся
+	tokens = oidcTokens(t, st, oc, login.GetAccessToken(), "profile", "nonce-2")
+	assert.Empty(t, tokens.IDToken)
+	status, _ = getJSON(t, gateway.URL+"/userinfo", tokens.AccessToken)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, _ = getJSON(t, gateway.URL+"/userinfo", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// токен первого приложения scope не имеет и видит email
+	status, info = getJSON(t, gateway.URL+"/userinfo", login.GetAccessToken())
+	require.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, info["email"])
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passHash читает хэш пароля пользователя напрямую из базы
+func passHash(t *testing.T, st *suite.Suite, userID int64) string {
+	t.Helper()
+
+	db, err := sql.Open("postgres", st.Cfg.StoragePath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var hash []byte
+	require.NoError(t, db.QueryRow("SELECT pass_hash FROM users WHERE id = $1", userID).Scan(&hash))
+	return string(hash)
+}
+
+func TestPasswordHash_RegisterUsesConfiguredAlgorithm(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	cfg := st.Cfg.PasswordHash
+	prefix := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$", cfg.Argon2Memory, cfg.Argon2Time, cfg.Argon2Threads)
+	assert.True(t, strings.HasPrefix(passHash(t, st, login.GetUserId()), prefix))
+}
+
+func TestPasswordHash_LegacyBcryptRehashedOnLogin(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	// пароль, сохранённый до перехода на argon2id
+	legacy, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", st.Cfg.StoragePath)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("UPDATE users SET pass_hash = $1 WHERE id = $2", legacy, login.GetUserId())
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(passHash(t, st, login.GetUserId()), "$argon2id$"))
+
+	// пересчитанный хэш по-прежнему подходит к паролю
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/internal/lib/passpolicy"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// policyViolations возвращает коды нарушенных правил из деталей ответа InvalidArgument
+func policyViolations(t *testing.T, err error, field string) []string {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	var rules []string
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, v := range badRequest.GetFieldViolations() {
+			assert.Equal(t, field, v.GetField())
+			assert.NotEmpty(t, v.GetDescription())
+			rules = append(rules, v.GetReason())
+		}
+	}
+
+	return rules
+}
+
+func TestPasswordPolicy_RegisterListsEveryViolation(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	// короткий и без цифр
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: gofakeit.Email(), Password: "abc"})
+	require.Error(t, err)
+
+	rules := policyViolations(t, err, "password")
+	assert.Contains(t, rules, passpolicy.RuleMinLength)
+	assert.Contains(t, rules, passpolicy.RuleDigit)
+}
+
+func TestPasswordPolicy_RegisterRejectsTooLongPassword(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	password := "a1" + strings.Repeat("x", st.Cfg.PasswordPolicy.MaxLength)
+
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: gofakeit.Email(), Password: password})
+	require.Error(t, err)
+
+	assert.Equal(t, []string{passpolicy.RuleMaxLength}, policyViolations(t, err, "password"))
+}
+
+func TestPasswordPolicy_RegisterRejectsEmailAsPassword(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: email})
+	require.Error(t, err)
+
+	assert.Contains(t, policyViolations(t, err, "password"), passpolicy.RuleEmailSimilar)
+}
+
+func TestPasswordPolicy_ResetKeepsTokenOnWeakPassword(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	registerAndLogin(t, ctx, st, email, randomFakePassword())
+
+	_, err := st.AuthClient.RequestPasswordReset(ctx, &ssov1.RequestPasswordResetRequest{Email: email})
+	require.NoError(t, err)
+
+	token := readMailToken(t, st)
+
+	_, err = st.AuthClient.ResetPassword(ctx, &ssov1.ResetPasswordRequest{Token: token, NewPassword: "short"})
+	require.Error(t, err)
+	assert.Contains(t, policyViolations(t, err, "new_password"), passpolicy.RuleMinLength)
+
+	// токен не погашен, с подходящим паролем сброс проходит
+	newPassword := randomFakePassword()
+	_, err = st.AuthClient.ResetPassword(ctx, &ssov1.ResetPasswordRequest{Token: token, NewPassword: newPassword})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: newPassword, AppId: appID})
+	require.NoError(t, err)
+}
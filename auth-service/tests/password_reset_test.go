@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var resetLinkRe = regexp.MustCompile(`https?://\S+`)
+
+// readMailToken достаёт токен из последнего письма, сохранённого file-mailer
+func readMailToken(t *testing.T, st *suite.Suite) string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(st.MailDir, "*.eml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "email was not sent")
+
+	body, err := os.ReadFile(files[len(files)-1])
+	require.NoError(t, err)
+
+	link := resetLinkRe.FindString(string(body))
+	require.NotEmpty(t, link)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	token := u.Query().Get("token")
+	require.NotEmpty(t, token)
+
+	return token
+}
+
+func registerAndLogin(t *testing.T, ctx context.Context, st *suite.Suite, email, password string) *ssov1.LoginResponse {
+	t.Helper()
+
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+
+	resp, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	return resp
+}
+
+func TestPasswordReset_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	oldPassword := randomFakePassword()
+	newPassword := randomFakePassword()
+
+	login := registerAndLogin(t, ctx, st, email, oldPassword)
+
+	_, err := st.AuthClient.RequestPasswordReset(ctx, &ssov1.RequestPasswordResetRequest{Email: email})
+	require.NoError(t, err)
+
+	token := readMailToken(t, st)
+
+	_, err = st.AuthClient.ResetPassword(ctx, &ssov1.ResetPasswordRequest{Token: token, NewPassword: newPassword})
+	require.NoError(t, err)
+
+	// старый пароль больше не подходит, новый — подходит
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: oldPassword, AppId: appID})
+	require.Error(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: newPassword, AppId: appID})
+	require.NoError(t, err)
+
+	// refresh токены, выданные до сброса, отозваны
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: login.GetRefreshToken(), AppId: appID})
+	require.Error(t, err)
+
+	// токен одноразовый
+	_, err = st.AuthClient.ResetPassword(ctx, &ssov1.ResetPasswordRequest{Token: token, NewPassword: randomFakePassword()})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPasswordReset_UnknownEmail_SameResponse(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.AuthClient.RequestPasswordReset(ctx, &ssov1.RequestPasswordResetRequest{Email: gofakeit.Email()})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	files, err := filepath.Glob(filepath.Join(st.MailDir, "*.eml"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestPasswordReset_FailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	tests := []struct {
+		name        string
+		token       string
+		newPassword string
+		expectedErr string
+	}{
+		{name: "empty token", token: "", newPassword: randomFakePassword(), expectedErr: "token is required"},
+		{name: "empty password", token: "some-token", newPassword: "", expectedErr: "new_password is required"},
+		{name: "unknown token", token: "some-token", newPassword: randomFakePassword(), expectedErr: "invalid or expired reset token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.AuthClient.ResetPassword(ctx, &ssov1.ResetPasswordRequest{Token: tt.token, NewPassword: tt.newPassword})
+			require.Error(t, err)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+
+	_, err := st.AuthClient.RequestPasswordReset(ctx, &ssov1.RequestPasswordResetRequest{Email: ""})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
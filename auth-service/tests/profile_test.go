@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// readMailTokenTo возвращает токен из последнего письма со ссылкой, отправленного на адрес to
+func readMailTokenTo(t *testing.T, st *suite.Suite, to string) string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(st.MailDir, "*.eml"))
+	require.NoError(t, err)
+
+	for i := len(files) - 1; i >= 0; i-- {
+		body, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+
+		if !strings.HasPrefix(string(body), "To: "+to+"\r\n") {
+			continue
+		}
+		link := resetLinkRe.FindString(string(body))
+		if link == "" {
+			continue
+		}
+
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		return u.Query().Get("token")
+	}
+
+	t.Fatalf("no email with a link was sent to %s", to)
+	return ""
+}
+
+func TestProfile_UpdateAndGet(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	login := registerAndLogin(t, ctx, st, email, randomFakePassword())
+
+	profile, err := st.AuthClient.GetProfile(ctx, &ssov1.GetProfileRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.Equal(t, email, profile.GetProfile().GetEmail())
+	assert.Empty(t, profile.GetProfile().GetUsername())
+	assert.NotZero(t, profile.GetProfile().GetCreatedAt())
+
+	// база тестов не очищается между запусками, поэтому имя пользователя уникально по времени
+	username := fmt.Sprintf("user_%d", time.Now().UnixNano())
+
+	updated, err := st.AuthClient.UpdateProfile(ctx, &ssov1.UpdateProfileRequest{
+		AccessToken: login.GetAccessToken(),
+		AppId:       appID,
+		DisplayName: "Test User",
+		Username:    strings.ToUpper(username),
+		AvatarUrl:   "https://cdn.example/avatar.png",
+		Bio:         "about me",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Test User", updated.GetProfile().GetDisplayName())
+	assert.Equal(t, username, updated.GetProfile().GetUsername())
+
+	profile, err = st.AuthClient.GetProfile(ctx, &ssov1.GetProfileRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example/avatar.png", profile.GetProfile().GetAvatarUrl())
+	assert.Equal(t, "about me", profile.GetProfile().GetBio())
+
+	// имя пользователя уникально без учёта регистра
+	other := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	_, err = st.AuthClient.UpdateProfile(ctx, &ssov1.UpdateProfileRequest{
+		AccessToken: other.GetAccessToken(), AppId: appID, Username: username,
+	})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = st.AuthClient.UpdateProfile(ctx, &ssov1.UpdateProfileRequest{
+		AccessToken: other.GetAccessToken(), AppId: appID, AvatarUrl: "javascript:alert(1)",
+	})
+	grpcStatus, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, grpcStatus.Code())
+	require.Len(t, grpcStatus.Details(), 1)
+	badRequest, ok := grpcStatus.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	assert.Equal(t, "avatar_url", badRequest.GetFieldViolations()[0].GetField())
+}
+
+func TestProfile_ChangePassword(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	other, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ChangePassword(ctx, &ssov1.ChangePasswordRequest{
+		AccessToken: login.GetAccessToken(), AppId: appID, CurrentPassword: "wrong-" + password, NewPassword: randomFakePassword(),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	newPassword := randomFakePassword()
+	changed, err := st.AuthClient.ChangePassword(ctx, &ssov1.ChangePasswordRequest{
+		AccessToken: login.GetAccessToken(), AppId: appID, CurrentPassword: password, NewPassword: newPassword,
+	})
+	require.NoError(t, err)
+
+	// прежние сессии завершены, новая пара токенов действует
+	for _, refreshToken := range []string{login.GetRefreshToken(), other.GetRefreshToken()} {
+		_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: refreshToken, AppId: appID})
+		require.Error(t, err)
+	}
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: changed.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: newPassword, AppId: appID})
+	require.NoError(t, err)
+}
+
+func TestProfile_ChangeEmail(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	taken := gofakeit.Email()
+	registerAndLogin(t, ctx, st, taken, randomFakePassword())
+	_, err := st.AuthClient.ChangeEmail(ctx, &ssov1.ChangeEmailRequest{
+		AccessToken: login.GetAccessToken(), AppId: appID, Password: password, NewEmail: taken,
+	})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	newEmail := gofakeit.Email()
+	_, err = st.AuthClient.ChangeEmail(ctx, &ssov1.ChangeEmailRequest{
+		AccessToken: login.GetAccessToken(), AppId: appID, Password: password, NewEmail: newEmail,
+	})
+	require.NoError(t, err)
+
+	// до подтверждения адрес не меняется
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	token := readMailTokenTo(t, st, newEmail)
+	_, err = st.AuthClient.ConfirmEmailChange(ctx, &ssov1.ConfirmEmailChangeRequest{Token: token})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ConfirmEmailChange(ctx, &ssov1.ConfirmEmailChangeRequest{Token: token})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: login.GetRefreshToken(), AppId: appID})
+	require.Error(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	relogin, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: newEmail, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	claims, err := st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: relogin.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.Equal(t, newEmail, claims.GetEmail())
+	assert.True(t, claims.GetEmailVerified())
+}
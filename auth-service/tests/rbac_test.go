@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const permissionPostDeleteAny = "forum.post.delete_any"
+
+func hasPermission(t *testing.T, ctx context.Context, st *suite.Suite, userID int64, permission string) bool {
+	t.Helper()
+
+	resp, err := st.AuthClient.HasPermission(ctx, &ssov1.HasPermissionRequest{UserId: userID, Permission: permission})
+	require.NoError(t, err)
+	return resp.GetAllowed()
+}
+
+func TestRBAC_NewUserHasUserRole(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	assert.True(t, hasPermission(t, ctx, st, login.GetUserId(), "forum.post.create"))
+	assert.False(t, hasPermission(t, ctx, st, login.GetUserId(), permissionPostDeleteAny))
+
+	claims, err := st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, claims.GetRoles())
+}
+
+func TestRBAC_AssignAndRevokeRole(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	user := registerAndLogin(t, ctx, st, email, password)
+
+	_, err := st.AuthClient.AssignRole(ctx, &ssov1.AssignRoleRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, UserId: user.GetUserId(), Role: "moderator",
+	})
+	require.NoError(t, err)
+	assert.True(t, hasPermission(t, ctx, st, user.GetUserId(), permissionPostDeleteAny))
+
+	// новая роль попадает в токены, выпущенные после её выдачи
+	relogin, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+	claims, err := st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: relogin.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"moderator", "user"}, claims.GetRoles())
+
+	_, err = st.AuthClient.RevokeRole(ctx, &ssov1.RevokeRoleRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, UserId: user.GetUserId(), Role: "moderator",
+	})
+	require.NoError(t, err)
+	assert.False(t, hasPermission(t, ctx, st, user.GetUserId(), permissionPostDeleteAny))
+
+	_, err = st.AuthClient.RevokeRole(ctx, &ssov1.RevokeRoleRequest{
+		AccessToken: admin.GetAccessToken(), AppId: appID, UserId: user.GetUserId(), Role: "moderator",
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRBAC_AssignRole_Errors(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+	user := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	tests := []struct {
+		name     string
+		token    string
+		userID   int64
+		role     string
+		expected codes.Code
+	}{
+		{name: "not an admin", token: user.GetAccessToken(), userID: user.GetUserId(), role: "admin", expected: codes.PermissionDenied},
+		{name: "unknown role", token: admin.GetAccessToken(), userID: user.GetUserId(), role: "owner", expected: codes.InvalidArgument},
+		{name: "unknown user", token: admin.GetAccessToken(), userID: 1 << 30, role: "moderator", expected: codes.NotFound},
+		{name: "empty role", token: admin.GetAccessToken(), userID: user.GetUserId(), role: "", expected: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.AuthClient.AssignRole(ctx, &ssov1.AssignRoleRequest{
+				AccessToken: tt.token, AppId: appID, UserId: tt.userID, Role: tt.role,
+			})
+			require.Equal(t, tt.expected, status.Code(err))
+		})
+	}
+}
+
+func TestRBAC_HasPermission_UnknownUser(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.HasPermission(ctx, &ssov1.HasPermissionRequest{UserId: 1 << 30, Permission: permissionPostDeleteAny})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	login := registerAndLogin(t, ctx, st, email, password)
+
+	rotated, err := st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{
+		RefreshToken: login.GetRefreshToken(),
+		AppId:        appID,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, login.GetRefreshToken(), rotated.GetRefreshToken())
+
+	// старый токен предъявлен повторно — как будто его украли до ротации
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{
+		RefreshToken: login.GetRefreshToken(),
+		AppId:        appID,
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// вместе с ним отозвана вся семья, включая токен, выданный при ротации
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{
+		RefreshToken: rotated.GetRefreshToken(),
+		AppId:        appID,
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// новый вход начинает новую семью, и отзыв её не затрагивает
+	relogin, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{
+		RefreshToken: relogin.GetRefreshToken(),
+		AppId:        appID,
+	})
+	require.NoError(t, err)
+}
+
+func TestRefreshTokens_RotatedTokenCannotLogout(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	login := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	rotated, err := st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{
+		RefreshToken: login.GetRefreshToken(),
+		AppId:        appID,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Logout(ctx, &ssov1.LogoutRequest{RefreshToken: login.GetRefreshToken(), AppId: appID})
+	require.Error(t, err)
+
+	_, err = st.AuthClient.Logout(ctx, &ssov1.LogoutRequest{RefreshToken: rotated.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+}
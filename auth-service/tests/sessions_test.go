@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+
+	// первый вход — как будто через grpc-gateway из браузера
+	browserCtx := metadata.AppendToOutgoingContext(ctx,
+		"x-forwarded-for", "203.0.113.7",
+		"grpcgateway-user-agent", "Mozilla/5.0 (sessions test)",
+	)
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	browser, err := st.AuthClient.Login(browserCtx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	// второй вход — напрямую по gRPC
+	direct, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	// обновление токенов не создаёт новую сессию
+	refreshed, err := st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: direct.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+
+	list, err := st.AuthClient.ListSessions(ctx, &ssov1.ListSessionsRequest{AccessToken: refreshed.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	require.Len(t, list.GetSessions(), 2)
+
+	var browserSession *ssov1.Session
+	for _, session := range list.GetSessions() {
+		assert.Equal(t, int32(appID), session.GetAppId())
+		assert.NotEmpty(t, session.GetIp())
+		assert.LessOrEqual(t, session.GetCreatedAt(), session.GetLastUsedAt())
+		if session.GetUserAgent() == "Mozilla/5.0 (sessions test)" {
+			browserSession = session
+		}
+	}
+	require.NotNil(t, browserSession)
+	assert.Equal(t, "203.0.113.7", browserSession.GetIp())
+
+	_, err = st.AuthClient.RevokeSession(ctx, &ssov1.RevokeSessionRequest{
+		AccessToken: refreshed.GetAccessToken(),
+		AppId:       appID,
+		SessionId:   browserSession.GetId(),
+	})
+	require.NoError(t, err)
+
+	// завершённая сессия больше не продлевается, остальные работают
+	_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: browser.GetRefreshToken(), AppId: appID})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	refreshed, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: refreshed.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+
+	// повторное завершение той же сессии
+	_, err = st.AuthClient.RevokeSession(ctx, &ssov1.RevokeSessionRequest{
+		AccessToken: refreshed.GetAccessToken(),
+		AppId:       appID,
+		SessionId:   browserSession.GetId(),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSessions_RevokeAll(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+
+	first := registerAndLogin(t, ctx, st, email, password)
+	second, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RevokeAllSessions(ctx, &ssov1.RevokeAllSessionsRequest{AccessToken: second.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+
+	for _, refreshToken := range []string{first.GetRefreshToken(), second.GetRefreshToken()} {
+		_, err = st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: refreshToken, AppId: appID})
+		require.Error(t, err)
+	}
+
+	list, err := st.AuthClient.ListSessions(ctx, &ssov1.ListSessionsRequest{AccessToken: second.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.Empty(t, list.GetSessions())
+}
+
+func TestSessions_PasswordResetRevokesAll(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	login := registerAndLogin(t, ctx, st, email, randomFakePassword())
+
+	_, err := st.AuthClient.RequestPasswordReset(ctx, &ssov1.RequestPasswordResetRequest{Email: email})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ResetPassword(ctx, &ssov1.ResetPasswordRequest{Token: readMailToken(t, st), NewPassword: randomFakePassword()})
+	require.NoError(t, err)
+
+	list, err := st.AuthClient.ListSessions(ctx, &ssov1.ListSessionsRequest{AccessToken: login.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+	assert.Empty(t, list.GetSessions())
+}
+
+func TestSessions_InvalidAccessToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.ListSessions(ctx, &ssov1.ListSessionsRequest{AccessToken: "invalid", AppId: appID})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"database/sql"
+	"github.com/14kear/forum-project/auth-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/golang-jwt/jwt/v5"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+// makeAdmin выдаёт пользователю права администратора напрямую в базе
+func makeAdmin(t *testing.T, st *suite.Suite, userID int64) {
+	t.Helper()
+
+	db, err := sql.Open("postgres", st.Cfg.StoragePath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO user_roles(user_id, role_id)
+		SELECT $1, id FROM roles WHERE name = 'admin'
+		ON CONFLICT DO NOTHING`, userID)
+	require.NoError(t, err)
+}
+
+func tokenKID(t *testing.T, token string) string {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func publishedKIDs(t *testing.T, resp *ssov1.GetJWKSResponse) []string {
+	kids := make([]string, 0, len(resp.GetKeys()))
+	for _, key := range resp.GetKeys() {
+		kids = append(kids, key.GetKid())
+	}
+	return kids
+}
+
+func TestSigningKeys_RotationKeepsIssuedTokens(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	user := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	oldKID := tokenKID(t, user.GetAccessToken())
+	require.NotEmpty(t, oldKID)
+
+	rotated, err := st.AuthClient.RotateSigningKey(ctx, &ssov1.RotateSigningKeyRequest{
+		AccessToken: admin.GetAccessToken(),
+		AppId:       appID,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, oldKID, rotated.GetKid())
+
+	// в JWKS опубликованы и новый, и уходящий ключ
+	jwks, err := st.AuthClient.GetJWKS(ctx, &ssov1.GetJWKSRequest{})
+	require.NoError(t, err)
+	assert.Contains(t, publishedKIDs(t, jwks), oldKID)
+	assert.Contains(t, publishedKIDs(t, jwks), rotated.GetKid())
+
+	// токены, подписанные прежним ключом, продолжают действовать
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+
+	refreshed, err := st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: user.GetRefreshToken(), AppId: appID})
+	require.NoError(t, err)
+
+	// новые токены подписываются новым ключом
+	assert.Equal(t, rotated.GetKid(), tokenKID(t, refreshed.GetAccessToken()))
+
+	_, err = st.AuthClient.ValidateToken(ctx, &ssov1.ValidateTokenRequest{AccessToken: refreshed.GetAccessToken(), AppId: appID})
+	require.NoError(t, err)
+}
+
+func TestSigningKeys_RotateFailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	user := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.RotateSigningKey(ctx, &ssov1.RotateSigningKeyRequest{AccessToken: user.GetAccessToken(), AppId: appID})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.RotateSigningKey(ctx, &ssov1.RotateSigningKeyRequest{AccessToken: "bad-token", AppId: appID})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.RotateSigningKey(ctx, &ssov1.RotateSigningKeyRequest{AccessToken: user.GetAccessToken()})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	admin := registerAndLogin(t, ctx, st, gofakeit.Email(), randomFakePassword())
+	makeAdmin(t, st, admin.GetUserId())
+
+	_, err = st.AuthClient.RotateSigningKey(ctx, &ssov1.RotateSigningKeyRequest{AccessToken: admin.GetAccessToken(), AppId: appID, Algorithm: "HS256"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.RotateSigningKey(ctx, &ssov1.RotateSigningKeyRequest{AccessToken: admin.GetAccessToken(), AppId: appID, TargetAppId: 777777})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
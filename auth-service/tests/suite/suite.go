@@ -10,6 +10,7 @@ import (
 
 	"github.com/14kear/forum-project/auth-service/internal/app"
 	"github.com/14kear/forum-project/auth-service/internal/config"
+	"github.com/14kear/forum-project/auth-service/internal/lib/mailer"
 	"github.com/14kear/forum-project/auth-service/utils"
 
 	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
@@ -24,10 +25,18 @@ type Suite struct {
 	Cfg        *config.Config
 	App        *app.App         // чтобы при желании дергать внутренние методы
 	AuthClient ssov1.AuthClient // gRPC‑клиент для тестов
+	MailDir    string           // каталог, куда file-mailer складывает письма
 }
 
 // New инициализирует приложение, поднимает gRPC‑сервер и возвращает gRPC‑клиента.
 func New(t *testing.T) (context.Context, *Suite) {
+	t.Helper()
+	return NewWithConfig(t, nil)
+}
+
+// NewWithConfig — как New, но перед запуском приложения даёт тесту поменять конфиг
+// (например, подключить тестового провайдера OpenID Connect).
+func NewWithConfig(t *testing.T, configure func(cfg *config.Config)) (context.Context, *Suite) {
 	t.Helper()
 	t.Parallel()
 
@@ -37,14 +46,35 @@ func New(t *testing.T) (context.Context, *Suite) {
 	// Заменяем порт на свободный (чтобы параллельные тесты не конфликтовали)
 	cfg.GRPC.Port = freePort()
 
+	// Письма сохраняются в отдельный каталог на каждый тест, чтобы их можно было прочитать
+	cfg.Mailer.Type = mailer.TypeFile
+	cfg.Mailer.Dir = t.TempDir()
+
+	if configure != nil {
+		configure(cfg)
+	}
+
 	// -------- 2. Стартуем приложение -----------------
 	log := utils.New(cfg.Env)
 	application := app.NewApp(
 		log,
 		cfg.GRPC.Port,
+		cfg.GRPC.TrustedProxies,
+		cfg.GRPC.InternalAppIDs,
 		cfg.StoragePath,
 		cfg.AccessTokenTTL,
 		cfg.RefreshTokenTTL,
+		cfg.PasswordReset,
+		cfg.EmailVerification,
+		cfg.MFA,
+		cfg.Signing,
+		cfg.BruteForce,
+		cfg.PasswordHash,
+		cfg.PasswordPolicy,
+		cfg.OAuth,
+		cfg.Federation,
+		cfg.Audit,
+		cfg.Mailer,
 	)
 
 	go func() {
@@ -70,7 +100,7 @@ func New(t *testing.T) (context.Context, *Suite) {
 
 	t.Cleanup(func() {
 		conn.Close()
-		application.GRPCServer.Stop() // корректно глушим сервер после теста
+		application.Stop() // корректно глушим сервер и фоновые задачи после теста
 	})
 
 	return ctx, &Suite{
@@ -78,6 +108,7 @@ func New(t *testing.T) (context.Context, *Suite) {
 		Cfg:        cfg,
 		App:        application,
 		AuthClient: ssov1.NewAuthClient(conn),
+		MailDir:    cfg.Mailer.Dir,
 	}
 }
 
@@ -24,7 +24,7 @@ func main() {
 	cfg := config.Load("forum-service/config/local.yaml")
 	log := utils.New(cfg.Env)
 
-	application := app.NewApp(log, cfg.HTTP.Port, cfg.StoragePath, cfg.GRPC.Address)
+	application := app.NewApp(log, cfg.HTTP.Port, cfg.StoragePath, cfg.GRPC.Address, cfg.AppID, cfg.Service, cfg.Chat, cfg.JWKS, cfg.Bans, cfg.Erasure, cfg.Export, cfg.RequireVerifiedEmail)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	httpapp "github.com/14kear/forum-project/forum-service/internal/app/http"
+	"github.com/14kear/forum-project/forum-service/internal/config"
 	"github.com/14kear/forum-project/forum-service/internal/grpcclient"
 	"github.com/14kear/forum-project/forum-service/internal/handlers/chat"
 	forumHandler "github.com/14kear/forum-project/forum-service/internal/handlers/forum"
@@ -21,27 +22,74 @@ type App struct {
 	cancel     context.CancelFunc
 }
 
-func NewApp(log *slog.Logger, httpPort int, storagePath string, authGRPCAddr string) *App {
+func NewApp(
+	log *slog.Logger,
+	httpPort int,
+	storagePath string,
+	authGRPCAddr string,
+	appID int,
+	serviceCfg config.ServiceConfig,
+	chatCfg config.ChatConfig,
+	jwksCfg config.JWKSConfig,
+	bansCfg config.BansConfig,
+	erasureCfg config.ErasureConfig,
+	exportCfg config.ExportConfig,
+	requireVerifiedEmail bool,
+) *App {
 	storage, err := postgres.New(storagePath)
 	if err != nil {
 		panic(err)
 	}
 
 	// gRPC client to auth-service
-	conn, err := grpc.Dial(authGRPCAddr, grpc.WithInsecure())
+	conn, err := grpc.Dial(authGRPCAddr, grpc.WithInsecure(), grpcclient.WithAppCredentials(serviceCfg.AppID, serviceCfg.AppSecret))
 	if err != nil {
 		panic(err)
 	}
 
 	authClient := grpcclient.NewClient(conn)
-	authMiddleware := middleware.NewAuthMiddleware(authClient.AuthClient, 1)
 
-	forumService := forum.NewForum(log, storage, storage, storage, authClient.AuthClient)
+	// access token проверяется по открытым ключам auth-service без запроса на каждый вызов
+	jwks := grpcclient.NewJWKSCache(authClient.AuthClient, jwksCfg.CacheTTL, jwksCfg.MinRefreshInterval)
+	bans := grpcclient.NewBanCache(authClient.AuthClient, bansCfg.CacheTTL)
+	authService := grpcclient.WithLocalValidation(authClient.AuthClient, jwks, bans, log)
+
+	authMiddleware := middleware.NewAuthMiddleware(authService, appID)
+
+	forumService := forum.NewForum(log, storage, storage, storage, storage, storage, authService)
 	forumServer := forumHandler.NewForumHandler(forumService)
 
-	chatServer := chat.NewChatHandler(forumService, authClient.AuthClient, 1, log)
+	chatLimits := chat.Limits{
+		UserRate:     chatCfg.UserRate,
+		UserBurst:    chatCfg.UserBurst,
+		ConnRate:     chatCfg.ConnRate,
+		ConnBurst:    chatCfg.ConnBurst,
+		SlowMode:     chatCfg.SlowMode,
+		MuteAfter:    chatCfg.MuteAfter,
+		MuteWindow:   chatCfg.MuteWindow,
+		MuteDuration: chatCfg.MuteDuration,
+	}
+	// интервал slow mode, сохранённый администратором, важнее значения из конфига
+	slowModeCtx, slowModeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	slowMode, ok, err := forumService.ChatSlowMode(slowModeCtx)
+	slowModeCancel()
+	if err != nil {
+		log.Warn("failed to load chat slow mode, using config value", slog.Any("error", err))
+	} else if ok {
+		chatLimits.SlowMode = slowMode
+	}
+
+	chatKeepalive := chat.Keepalive{
+		PingInterval: chatCfg.PingInterval,
+		PongWait:     chatCfg.PongWait,
+		WriteWait:    chatCfg.WriteWait,
+	}
+	chatServer := chat.NewChatHandler(forumService, authService, appID, chatLimits, chatKeepalive, requireVerifiedEmail, log)
 
-	httpApp := httpapp.NewApp(log, httpPort, forumServer, chatServer, authMiddleware.Middleware())
+	httpApp := httpapp.NewApp(
+		log, httpPort, forumServer, chatServer,
+		authMiddleware.Middleware(), middleware.RequireVerifiedEmail(requireVerifiedEmail),
+	)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -52,9 +100,9 @@ func NewApp(log *slog.Logger, httpPort int, storagePath string, authGRPCAddr str
 		cancel:     cancel,
 	}
 
-	// фоновая задача очистки
+	// фоновая очистка чата по политике хранения из БД
 	go func() {
-		ticker := time.NewTicker(30 * time.Minute)
+		ticker := time.NewTicker(chatCfg.RetentionSweepInterval)
 		defer ticker.Stop()
 
 		for {
@@ -64,11 +112,55 @@ func NewApp(log *slog.Logger, httpPort int, storagePath string, authGRPCAddr str
 				return
 			case <-ticker.C:
 				ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				err := forumService.CleanupOldMessages(ctxTimeout, 24*time.Hour)
+				_, err := forumService.ApplyChatRetention(ctxTimeout)
+				cancel()
+
+				if err != nil {
+					log.Error("failed to apply chat retention", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+
+	// обработка аккаунтов, удалённых в auth-service
+	go func() {
+		ticker := time.NewTicker(erasureCfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("account erasure goroutine stopped")
+				return
+			case <-ticker.C:
+				ctxTimeout, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				_, err := forumService.ApplyAccountErasures(ctxTimeout, erasureCfg.ContentPolicy, erasureCfg.BatchSize)
+				cancel()
+
+				if err != nil {
+					log.Error("failed to apply account erasures", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+
+	// сборка выгрузок персональных данных и удаление просроченных архивов
+	go func() {
+		ticker := time.NewTicker(exportCfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("data export goroutine stopped")
+				return
+			case <-ticker.C:
+				ctxTimeout, cancel := context.WithTimeout(ctx, exportCfg.StaleAfter)
+				_, err := forumService.ProcessDataExports(ctxTimeout, exportCfg.TTL, exportCfg.StaleAfter)
 				cancel()
 
 				if err != nil {
-					log.Error("failed to cleanup old messages", slog.Any("error", err))
+					log.Error("failed to process data exports", slog.Any("error", err))
 				}
 			}
 		}
@@ -16,10 +16,11 @@ import (
 )
 
 type App struct {
-	engine *gin.Engine
-	server *http.Server
-	log    *slog.Logger
-	port   int
+	engine      *gin.Engine
+	server      *http.Server
+	chatHandler *chat.ChatHandler
+	log         *slog.Logger
+	port        int
 }
 
 // NewApp инициализирует HTTP-сервер Gin и настраивает маршруты
@@ -29,6 +30,7 @@ func NewApp(
 	handler *forumHandler.ForumHandler,
 	chatHandler *chat.ChatHandler,
 	authMiddleware gin.HandlerFunc,
+	verifiedEmail gin.HandlerFunc,
 ) *App {
 	r := gin.Default()
 
@@ -50,7 +52,7 @@ func NewApp(
 
 		// Приватные маршруты (с авторизацией)
 		privateForumGroup := api.Group("/forum", authMiddleware)
-		forumRoutes.RegisterPrivateRoutes(privateForumGroup, handler)
+		forumRoutes.RegisterPrivateRoutes(privateForumGroup, handler, chatHandler, verifiedEmail)
 	}
 
 	// Healthcheck
@@ -66,10 +68,11 @@ func NewApp(
 	}
 
 	return &App{
-		engine: r,
-		server: httpServer,
-		log:    log,
-		port:   port,
+		engine:      r,
+		server:      httpServer,
+		chatHandler: chatHandler,
+		log:         log,
+		port:        port,
 	}
 }
 
@@ -79,9 +82,13 @@ func (a *App) Run() error {
 	return a.server.ListenAndServe()
 }
 
-// Stop корректно останавливает сервер
+// Stop корректно останавливает сервер. WebSocket-подключения захвачены у http.Server
+// и не закрываются его Shutdown, поэтому сначала закрывается чат.
 func (a *App) Stop(ctx context.Context) error {
 	a.log.Info("HTTP server is stopping...")
+	if err := a.chatHandler.Shutdown(ctx); err != nil {
+		a.log.Warn("failed to close chat connections", slog.Any("error", err))
+	}
 	return a.server.Shutdown(ctx)
 }
 
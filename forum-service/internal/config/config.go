@@ -3,13 +3,33 @@ package config
 import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
+	"time"
 )
 
 type Config struct {
-	Env         string     `yaml:"env" env-default:"local"`
-	StoragePath string     `yaml:"storage_path" env-required:"true"`
-	GRPC        GRPCConfig `yaml:"grpc"`
-	HTTP        HTTPConfig `yaml:"http"`
+	Env         string        `yaml:"env" env-default:"local"`
+	StoragePath string        `yaml:"storage_path" env-required:"true"`
+	GRPC        GRPCConfig    `yaml:"grpc"`
+	HTTP        HTTPConfig    `yaml:"http"`
+	Chat        ChatConfig    `yaml:"chat"`
+	JWKS        JWKSConfig    `yaml:"jwks"`
+	Bans        BansConfig    `yaml:"bans"`
+	Erasure     ErasureConfig `yaml:"erasure"`
+	Export      ExportConfig  `yaml:"export"`
+	// AppID — приложение форума в auth-service: токены пользователей проверяются для него
+	AppID int `yaml:"app_id" env-default:"1"`
+	// Service — учётные данные, с которыми форум вызывает внутренние RPC auth-service
+	Service ServiceConfig `yaml:"service"`
+	// RequireVerifiedEmail запрещает создавать темы, комментарии и сообщения чата
+	// пользователям, не подтвердившим email в auth-service
+	RequireVerifiedEmail bool `yaml:"require_verified_email" env-default:"false"`
+}
+
+// ServiceConfig — приложение форума в auth-service и его секрет для внутренних RPC (баны, удалённые
+// аккаунты, выгрузка данных). AppID должен быть в internal_app_ids auth-service.
+type ServiceConfig struct {
+	AppID     int    `yaml:"app_id" env-default:"1"`
+	AppSecret string `yaml:"app_secret" env:"APP_SECRET" env-required:"true"`
 }
 
 type GRPCConfig struct {
@@ -20,6 +40,52 @@ type HTTPConfig struct {
 	Port int `yaml:"port"`
 }
 
+// ChatConfig — ограничения частоты сообщений и heartbeat WebSocket-чата
+type ChatConfig struct {
+	UserRate     float64       `yaml:"user_rate" env-default:"1"`
+	UserBurst    int           `yaml:"user_burst" env-default:"5"`
+	ConnRate     float64       `yaml:"conn_rate" env-default:"2"`
+	ConnBurst    int           `yaml:"conn_burst" env-default:"10"`
+	SlowMode     time.Duration `yaml:"slow_mode" env-default:"0s"`
+	MuteAfter    int           `yaml:"mute_after" env-default:"10"`
+	MuteWindow   time.Duration `yaml:"mute_window" env-default:"1m"`
+	MuteDuration time.Duration `yaml:"mute_duration" env-default:"5m"`
+	PingInterval time.Duration `yaml:"ping_interval" env-default:"30s"`
+	PongWait     time.Duration `yaml:"pong_wait" env-default:"60s"`
+	WriteWait    time.Duration `yaml:"write_wait" env-default:"10s"`
+	// RetentionSweepInterval — как часто применяется политика хранения сообщений
+	RetentionSweepInterval time.Duration `yaml:"retention_sweep_interval" env-default:"30m"`
+}
+
+// JWKSConfig — кэш открытых ключей auth-service для локальной проверки access token.
+// MinRefreshInterval ограничивает запросы ключей при токенах с незнакомым kid.
+type JWKSConfig struct {
+	CacheTTL           time.Duration `yaml:"cache_ttl" env-default:"10m"`
+	MinRefreshInterval time.Duration `yaml:"min_refresh_interval" env-default:"30s"`
+}
+
+// BansConfig — кэш банов пользователей из auth-service для локальной проверки access token.
+// Бан начинает действовать в форуме не позже чем через CacheTTL.
+type BansConfig struct {
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"5s"`
+}
+
+// ErasureConfig — обработка удалённых в auth-service аккаунтов. ContentPolicy определяет,
+// что станет с темами, комментариями и сообщениями пользователя: anonymize или delete.
+type ErasureConfig struct {
+	ContentPolicy string        `yaml:"content_policy" env-default:"anonymize"`
+	PollInterval  time.Duration `yaml:"poll_interval" env-default:"1m"`
+	BatchSize     int           `yaml:"batch_size" env-default:"100"`
+}
+
+// ExportConfig — выгрузка персональных данных. Готовый архив можно скачать в течение TTL;
+// выгрузка, которая собирается дольше StaleAfter, считается брошенной и собирается заново.
+type ExportConfig struct {
+	TTL          time.Duration `yaml:"ttl" env-default:"24h"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"10s"`
+	StaleAfter   time.Duration `yaml:"stale_after" env-default:"10m"`
+}
+
 func Load(path string) *Config {
 	var config Config
 	err := cleanenv.ReadConfig(path, &config)
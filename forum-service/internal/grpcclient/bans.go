@@ -0,0 +1,149 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"sync"
+	"time"
+)
+
+// BanKindDeleted — вид блокировки, которым auth-service помечает удалённые аккаунты
+const BanKindDeleted = "deleted"
+
+// banRefreshTimeout ограничивает запрос списка банов, чтобы медленный auth-service
+// не задерживал проверку токенов надолго
+const banRefreshTimeout = 2 * time.Second
+
+var errBansNotLoaded = errors.New("ban list is not loaded")
+
+// BanCache хранит действующие баны и приостановки из auth-service для локальной проверки
+// access token, а также недавно удалённые аккаунты. Список перечитывается, когда он старше TTL,
+// поэтому бан начинает действовать в forum-service не позже чем через TTL. Истёкшие приостановки
+// перестают действовать сразу.
+type BanCache struct {
+	authClient ssov1.AuthClient
+	ttl        time.Duration
+
+	mu        sync.Mutex
+	bans      map[int64]cachedBan
+	loaded    bool
+	fetchedAt time.Time
+	// refreshing закрывается по окончании идущего запроса списка; nil — запроса нет
+	refreshing chan struct{}
+	now        func() time.Time
+}
+
+func NewBanCache(authClient ssov1.AuthClient, ttl time.Duration) *BanCache {
+	return &BanCache{
+		authClient: authClient,
+		ttl:        ttl,
+		bans:       map[int64]cachedBan{},
+		now:        time.Now,
+	}
+}
+
+type cachedBan struct {
+	kind string
+	// expiresAt — окончание блокировки; нулевое значение — бессрочно
+	expiresAt time.Time
+}
+
+// BanKind возвращает вид действующей блокировки пользователя userID (ban, suspension или
+// BanKindDeleted) или пустую строку, если пользователь не заблокирован
+func (c *BanCache) BanKind(ctx context.Context, userID int64) (string, error) {
+	const op = "grpcclient.BanCache.BanKind"
+
+	if err := c.ensureFresh(ctx); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ban, ok := c.bans[userID]
+	if !ok || (!ban.expiresAt.IsZero() && !c.now().Before(ban.expiresAt)) {
+		return "", nil
+	}
+
+	return ban.kind, nil
+}
+
+// ensureFresh перечитывает список, если он старше TTL. Запрос к auth-service идёт без блокировки
+// и только один за раз: пока он выполняется, остальные вызовы проверяют по прежнему списку,
+// а если списка ещё нет — ждут окончания запроса.
+func (c *BanCache) ensureFresh(ctx context.Context) error {
+	c.mu.Lock()
+	if c.loaded && c.now().Sub(c.fetchedAt) < c.ttl {
+		c.mu.Unlock()
+		return nil
+	}
+
+	if done := c.refreshing; done != nil {
+		loaded := c.loaded
+		c.mu.Unlock()
+		if loaded {
+			return nil
+		}
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if !c.loaded {
+			return errBansNotLoaded
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	c.refreshing = done
+	// время запроса фиксируется до вызова, чтобы и после ошибки список перечитывался не чаще раза в TTL
+	c.fetchedAt = c.now()
+	c.mu.Unlock()
+
+	bans, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = nil
+	close(done)
+
+	if err != nil {
+		// если auth-service недоступен, продолжаем с последним известным списком
+		if c.loaded {
+			return nil
+		}
+		return err
+	}
+
+	c.bans = bans
+	c.loaded = true
+	return nil
+}
+
+func (c *BanCache) fetch(ctx context.Context) (map[int64]cachedBan, error) {
+	ctx, cancel := context.WithTimeout(ctx, banRefreshTimeout)
+	defer cancel()
+
+	resp, err := c.authClient.ListActiveBans(ctx, &ssov1.ListActiveBansRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make(map[int64]cachedBan, len(resp.GetBans()))
+	for _, ban := range resp.GetBans() {
+		var expiresAt time.Time
+		if ban.GetExpiresAt() > 0 {
+			expiresAt = time.Unix(ban.GetExpiresAt(), 0)
+		}
+		bans[ban.GetUserId()] = cachedBan{kind: ban.GetKind(), expiresAt: expiresAt}
+	}
+
+	return bans, nil
+}
@@ -0,0 +1,32 @@
+package grpcclient
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"strconv"
+)
+
+// appCredentials подтверждает auth-service, что вызывающий — сервис форума. Без них auth-service
+// отклоняет внутренние RPC: ListActiveBans, ListAccountErasures и ExportUserData.
+// Секрет передаётся в метаданных каждого вызова, поэтому соединение с auth-service должно
+// идти по внутренней сети или по TLS.
+type appCredentials struct {
+	appID  int
+	secret string
+}
+
+// WithAppCredentials добавляет к каждому вызову auth-service id сервисного приложения форума и его секрет
+func WithAppCredentials(appID int, secret string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(appCredentials{appID: appID, secret: secret})
+}
+
+func (c appCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{
+		"x-app-id":     strconv.Itoa(c.appID),
+		"x-app-secret": c.secret,
+	}, nil
+}
+
+func (appCredentials) RequireTransportSecurity() bool {
+	return false
+}
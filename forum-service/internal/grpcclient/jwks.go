@@ -0,0 +1,171 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// jwksRefreshTimeout ограничивает запрос ключей, чтобы медленный auth-service
+// не задерживал проверку токенов надолго
+const jwksRefreshTimeout = 2 * time.Second
+
+type publicKey struct {
+	alg string
+	key interface{}
+}
+
+// JWKSCache хранит открытые ключи auth-service. Набор перечитывается раз в TTL,
+// а также при встрече незнакомого kid, но не чаще MinRefreshInterval.
+type JWKSCache struct {
+	authClient         ssov1.AuthClient
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+	// refreshing закрывается по окончании идущего запроса ключей; nil — запроса нет
+	refreshing chan struct{}
+	now        func() time.Time
+}
+
+func NewJWKSCache(authClient ssov1.AuthClient, ttl, minRefreshInterval time.Duration) *JWKSCache {
+	return &JWKSCache{
+		authClient:         authClient,
+		ttl:                ttl,
+		minRefreshInterval: minRefreshInterval,
+		keys:               map[string]publicKey{},
+		now:                time.Now,
+	}
+}
+
+// Key возвращает алгоритм и открытый ключ по kid
+func (c *JWKSCache) Key(ctx context.Context, kid string) (string, interface{}, error) {
+	const op = "grpcclient.JWKSCache.Key"
+
+	if err := c.ensureFresh(ctx, kid); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mu.Lock()
+	key, ok := c.keys[kid]
+	c.mu.Unlock()
+
+	if !ok {
+		return "", nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownKey, kid)
+	}
+
+	return key.alg, key.key, nil
+}
+
+// ensureFresh перечитывает ключи, если известный ключ kid старше TTL или kid незнаком и с прошлого
+// запроса прошло MinRefreshInterval. Запрос к auth-service идёт без блокировки и только один за раз:
+// пока он выполняется, известные ключи принимаются как прежде, а незнакомый kid ждёт его окончания.
+func (c *JWKSCache) ensureFresh(ctx context.Context, kid string) error {
+	c.mu.Lock()
+	_, ok := c.keys[kid]
+	since := c.now().Sub(c.fetchedAt)
+
+	if done := c.refreshing; done != nil {
+		c.mu.Unlock()
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if !(ok && since >= c.ttl) && !(!ok && since >= c.minRefreshInterval) {
+		c.mu.Unlock()
+		return nil
+	}
+
+	done := make(chan struct{})
+	c.refreshing = done
+	// время запроса фиксируется до вызова, чтобы ошибки тоже ограничивались MinRefreshInterval
+	c.fetchedAt = c.now()
+	c.mu.Unlock()
+
+	keys, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = nil
+	close(done)
+
+	if err != nil {
+		// если auth-service недоступен, продолжаем проверять уже известными ключами
+		if ok {
+			return nil
+		}
+		return err
+	}
+
+	c.keys = keys
+	return nil
+}
+
+func (c *JWKSCache) fetch(ctx context.Context) (map[string]publicKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, jwksRefreshTimeout)
+	defer cancel()
+
+	resp, err := c.authClient.GetJWKS(ctx, &ssov1.GetJWKSRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]publicKey, len(resp.GetKeys()))
+	for _, jwk := range resp.GetKeys() {
+		key, err := parseJWK(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("kid %q: %w", jwk.GetKid(), err)
+		}
+		keys[jwk.GetKid()] = publicKey{alg: jwk.GetAlg(), key: key}
+	}
+
+	return keys, nil
+}
+
+func parseJWK(jwk *ssov1.JWK) (interface{}, error) {
+	switch jwk.GetKty() {
+	case "OKP":
+		if jwk.GetCrv() != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.GetCrv())
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.GetX())
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.GetN())
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.GetE())
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.GetKty())
+	}
+}
@@ -0,0 +1,128 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+)
+
+// localValidator проверяет access token по открытым ключам из JWKS без обращения к auth-service.
+// Токены без kid подписаны секретом приложения, который есть только у auth-service,
+// поэтому их проверка по-прежнему уходит в ValidateToken. Остальные методы вызываются как есть.
+// Токены забаненных пользователей отклоняются с PermissionDenied, а токены удалённых
+// аккаунтов — с Unauthenticated, как и в auth-service.
+type localValidator struct {
+	ssov1.AuthClient
+	keys *JWKSCache
+	bans *BanCache
+	log  *slog.Logger
+}
+
+// WithLocalValidation оборачивает клиент auth-service так, что ValidateToken выполняется локально
+func WithLocalValidation(authClient ssov1.AuthClient, keys *JWKSCache, bans *BanCache, log *slog.Logger) ssov1.AuthClient {
+	return &localValidator{AuthClient: authClient, keys: keys, bans: bans, log: log}
+}
+
+func (v *localValidator) ValidateToken(ctx context.Context, req *ssov1.ValidateTokenRequest, opts ...grpc.CallOption) (*ssov1.ValidateTokenResponse, error) {
+	const op = "grpcclient.ValidateToken"
+
+	token, _, err := jwt.NewParser().ParseUnverified(req.GetAccessToken(), jwt.MapClaims{})
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid access token")
+	}
+	if _, ok := token.Header["kid"].(string); !ok {
+		return v.AuthClient.ValidateToken(ctx, req, opts...)
+	}
+
+	resp, err := v.validate(ctx, req)
+	if err != nil {
+		v.log.Debug("access token rejected", slog.String("op", op), slog.Any("error", err))
+		return nil, status.Error(codes.Unauthenticated, "invalid access token")
+	}
+
+	banKind, err := v.bans.BanKind(ctx, resp.GetUserId())
+	if err != nil {
+		v.log.Error("failed to check user ban", slog.String("op", op), slog.Any("error", err))
+		return nil, status.Error(codes.Unavailable, "auth service is unavailable")
+	}
+	switch banKind {
+	case "":
+	case BanKindDeleted:
+		v.log.Debug("access token of deleted user rejected", slog.String("op", op), slog.Int64("user_id", resp.GetUserId()))
+		return nil, status.Error(codes.Unauthenticated, "invalid access token")
+	default:
+		return nil, status.Error(codes.PermissionDenied, "user is banned")
+	}
+
+	return resp, nil
+}
+
+func (v *localValidator) validate(ctx context.Context, req *ssov1.ValidateTokenRequest) (*ssov1.ValidateTokenResponse, error) {
+	token, err := jwt.ParseWithClaims(req.GetAccessToken(), jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		alg, key, err := v.keys.Key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != alg {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return key, nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	if typ, ok := claims["typ"].(string); !ok || typ != "access" {
+		return nil, fmt.Errorf("invalid token type: expected access, got %v", claims["typ"])
+	}
+
+	if appID, ok := claims["app_id"].(float64); !ok || int32(appID) != req.GetAppId() {
+		return nil, fmt.Errorf("token was issued for another app")
+	}
+
+	uid, ok := claims["uid"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("uid claim missing or invalid")
+	}
+
+	email, ok := claims["email"].(string)
+	if !ok {
+		return nil, fmt.Errorf("email claim missing or invalid")
+	}
+
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	var roles []string
+	if rawRoles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range rawRoles {
+			if name, ok := role.(string); ok {
+				roles = append(roles, name)
+			}
+		}
+	}
+
+	// scope есть только у токенов, выданных OAuth-клиентам
+	scope, _ := claims["scope"].(string)
+
+	return &ssov1.ValidateTokenResponse{
+		UserId:        int64(uid),
+		Email:         email,
+		EmailVerified: emailVerified,
+		Roles:         roles,
+		Scopes:        strings.Fields(scope),
+	}, nil
+}
@@ -0,0 +1,374 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/14kear/forum-project/forum-service/internal/services/mocks"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type testKey struct {
+	kid     string
+	private ed25519.PrivateKey
+}
+
+func newTestKey(t *testing.T, kid string) testKey {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return testKey{kid: kid, private: private}
+}
+
+func (k testKey) jwk() *ssov1.JWK {
+	crv := "Ed25519"
+	x := base64.RawURLEncoding.EncodeToString(k.private.Public().(ed25519.PublicKey))
+	return &ssov1.JWK{Kty: "OKP", Kid: k.kid, Use: "sig", Alg: "EdDSA", Crv: &crv, X: &x}
+}
+
+func (k testKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = k.kid
+
+	signed, err := token.SignedString(k.private)
+	require.NoError(t, err)
+	return signed
+}
+
+func accessClaims(appID int) jwt.MapClaims {
+	return jwt.MapClaims{
+		"uid":            int64(7),
+		"email":          "user@example.com",
+		"email_verified": true,
+		"roles":          []string{"moderator", "user"},
+		"app_id":         appID,
+		"typ":            "access",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func newTestValidator(ac *mocks.MockAuthClient) ssov1.AuthClient {
+	ac.EXPECT().ListActiveBans(gomock.Any(), gomock.Any()).Return(&ssov1.ListActiveBansResponse{}, nil).AnyTimes()
+	return newTestValidatorWithBans(ac)
+}
+
+func newTestValidatorWithBans(ac *mocks.MockAuthClient) ssov1.AuthClient {
+	keys := NewJWKSCache(ac, time.Hour, time.Minute)
+	bans := NewBanCache(ac, time.Minute)
+	return WithLocalValidation(ac, keys, bans, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestValidateToken_Local(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := newTestKey(t, "k1")
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	// ключи запрашиваются один раз, ValidateToken в auth-service не вызывается
+	ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{key.jwk()}}, nil).Times(1)
+
+	v := newTestValidator(ac)
+
+	for i := 0; i < 2; i++ {
+		resp, err := v.ValidateToken(context.Background(), &ssov1.ValidateTokenRequest{AccessToken: key.sign(t, accessClaims(1)), AppId: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), resp.GetUserId())
+		assert.Equal(t, "user@example.com", resp.GetEmail())
+		assert.True(t, resp.GetEmailVerified())
+		assert.Equal(t, []string{"moderator", "user"}, resp.GetRoles())
+	}
+}
+
+func TestValidateToken_LocalScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := newTestKey(t, "k1")
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{key.jwk()}}, nil)
+
+	claims := accessClaims(1)
+	claims["scope"] = "forum.read profile"
+
+	resp, err := newTestValidator(ac).ValidateToken(context.Background(), &ssov1.ValidateTokenRequest{AccessToken: key.sign(t, claims), AppId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"forum.read", "profile"}, resp.GetScopes())
+}
+
+func TestValidateToken_Rejected(t *testing.T) {
+	key := newTestKey(t, "k1")
+
+	expired := accessClaims(1)
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+	refresh := accessClaims(1)
+	refresh["typ"] = "refresh"
+
+	forged := newTestKey(t, "k1")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "another app", token: key.sign(t, accessClaims(2))},
+		{name: "expired", token: key.sign(t, expired)},
+		{name: "refresh token", token: key.sign(t, refresh)},
+		{name: "forged signature", token: forged.sign(t, accessClaims(1))},
+		{name: "garbage", token: "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ac := mocks.NewMockAuthClient(ctrl)
+			ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{key.jwk()}}, nil).AnyTimes()
+
+			_, err := newTestValidator(ac).ValidateToken(context.Background(), &ssov1.ValidateTokenRequest{AccessToken: tt.token, AppId: 1})
+			require.Error(t, err)
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
+
+func TestValidateToken_LegacyTokenGoesToAuthService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims(1)).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+
+	req := &ssov1.ValidateTokenRequest{AccessToken: legacy, AppId: 1}
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	ac.EXPECT().ValidateToken(gomock.Any(), req).Return(&ssov1.ValidateTokenResponse{UserId: 7}, nil)
+
+	resp, err := newTestValidator(ac).ValidateToken(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), resp.GetUserId())
+}
+
+func TestJWKSCache_RefreshOnUnknownKid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	old := newTestKey(t, "old")
+	rotated := newTestKey(t, "new")
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	gomock.InOrder(
+		ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{old.jwk()}}, nil),
+		ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{old.jwk(), rotated.jwk()}}, nil),
+	)
+
+	now := time.Now()
+	cache := NewJWKSCache(ac, time.Hour, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	_, _, err := cache.Key(context.Background(), "old")
+	require.NoError(t, err)
+
+	// незнакомый kid не вызывает повторный запрос раньше MinRefreshInterval
+	_, _, err = cache.Key(context.Background(), "new")
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	now = now.Add(time.Minute)
+
+	alg, _, err := cache.Key(context.Background(), "new")
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", alg)
+}
+
+func TestJWKSCache_RefreshDoesNotBlockLookups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := newTestKey(t, "k1")
+	started, release := make(chan struct{}), make(chan struct{})
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	gomock.InOrder(
+		ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{key.jwk()}}, nil),
+		ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *ssov1.GetJWKSRequest, _ ...grpc.CallOption) (*ssov1.GetJWKSResponse, error) {
+				// у запроса свой короткий таймаут
+				_, ok := ctx.Deadline()
+				assert.True(t, ok)
+
+				close(started)
+				<-release
+				return &ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{key.jwk()}}, nil
+			}),
+	)
+
+	now := time.Now()
+	cache := NewJWKSCache(ac, time.Hour, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	_, _, err := cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		_, _, _ = cache.Key(context.Background(), "k1")
+	}()
+	<-started
+
+	// пока ключи перечитываются, известный kid принимается без ожидания auth-service
+	alg, _, err := cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", alg)
+
+	// незнакомый kid ждёт идущий запрос не дольше своего контекста
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = cache.Key(ctx, "other")
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	<-refreshed
+}
+
+func TestValidateToken_LocalBanned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := newTestKey(t, "k1")
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{key.jwk()}}, nil)
+	ac.EXPECT().ListActiveBans(gomock.Any(), gomock.Any()).Return(&ssov1.ListActiveBansResponse{
+		Bans: []*ssov1.UserBan{{UserId: 7, Kind: "ban"}},
+	}, nil)
+
+	_, err := newTestValidatorWithBans(ac).ValidateToken(context.Background(), &ssov1.ValidateTokenRequest{AccessToken: key.sign(t, accessClaims(1)), AppId: 1})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestValidateToken_LocalDeletedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := newTestKey(t, "k1")
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	ac.EXPECT().GetJWKS(gomock.Any(), gomock.Any()).Return(&ssov1.GetJWKSResponse{Keys: []*ssov1.JWK{key.jwk()}}, nil)
+	ac.EXPECT().ListActiveBans(gomock.Any(), gomock.Any()).Return(&ssov1.ListActiveBansResponse{
+		Bans: []*ssov1.UserBan{{UserId: 7, Kind: BanKindDeleted, ExpiresAt: time.Now().Add(time.Hour).Unix()}},
+	}, nil)
+
+	_, err := newTestValidatorWithBans(ac).ValidateToken(context.Background(), &ssov1.ValidateTokenRequest{AccessToken: key.sign(t, accessClaims(1)), AppId: 1})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestBanCache_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	gomock.InOrder(
+		ac.EXPECT().ListActiveBans(gomock.Any(), gomock.Any()).Return(&ssov1.ListActiveBansResponse{
+			Bans: []*ssov1.UserBan{{UserId: 7, Kind: "suspension", ExpiresAt: now.Add(time.Hour).Unix()}},
+		}, nil),
+		ac.EXPECT().ListActiveBans(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unavailable, "unavailable")).Times(2),
+	)
+
+	cache := NewBanCache(ac, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	kind, err := cache.BanKind(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "suspension", kind)
+
+	kind, err = cache.BanKind(context.Background(), 8)
+	require.NoError(t, err)
+	assert.Empty(t, kind)
+
+	// auth-service недоступен: действует последний известный список
+	now = now.Add(time.Minute)
+
+	kind, err = cache.BanKind(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "suspension", kind)
+
+	// приостановка закончилась, хотя список так и не перечитан
+	now = now.Add(time.Hour)
+
+	kind, err = cache.BanKind(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Empty(t, kind)
+}
+
+func TestBanCache_RefreshDoesNotBlockLookups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	started, release := make(chan struct{}), make(chan struct{})
+
+	ac := mocks.NewMockAuthClient(ctrl)
+	gomock.InOrder(
+		ac.EXPECT().ListActiveBans(gomock.Any(), gomock.Any()).Return(&ssov1.ListActiveBansResponse{
+			Bans: []*ssov1.UserBan{{UserId: 7, Kind: "ban"}},
+		}, nil),
+		ac.EXPECT().ListActiveBans(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *ssov1.ListActiveBansRequest, _ ...grpc.CallOption) (*ssov1.ListActiveBansResponse, error) {
+				// у запроса свой короткий таймаут
+				_, ok := ctx.Deadline()
+				assert.True(t, ok)
+
+				close(started)
+				<-release
+				return &ssov1.ListActiveBansResponse{}, nil
+			}),
+	)
+
+	cache := NewBanCache(ac, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	kind, err := cache.BanKind(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, "ban", kind)
+
+	now = now.Add(time.Minute)
+
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		_, _ = cache.BanKind(context.Background(), 7)
+	}()
+	<-started
+
+	// пока список перечитывается, проверка идёт по прежнему списку и не ждёт auth-service
+	kind, err = cache.BanKind(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "ban", kind)
+
+	close(release)
+	<-refreshed
+
+	kind, err = cache.BanKind(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Empty(t, kind)
+}
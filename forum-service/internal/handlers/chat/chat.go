@@ -1,36 +1,141 @@
 package chat
 
 import (
+	"context"
+	"errors"
+	"github.com/14kear/forum-project/forum-service/internal/models"
 	"github.com/14kear/forum-project/forum-service/internal/services/forum"
+	"github.com/14kear/forum-project/forum-service/internal/storage"
 	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type ChatHandler struct {
 	chatService *forum.Forum
 	authService ssov1.AuthClient
+	hub         *Hub
+	limiter     *rateLimiter
+	keepalive   Keepalive
 	appID       int
-	log         *slog.Logger
+	// requireVerifiedEmail запрещает отправку сообщений без подтверждённого email
+	requireVerifiedEmail bool
+	log                  *slog.Logger
 }
 
+// сколько сообщений истории читается из хранилища за один запрос при переподключении
+const replayBatchSize = 100
+
 // MessageResponse представляет структуру ответа с сообщением в чате
 // swagger:model
 type MessageResponse struct {
-	ID        int64  `json:"id"`
-	Content   string `json:"content"`
-	UserID    int64  `json:"userID"`
-	UserEmail string `json:"userEmail"`
+	ID        int64      `json:"id"`
+	Content   string     `json:"content"`
+	UserID    int64      `json:"userID"`
+	UserEmail string     `json:"userEmail"`
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+	// Deleted помечает tombstone удалённого сообщения, Content при этом пустой
+	Deleted bool `json:"deleted,omitempty"`
 }
 
-func NewChatHandler(chatService *forum.Forum, authServer ssov1.AuthClient, appID int, log *slog.Logger) *ChatHandler {
+// ErrorFrame — ошибка, отправляемая клиенту по WebSocket. Для ограничений частоты
+// Code принимает значения rate_limited, slow_mode или muted, а RetryAfterMs сообщает,
+// через сколько миллисекунд клиент может отправить сообщение снова.
+// swagger:model
+type ErrorFrame struct {
+	Error        string `json:"error"`
+	Code         string `json:"code,omitempty"`
+	RetryAfterMs int64  `json:"retryAfterMs,omitempty"`
+}
+
+// SlowModeRequest задаёт минимальный интервал между сообщениями одного пользователя, 0 выключает slow mode
+// swagger:model
+type SlowModeRequest struct {
+	IntervalSeconds int `json:"intervalSeconds" binding:"min=0"`
+}
+
+// SlowModeResponse содержит текущий интервал slow mode
+// swagger:model
+type SlowModeResponse struct {
+	IntervalSeconds int `json:"intervalSeconds"`
+}
+
+// RetentionPolicyRequest задаёт политику хранения сообщений чата.
+// Mode: delete — удалять сообщения старше maxAgeSeconds, archive — переносить их в архив,
+// keep — хранить бессрочно (maxAgeSeconds игнорируется).
+// swagger:model
+type RetentionPolicyRequest struct {
+	Mode          string `json:"mode" binding:"required,oneof=delete archive keep"`
+	MaxAgeSeconds int64  `json:"maxAgeSeconds" binding:"min=0"`
+}
+
+// RetentionPolicyResponse описывает текущую политику хранения сообщений чата
+// swagger:model
+type RetentionPolicyResponse struct {
+	Mode          string    `json:"mode"`
+	MaxAgeSeconds int64     `json:"maxAgeSeconds"`
+	UpdatedBy     int64     `json:"updatedBy,omitempty"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// RetentionSweepResponse сообщает, сколько сообщений удалено из чата при очистке
+// swagger:model
+type RetentionSweepResponse struct {
+	Removed int64 `json:"removed"`
+}
+
+// EditChatMessageRequest описывает новый текст сообщения
+// swagger:model
+type EditChatMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// incomingMessage — фрейм от клиента. Пустой action означает отправку нового сообщения,
+// edit и delete меняют уже отправленное сообщение с указанным id, auth продлевает
+// сессию новым accessToken.
+type incomingMessage struct {
+	Action      string `json:"action"`
+	ID          int64  `json:"id"`
+	Content     string `json:"content"`
+	AccessToken string `json:"accessToken"`
+}
+
+var limitErrors = map[string]string{
+	codeRateLimited: "too many messages",
+	codeSlowMode:    "slow mode is enabled",
+	codeMuted:       "you are temporarily muted",
+}
+
+const (
+	actionSend   = "send"
+	actionEdit   = "edit"
+	actionDelete = "delete"
+	actionAuth   = "auth"
+)
+
+func NewChatHandler(
+	chatService *forum.Forum,
+	authServer ssov1.AuthClient,
+	appID int,
+	limits Limits,
+	keepalive Keepalive,
+	requireVerifiedEmail bool,
+	log *slog.Logger,
+) *ChatHandler {
 	return &ChatHandler{
-		chatService: chatService,
-		authService: authServer,
-		appID:       appID,
-		log:         log,
+		chatService:          chatService,
+		authService:          authServer,
+		hub:                  NewHub(),
+		limiter:              newRateLimiter(limits),
+		keepalive:            keepalive.withDefaults(),
+		appID:                appID,
+		requireVerifiedEmail: requireVerifiedEmail,
+		log:                  log,
 	}
 }
 
@@ -43,9 +148,14 @@ var upgrader = websocket.Upgrader{
 // HandleWebSocket godoc
 // @Summary WebSocket endpoint for chat
 // @Description Establishes a WebSocket connection for exchanging chat messages. Used only for WebSocket clients. Requires `accessToken` in query parameters.
+// @Description If `since_id` is set, all messages with a greater ID are replayed in order before live delivery starts. Clients that reconnect pass the ID of the last message they received.
+// @Description Messages are rate limited per user and per connection. A rejected message produces an error frame `{"error": "...", "code": "rate_limited"|"slow_mode"|"muted", "retryAfterMs": n}`; repeated violations mute the user for a while.
+// @Description The server pings every connection and drops peers that stop answering. Before the access token expires the client sends `{"action": "auth", "accessToken": "<new token>"}` and receives `{"event": "reauthenticated", "expiresAt": ...}`; otherwise it gets a `token_expired` error frame and the connection is closed with code 1008. On shutdown clients receive close code 1001.
 // @Tags chat
 // @Param accessToken query string true "Access token for authentication"
+// @Param since_id query int false "ID of the last received message to resume from"
 // @Success 101 {string} string "Switching Protocols – WebSocket connection established"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid since_id"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized – invalid or missing token"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /api/forum/ws/chat [get]
@@ -64,20 +174,37 @@ func (h *ChatHandler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	sinceIDStr, resume := c.GetQuery("since_id")
+	var sinceID int64
+	if resume {
+		var err error
+		sinceID, err = strconv.ParseInt(sinceIDStr, 10, 64)
+		if err != nil || sinceID < 0 {
+			log.Warn("invalid since_id", slog.String("since_id", sinceIDStr))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since_id"})
+			return
+		}
+	}
+
 	claims, err := h.authService.ValidateToken(ctx, &ssov1.ValidateTokenRequest{
 		AccessToken: accessToken,
 		AppId:       int32(h.appID),
 	})
+	var expiresAt time.Time
+	if err == nil {
+		expiresAt, err = tokenExpiry(accessToken)
+	}
 	if err != nil {
 		log.Warn("invalid access token", slog.Any("error", err))
 		conn, _ := upgrader.Upgrade(c.Writer, c.Request, nil)
-		_ = conn.WriteJSON(map[string]string{"error": "unauthorized"})
+		_ = conn.WriteJSON(ErrorFrame{Error: "unauthorized"})
 		_ = conn.Close()
 		return
 	}
 
 	userID := claims.GetUserId()
 	userEmail := claims.GetEmail()
+	emailVerified := claims.GetEmailVerified()
 
 	log = log.With(
 		slog.Int64("userID", userID),
@@ -89,54 +216,281 @@ func (h *ChatHandler) HandleWebSocket(c *gin.Context) {
 		log.Error("failed to upgrade connection", slog.Any("error", err))
 		return
 	}
-	defer func() {
-		if err := conn.Close(); err != nil {
-			log.Error("failed to close connection", slog.Any("error", err))
+
+	// Клиент регистрируется до догрузки истории: сообщения, пришедшие во время
+	// догрузки, накапливаются в очереди и не теряются.
+	cl := &client{send: make(chan any, sendBufferSize)}
+	if !h.hub.register(cl) {
+		log.Warn("chat is shutting down, rejecting connection")
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"), time.Now().Add(h.keepalive.WriteWait))
+		_ = conn.Close()
+		return
+	}
+
+	var replayed map[int64]struct{}
+	if resume {
+		replayed, err = h.replay(ctx, conn, sinceID)
+		if err != nil {
+			log.Error("failed to replay chat history", slog.Any("error", err))
+			_ = conn.WriteJSON(ErrorFrame{Error: "internal server error"})
 		}
+	}
+
+	done := make(chan struct{})
+	go h.writePump(conn, cl, replayed, done, log)
+
+	defer func() {
+		h.hub.unregister(cl)
+		<-done
+		h.hub.release()
 	}()
 
-	log.Info("WebSocket connection established")
+	if err != nil {
+		return
+	}
+
+	log.Info("WebSocket connection established", slog.Int("replayed", len(replayed)))
+
+	// модераторы чата (право forum.chat.manage) не подпадают под slow mode
+	canManage, err := h.chatService.CanManageChat(ctx, userID)
+	if err != nil {
+		log.Warn("failed to check chat manage permission", slog.Any("error", err))
+	}
+
+	connBucket := h.limiter.newConnBucket()
+
+	// Без продления токена сообщением auth клиент отключается, когда истекает его access token.
+	sess := newSession(expiresAt, func() {
+		log.Info("access token expired, closing connection")
+		h.hub.sendTo(cl, ErrorFrame{Error: "access token expired", Code: codeTokenExpired})
+		h.hub.sendTo(cl, closeFrame{code: websocket.ClosePolicyViolation, text: "access token expired"})
+	})
+	defer sess.stop()
+
+	// Клиент, от которого дольше PongWait не пришло ни одного фрейма (включая pong), считается мёртвым.
+	_ = conn.SetReadDeadline(time.Now().Add(h.keepalive.PongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(h.keepalive.PongWait))
+	})
 
 	for {
-		var incoming struct {
-			Content string `json:"content"`
-		}
+		var incoming incomingMessage
 
 		if err := conn.ReadJSON(&incoming); err != nil {
-			if websocket.IsUnexpectedCloseError(err) {
+			var netErr net.Error
+			switch {
+			case errors.As(err, &netErr) && netErr.Timeout():
+				log.Info("peer is not responding, closing connection")
+			case websocket.IsUnexpectedCloseError(err):
 				log.Info("connection closed by client")
-			} else {
+			default:
 				log.Error("failed to read message", slog.Any("error", err))
 			}
 			break
 		}
+		_ = conn.SetReadDeadline(time.Now().Add(h.keepalive.PongWait))
+
+		if incoming.Action == actionAuth {
+			claims := h.reauth(ctx, cl, sess, incoming.AccessToken, userID, log)
+			if claims == nil {
+				break
+			}
+			// email мог быть подтверждён уже после подключения
+			emailVerified = claims.GetEmailVerified()
+			continue
+		}
+
+		send := incoming.Action == "" || incoming.Action == actionSend
+		if send && h.requireVerifiedEmail && !emailVerified {
+			h.hub.sendTo(cl, ErrorFrame{Error: "email is not verified", Code: codeEmailNotVerified})
+			continue
+		}
+		if code, wait, ok := h.limiter.allow(userID, connBucket, send, canManage); !ok {
+			log.Warn("chat message rejected by rate limiter", slog.String("code", code), slog.Duration("retryAfter", wait))
+			h.hub.sendTo(cl, ErrorFrame{Error: limitErrors[code], Code: code, RetryAfterMs: wait.Milliseconds()})
+			continue
+		}
+
+		switch incoming.Action {
+		case "", actionSend:
+		case actionEdit, actionDelete:
+			h.changeMessage(ctx, cl, incoming, userID, log)
+			continue
+		default:
+			h.hub.sendTo(cl, ErrorFrame{Error: "unknown action"})
+			continue
+		}
 
 		log.Debug("message received", slog.String("content", incoming.Content))
 
 		chatMessageID, err := h.chatService.CreateChatMessage(ctx, userID, incoming.Content, userEmail)
+		if errors.Is(err, forum.ErrForbidden) {
+			log.Warn("chat message rejected: user may not post")
+			h.hub.sendTo(cl, ErrorFrame{Error: "forbidden"})
+			continue
+		}
 		if err != nil {
 			log.Error("failed to create chat message",
 				slog.Any("error", err),
 				slog.String("content", incoming.Content),
 			)
-			_ = conn.WriteJSON(map[string]string{"error": "internal server error"})
+			h.hub.sendTo(cl, ErrorFrame{Error: "internal server error"})
 			break
 		}
 
-		response := MessageResponse{
+		h.hub.broadcast(MessageResponse{
 			ID:        chatMessageID,
 			Content:   incoming.Content,
 			UserID:    userID,
 			UserEmail: userEmail,
+		})
+	}
+}
+
+// reauth продлевает сессию новым access token того же пользователя и возвращает его claims.
+// При ошибке клиенту отправляется ошибка и close-фрейм, reauth возвращает nil,
+// а обработчик должен завершить чтение.
+func (h *ChatHandler) reauth(ctx context.Context, cl *client, sess *session, accessToken string, userID int64, log *slog.Logger) *ssov1.ValidateTokenResponse {
+	reject := func(reason string) *ssov1.ValidateTokenResponse {
+		h.hub.sendTo(cl, ErrorFrame{Error: reason, Code: codeUnauthorized})
+		h.hub.sendTo(cl, closeFrame{code: websocket.ClosePolicyViolation, text: reason})
+		return nil
+	}
+
+	claims, err := h.authService.ValidateToken(ctx, &ssov1.ValidateTokenRequest{
+		AccessToken: accessToken,
+		AppId:       int32(h.appID),
+	})
+	if err != nil {
+		log.Warn("re-authentication failed", slog.Any("error", err))
+		return reject("unauthorized")
+	}
+
+	if claims.GetUserId() != userID {
+		log.Warn("re-authentication with token of another user", slog.Int64("tokenUserID", claims.GetUserId()))
+		return reject("token belongs to another user")
+	}
+
+	expiresAt, err := tokenExpiry(accessToken)
+	if err != nil {
+		log.Warn("failed to read token expiry", slog.Any("error", err))
+		return reject("unauthorized")
+	}
+
+	if !sess.extend(expiresAt) {
+		return nil
+	}
+
+	log.Info("session re-authenticated", slog.Time("expiresAt", expiresAt))
+	h.hub.sendTo(cl, ReauthFrame{Event: "reauthenticated", ExpiresAt: expiresAt})
+	return claims
+}
+
+// changeMessage применяет edit/delete из WebSocket и рассылает обновлённое сообщение всем клиентам.
+// Ошибка отправляется только автору запроса, соединение при этом не разрывается.
+func (h *ChatHandler) changeMessage(ctx context.Context, cl *client, in incomingMessage, userID int64, log *slog.Logger) {
+	var (
+		msg models.ChatMessage
+		err error
+	)
+	if in.Action == actionEdit {
+		msg, err = h.chatService.EditChatMessage(ctx, in.ID, userID, in.Content)
+	} else {
+		msg, err = h.chatService.DeleteChatMessage(ctx, in.ID, userID)
+	}
+	if err != nil {
+		log.Warn("failed to change chat message", slog.String("action", in.Action), slog.Int64("chatMessageID", in.ID), slog.Any("error", err))
+		_, msg := errorStatus(err)
+		h.hub.sendTo(cl, ErrorFrame{Error: msg})
+		return
+	}
+
+	h.hub.broadcast(newMessageResponse(msg))
+}
+
+// replay отправляет клиенту все сообщения с ID больше sinceID и возвращает
+// множество отправленных ID, чтобы не продублировать их при живой доставке.
+func (h *ChatHandler) replay(ctx context.Context, conn *websocket.Conn, sinceID int64) (map[int64]struct{}, error) {
+	replayed := make(map[int64]struct{})
+	lastID := sinceID
+
+	for {
+		messages, err := h.chatService.ChatMessagesSince(ctx, lastID, replayBatchSize)
+		if err != nil {
+			return replayed, err
 		}
 
-		if err := conn.WriteJSON(response); err != nil {
-			log.Error("failed to send response", slog.Any("error", err))
-			break
+		for _, m := range messages {
+			_ = conn.SetWriteDeadline(time.Now().Add(h.keepalive.WriteWait))
+			if err := conn.WriteJSON(newMessageResponse(m)); err != nil {
+				return replayed, err
+			}
+			lastID = int64(m.ID)
+			replayed[lastID] = struct{}{}
+		}
+
+		if len(messages) < replayBatchSize {
+			return replayed, nil
+		}
+	}
+}
+
+// writePump — единственный писатель в соединение. Отправляет клиенту сообщения из очереди
+// и периодические ping. Завершается, когда hub закрывает очередь клиента или просит
+// закрыть соединение, после чего закрывает соединение.
+func (h *ChatHandler) writePump(conn *websocket.Conn, cl *client, replayed map[int64]struct{}, done chan<- struct{}, log *slog.Logger) {
+	ticker := time.NewTicker(h.keepalive.PingInterval)
+	defer close(done)
+	defer ticker.Stop()
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Error("failed to close connection", slog.Any("error", err))
+		}
+	}()
+
+	for {
+		select {
+		case msg, ok := <-cl.send:
+			if !ok {
+				return
+			}
+
+			if f, ok := msg.(closeFrame); ok {
+				err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(f.code, f.text), time.Now().Add(h.keepalive.WriteWait))
+				if err != nil {
+					log.Warn("failed to send close frame", slog.Any("error", err))
+				}
+				return
+			}
+
+			// повторно не отправляем только создание уже догруженных сообщений, правки и удаления доставляются всегда
+			if m, ok := msg.(MessageResponse); ok && m.EditedAt == nil && !m.Deleted {
+				if _, seen := replayed[m.ID]; seen {
+					delete(replayed, m.ID)
+					continue
+				}
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(h.keepalive.WriteWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				log.Error("failed to send response", slog.Any("error", err))
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.keepalive.WriteWait)); err != nil {
+				log.Warn("failed to send ping", slog.Any("error", err))
+				return
+			}
 		}
 	}
 }
 
+// Shutdown отправляет всем подключённым клиентам close-фрейм и ждёт, пока подключения закроются.
+// Новые подключения после вызова не принимаются.
+func (h *ChatHandler) Shutdown(ctx context.Context) error {
+	return h.hub.shutdown(ctx, closeFrame{code: websocket.CloseGoingAway, text: "server is shutting down"})
+}
+
 // GetChatMessages godoc
 // @Summary Get all chat messages
 // @Description Returns a list of all messages from a chat
@@ -158,13 +512,318 @@ func (h *ChatHandler) GetChatMessages(c *gin.Context) {
 
 	var response []MessageResponse
 	for _, m := range messages {
-		response = append(response, MessageResponse{
-			ID:        int64(m.ID),
-			Content:   m.Content,
-			UserID:    m.UserID,
-			UserEmail: m.UserEmail,
-		})
+		response = append(response, newMessageResponse(m))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// EditChatMessage godoc
+// @Summary Edit a chat message
+// @Description Replaces the content of a chat message (author only). The change is pushed to all connected WebSocket clients.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path int true "Chat message ID"
+// @Param input body EditChatMessageRequest true "New content"
+// @Success 200 {object} MessageResponse "Edited message"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid input or message ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not the author"
+// @Failure 404 {object} handlers.ErrorResponse "Message not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/ws/chat/messages/{id} [put]
+func (h *ChatHandler) EditChatMessage(c *gin.Context) {
+	const op = "chat.EditChatMessage"
+	log := h.log.With(slog.String("op", op))
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message ID"})
+		return
 	}
 
+	var req EditChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	userID, ok := userIDValue.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id in context"})
+		return
+	}
+
+	msg, err := h.chatService.EditChatMessage(c.Request.Context(), id, userID, req.Content)
+	if err != nil {
+		log.Warn("failed to edit chat message", slog.Any("error", err))
+		status, msg := errorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	response := newMessageResponse(msg)
+	h.hub.broadcast(response)
+
 	c.JSON(http.StatusOK, response)
 }
+
+// DeleteChatMessage godoc
+// @Summary Delete a chat message
+// @Description Deletes a chat message (author or admin). Connected WebSocket clients receive a tombstone with `deleted: true`.
+// @Tags chat
+// @Param id path int true "Chat message ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid message ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Neither the author nor an admin"
+// @Failure 404 {object} handlers.ErrorResponse "Message not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/ws/chat/messages/{id} [delete]
+func (h *ChatHandler) DeleteChatMessage(c *gin.Context) {
+	const op = "chat.DeleteChatMessage"
+	log := h.log.With(slog.String("op", op))
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message ID"})
+		return
+	}
+
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	userID, ok := userIDValue.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id in context"})
+		return
+	}
+
+	msg, err := h.chatService.DeleteChatMessage(c.Request.Context(), id, userID)
+	if err != nil {
+		log.Warn("failed to delete chat message", slog.Any("error", err))
+		status, msg := errorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	h.hub.broadcast(newMessageResponse(msg))
+
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+// GetSlowMode godoc
+// @Summary Get chat slow mode
+// @Description Returns the minimum interval between messages of one user
+// @Tags chat
+// @Produce json
+// @Success 200 {object} SlowModeResponse "Current slow mode interval"
+// @Router /api/forum/ws/chat/slow-mode [get]
+func (h *ChatHandler) GetSlowMode(c *gin.Context) {
+	c.JSON(http.StatusOK, SlowModeResponse{IntervalSeconds: int(h.limiter.slowMode() / time.Second)})
+}
+
+// SetSlowMode godoc
+// @Summary Set chat slow mode
+// @Description Sets and persists the minimum interval between messages of one user (requires forum.chat.manage). 0 disables slow mode. Users with forum.chat.manage are not affected by slow mode.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param input body SlowModeRequest true "Slow mode interval"
+// @Success 200 {object} SlowModeResponse "New slow mode interval"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid input"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not an admin"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/ws/chat/slow-mode [put]
+func (h *ChatHandler) SetSlowMode(c *gin.Context) {
+	const op = "chat.SetSlowMode"
+	log := h.log.With(slog.String("op", op))
+
+	var req SlowModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	userID, ok := userIDValue.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id in context"})
+		return
+	}
+
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if err := h.chatService.SetChatSlowMode(c.Request.Context(), userID, interval); err != nil {
+		log.Warn("failed to set slow mode", slog.Any("error", err))
+		status, msg := errorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	h.limiter.setSlowMode(interval)
+	log.Info("slow mode changed", slog.Int64("userID", userID), slog.Int("intervalSeconds", req.IntervalSeconds))
+
+	c.JSON(http.StatusOK, SlowModeResponse{IntervalSeconds: req.IntervalSeconds})
+}
+
+// GetRetentionPolicy godoc
+// @Summary Get chat retention policy
+// @Description Returns how long chat messages are kept and what happens to them afterwards
+// @Tags chat
+// @Produce json
+// @Success 200 {object} RetentionPolicyResponse "Current retention policy"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/ws/chat/retention [get]
+func (h *ChatHandler) GetRetentionPolicy(c *gin.Context) {
+	const op = "chat.GetRetentionPolicy"
+	log := h.log.With(slog.String("op", op))
+
+	policy, err := h.chatService.ChatRetentionPolicy(c.Request.Context())
+	if err != nil {
+		log.Error("failed to get chat retention policy", slog.Any("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newRetentionPolicyResponse(policy))
+}
+
+// SetRetentionPolicy godoc
+// @Summary Set chat retention policy
+// @Description Changes the chat retention policy (admin only). Modes: delete removes messages older than maxAgeSeconds, archive moves them to the archive table first, keep stores messages forever.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param input body RetentionPolicyRequest true "Retention policy"
+// @Success 200 {object} RetentionPolicyResponse "New retention policy"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid input"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not an admin"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/ws/chat/retention [put]
+func (h *ChatHandler) SetRetentionPolicy(c *gin.Context) {
+	const op = "chat.SetRetentionPolicy"
+	log := h.log.With(slog.String("op", op))
+
+	var req RetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	userID, ok := userIDValue.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id in context"})
+		return
+	}
+
+	policy, err := h.chatService.SetChatRetentionPolicy(c.Request.Context(), userID, req.Mode, time.Duration(req.MaxAgeSeconds)*time.Second)
+	if err != nil {
+		log.Warn("failed to set chat retention policy", slog.Any("error", err))
+		status, msg := errorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, newRetentionPolicyResponse(policy))
+}
+
+// SweepChatMessages godoc
+// @Summary Apply chat retention now
+// @Description Applies the current retention policy immediately (admin only) and reports how many messages were removed from the chat
+// @Tags chat
+// @Produce json
+// @Success 200 {object} RetentionSweepResponse "Number of removed messages"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not an admin"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/ws/chat/retention/sweep [post]
+func (h *ChatHandler) SweepChatMessages(c *gin.Context) {
+	const op = "chat.SweepChatMessages"
+	log := h.log.With(slog.String("op", op))
+
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	userID, ok := userIDValue.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id in context"})
+		return
+	}
+
+	removed, err := h.chatService.SweepChatMessages(c.Request.Context(), userID)
+	if err != nil {
+		log.Warn("failed to sweep chat messages", slog.Any("error", err))
+		status, msg := errorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, RetentionSweepResponse{Removed: removed})
+}
+
+func newRetentionPolicyResponse(p models.ChatRetentionPolicy) RetentionPolicyResponse {
+	return RetentionPolicyResponse{
+		Mode:          p.Mode,
+		MaxAgeSeconds: int64(p.MaxAge / time.Second),
+		UpdatedBy:     p.UpdatedBy,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+func newMessageResponse(m models.ChatMessage) MessageResponse {
+	return MessageResponse{
+		ID:        int64(m.ID),
+		Content:   m.Content,
+		UserID:    m.UserID,
+		UserEmail: m.UserEmail,
+		EditedAt:  m.EditedAt,
+		Deleted:   m.DeletedAt != nil,
+	}
+}
+
+// errorStatus сопоставляет ошибку сервиса с HTTP-статусом и текстом для клиента
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, forum.ErrValidation):
+		return http.StatusBadRequest, "invalid input"
+	case errors.Is(err, forum.ErrForbidden):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, storage.ErrChatMessageNotFound):
+		return http.StatusNotFound, "chat message not found"
+	default:
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
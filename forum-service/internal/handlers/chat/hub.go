@@ -0,0 +1,126 @@
+package chat
+
+import (
+	"context"
+	"sync"
+)
+
+// размер очереди исходящих сообщений одного клиента
+const sendBufferSize = 256
+
+// client — одно WebSocket-подключение к чату
+type client struct {
+	send chan any
+}
+
+// closeFrame — команда писателю отправить клиенту close-фрейм и закрыть соединение
+type closeFrame struct {
+	code int
+	text string
+}
+
+// Hub хранит активные подключения к чату и рассылает им новые сообщения
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	closed  bool
+	// conns считает обработчики подключений, которые ещё не завершились
+	conns sync.WaitGroup
+}
+
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*client]struct{})}
+}
+
+// register добавляет клиента. После shutdown новые клиенты не принимаются.
+// Каждый успешный register должен завершаться вызовом release.
+func (h *Hub) register(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+
+	h.clients[c] = struct{}{}
+	h.conns.Add(1)
+	return true
+}
+
+// release отмечает, что обработчик подключения полностью завершился
+func (h *Hub) release() {
+	h.conns.Done()
+}
+
+// unregister удаляет клиента и закрывает его очередь. Повторный вызов безопасен.
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// broadcast отправляет сообщение всем клиентам. Клиенты, которые не успевают
+// разбирать очередь, отключаются, чтобы не блокировать остальных.
+func (h *Hub) broadcast(msg any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients {
+		select {
+		case c.send <- msg:
+		default:
+			delete(h.clients, c)
+			close(c.send)
+		}
+	}
+}
+
+// sendTo кладёт сообщение в очередь одного клиента, не блокируясь.
+func (h *Hub) sendTo(c *client, msg any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+
+	select {
+	case c.send <- msg:
+	default:
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// shutdown отправляет всем клиентам close-фрейм и ждёт, пока обработчики подключений
+// завершатся, либо пока не истечёт ctx.
+func (h *Hub) shutdown(ctx context.Context, frame closeFrame) error {
+	h.mu.Lock()
+	h.closed = true
+	for c := range h.clients {
+		select {
+		case c.send <- frame:
+		default:
+			delete(h.clients, c)
+			close(c.send)
+		}
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
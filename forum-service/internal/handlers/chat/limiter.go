@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// коды ошибок ограничения частоты, которые получает клиент в ErrorFrame
+const (
+	codeRateLimited = "rate_limited"
+	codeSlowMode    = "slow_mode"
+	codeMuted       = "muted"
+)
+
+// через сколько простоя состояние пользователя удаляется из памяти
+const userStateTTL = 10 * time.Minute
+
+// Limits — настройки ограничения частоты сообщений в чате
+type Limits struct {
+	// UserRate и UserBurst — token bucket на пользователя (общий для всех его подключений)
+	UserRate  float64
+	UserBurst int
+	// ConnRate и ConnBurst — token bucket на одно подключение
+	ConnRate  float64
+	ConnBurst int
+	// SlowMode — минимальный интервал между новыми сообщениями пользователя, 0 — выключен
+	SlowMode time.Duration
+	// После MuteAfter нарушений за MuteWindow пользователь не может писать MuteDuration
+	MuteAfter    int
+	MuteWindow   time.Duration
+	MuteDuration time.Duration
+}
+
+// tokenBucket пополняется на rate токенов в секунду, но хранит не больше burst.
+// Нулевой rate отключает ограничение.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// take забирает токен. Если токена нет, возвращает время до появления следующего.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	if b.rate <= 0 {
+		return true, 0
+	}
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, wait
+}
+
+type userState struct {
+	bucket      *tokenBucket
+	lastSent    time.Time
+	violations  int
+	windowStart time.Time
+	mutedUntil  time.Time
+	lastSeen    time.Time
+}
+
+// rateLimiter хранит состояние ограничений по пользователям
+type rateLimiter struct {
+	mu        sync.Mutex
+	limits    Limits
+	users     map[int64]*userState
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(limits Limits) *rateLimiter {
+	return &rateLimiter{
+		limits: limits,
+		users:  make(map[int64]*userState),
+		now:    time.Now,
+	}
+}
+
+func (l *rateLimiter) newConnBucket() *tokenBucket {
+	return newTokenBucket(l.limits.ConnRate, l.limits.ConnBurst, l.now())
+}
+
+func (l *rateLimiter) slowMode() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limits.SlowMode
+}
+
+func (l *rateLimiter) setSlowMode(interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits.SlowMode = interval
+}
+
+// allow проверяет, может ли пользователь выполнить действие прямо сейчас.
+// send — отправка нового сообщения (на неё действует slow mode), exempt — пользователь
+// не подпадает под slow mode (администратор). При отказе возвращает код и время ожидания.
+func (l *rateLimiter) allow(userID int64, conn *tokenBucket, send, exempt bool) (string, time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	st, ok := l.users[userID]
+	if !ok {
+		st = &userState{bucket: newTokenBucket(l.limits.UserRate, l.limits.UserBurst, now)}
+		l.users[userID] = st
+	}
+	st.lastSeen = now
+
+	if now.Before(st.mutedUntil) {
+		return codeMuted, st.mutedUntil.Sub(now), false
+	}
+
+	if ok, wait := conn.take(now); !ok {
+		return l.violation(st, now, codeRateLimited, wait)
+	}
+	if ok, wait := st.bucket.take(now); !ok {
+		return l.violation(st, now, codeRateLimited, wait)
+	}
+
+	if send && !exempt && l.limits.SlowMode > 0 {
+		if since := now.Sub(st.lastSent); since < l.limits.SlowMode {
+			return l.violation(st, now, codeSlowMode, l.limits.SlowMode-since)
+		}
+	}
+
+	if send {
+		st.lastSent = now
+	}
+
+	return "", 0, true
+}
+
+// violation засчитывает нарушение и при превышении порога временно отключает пользователю чат
+func (l *rateLimiter) violation(st *userState, now time.Time, code string, wait time.Duration) (string, time.Duration, bool) {
+	if l.limits.MuteAfter <= 0 {
+		return code, wait, false
+	}
+
+	if now.Sub(st.windowStart) > l.limits.MuteWindow {
+		st.windowStart = now
+		st.violations = 0
+	}
+	st.violations++
+
+	if st.violations >= l.limits.MuteAfter {
+		st.violations = 0
+		st.mutedUntil = now.Add(l.limits.MuteDuration)
+		return codeMuted, l.limits.MuteDuration, false
+	}
+
+	return code, wait, false
+}
+
+// prune раз в userStateTTL удаляет давно неактивных пользователей, чтобы карта не росла бесконечно
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < userStateTTL {
+		return
+	}
+	l.lastPrune = now
+
+	for id, st := range l.users {
+		if now.Sub(st.lastSeen) > userStateTTL && now.After(st.mutedUntil) {
+			delete(l.users, id)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(limits Limits) (*rateLimiter, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(limits)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiter_UserBurst(t *testing.T) {
+	l, now := newTestLimiter(Limits{UserRate: 1, UserBurst: 2})
+	conn := l.newConnBucket()
+
+	for i := 0; i < 2; i++ {
+		_, _, ok := l.allow(1, conn, true, false)
+		require.True(t, ok)
+	}
+
+	code, wait, ok := l.allow(1, conn, true, false)
+	require.False(t, ok)
+	assert.Equal(t, codeRateLimited, code)
+	assert.Equal(t, time.Second, wait)
+
+	// другой пользователь не затронут
+	_, _, ok = l.allow(2, l.newConnBucket(), true, false)
+	assert.True(t, ok)
+
+	*now = now.Add(time.Second)
+	_, _, ok = l.allow(1, conn, true, false)
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_ConnBucketSharedAcrossUsers(t *testing.T) {
+	l, _ := newTestLimiter(Limits{ConnRate: 1, ConnBurst: 1})
+	conn := l.newConnBucket()
+
+	_, _, ok := l.allow(1, conn, true, false)
+	require.True(t, ok)
+
+	code, _, ok := l.allow(1, conn, true, false)
+	require.False(t, ok)
+	assert.Equal(t, codeRateLimited, code)
+}
+
+func TestRateLimiter_SlowMode(t *testing.T) {
+	l, now := newTestLimiter(Limits{})
+	l.setSlowMode(10 * time.Second)
+	conn := l.newConnBucket()
+
+	_, _, ok := l.allow(1, conn, true, false)
+	require.True(t, ok)
+
+	*now = now.Add(3 * time.Second)
+	code, wait, ok := l.allow(1, conn, true, false)
+	require.False(t, ok)
+	assert.Equal(t, codeSlowMode, code)
+	assert.Equal(t, 7*time.Second, wait)
+
+	// правка и удаление под slow mode не попадают, как и администраторы
+	_, _, ok = l.allow(1, conn, false, false)
+	assert.True(t, ok)
+	_, _, ok = l.allow(1, conn, true, true)
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_AutoMute(t *testing.T) {
+	l, now := newTestLimiter(Limits{UserRate: 1, UserBurst: 1, MuteAfter: 3, MuteWindow: time.Minute, MuteDuration: 5 * time.Minute})
+	conn := l.newConnBucket()
+
+	_, _, ok := l.allow(1, conn, true, false)
+	require.True(t, ok)
+
+	for i := 0; i < 2; i++ {
+		code, _, ok := l.allow(1, conn, true, false)
+		require.False(t, ok)
+		assert.Equal(t, codeRateLimited, code)
+	}
+
+	code, wait, ok := l.allow(1, conn, true, false)
+	require.False(t, ok)
+	assert.Equal(t, codeMuted, code)
+	assert.Equal(t, 5*time.Minute, wait)
+
+	*now = now.Add(time.Minute)
+	code, wait, ok = l.allow(1, conn, true, false)
+	require.False(t, ok)
+	assert.Equal(t, codeMuted, code)
+	assert.Equal(t, 4*time.Minute, wait)
+
+	*now = now.Add(4 * time.Minute)
+	_, _, ok = l.allow(1, conn, true, false)
+	assert.True(t, ok)
+}
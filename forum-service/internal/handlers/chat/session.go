@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"sync"
+	"time"
+)
+
+// коды ошибок авторизации внутри WebSocket-сессии
+const (
+	codeUnauthorized = "unauthorized"
+	codeTokenExpired = "token_expired"
+	// отправка сообщений запрещена, пока пользователь не подтвердит email
+	codeEmailNotVerified = "email_not_verified"
+)
+
+// Keepalive — настройки heartbeat для WebSocket-подключений
+type Keepalive struct {
+	// PingInterval — как часто сервер отправляет ping
+	PingInterval time.Duration
+	// PongWait — сколько ждать любого входящего фрейма (в том числе pong), прежде чем считать клиента мёртвым
+	PongWait time.Duration
+	// WriteWait — ограничение времени на запись одного фрейма
+	WriteWait time.Duration
+}
+
+var defaultKeepalive = Keepalive{
+	PingInterval: 30 * time.Second,
+	PongWait:     60 * time.Second,
+	WriteWait:    10 * time.Second,
+}
+
+func (k Keepalive) withDefaults() Keepalive {
+	if k.PingInterval <= 0 {
+		k.PingInterval = defaultKeepalive.PingInterval
+	}
+	if k.PongWait <= 0 {
+		k.PongWait = defaultKeepalive.PongWait
+	}
+	if k.WriteWait <= 0 {
+		k.WriteWait = defaultKeepalive.WriteWait
+	}
+	return k
+}
+
+// ReauthFrame подтверждает, что клиент предъявил новый access token, и сообщает,
+// до какого момента сессия остаётся действительной.
+// swagger:model
+type ReauthFrame struct {
+	Event     string    `json:"event"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// tokenExpiry достаёт exp из access token. Подпись уже проверена в ValidateToken,
+// поэтому токен разбирается без проверки.
+func tokenExpiry(accessToken string) (time.Time, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(accessToken, jwt.MapClaims{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	exp, err := token.Claims.GetExpirationTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if exp == nil {
+		return time.Time{}, errors.New("exp claim is missing")
+	}
+
+	return exp.Time, nil
+}
+
+// session следит за сроком действия токена, которым авторизовано подключение,
+// и вызывает onExpire, если клиент не продлил его вовремя.
+type session struct {
+	mu       sync.Mutex
+	timer    *time.Timer
+	deadline time.Time
+	expired  bool
+}
+
+func newSession(expiresAt time.Time, onExpire func()) *session {
+	s := &session{deadline: expiresAt}
+
+	s.timer = time.AfterFunc(time.Until(expiresAt), func() {
+		s.mu.Lock()
+		// срок мог быть продлён, пока таймер ждал блокировку
+		if s.expired || time.Now().Before(s.deadline) {
+			s.mu.Unlock()
+			return
+		}
+		s.expired = true
+		s.mu.Unlock()
+
+		onExpire()
+	})
+
+	return s
+}
+
+// extend переносит срок действия сессии. Возвращает false, если сессия уже истекла.
+func (s *session) extend(expiresAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expired {
+		return false
+	}
+
+	s.deadline = expiresAt
+	s.timer.Reset(time.Until(expiresAt))
+	return true
+}
+
+func (s *session) stop() {
+	s.timer.Stop()
+}
@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": exp.Unix()}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	got, err := tokenExpiry(token)
+	require.NoError(t, err)
+	assert.True(t, exp.Equal(got))
+}
+
+func TestTokenExpiry_MissingExp(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"uid": 1}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = tokenExpiry(token)
+	assert.Error(t, err)
+}
+
+func TestSession_Extend(t *testing.T) {
+	expired := make(chan struct{})
+	s := newSession(time.Now().Add(50*time.Millisecond), func() { close(expired) })
+	defer s.stop()
+
+	require.True(t, s.extend(time.Now().Add(200*time.Millisecond)))
+
+	select {
+	case <-expired:
+		t.Fatal("session expired before extended deadline")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("session did not expire")
+	}
+
+	assert.False(t, s.extend(time.Now().Add(time.Hour)))
+}
@@ -0,0 +1,171 @@
+package forum
+
+import (
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/forum-service/internal/models"
+	"github.com/14kear/forum-project/forum-service/internal/services/forum"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DataExportResponse описывает выгрузку персональных данных и ход её сборки.
+// Status: pending, running, ready или failed; архив доступен по downloadUrl до expiresAt.
+// swagger:model
+type DataExportResponse struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	Progress    int        `json:"progress"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+}
+
+// StartDataExport godoc
+// @Summary Request a personal data export
+// @Description Queues an export of everything stored about the current user (account and sessions from auth-service, topics, comments and chat messages) into a ZIP archive of JSON files. If an export is already being prepared, it is returned instead of a new one.
+// @Tags export
+// @Produce json
+// @Success 202 {object} DataExportResponse "Queued export"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/export [post]
+func (f *ForumHandler) StartDataExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	export, err := f.forumService.StartDataExport(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, newDataExportResponse(export))
+}
+
+// GetDataExport godoc
+// @Summary Get personal data export status
+// @Description Returns the status and progress of the current user's data export
+// @Tags export
+// @Produce json
+// @Param id path int true "Export ID"
+// @Success 200 {object} DataExportResponse "Export status"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid export ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Export not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/export/{id} [get]
+func (f *ForumHandler) GetDataExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	exportID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+		return
+	}
+
+	export, err := f.forumService.GetDataExport(c.Request.Context(), userID, exportID)
+	if err != nil {
+		status, msg := exportErrorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, newDataExportResponse(export))
+}
+
+// DownloadDataExport godoc
+// @Summary Download personal data export
+// @Description Returns the ZIP archive of a finished export. The archive is available only for a limited time after the export completes.
+// @Tags export
+// @Produce application/zip
+// @Param id path int true "Export ID"
+// @Success 200 {file} file "ZIP archive with JSON files"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid export ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Export not found"
+// @Failure 409 {object} handlers.ErrorResponse "Export is not ready yet"
+// @Failure 410 {object} handlers.ErrorResponse "Export has expired"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /api/forum/export/{id}/download [get]
+func (f *ForumHandler) DownloadDataExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	exportID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+		return
+	}
+
+	archive, err := f.forumService.DataExportArchive(c.Request.Context(), userID, exportID)
+	if err != nil {
+		status, msg := exportErrorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="forum-export-%d.zip"`, exportID))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// currentUserID достаёт id пользователя, выставленный middleware авторизации.
+// Если его нет, ответ уже отправлен.
+func currentUserID(c *gin.Context) (int64, bool) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, false
+	}
+
+	userID, ok := userIDValue.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id in context"})
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func exportErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, forum.ErrExportNotFound):
+		return http.StatusNotFound, "data export not found"
+	case errors.Is(err, forum.ErrExportNotReady):
+		return http.StatusConflict, "data export is not ready"
+	case errors.Is(err, forum.ErrExportExpired):
+		return http.StatusGone, "data export has expired"
+	default:
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
+
+func newDataExportResponse(export models.DataExport) DataExportResponse {
+	resp := DataExportResponse{
+		ID:          export.ID,
+		Status:      export.Status,
+		Progress:    export.Progress,
+		Error:       export.Error,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+	if export.Status == models.ExportReady {
+		resp.DownloadURL = fmt.Sprintf("/api/forum/export/%d/download", export.ID)
+	}
+	return resp
+}
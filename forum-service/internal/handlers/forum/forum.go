@@ -1,6 +1,7 @@
 package forum
 
 import (
+	"errors"
 	"github.com/14kear/forum-project/forum-service/internal/services/forum"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -38,6 +39,7 @@ func NewForumHandler(forumService *forum.Forum) *ForumHandler {
 // @Success 201 {object} handlers.SuccessIDResponse "Created topic ID"
 // @Failure 400 {object} handlers.ErrorResponse "Invalid input"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Email is not verified or user may not post"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Router /api/forum/topics [post]
@@ -73,6 +75,10 @@ func (f *ForumHandler) CreateTopic(c *gin.Context) {
 	}
 
 	topicID, err := f.forumService.CreateTopic(c.Request.Context(), req.Title, req.Content, userID, userEmail)
+	if errors.Is(err, forum.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -178,6 +184,7 @@ func (f *ForumHandler) DeleteTopic(c *gin.Context) {
 // @Success 201 {object} handlers.SuccessIDResponse "Created comment ID"
 // @Failure 400 {object} handlers.ErrorResponse "Invalid input or topic ID"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Email is not verified or user may not post"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Router /api/forum/topics/{id}/comments [post]
@@ -221,6 +228,10 @@ func (f *ForumHandler) CreateComment(c *gin.Context) {
 	}
 
 	commentID, err := f.forumService.CreateComment(c.Request.Context(), topicID, userID, req.Content, userEmail)
+	if errors.Is(err, forum.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
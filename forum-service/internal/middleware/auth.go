@@ -47,12 +47,19 @@ func (m *AuthMiddleware) Middleware() gin.HandlerFunc {
 		if err == nil {
 			c.Set("userID", resp.GetUserId())
 			c.Set("userEmail", resp.GetEmail())
+			c.Set("emailVerified", resp.GetEmailVerified())
 			c.Next()
 			return
 		}
 
-		// Если ошибка НЕ связана с истёкшим токеном - 401
+		// Забаненному пользователю обновление токенов не поможет - 403
 		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.PermissionDenied {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": st.Message()})
+			return
+		}
+
+		// Если ошибка НЕ связана с истёкшим токеном - 401
 		if !ok || st.Code() != codes.Unauthenticated || refreshToken == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			return
@@ -65,6 +72,11 @@ func (m *AuthMiddleware) Middleware() gin.HandlerFunc {
 		})
 
 		if err != nil {
+			// токен истёк, а пользователя за это время забанили
+			if st, ok := status.FromError(err); ok && st.Code() == codes.PermissionDenied {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token refresh failed"})
 			return
 		}
@@ -88,6 +100,7 @@ func (m *AuthMiddleware) Middleware() gin.HandlerFunc {
 		c.Request.Header.Set("X-Refresh-Token", newTokens.RefreshToken)
 		c.Set("userID", newValidateResp.GetUserId())
 		c.Set("userEmail", newValidateResp.GetEmail())
+		c.Set("emailVerified", newValidateResp.GetEmailVerified())
 
 		// Пропускаем запрос дальше с новыми токенами
 		c.Next()
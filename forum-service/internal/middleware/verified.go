@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequireVerifiedEmail пропускает запрос только от пользователей с подтверждённым email.
+// Должен стоять после AuthMiddleware, который кладёт в контекст emailVerified.
+// Если required выключен, ничего не проверяет.
+func RequireVerifiedEmail(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required || c.GetBool("emailVerified") {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "email is not verified"})
+	}
+}
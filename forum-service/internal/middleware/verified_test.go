@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		required bool
+		verified bool
+		want     int
+	}{
+		{name: "not required", required: false, verified: false, want: http.StatusOK},
+		{name: "verified", required: true, verified: true, want: http.StatusOK},
+		{name: "not verified", required: true, verified: false, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/topics",
+				func(c *gin.Context) { c.Set("emailVerified", tt.verified) },
+				RequireVerifiedEmail(tt.required),
+				func(c *gin.Context) { c.Status(http.StatusOK) },
+			)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/topics", nil))
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// что делать с темами, комментариями и сообщениями чата удалённого аккаунта
+const (
+	// ErasureAnonymize оставляет содержимое, но отвязывает его от автора
+	ErasureAnonymize = "anonymize"
+	// ErasureDelete удаляет всё, что написал пользователь
+	ErasureDelete = "delete"
+)
+
+// ErasedAuthorEmail подставляется вместо email автора обезличенного содержимого
+const ErasedAuthorEmail = "[deleted]"
@@ -8,4 +8,7 @@ type ChatMessage struct {
 	UserEmail string
 	Content   string
 	CreatedAt time.Time
+	EditedAt  *time.Time
+	// DeletedAt выставляется при удалении: строка остаётся как tombstone с пустым Content
+	DeletedAt *time.Time
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// режимы хранения сообщений чата
+const (
+	// RetentionDelete удаляет сообщения старше MaxAge
+	RetentionDelete = "delete"
+	// RetentionArchive переносит сообщения старше MaxAge в архив и удаляет из чата
+	RetentionArchive = "archive"
+	// RetentionKeep хранит сообщения бессрочно
+	RetentionKeep = "keep"
+)
+
+type ChatRetentionPolicy struct {
+	Mode      string
+	MaxAge    time.Duration
+	UpdatedBy int64
+	UpdatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// состояния выгрузки персональных данных
+const (
+	// ExportPending — выгрузка ждёт фонового обработчика
+	ExportPending = "pending"
+	// ExportRunning — данные собираются, ход виден по Progress
+	ExportRunning = "running"
+	// ExportReady — архив можно скачать до ExpiresAt
+	ExportReady = "ready"
+	// ExportFailed — выгрузка не удалась, причина в Error
+	ExportFailed = "failed"
+)
+
+// DataExport — выгрузка всех данных пользователя из auth-service и форума в ZIP-архив
+type DataExport struct {
+	ID          int64
+	UserID      int64
+	Status      string
+	Progress    int
+	Error       string
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+}
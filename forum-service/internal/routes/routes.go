@@ -16,15 +16,29 @@ func RegisterPublicRoutes(rg *gin.RouterGroup, handler *forum.ForumHandler, chat
 
 		rg.GET("ws/chat/messages", chatHandler.GetChatMessages)
 		rg.GET("/ws/chat", chatHandler.HandleWebSocket)
+		rg.GET("/ws/chat/slow-mode", chatHandler.GetSlowMode)
 	}
 }
 
-func RegisterPrivateRoutes(rg *gin.RouterGroup, handler *forum.ForumHandler) {
+// RegisterPrivateRoutes регистрирует маршруты, требующие авторизации.
+// verified ставится перед обработчиками, создающими контент.
+func RegisterPrivateRoutes(rg *gin.RouterGroup, handler *forum.ForumHandler, chatHandler *chat.ChatHandler, verified gin.HandlerFunc) {
 	{
-		rg.POST("/topics", handler.CreateTopic)
+		rg.POST("/topics", verified, handler.CreateTopic)
 		rg.DELETE("/topics/:id", handler.DeleteTopic)
 
-		rg.POST("/topics/:id/comments", handler.CreateComment)
+		rg.POST("/topics/:id/comments", verified, handler.CreateComment)
 		rg.DELETE("topics/:id/comments/:commentID", handler.DeleteComment)
+
+		rg.PUT("/ws/chat/messages/:id", chatHandler.EditChatMessage)
+		rg.DELETE("/ws/chat/messages/:id", chatHandler.DeleteChatMessage)
+		rg.PUT("/ws/chat/slow-mode", chatHandler.SetSlowMode)
+		rg.GET("/ws/chat/retention", chatHandler.GetRetentionPolicy)
+		rg.PUT("/ws/chat/retention", chatHandler.SetRetentionPolicy)
+		rg.POST("/ws/chat/retention/sweep", chatHandler.SweepChatMessages)
+
+		rg.POST("/export", handler.StartDataExport)
+		rg.GET("/export/:id", handler.GetDataExport)
+		rg.GET("/export/:id/download", handler.DownloadDataExport)
 	}
 }
@@ -0,0 +1,287 @@
+package forum
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/14kear/forum-project/forum-service/internal/models"
+	"github.com/14kear/forum-project/forum-service/internal/storage"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrExportNotFound = errors.New("data export not found")
+	ErrExportNotReady = errors.New("data export is not ready")
+	ErrExportExpired  = errors.New("data export has expired")
+)
+
+// exportAccount — данные пользователя из auth-service в архиве выгрузки
+type exportAccount struct {
+	UserID        int64     `json:"user_id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	DisplayName   string    `json:"display_name"`
+	Username      string    `json:"username"`
+	AvatarURL     string    `json:"avatar_url"`
+	Bio           string    `json:"bio"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// exportRefreshToken — refresh токен пользователя из auth-service (без значения токена)
+type exportRefreshToken struct {
+	ID               int64      `json:"id"`
+	AppID            int32      `json:"app_id"`
+	SessionID        int64      `json:"session_id"`
+	IP               string     `json:"ip"`
+	UserAgent        string     `json:"user_agent"`
+	SessionCreatedAt time.Time  `json:"session_created_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RotatedAt        *time.Time `json:"rotated_at,omitempty"`
+	Revoked          bool       `json:"revoked"`
+}
+
+// exportManifest описывает архив выгрузки
+type exportManifest struct {
+	ExportID    int64     `json:"export_id"`
+	UserID      int64     `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// exportFile — файл архива с данными, которые кодируются в JSON
+type exportFile struct {
+	name string
+	data any
+}
+
+// exportStep — шаг сборки выгрузки; после каждого шага обновляется ход сборки
+type exportStep func(ctx context.Context, userID int64) ([]exportFile, error)
+
+// StartDataExport ставит в очередь выгрузку всех данных пользователя. Если предыдущая
+// выгрузка ещё собирается, новая не создаётся и возвращается она.
+func (f *Forum) StartDataExport(ctx context.Context, userID int64) (models.DataExport, error) {
+	const op = "forum.StartDataExport"
+
+	log := f.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	export, err := f.dataExportStorage.CreateDataExport(ctx, userID)
+	if err != nil {
+		log.Error("failed to create data export", slog.Any("error", err))
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("data export queued", slog.Int64("exportID", export.ID), slog.String("status", export.Status))
+
+	return export, nil
+}
+
+// GetDataExport возвращает состояние выгрузки. Чужие выгрузки не видны: для них, как и для
+// несуществующих, возвращается ErrExportNotFound.
+func (f *Forum) GetDataExport(ctx context.Context, userID, exportID int64) (models.DataExport, error) {
+	const op = "forum.GetDataExport"
+
+	export, err := f.dataExportStorage.DataExportByID(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataExportNotFound) {
+			return models.DataExport{}, fmt.Errorf("%s: %w", op, ErrExportNotFound)
+		}
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if export.UserID != userID {
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, ErrExportNotFound)
+	}
+
+	return export, nil
+}
+
+// DataExportArchive возвращает ZIP-архив готовой выгрузки, пока не истёк срок её хранения
+func (f *Forum) DataExportArchive(ctx context.Context, userID, exportID int64) ([]byte, error) {
+	const op = "forum.DataExportArchive"
+
+	export, err := f.GetDataExport(ctx, userID, exportID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if export.Status != models.ExportReady {
+		return nil, fmt.Errorf("%s: %w", op, ErrExportNotReady)
+	}
+	if export.ExpiresAt != nil && !time.Now().Before(*export.ExpiresAt) {
+		return nil, fmt.Errorf("%s: %w", op, ErrExportExpired)
+	}
+
+	archive, err := f.dataExportStorage.DataExportArchive(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataExportNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrExportExpired)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return archive, nil
+}
+
+// ProcessDataExports удаляет просроченные выгрузки и собирает все ожидающие. Архив хранится ttl;
+// выгрузки, которые собираются дольше staleAfter, считаются брошенными и собираются заново.
+// Возвращает число обработанных выгрузок.
+func (f *Forum) ProcessDataExports(ctx context.Context, ttl, staleAfter time.Duration) (int, error) {
+	const op = "forum.ProcessDataExports"
+
+	log := f.log.With(slog.String("op", op))
+
+	removed, err := f.dataExportStorage.DeleteExpiredDataExports(ctx, time.Now())
+	if err != nil {
+		log.Error("failed to delete expired data exports", slog.Any("error", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if removed > 0 {
+		log.Info("expired data exports deleted", slog.Int64("removed", removed))
+	}
+
+	processed := 0
+	for ctx.Err() == nil {
+		export, err := f.dataExportStorage.ClaimDataExport(ctx, time.Now().Add(-staleAfter))
+		if err != nil {
+			if errors.Is(err, storage.ErrDataExportNotFound) {
+				break
+			}
+			return processed, fmt.Errorf("%s: %w", op, err)
+		}
+
+		exportLog := log.With(slog.Int64("exportID", export.ID), slog.Int64("userID", export.UserID))
+
+		archive, buildErr := f.buildDataExport(ctx, export)
+		now := time.Now()
+		if buildErr != nil {
+			exportLog.Error("failed to build data export", slog.Any("error", buildErr))
+			if err := f.dataExportStorage.FailDataExport(ctx, export.ID, "failed to collect user data", now, now.Add(ttl)); err != nil {
+				return processed, fmt.Errorf("%s: %w", op, err)
+			}
+		} else {
+			if err := f.dataExportStorage.CompleteDataExport(ctx, export.ID, archive, now, now.Add(ttl)); err != nil {
+				return processed, fmt.Errorf("%s: %w", op, err)
+			}
+			exportLog.Info("data export ready", slog.Int("bytes", len(archive)))
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+// buildDataExport собирает данные пользователя по шагам, сообщая ход сборки, и упаковывает их в ZIP
+func (f *Forum) buildDataExport(ctx context.Context, export models.DataExport) ([]byte, error) {
+	steps := []exportStep{
+		f.exportAuthData,
+		func(ctx context.Context, userID int64) ([]exportFile, error) {
+			topics, err := f.topicStorage.TopicsByUserID(ctx, userID)
+			return []exportFile{{name: "topics.json", data: topics}}, err
+		},
+		func(ctx context.Context, userID int64) ([]exportFile, error) {
+			comments, err := f.commentStorage.CommentsByUserID(ctx, userID)
+			return []exportFile{{name: "comments.json", data: comments}}, err
+		},
+		func(ctx context.Context, userID int64) ([]exportFile, error) {
+			messages, err := f.chatMessageStorage.ChatMessagesByUserID(ctx, userID)
+			return []exportFile{{name: "chat_messages.json", data: messages}}, err
+		},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	manifest := exportManifest{ExportID: export.ID, UserID: export.UserID, GeneratedAt: time.Now()}
+	for i, step := range steps {
+		files, err := step(ctx, export.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if err := writeJSONFile(zw, file.name, file.data); err != nil {
+				return nil, err
+			}
+			manifest.Files = append(manifest.Files, file.name)
+		}
+
+		// последние проценты остаются на упаковку и сохранение архива
+		progress := (i + 1) * 90 / len(steps)
+		if err := f.dataExportStorage.UpdateDataExportProgress(ctx, export.ID, progress); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeJSONFile(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// exportAuthData запрашивает у auth-service профиль пользователя и его refresh токены
+func (f *Forum) exportAuthData(ctx context.Context, userID int64) ([]exportFile, error) {
+	resp, err := f.authService.ExportUserData(ctx, &ssov1.ExportUserDataRequest{UserId: userID})
+	if err != nil {
+		return nil, fmt.Errorf("auth-service: %w", err)
+	}
+
+	profile := resp.GetProfile()
+	account := exportAccount{
+		UserID:        profile.GetUserId(),
+		Email:         profile.GetEmail(),
+		EmailVerified: profile.GetEmailVerified(),
+		DisplayName:   profile.GetDisplayName(),
+		Username:      profile.GetUsername(),
+		AvatarURL:     profile.GetAvatarUrl(),
+		Bio:           profile.GetBio(),
+		CreatedAt:     time.Unix(profile.GetCreatedAt(), 0).UTC(),
+		UpdatedAt:     time.Unix(profile.GetUpdatedAt(), 0).UTC(),
+	}
+
+	tokens := make([]exportRefreshToken, 0, len(resp.GetRefreshTokens()))
+	for _, token := range resp.GetRefreshTokens() {
+		exported := exportRefreshToken{
+			ID:               token.GetId(),
+			AppID:            token.GetAppId(),
+			SessionID:        token.GetSessionId(),
+			IP:               token.GetIp(),
+			UserAgent:        token.GetUserAgent(),
+			SessionCreatedAt: time.Unix(token.GetSessionCreatedAt(), 0).UTC(),
+			CreatedAt:        time.Unix(token.GetCreatedAt(), 0).UTC(),
+			ExpiresAt:        time.Unix(token.GetExpiresAt(), 0).UTC(),
+			Revoked:          token.GetRevoked(),
+		}
+		if token.GetRotatedAt() != 0 {
+			rotatedAt := time.Unix(token.GetRotatedAt(), 0).UTC()
+			exported.RotatedAt = &rotatedAt
+		}
+		tokens = append(tokens, exported)
+	}
+
+	return []exportFile{
+		{name: "account.json", data: account},
+		{name: "refresh_tokens.json", data: tokens},
+	}, nil
+}
+
+func writeJSONFile(zw *zip.Writer, name string, data any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
@@ -5,18 +5,34 @@ import (
 	"errors"
 	"fmt"
 	"github.com/14kear/forum-project/forum-service/internal/models"
+	"github.com/14kear/forum-project/forum-service/internal/storage"
 	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
 	"log/slog"
 	"time"
 )
 
-var ErrValidation = errors.New("validation error")
+var (
+	ErrValidation = errors.New("validation error")
+	ErrForbidden  = errors.New("forbidden")
+)
+
+// Права форума в auth-service; привязка к ролям — в миграциях auth-service
+const (
+	// PermissionPostCreate — право создавать топики, комментарии и сообщения чата (нет у роли read_only)
+	PermissionPostCreate = "forum.post.create"
+	// PermissionPostDeleteAny — право удалять чужие топики, комментарии и сообщения чата (выдаётся ролям admin и moderator)
+	PermissionPostDeleteAny = "forum.post.delete_any"
+	// PermissionChatManage — право менять настройки чата: политику хранения и очистку (выдаётся роли admin)
+	PermissionChatManage = "forum.chat.manage"
+)
 
 type Forum struct {
 	log                *slog.Logger
 	topicStorage       TopicStorage
 	commentStorage     CommentStorage
 	chatMessageStorage ChatMessageStorage
+	erasureStorage     ErasureStorage
+	dataExportStorage  DataExportStorage
 	authService        ssov1.AuthClient
 }
 
@@ -26,6 +42,7 @@ type TopicStorage interface {
 	Topics(ctx context.Context) ([]models.Topic, error)
 	DeleteTopic(ctx context.Context, id int) error
 	GetTopicAuthorID(ctx context.Context, id int) (int64, error)
+	TopicsByUserID(ctx context.Context, userID int64) ([]models.Topic, error)
 }
 
 type CommentStorage interface {
@@ -34,12 +51,45 @@ type CommentStorage interface {
 	CommentsByTopicID(ctx context.Context, topicID int) ([]models.Comment, error)
 	DeleteComment(ctx context.Context, id int, topicID int) error
 	GetCommentAuthorID(ctx context.Context, id int) (int64, error)
+	CommentsByUserID(ctx context.Context, userID int64) ([]models.Comment, error)
 }
 
 type ChatMessageStorage interface {
 	SaveChatMessage(ctx context.Context, userID int64, content string, email string) (int64, error)
 	ChatMessages(ctx context.Context) ([]models.ChatMessage, error)
-	DeleteChatMessagesBefore(ctx context.Context, before time.Time) error
+	ChatMessagesAfter(ctx context.Context, afterID int64, limit int) ([]models.ChatMessage, error)
+	ChatMessageByID(ctx context.Context, id int64) (models.ChatMessage, error)
+	UpdateChatMessage(ctx context.Context, id int64, content string, editedAt time.Time) error
+	DeleteChatMessage(ctx context.Context, id int64, deletedAt time.Time) error
+	DeleteChatMessagesBefore(ctx context.Context, before time.Time) (int64, error)
+	ArchiveChatMessagesBefore(ctx context.Context, before time.Time) (int64, error)
+	ChatRetentionPolicy(ctx context.Context) (models.ChatRetentionPolicy, error)
+	SaveChatRetentionPolicy(ctx context.Context, policy models.ChatRetentionPolicy) error
+	ChatSlowMode(ctx context.Context) (time.Duration, bool, error)
+	SaveChatSlowMode(ctx context.Context, interval time.Duration) error
+	ChatMessagesByUserID(ctx context.Context, userID int64) ([]models.ChatMessage, error)
+}
+
+// ErasureStorage применяет события удаления аккаунтов из auth-service
+type ErasureStorage interface {
+	// AccountErasureCursor возвращает id последнего применённого события
+	AccountErasureCursor(ctx context.Context) (int64, error)
+	// EraseUserContent обезличивает или удаляет содержимое пользователя и запоминает eventID как применённое
+	EraseUserContent(ctx context.Context, eventID, userID int64, mode string) error
+}
+
+// DataExportStorage хранит выгрузки персональных данных и их архивы
+type DataExportStorage interface {
+	// CreateDataExport создаёт выгрузку или возвращает незавершённую выгрузку пользователя
+	CreateDataExport(ctx context.Context, userID int64) (models.DataExport, error)
+	DataExportByID(ctx context.Context, id int64) (models.DataExport, error)
+	DataExportArchive(ctx context.Context, id int64) ([]byte, error)
+	// ClaimDataExport берёт в работу следующую выгрузку; storage.ErrDataExportNotFound, если ждущих нет
+	ClaimDataExport(ctx context.Context, staleBefore time.Time) (models.DataExport, error)
+	UpdateDataExportProgress(ctx context.Context, id int64, progress int) error
+	CompleteDataExport(ctx context.Context, id int64, archive []byte, completedAt, expiresAt time.Time) error
+	FailDataExport(ctx context.Context, id int64, reason string, completedAt, expiresAt time.Time) error
+	DeleteExpiredDataExports(ctx context.Context, now time.Time) (int64, error)
 }
 
 func NewForum(
@@ -47,6 +97,8 @@ func NewForum(
 	topicStorage TopicStorage,
 	commentStorage CommentStorage,
 	chatMessageStorage ChatMessageStorage,
+	erasureStorage ErasureStorage,
+	dataExportStorage DataExportStorage,
 	authService ssov1.AuthClient,
 ) *Forum {
 	return &Forum{
@@ -54,6 +106,8 @@ func NewForum(
 		topicStorage:       topicStorage,
 		commentStorage:     commentStorage,
 		chatMessageStorage: chatMessageStorage,
+		erasureStorage:     erasureStorage,
+		dataExportStorage:  dataExportStorage,
 		authService:        authService,
 	}
 }
@@ -70,6 +124,10 @@ func (f *Forum) CreateTopic(ctx context.Context, title, content string, userID i
 		return 0, fmt.Errorf("%w: title or content is empty", ErrValidation)
 	}
 
+	if err := f.requirePermission(ctx, userID, PermissionPostCreate); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	topicID, err := f.topicStorage.SaveTopic(ctx, title, content, userID, email)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -124,14 +182,12 @@ func (f *Forum) DeleteTopic(ctx context.Context, id int, userID int64) error {
 	}
 
 	if authorID != userID {
-		isAdminResp, err := f.authService.IsAdmin(ctx, &ssov1.IsAdminRequest{
-			UserId: userID,
-		})
+		allowed, err := f.hasPermission(ctx, userID, PermissionPostDeleteAny)
 		if err != nil {
-			return fmt.Errorf("%s: failed to check admin rights: %w", op, err)
+			return fmt.Errorf("%s: failed to check permission: %w", op, err)
 		}
 
-		if !isAdminResp.IsAdmin {
+		if !allowed {
 			return fmt.Errorf("%s: user not authorized to delete this topic", op)
 		}
 	}
@@ -158,6 +214,10 @@ func (f *Forum) CreateComment(ctx context.Context, topicID int, userID int64, co
 		return 0, fmt.Errorf("%w: content is empty", ErrValidation)
 	}
 
+	if err := f.requirePermission(ctx, userID, PermissionPostCreate); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	commentID, err := f.commentStorage.SaveComment(ctx, topicID, userID, content, email)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -212,14 +272,12 @@ func (f *Forum) DeleteComment(ctx context.Context, id int, topicID int, userID i
 	}
 
 	if authorID != userID {
-		isAdminResp, err := f.authService.IsAdmin(ctx, &ssov1.IsAdminRequest{
-			UserId: userID,
-		})
+		allowed, err := f.hasPermission(ctx, userID, PermissionPostDeleteAny)
 		if err != nil {
-			return fmt.Errorf("%s: failed to check admin rights: %w", op, err)
+			return fmt.Errorf("%s: failed to check permission: %w", op, err)
 		}
 
-		if !isAdminResp.IsAdmin {
+		if !allowed {
 			return fmt.Errorf("%s: user not authorized to delete this topic", op)
 		}
 	}
@@ -246,6 +304,10 @@ func (f *Forum) CreateChatMessage(ctx context.Context, userID int64, content str
 		return 0, fmt.Errorf("%w: content is empty", ErrValidation)
 	}
 
+	if err := f.requirePermission(ctx, userID, PermissionPostCreate); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	chatMessageID, err := f.chatMessageStorage.SaveChatMessage(ctx, userID, content, email)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -272,6 +334,106 @@ func (f *Forum) ListChatMessages(ctx context.Context) ([]models.ChatMessage, err
 	return chatMessages, nil
 }
 
+// EditChatMessage меняет текст сообщения. Редактировать может только автор.
+func (f *Forum) EditChatMessage(ctx context.Context, id int64, userID int64, content string) (models.ChatMessage, error) {
+	const op = "forum.EditChatMessage"
+
+	log := f.log.With(slog.String("op", op), slog.Int64("chatMessageID", id))
+	log.Info("editing chat message")
+
+	if content == "" {
+		log.Error("failed to edit chat message", slog.String("reason", "content is empty"))
+		return models.ChatMessage{}, fmt.Errorf("%w: content is empty", ErrValidation)
+	}
+
+	msg, err := f.chatMessageStorage.ChatMessageByID(ctx, id)
+	if err != nil {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if msg.DeletedAt != nil {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, storage.ErrChatMessageNotFound)
+	}
+
+	if msg.UserID != userID {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w: only the author can edit this message", op, ErrForbidden)
+	}
+
+	editedAt := time.Now()
+	if err := f.chatMessageStorage.UpdateChatMessage(ctx, id, content, editedAt); err != nil {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	msg.Content = content
+	msg.EditedAt = &editedAt
+
+	log.Info("chat message edited")
+
+	return msg, nil
+}
+
+// DeleteChatMessage удаляет сообщение, оставляя tombstone. Удалять может автор или пользователь
+// с правом forum.post.delete_any.
+func (f *Forum) DeleteChatMessage(ctx context.Context, id int64, userID int64) (models.ChatMessage, error) {
+	const op = "forum.DeleteChatMessage"
+
+	log := f.log.With(slog.String("op", op), slog.Int64("chatMessageID", id))
+	log.Info("deleting chat message")
+
+	msg, err := f.chatMessageStorage.ChatMessageByID(ctx, id)
+	if err != nil {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if msg.DeletedAt != nil {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, storage.ErrChatMessageNotFound)
+	}
+
+	if msg.UserID != userID {
+		allowed, err := f.hasPermission(ctx, userID, PermissionPostDeleteAny)
+		if err != nil {
+			return models.ChatMessage{}, fmt.Errorf("%s: failed to check permission: %w", op, err)
+		}
+
+		if !allowed {
+			return models.ChatMessage{}, fmt.Errorf("%s: %w: user not authorized to delete this message", op, ErrForbidden)
+		}
+	}
+
+	deletedAt := time.Now()
+	if err := f.chatMessageStorage.DeleteChatMessage(ctx, id, deletedAt); err != nil {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	msg.Content = ""
+	msg.DeletedAt = &deletedAt
+
+	log.Info("chat message deleted", slog.Int64("deletedBy", userID))
+
+	return msg, nil
+}
+
+// ChatMessagesSince возвращает до limit сообщений с ID больше sinceID в порядке их создания.
+// Используется для догрузки пропущенных сообщений при переподключении к чату.
+func (f *Forum) ChatMessagesSince(ctx context.Context, sinceID int64, limit int) ([]models.ChatMessage, error) {
+	const op = "forum.ChatMessagesSince"
+
+	log := f.log.With(slog.String("op", op), slog.Int64("sinceID", sinceID))
+	log.Info("listing chat messages since id")
+
+	if sinceID < 0 || limit <= 0 {
+		log.Error("failed to list chat messages", slog.String("reason", "invalid since id or limit"))
+		return nil, fmt.Errorf("%w: invalid since id or limit", ErrValidation)
+	}
+
+	chatMessages, err := f.chatMessageStorage.ChatMessagesAfter(ctx, sinceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("chat messages listed", slog.Int("chatMessages", len(chatMessages)))
+
+	return chatMessages, nil
+}
+
 func (f *Forum) CleanupOldMessages(ctx context.Context, olderThan time.Duration) error {
 	const op = "forum.CleanupOldMessages"
 
@@ -280,12 +442,234 @@ func (f *Forum) CleanupOldMessages(ctx context.Context, olderThan time.Duration)
 
 	threshold := time.Now().Add(-olderThan)
 
-	err := f.chatMessageStorage.DeleteChatMessagesBefore(ctx, threshold)
+	removed, err := f.chatMessageStorage.DeleteChatMessagesBefore(ctx, threshold)
 	if err != nil {
 		log.Error("failed to delete old chat messages", slog.Any("error", err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("old chat messages cleanup completed", slog.String("before", threshold.Format(time.RFC3339)))
+	log.Info("old chat messages cleanup completed", slog.String("before", threshold.Format(time.RFC3339)), slog.Int64("removed", removed))
+	return nil
+}
+
+// ChatRetentionPolicy возвращает текущую политику хранения сообщений чата
+func (f *Forum) ChatRetentionPolicy(ctx context.Context) (models.ChatRetentionPolicy, error) {
+	const op = "forum.ChatRetentionPolicy"
+
+	policy, err := f.chatMessageStorage.ChatRetentionPolicy(ctx)
+	if err != nil {
+		return models.ChatRetentionPolicy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return policy, nil
+}
+
+// SetChatRetentionPolicy меняет политику хранения сообщений чата. Требует права forum.chat.manage.
+// Для режимов delete и archive maxAge должен быть положительным, для keep он не используется.
+func (f *Forum) SetChatRetentionPolicy(ctx context.Context, userID int64, mode string, maxAge time.Duration) (models.ChatRetentionPolicy, error) {
+	const op = "forum.SetChatRetentionPolicy"
+
+	log := f.log.With(slog.String("op", op), slog.Int64("userID", userID))
+	log.Info("setting chat retention policy")
+
+	switch mode {
+	case models.RetentionDelete, models.RetentionArchive:
+		if maxAge <= 0 {
+			log.Error("failed to set chat retention policy", slog.String("reason", "max age must be positive"))
+			return models.ChatRetentionPolicy{}, fmt.Errorf("%w: max age must be positive", ErrValidation)
+		}
+	case models.RetentionKeep:
+		maxAge = 0
+	default:
+		log.Error("failed to set chat retention policy", slog.String("reason", "unknown mode"))
+		return models.ChatRetentionPolicy{}, fmt.Errorf("%w: unknown retention mode %q", ErrValidation, mode)
+	}
+
+	if err := f.requirePermission(ctx, userID, PermissionChatManage); err != nil {
+		return models.ChatRetentionPolicy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	policy := models.ChatRetentionPolicy{
+		Mode:      mode,
+		MaxAge:    maxAge,
+		UpdatedBy: userID,
+		UpdatedAt: time.Now(),
+	}
+	if err := f.chatMessageStorage.SaveChatRetentionPolicy(ctx, policy); err != nil {
+		return models.ChatRetentionPolicy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("chat retention policy changed", slog.String("mode", mode), slog.Duration("maxAge", maxAge))
+
+	return policy, nil
+}
+
+// ChatSlowMode возвращает сохранённый интервал slow mode. ok == false, если администратор его не задавал.
+func (f *Forum) ChatSlowMode(ctx context.Context) (time.Duration, bool, error) {
+	const op = "forum.ChatSlowMode"
+
+	interval, ok, err := f.chatMessageStorage.ChatSlowMode(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return interval, ok, nil
+}
+
+// SetChatSlowMode сохраняет интервал slow mode, 0 выключает его. Требует права forum.chat.manage.
+func (f *Forum) SetChatSlowMode(ctx context.Context, userID int64, interval time.Duration) error {
+	const op = "forum.SetChatSlowMode"
+
+	log := f.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	if interval < 0 {
+		log.Error("failed to set chat slow mode", slog.String("reason", "negative interval"))
+		return fmt.Errorf("%w: slow mode interval must not be negative", ErrValidation)
+	}
+
+	if err := f.requirePermission(ctx, userID, PermissionChatManage); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := f.chatMessageStorage.SaveChatSlowMode(ctx, interval); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("chat slow mode changed", slog.Duration("interval", interval))
+
+	return nil
+}
+
+// CanManageChat сообщает, есть ли у пользователя право forum.chat.manage
+func (f *Forum) CanManageChat(ctx context.Context, userID int64) (bool, error) {
+	const op = "forum.CanManageChat"
+
+	allowed, err := f.hasPermission(ctx, userID, PermissionChatManage)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return allowed, nil
+}
+
+// ApplyChatRetention применяет текущую политику хранения и возвращает число сообщений,
+// удалённых из чата (в режиме archive — перенесённых в архив).
+func (f *Forum) ApplyChatRetention(ctx context.Context) (int64, error) {
+	const op = "forum.ApplyChatRetention"
+
+	log := f.log.With(slog.String("op", op))
+
+	policy, err := f.chatMessageStorage.ChatRetentionPolicy(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	threshold := time.Now().Add(-policy.MaxAge)
+
+	var removed int64
+	switch policy.Mode {
+	case models.RetentionKeep:
+		log.Info("chat retention sweep skipped", slog.String("mode", policy.Mode))
+		return 0, nil
+	case models.RetentionArchive:
+		removed, err = f.chatMessageStorage.ArchiveChatMessagesBefore(ctx, threshold)
+	case models.RetentionDelete:
+		removed, err = f.chatMessageStorage.DeleteChatMessagesBefore(ctx, threshold)
+	default:
+		return 0, fmt.Errorf("%s: unknown retention mode %q", op, policy.Mode)
+	}
+	if err != nil {
+		log.Error("failed to apply chat retention", slog.Any("error", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("chat retention sweep completed",
+		slog.String("mode", policy.Mode),
+		slog.String("before", threshold.Format(time.RFC3339)),
+		slog.Int64("removed", removed),
+	)
+
+	return removed, nil
+}
+
+// SweepChatMessages запускает очистку чата по требованию пользователя с правом forum.chat.manage
+func (f *Forum) SweepChatMessages(ctx context.Context, userID int64) (int64, error) {
+	const op = "forum.SweepChatMessages"
+
+	if err := f.requirePermission(ctx, userID, PermissionChatManage); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	removed, err := f.ApplyChatRetention(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return removed, nil
+}
+
+// ApplyAccountErasures забирает из auth-service до batchSize новых событий удаления аккаунтов и
+// обезличивает (models.ErasureAnonymize) или удаляет (models.ErasureDelete) содержимое этих пользователей.
+// События применяются по порядку; при ошибке следующий вызов продолжит с неприменённого события.
+func (f *Forum) ApplyAccountErasures(ctx context.Context, mode string, batchSize int) (int, error) {
+	const op = "forum.ApplyAccountErasures"
+
+	log := f.log.With(slog.String("op", op), slog.String("mode", mode))
+
+	if mode != models.ErasureAnonymize && mode != models.ErasureDelete {
+		return 0, fmt.Errorf("%s: unknown erasure mode %q", op, mode)
+	}
+
+	cursor, err := f.erasureStorage.AccountErasureCursor(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := f.authService.ListAccountErasures(ctx, &ssov1.ListAccountErasuresRequest{
+		AfterId: cursor,
+		Limit:   int32(batchSize),
+	})
+	if err != nil {
+		log.Error("failed to list account erasures", slog.Any("error", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied := 0
+	for _, erasure := range resp.GetErasures() {
+		if err := f.erasureStorage.EraseUserContent(ctx, erasure.GetId(), erasure.GetUserId(), mode); err != nil {
+			log.Error("failed to erase user content", slog.Int64("erasure_id", erasure.GetId()), slog.Any("error", err))
+			return applied, fmt.Errorf("%s: %w", op, err)
+		}
+		log.Info("user content erased", slog.Int64("erasure_id", erasure.GetId()), slog.Int64("user_id", erasure.GetUserId()))
+		applied++
+	}
+
+	return applied, nil
+}
+
+// hasPermission спрашивает у auth-service, есть ли у пользователя право permission
+func (f *Forum) hasPermission(ctx context.Context, userID int64, permission string) (bool, error) {
+	resp, err := f.authService.HasPermission(ctx, &ssov1.HasPermissionRequest{
+		UserId:     userID,
+		Permission: permission,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return resp.GetAllowed(), nil
+}
+
+// requirePermission возвращает ErrForbidden, если у пользователя нет права permission
+func (f *Forum) requirePermission(ctx context.Context, userID int64, permission string) error {
+	allowed, err := f.hasPermission(ctx, userID, permission)
+	if err != nil {
+		return fmt.Errorf("failed to check permission: %w", err)
+	}
+
+	if !allowed {
+		return fmt.Errorf("%w: user has no %s permission", ErrForbidden, permission)
+	}
+
 	return nil
 }
@@ -1,11 +1,14 @@
 package forum
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"errors"
 	"github.com/14kear/forum-project/forum-service/internal/config"
 	"github.com/14kear/forum-project/forum-service/internal/models"
 	"github.com/14kear/forum-project/forum-service/internal/services/mocks"
+	"github.com/14kear/forum-project/forum-service/internal/storage"
 	"github.com/14kear/forum-project/forum-service/utils"
 	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
 	"github.com/golang/mock/gomock"
@@ -23,7 +26,16 @@ func newTestForum(
 	commentStorage *mocks.MockCommentStorage,
 	chatMessagesStorage *mocks.MockChatMessageStorage,
 	authClient ssov1.AuthClient) *Forum {
-	return NewForum(utils.New(config.Load(configPath).Env), topicStorage, commentStorage, chatMessagesStorage, authClient)
+	return NewForum(utils.New(config.Load(configPath).Env), topicStorage, commentStorage, chatMessagesStorage, nil, nil, authClient)
+}
+
+// allowed возвращает клиент auth-service, который отвечает allow на проверку права permission у userID
+func allowed(ctrl *gomock.Controller, userID int64, permission string, allow bool) *mocks.MockAuthClient {
+	authClient := mocks.NewMockAuthClient(ctrl)
+	authClient.EXPECT().
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: userID, Permission: permission}).
+		Return(&ssov1.HasPermissionResponse{Allowed: allow}, nil)
+	return authClient
 }
 
 func TestForum_CreateTopic_Success(t *testing.T) {
@@ -34,7 +46,7 @@ func TestForum_CreateTopic_Success(t *testing.T) {
 
 	topicStorage.EXPECT().SaveTopic(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(155), nil)
 
-	testForum := newTestForum(ctrl, topicStorage, nil, nil, nil)
+	testForum := newTestForum(ctrl, topicStorage, nil, nil, allowed(ctrl, 66, PermissionPostCreate, true))
 
 	topicID, err := testForum.CreateTopic(context.Background(), "new topic", "about tests", 66, "test@test.com")
 	require.NoError(t, err)
@@ -60,7 +72,7 @@ func TestForum_CreateTopic_FailSave(t *testing.T) {
 
 	topicStorage.EXPECT().SaveTopic(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("save failed"))
 
-	testForum := newTestForum(ctrl, topicStorage, nil, nil, nil)
+	testForum := newTestForum(ctrl, topicStorage, nil, nil, allowed(ctrl, 66, PermissionPostCreate, true))
 
 	id, err := testForum.CreateTopic(context.Background(), "a", "b", 66, "test@test.com")
 	require.Error(t, err)
@@ -68,6 +80,16 @@ func TestForum_CreateTopic_FailSave(t *testing.T) {
 	assert.Equal(t, int64(0), id)
 }
 
+func TestForum_CreateTopic_NoPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, allowed(ctrl, 66, PermissionPostCreate, false))
+
+	_, err := testForum.CreateTopic(context.Background(), "a", "b", 66, "test@test.com")
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
 func TestForum_DeleteTopic_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -112,17 +134,17 @@ func TestForum_DeleteTopic_FailDelete(t *testing.T) {
 	topicStorage.EXPECT().GetTopicAuthorID(gomock.Any(), topicID).Return(authorID, nil)
 
 	authClient.EXPECT().
-		IsAdmin(gomock.Any(), &ssov1.IsAdminRequest{UserId: userID}).
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: userID, Permission: PermissionPostDeleteAny}).
 		Return(nil, errors.New("grpc error"))
 
 	testForum := newTestForum(ctrl, topicStorage, nil, nil, authClient)
 
 	err := testForum.DeleteTopic(context.Background(), topicID, userID)
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to check admin rights")
+	assert.Contains(t, err.Error(), "failed to check permission")
 }
 
-func TestForum_DeleteTopic_FailIsAdmin(t *testing.T) {
+func TestForum_DeleteTopic_NoPermission(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -136,8 +158,8 @@ func TestForum_DeleteTopic_FailIsAdmin(t *testing.T) {
 	topicStorage.EXPECT().GetTopicAuthorID(gomock.Any(), topicID).Return(authorID, nil)
 
 	authClient.EXPECT().
-		IsAdmin(gomock.Any(), &ssov1.IsAdminRequest{UserId: userID}).
-		Return(&ssov1.IsAdminResponse{IsAdmin: false}, nil)
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: userID, Permission: PermissionPostDeleteAny}).
+		Return(&ssov1.HasPermissionResponse{Allowed: false}, nil)
 
 	testForum := newTestForum(ctrl, topicStorage, nil, nil, authClient)
 
@@ -232,7 +254,7 @@ func TestForum_CreateComment_Success(t *testing.T) {
 
 	commentStorage.EXPECT().SaveComment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(55), nil)
 
-	testForum := newTestForum(ctrl, nil, commentStorage, nil, nil)
+	testForum := newTestForum(ctrl, nil, commentStorage, nil, allowed(ctrl, 11, PermissionPostCreate, true))
 
 	commentID, err := testForum.CreateComment(context.Background(), 1, 11, "new comment", "test@test.com")
 	require.NoError(t, err)
@@ -258,13 +280,23 @@ func TestForum_CreateComment_FailCreateComment(t *testing.T) {
 
 	commentStorage.EXPECT().SaveComment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("CreateComment failed"))
 
-	testForum := newTestForum(ctrl, nil, commentStorage, nil, nil)
+	testForum := newTestForum(ctrl, nil, commentStorage, nil, allowed(ctrl, 11, PermissionPostCreate, true))
 
 	_, err := testForum.CreateComment(context.Background(), 1, 11, "new comment", "test@test.com")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "CreateComment failed")
 }
 
+func TestForum_CreateComment_NoPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, allowed(ctrl, 11, PermissionPostCreate, false))
+
+	_, err := testForum.CreateComment(context.Background(), 1, 11, "new comment", "test@test.com")
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
 func TestForum_CommentByTopicID_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -375,17 +407,17 @@ func TestForum_DeleteComment_FailDelete(t *testing.T) {
 	commentStorage.EXPECT().GetCommentAuthorID(gomock.Any(), commentID).Return(authorID, nil)
 
 	authClient.EXPECT().
-		IsAdmin(gomock.Any(), &ssov1.IsAdminRequest{UserId: userID}).
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: userID, Permission: PermissionPostDeleteAny}).
 		Return(nil, errors.New("grpc error"))
 
 	testForum := newTestForum(ctrl, nil, commentStorage, nil, authClient)
 
 	err := testForum.DeleteComment(context.Background(), commentID, topicID, userID)
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to check admin rights")
+	assert.Contains(t, err.Error(), "failed to check permission")
 }
 
-func TestForum_DeleteComment_FailIsAdmin(t *testing.T) {
+func TestForum_DeleteComment_NoPermission(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -400,8 +432,8 @@ func TestForum_DeleteComment_FailIsAdmin(t *testing.T) {
 	commentStorage.EXPECT().GetCommentAuthorID(gomock.Any(), commentID).Return(authorID, nil)
 
 	authClient.EXPECT().
-		IsAdmin(gomock.Any(), &ssov1.IsAdminRequest{UserId: userID}).
-		Return(&ssov1.IsAdminResponse{IsAdmin: false}, nil)
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: userID, Permission: PermissionPostDeleteAny}).
+		Return(&ssov1.HasPermissionResponse{Allowed: false}, nil)
 
 	testForum := newTestForum(ctrl, nil, commentStorage, nil, authClient)
 
@@ -434,7 +466,7 @@ func TestForum_CreateChatMessage_Success(t *testing.T) {
 
 	chatMessageStorage.EXPECT().SaveChatMessage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(15), nil)
 
-	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, allowed(ctrl, 55, PermissionPostCreate, true))
 
 	chatMessageID, err := testForum.CreateChatMessage(context.Background(), 55, "hi", "test@test.com")
 	require.NoError(t, err)
@@ -460,12 +492,22 @@ func TestForum_CreateChatMessage_FailSaveChatMessage(t *testing.T) {
 
 	chatMessageStorage.EXPECT().SaveChatMessage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("SaveChatMessage failed"))
 
-	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, allowed(ctrl, 55, PermissionPostCreate, true))
 	_, err := testForum.CreateChatMessage(context.Background(), 55, "hi", "test@test.com")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "SaveChatMessage failed")
 }
 
+func TestForum_CreateChatMessage_NoPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, allowed(ctrl, 55, PermissionPostCreate, false))
+
+	_, err := testForum.CreateChatMessage(context.Background(), 55, "hi", "test@test.com")
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
 func TestForum_ListChatMessages_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -496,13 +538,169 @@ func TestForum_ListChatMessages_FailChatMessages(t *testing.T) {
 	assert.Contains(t, err.Error(), "ChatMessages failed")
 }
 
+func TestForum_EditChatMessage_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+
+	chatMessageStorage.EXPECT().ChatMessageByID(gomock.Any(), int64(7)).Return(models.ChatMessage{ID: 7, UserID: 55, Content: "old"}, nil)
+	chatMessageStorage.EXPECT().UpdateChatMessage(gomock.Any(), int64(7), "new", gomock.Any()).Return(nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	msg, err := testForum.EditChatMessage(context.Background(), 7, 55, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "new", msg.Content)
+	assert.NotNil(t, msg.EditedAt)
+}
+
+func TestForum_EditChatMessage_NotAuthor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+
+	chatMessageStorage.EXPECT().ChatMessageByID(gomock.Any(), int64(7)).Return(models.ChatMessage{ID: 7, UserID: 999}, nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	_, err := testForum.EditChatMessage(context.Background(), 7, 55, "new")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestForum_EditChatMessage_Deleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+
+	deletedAt := time.Now()
+	chatMessageStorage.EXPECT().ChatMessageByID(gomock.Any(), int64(7)).Return(models.ChatMessage{ID: 7, UserID: 55, DeletedAt: &deletedAt}, nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	_, err := testForum.EditChatMessage(context.Background(), 7, 55, "new")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, storage.ErrChatMessageNotFound)
+}
+
+func TestForum_EditChatMessage_EmptyContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, nil)
+
+	_, err := testForum.EditChatMessage(context.Background(), 7, 55, "")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestForum_DeleteChatMessage_Author(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+
+	chatMessageStorage.EXPECT().ChatMessageByID(gomock.Any(), int64(7)).Return(models.ChatMessage{ID: 7, UserID: 55, Content: "text"}, nil)
+	chatMessageStorage.EXPECT().DeleteChatMessage(gomock.Any(), int64(7), gomock.Any()).Return(nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	msg, err := testForum.DeleteChatMessage(context.Background(), 7, 55)
+	require.NoError(t, err)
+	assert.Empty(t, msg.Content)
+	assert.NotNil(t, msg.DeletedAt)
+}
+
+func TestForum_DeleteChatMessage_Moderator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+
+	chatMessageStorage.EXPECT().ChatMessageByID(gomock.Any(), int64(7)).Return(models.ChatMessage{ID: 7, UserID: 999}, nil)
+	authClient.EXPECT().
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: 55, Permission: PermissionPostDeleteAny}).
+		Return(&ssov1.HasPermissionResponse{Allowed: true}, nil)
+	chatMessageStorage.EXPECT().DeleteChatMessage(gomock.Any(), int64(7), gomock.Any()).Return(nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, authClient)
+
+	_, err := testForum.DeleteChatMessage(context.Background(), 7, 55)
+	require.NoError(t, err)
+}
+
+func TestForum_DeleteChatMessage_NoPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+
+	chatMessageStorage.EXPECT().ChatMessageByID(gomock.Any(), int64(7)).Return(models.ChatMessage{ID: 7, UserID: 999}, nil)
+	authClient.EXPECT().
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: 55, Permission: PermissionPostDeleteAny}).
+		Return(&ssov1.HasPermissionResponse{Allowed: false}, nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, authClient)
+
+	_, err := testForum.DeleteChatMessage(context.Background(), 7, 55)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestForum_ChatMessagesSince_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+
+	expected := []models.ChatMessage{{ID: 11, Content: "a"}, {ID: 12, Content: "b"}}
+	chatMessageStorage.EXPECT().ChatMessagesAfter(gomock.Any(), int64(10), 100).Return(expected, nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	chatMessages, err := testForum.ChatMessagesSince(context.Background(), 10, 100)
+	require.NoError(t, err)
+	assert.Equal(t, expected, chatMessages)
+}
+
+func TestForum_ChatMessagesSince_InvalidSinceID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, nil)
+
+	_, err := testForum.ChatMessagesSince(context.Background(), -1, 100)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestForum_ChatMessagesSince_FailChatMessagesAfter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+
+	chatMessageStorage.EXPECT().ChatMessagesAfter(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("ChatMessagesAfter failed"))
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	_, err := testForum.ChatMessagesSince(context.Background(), 10, 100)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ChatMessagesAfter failed")
+}
+
 func TestForum_CleanupOldMessages_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
 
-	chatMessageStorage.EXPECT().DeleteChatMessagesBefore(gomock.Any(), gomock.Any()).Return(nil)
+	chatMessageStorage.EXPECT().DeleteChatMessagesBefore(gomock.Any(), gomock.Any()).Return(int64(3), nil)
 
 	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
 
@@ -516,7 +714,7 @@ func TestForum_CleanupOldMessages_FailDeleteOldMessages(t *testing.T) {
 
 	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
 
-	chatMessageStorage.EXPECT().DeleteChatMessagesBefore(gomock.Any(), gomock.Any()).Return(errors.New("DeleteChatMessages failed"))
+	chatMessageStorage.EXPECT().DeleteChatMessagesBefore(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("DeleteChatMessages failed"))
 
 	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
 
@@ -524,3 +722,336 @@ func TestForum_CleanupOldMessages_FailDeleteOldMessages(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "DeleteChatMessages failed")
 }
+
+func TestForum_SetChatRetentionPolicy_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+
+	authClient.EXPECT().
+		HasPermission(gomock.Any(), &ssov1.HasPermissionRequest{UserId: 55, Permission: PermissionChatManage}).
+		Return(&ssov1.HasPermissionResponse{Allowed: true}, nil)
+	chatMessageStorage.EXPECT().
+		SaveChatRetentionPolicy(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, p models.ChatRetentionPolicy) error {
+			assert.Equal(t, models.RetentionArchive, p.Mode)
+			assert.Equal(t, 7*24*time.Hour, p.MaxAge)
+			assert.Equal(t, int64(55), p.UpdatedBy)
+			return nil
+		})
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, authClient)
+
+	policy, err := testForum.SetChatRetentionPolicy(context.Background(), 55, models.RetentionArchive, 7*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, models.RetentionArchive, policy.Mode)
+}
+
+func TestForum_SetChatRetentionPolicy_KeepIgnoresMaxAge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+
+	authClient.EXPECT().HasPermission(gomock.Any(), gomock.Any()).Return(&ssov1.HasPermissionResponse{Allowed: true}, nil)
+	chatMessageStorage.EXPECT().SaveChatRetentionPolicy(gomock.Any(), gomock.Any()).Return(nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, authClient)
+
+	policy, err := testForum.SetChatRetentionPolicy(context.Background(), 55, models.RetentionKeep, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, policy.MaxAge)
+}
+
+func TestForum_SetChatRetentionPolicy_InvalidInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, nil)
+
+	_, err := testForum.SetChatRetentionPolicy(context.Background(), 55, "forever", time.Hour)
+	require.ErrorIs(t, err, ErrValidation)
+
+	_, err = testForum.SetChatRetentionPolicy(context.Background(), 55, models.RetentionDelete, 0)
+	require.ErrorIs(t, err, ErrValidation)
+}
+
+func TestForum_SetChatRetentionPolicy_NoPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, allowed(ctrl, 55, PermissionChatManage, false))
+
+	_, err := testForum.SetChatRetentionPolicy(context.Background(), 55, models.RetentionDelete, time.Hour)
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestForum_SetChatSlowMode_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	chatMessageStorage.EXPECT().SaveChatSlowMode(gomock.Any(), 30*time.Second).Return(nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, allowed(ctrl, 55, PermissionChatManage, true))
+
+	err := testForum.SetChatSlowMode(context.Background(), 55, 30*time.Second)
+	require.NoError(t, err)
+}
+
+func TestForum_SetChatSlowMode_Negative(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, nil)
+
+	err := testForum.SetChatSlowMode(context.Background(), 55, -time.Second)
+	require.ErrorIs(t, err, ErrValidation)
+}
+
+func TestForum_SetChatSlowMode_NoPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, allowed(ctrl, 55, PermissionChatManage, false))
+
+	err := testForum.SetChatSlowMode(context.Background(), 55, time.Second)
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestForum_ChatSlowMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	chatMessageStorage.EXPECT().ChatSlowMode(gomock.Any()).Return(15*time.Second, true, nil)
+
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	interval, ok, err := testForum.ChatSlowMode(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 15*time.Second, interval)
+}
+
+func TestForum_ApplyChatRetention_Modes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	testForum := newTestForum(ctrl, nil, nil, chatMessageStorage, nil)
+
+	chatMessageStorage.EXPECT().
+		ChatRetentionPolicy(gomock.Any()).
+		Return(models.ChatRetentionPolicy{Mode: models.RetentionDelete, MaxAge: time.Hour}, nil)
+	chatMessageStorage.EXPECT().DeleteChatMessagesBefore(gomock.Any(), gomock.Any()).Return(int64(4), nil)
+
+	removed, err := testForum.ApplyChatRetention(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), removed)
+
+	chatMessageStorage.EXPECT().
+		ChatRetentionPolicy(gomock.Any()).
+		Return(models.ChatRetentionPolicy{Mode: models.RetentionArchive, MaxAge: time.Hour}, nil)
+	chatMessageStorage.EXPECT().ArchiveChatMessagesBefore(gomock.Any(), gomock.Any()).Return(int64(2), nil)
+
+	removed, err = testForum.ApplyChatRetention(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	chatMessageStorage.EXPECT().
+		ChatRetentionPolicy(gomock.Any()).
+		Return(models.ChatRetentionPolicy{Mode: models.RetentionKeep}, nil)
+
+	removed, err = testForum.ApplyChatRetention(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+func TestForum_SweepChatMessages_NoPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testForum := newTestForum(ctrl, nil, nil, nil, allowed(ctrl, 55, PermissionChatManage, false))
+
+	_, err := testForum.SweepChatMessages(context.Background(), 55)
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestForum_ApplyAccountErasures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	erasureStorage := mocks.NewMockErasureStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+	testForum := NewForum(utils.New(config.Load(configPath).Env), nil, nil, nil, erasureStorage, nil, authClient)
+
+	erasureStorage.EXPECT().AccountErasureCursor(gomock.Any()).Return(int64(10), nil)
+	authClient.EXPECT().
+		ListAccountErasures(gomock.Any(), &ssov1.ListAccountErasuresRequest{AfterId: 10, Limit: 50}).
+		Return(&ssov1.ListAccountErasuresResponse{Erasures: []*ssov1.AccountErasure{
+			{Id: 11, UserId: 5},
+			{Id: 12, UserId: 6},
+		}}, nil)
+	gomock.InOrder(
+		erasureStorage.EXPECT().EraseUserContent(gomock.Any(), int64(11), int64(5), models.ErasureDelete).Return(nil),
+		erasureStorage.EXPECT().EraseUserContent(gomock.Any(), int64(12), int64(6), models.ErasureDelete).Return(nil),
+	)
+
+	applied, err := testForum.ApplyAccountErasures(context.Background(), models.ErasureDelete, 50)
+	require.NoError(t, err)
+	assert.Equal(t, 2, applied)
+}
+
+func TestForum_ApplyAccountErasures_StopsOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	erasureStorage := mocks.NewMockErasureStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+	testForum := NewForum(utils.New(config.Load(configPath).Env), nil, nil, nil, erasureStorage, nil, authClient)
+
+	erasureStorage.EXPECT().AccountErasureCursor(gomock.Any()).Return(int64(0), nil)
+	authClient.EXPECT().ListAccountErasures(gomock.Any(), gomock.Any()).
+		Return(&ssov1.ListAccountErasuresResponse{Erasures: []*ssov1.AccountErasure{
+			{Id: 1, UserId: 5},
+			{Id: 2, UserId: 6},
+		}}, nil)
+	erasureStorage.EXPECT().EraseUserContent(gomock.Any(), int64(1), int64(5), models.ErasureAnonymize).Return(errors.New("db is down"))
+
+	// следующее событие не применяется раньше неудавшегося, иначе курсор его пропустит
+	applied, err := testForum.ApplyAccountErasures(context.Background(), models.ErasureAnonymize, 100)
+	require.Error(t, err)
+	assert.Zero(t, applied)
+}
+
+func TestForum_ApplyAccountErasures_UnknownMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	erasureStorage := mocks.NewMockErasureStorage(ctrl)
+	testForum := NewForum(utils.New(config.Load(configPath).Env), nil, nil, nil, erasureStorage, nil, nil)
+
+	erasureStorage.EXPECT().AccountErasureCursor(gomock.Any()).Times(0)
+
+	_, err := testForum.ApplyAccountErasures(context.Background(), "archive", 100)
+	require.Error(t, err)
+}
+
+func TestForum_ProcessDataExports(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	topicStorage := mocks.NewMockTopicStorage(ctrl)
+	commentStorage := mocks.NewMockCommentStorage(ctrl)
+	chatMessageStorage := mocks.NewMockChatMessageStorage(ctrl)
+	exportStorage := mocks.NewMockDataExportStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+	testForum := NewForum(utils.New(config.Load(configPath).Env), topicStorage, commentStorage, chatMessageStorage, nil, exportStorage, authClient)
+
+	exportStorage.EXPECT().DeleteExpiredDataExports(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	gomock.InOrder(
+		exportStorage.EXPECT().ClaimDataExport(gomock.Any(), gomock.Any()).
+			Return(models.DataExport{ID: 3, UserID: 55, Status: models.ExportRunning}, nil),
+		exportStorage.EXPECT().ClaimDataExport(gomock.Any(), gomock.Any()).
+			Return(models.DataExport{}, storage.ErrDataExportNotFound),
+	)
+
+	authClient.EXPECT().ExportUserData(gomock.Any(), &ssov1.ExportUserDataRequest{UserId: 55}).
+		Return(&ssov1.ExportUserDataResponse{
+			Profile:       &ssov1.Profile{UserId: 55, Email: "user@mail.ru"},
+			RefreshTokens: []*ssov1.RefreshTokenRecord{{Id: 1, SessionId: 1}},
+		}, nil)
+	topicStorage.EXPECT().TopicsByUserID(gomock.Any(), int64(55)).Return([]models.Topic{{ID: 1, UserID: 55}}, nil)
+	commentStorage.EXPECT().CommentsByUserID(gomock.Any(), int64(55)).Return([]models.Comment{{ID: 2, UserID: 55}}, nil)
+	chatMessageStorage.EXPECT().ChatMessagesByUserID(gomock.Any(), int64(55)).Return(nil, nil)
+
+	var progress []int
+	exportStorage.EXPECT().UpdateDataExportProgress(gomock.Any(), int64(3), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, p int) error {
+			progress = append(progress, p)
+			return nil
+		}).Times(4)
+
+	var archive []byte
+	exportStorage.EXPECT().CompleteDataExport(gomock.Any(), int64(3), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, data []byte, completedAt, expiresAt time.Time) error {
+			archive = data
+			assert.Equal(t, 24*time.Hour, expiresAt.Sub(completedAt))
+			return nil
+		})
+
+	processed, err := testForum.ProcessDataExports(context.Background(), 24*time.Hour, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, []int{22, 45, 67, 90}, progress)
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	var names []string
+	for _, file := range zr.File {
+		names = append(names, file.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"account.json", "refresh_tokens.json", "topics.json", "comments.json", "chat_messages.json", "manifest.json",
+	}, names)
+}
+
+func TestForum_ProcessDataExports_AuthFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	exportStorage := mocks.NewMockDataExportStorage(ctrl)
+	authClient := mocks.NewMockAuthClient(ctrl)
+	testForum := NewForum(utils.New(config.Load(configPath).Env), nil, nil, nil, nil, exportStorage, authClient)
+
+	exportStorage.EXPECT().DeleteExpiredDataExports(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+	gomock.InOrder(
+		exportStorage.EXPECT().ClaimDataExport(gomock.Any(), gomock.Any()).
+			Return(models.DataExport{ID: 3, UserID: 55, Status: models.ExportRunning}, nil),
+		exportStorage.EXPECT().ClaimDataExport(gomock.Any(), gomock.Any()).
+			Return(models.DataExport{}, storage.ErrDataExportNotFound),
+	)
+	authClient.EXPECT().ExportUserData(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable"))
+	exportStorage.EXPECT().FailDataExport(gomock.Any(), int64(3), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	exportStorage.EXPECT().CompleteDataExport(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	processed, err := testForum.ProcessDataExports(context.Background(), time.Hour, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+}
+
+func TestForum_DataExportArchive_Errors(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	valid := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		export models.DataExport
+		want   error
+	}{
+		{name: "other user", export: models.DataExport{ID: 3, UserID: 56, Status: models.ExportReady, ExpiresAt: &valid}, want: ErrExportNotFound},
+		{name: "still running", export: models.DataExport{ID: 3, UserID: 55, Status: models.ExportRunning}, want: ErrExportNotReady},
+		{name: "failed", export: models.DataExport{ID: 3, UserID: 55, Status: models.ExportFailed, ExpiresAt: &valid}, want: ErrExportNotReady},
+		{name: "expired", export: models.DataExport{ID: 3, UserID: 55, Status: models.ExportReady, ExpiresAt: &expired}, want: ErrExportExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			exportStorage := mocks.NewMockDataExportStorage(ctrl)
+			testForum := NewForum(utils.New(config.Load(configPath).Env), nil, nil, nil, nil, exportStorage, nil)
+
+			exportStorage.EXPECT().DataExportByID(gomock.Any(), int64(3)).Return(tt.export, nil)
+			exportStorage.EXPECT().DataExportArchive(gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := testForum.DataExportArchive(context.Background(), 55, 3)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
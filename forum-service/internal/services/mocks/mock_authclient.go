@@ -36,162 +36,1322 @@ func (m *MockAuthClient) EXPECT() *MockAuthClientMockRecorder {
 	return m.recorder
 }
 
+// AssignRole mocks base method.
+func (m *MockAuthClient) AssignRole(ctx context.Context, in *ssov1.AssignRoleRequest, opts ...grpc.CallOption) (*ssov1.AssignRoleResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AssignRole", varargs...)
+	ret0, _ := ret[0].(*ssov1.AssignRoleResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockAuthClientMockRecorder) AssignRole(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockAuthClient)(nil).AssignRole), varargs...)
+}
+
+// BanUser mocks base method.
+func (m *MockAuthClient) BanUser(ctx context.Context, in *ssov1.BanUserRequest, opts ...grpc.CallOption) (*ssov1.BanUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BanUser", varargs...)
+	ret0, _ := ret[0].(*ssov1.BanUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockAuthClientMockRecorder) BanUser(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockAuthClient)(nil).BanUser), varargs...)
+}
+
+// ChangeEmail mocks base method.
+func (m *MockAuthClient) ChangeEmail(ctx context.Context, in *ssov1.ChangeEmailRequest, opts ...grpc.CallOption) (*ssov1.ChangeEmailResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ChangeEmail", varargs...)
+	ret0, _ := ret[0].(*ssov1.ChangeEmailResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeEmail indicates an expected call of ChangeEmail.
+func (mr *MockAuthClientMockRecorder) ChangeEmail(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeEmail", reflect.TypeOf((*MockAuthClient)(nil).ChangeEmail), varargs...)
+}
+
+// ChangePassword mocks base method.
+func (m *MockAuthClient) ChangePassword(ctx context.Context, in *ssov1.ChangePasswordRequest, opts ...grpc.CallOption) (*ssov1.ChangePasswordResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ChangePassword", varargs...)
+	ret0, _ := ret[0].(*ssov1.ChangePasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthClientMockRecorder) ChangePassword(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthClient)(nil).ChangePassword), varargs...)
+}
+
+// ClearLoginLockout mocks base method.
+func (m *MockAuthClient) ClearLoginLockout(ctx context.Context, in *ssov1.ClearLoginLockoutRequest, opts ...grpc.CallOption) (*ssov1.ClearLoginLockoutResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ClearLoginLockout", varargs...)
+	ret0, _ := ret[0].(*ssov1.ClearLoginLockoutResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClearLoginLockout indicates an expected call of ClearLoginLockout.
+func (mr *MockAuthClientMockRecorder) ClearLoginLockout(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginLockout", reflect.TypeOf((*MockAuthClient)(nil).ClearLoginLockout), varargs...)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockAuthClient) ConfirmEmailChange(ctx context.Context, in *ssov1.ConfirmEmailChangeRequest, opts ...grpc.CallOption) (*ssov1.ConfirmEmailChangeResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", varargs...)
+	ret0, _ := ret[0].(*ssov1.ConfirmEmailChangeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockAuthClientMockRecorder) ConfirmEmailChange(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockAuthClient)(nil).ConfirmEmailChange), varargs...)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockAuthClient) ConfirmTOTP(ctx context.Context, in *ssov1.ConfirmTOTPRequest, opts ...grpc.CallOption) (*ssov1.ConfirmTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConfirmTOTP", varargs...)
+	ret0, _ := ret[0].(*ssov1.ConfirmTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockAuthClientMockRecorder) ConfirmTOTP(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockAuthClient)(nil).ConfirmTOTP), varargs...)
+}
+
+// CreateApp mocks base method.
+func (m *MockAuthClient) CreateApp(ctx context.Context, in *ssov1.CreateAppRequest, opts ...grpc.CallOption) (*ssov1.CreateAppResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateApp", varargs...)
+	ret0, _ := ret[0].(*ssov1.CreateAppResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApp indicates an expected call of CreateApp.
+func (mr *MockAuthClientMockRecorder) CreateApp(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApp", reflect.TypeOf((*MockAuthClient)(nil).CreateApp), varargs...)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockAuthClient) DeleteAccount(ctx context.Context, in *ssov1.DeleteAccountRequest, opts ...grpc.CallOption) (*ssov1.DeleteAccountResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteAccount", varargs...)
+	ret0, _ := ret[0].(*ssov1.DeleteAccountResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAuthClientMockRecorder) DeleteAccount(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAuthClient)(nil).DeleteAccount), varargs...)
+}
+
+// DisableApp mocks base method.
+func (m *MockAuthClient) DisableApp(ctx context.Context, in *ssov1.DisableAppRequest, opts ...grpc.CallOption) (*ssov1.DisableAppResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DisableApp", varargs...)
+	ret0, _ := ret[0].(*ssov1.DisableAppResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableApp indicates an expected call of DisableApp.
+func (mr *MockAuthClientMockRecorder) DisableApp(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableApp", reflect.TypeOf((*MockAuthClient)(nil).DisableApp), varargs...)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockAuthClient) DisableTOTP(ctx context.Context, in *ssov1.DisableTOTPRequest, opts ...grpc.CallOption) (*ssov1.DisableTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DisableTOTP", varargs...)
+	ret0, _ := ret[0].(*ssov1.DisableTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockAuthClientMockRecorder) DisableTOTP(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockAuthClient)(nil).DisableTOTP), varargs...)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockAuthClient) EnrollTOTP(ctx context.Context, in *ssov1.EnrollTOTPRequest, opts ...grpc.CallOption) (*ssov1.EnrollTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "EnrollTOTP", varargs...)
+	ret0, _ := ret[0].(*ssov1.EnrollTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockAuthClientMockRecorder) EnrollTOTP(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockAuthClient)(nil).EnrollTOTP), varargs...)
+}
+
+// ExportUserData mocks base method.
+func (m *MockAuthClient) ExportUserData(ctx context.Context, in *ssov1.ExportUserDataRequest, opts ...grpc.CallOption) (*ssov1.ExportUserDataResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExportUserData", varargs...)
+	ret0, _ := ret[0].(*ssov1.ExportUserDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockAuthClientMockRecorder) ExportUserData(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockAuthClient)(nil).ExportUserData), varargs...)
+}
+
+// GetJWKS mocks base method.
+func (m *MockAuthClient) GetJWKS(ctx context.Context, in *ssov1.GetJWKSRequest, opts ...grpc.CallOption) (*ssov1.GetJWKSResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetJWKS", varargs...)
+	ret0, _ := ret[0].(*ssov1.GetJWKSResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJWKS indicates an expected call of GetJWKS.
+func (mr *MockAuthClientMockRecorder) GetJWKS(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockAuthClient)(nil).GetJWKS), varargs...)
+}
+
+// GetOpenIDConfiguration mocks base method.
+func (m *MockAuthClient) GetOpenIDConfiguration(ctx context.Context, in *ssov1.GetOpenIDConfigurationRequest, opts ...grpc.CallOption) (*ssov1.GetOpenIDConfigurationResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetOpenIDConfiguration", varargs...)
+	ret0, _ := ret[0].(*ssov1.GetOpenIDConfigurationResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenIDConfiguration indicates an expected call of GetOpenIDConfiguration.
+func (mr *MockAuthClientMockRecorder) GetOpenIDConfiguration(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenIDConfiguration", reflect.TypeOf((*MockAuthClient)(nil).GetOpenIDConfiguration), varargs...)
+}
+
+// GetProfile mocks base method.
+func (m *MockAuthClient) GetProfile(ctx context.Context, in *ssov1.GetProfileRequest, opts ...grpc.CallOption) (*ssov1.GetProfileResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetProfile", varargs...)
+	ret0, _ := ret[0].(*ssov1.GetProfileResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockAuthClientMockRecorder) GetProfile(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockAuthClient)(nil).GetProfile), varargs...)
+}
+
+// HasPermission mocks base method.
+func (m *MockAuthClient) HasPermission(ctx context.Context, in *ssov1.HasPermissionRequest, opts ...grpc.CallOption) (*ssov1.HasPermissionResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "HasPermission", varargs...)
+	ret0, _ := ret[0].(*ssov1.HasPermissionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasPermission indicates an expected call of HasPermission.
+func (mr *MockAuthClientMockRecorder) HasPermission(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPermission", reflect.TypeOf((*MockAuthClient)(nil).HasPermission), varargs...)
+}
+
 // IsAdmin mocks base method.
 func (m *MockAuthClient) IsAdmin(ctx context.Context, in *ssov1.IsAdminRequest, opts ...grpc.CallOption) (*ssov1.IsAdminResponse, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, in}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "IsAdmin", varargs...)
-	ret0, _ := ret[0].(*ssov1.IsAdminResponse)
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "IsAdmin", varargs...)
+	ret0, _ := ret[0].(*ssov1.IsAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAdmin indicates an expected call of IsAdmin.
+func (mr *MockAuthClientMockRecorder) IsAdmin(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockAuthClient)(nil).IsAdmin), varargs...)
+}
+
+// ListAccountErasures mocks base method.
+func (m *MockAuthClient) ListAccountErasures(ctx context.Context, in *ssov1.ListAccountErasuresRequest, opts ...grpc.CallOption) (*ssov1.ListAccountErasuresResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListAccountErasures", varargs...)
+	ret0, _ := ret[0].(*ssov1.ListAccountErasuresResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountErasures indicates an expected call of ListAccountErasures.
+func (mr *MockAuthClientMockRecorder) ListAccountErasures(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountErasures", reflect.TypeOf((*MockAuthClient)(nil).ListAccountErasures), varargs...)
+}
+
+// ListActiveBans mocks base method.
+func (m *MockAuthClient) ListActiveBans(ctx context.Context, in *ssov1.ListActiveBansRequest, opts ...grpc.CallOption) (*ssov1.ListActiveBansResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListActiveBans", varargs...)
+	ret0, _ := ret[0].(*ssov1.ListActiveBansResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveBans indicates an expected call of ListActiveBans.
+func (mr *MockAuthClientMockRecorder) ListActiveBans(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveBans", reflect.TypeOf((*MockAuthClient)(nil).ListActiveBans), varargs...)
+}
+
+// ListApps mocks base method.
+func (m *MockAuthClient) ListApps(ctx context.Context, in *ssov1.ListAppsRequest, opts ...grpc.CallOption) (*ssov1.ListAppsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListApps", varargs...)
+	ret0, _ := ret[0].(*ssov1.ListAppsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApps indicates an expected call of ListApps.
+func (mr *MockAuthClientMockRecorder) ListApps(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApps", reflect.TypeOf((*MockAuthClient)(nil).ListApps), varargs...)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAuthClient) ListAuditEvents(ctx context.Context, in *ssov1.ListAuditEventsRequest, opts ...grpc.CallOption) (*ssov1.ListAuditEventsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListAuditEvents", varargs...)
+	ret0, _ := ret[0].(*ssov1.ListAuditEventsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuthClientMockRecorder) ListAuditEvents(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAuthClient)(nil).ListAuditEvents), varargs...)
+}
+
+// ListLoginLockouts mocks base method.
+func (m *MockAuthClient) ListLoginLockouts(ctx context.Context, in *ssov1.ListLoginLockoutsRequest, opts ...grpc.CallOption) (*ssov1.ListLoginLockoutsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListLoginLockouts", varargs...)
+	ret0, _ := ret[0].(*ssov1.ListLoginLockoutsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginLockouts indicates an expected call of ListLoginLockouts.
+func (mr *MockAuthClientMockRecorder) ListLoginLockouts(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginLockouts", reflect.TypeOf((*MockAuthClient)(nil).ListLoginLockouts), varargs...)
+}
+
+// ListOAuthConsents mocks base method.
+func (m *MockAuthClient) ListOAuthConsents(ctx context.Context, in *ssov1.ListOAuthConsentsRequest, opts ...grpc.CallOption) (*ssov1.ListOAuthConsentsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListOAuthConsents", varargs...)
+	ret0, _ := ret[0].(*ssov1.ListOAuthConsentsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOAuthConsents indicates an expected call of ListOAuthConsents.
+func (mr *MockAuthClientMockRecorder) ListOAuthConsents(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOAuthConsents", reflect.TypeOf((*MockAuthClient)(nil).ListOAuthConsents), varargs...)
+}
+
+// ListSessions mocks base method.
+func (m *MockAuthClient) ListSessions(ctx context.Context, in *ssov1.ListSessionsRequest, opts ...grpc.CallOption) (*ssov1.ListSessionsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListSessions", varargs...)
+	ret0, _ := ret[0].(*ssov1.ListSessionsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockAuthClientMockRecorder) ListSessions(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockAuthClient)(nil).ListSessions), varargs...)
+}
+
+// Login mocks base method.
+func (m *MockAuthClient) Login(ctx context.Context, in *ssov1.LoginRequest, opts ...grpc.CallOption) (*ssov1.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Login", varargs...)
+	ret0, _ := ret[0].(*ssov1.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockAuthClientMockRecorder) Login(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthClient)(nil).Login), varargs...)
+}
+
+// Logout mocks base method.
+func (m *MockAuthClient) Logout(ctx context.Context, in *ssov1.LogoutRequest, opts ...grpc.CallOption) (*ssov1.LogoutResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Logout", varargs...)
+	ret0, _ := ret[0].(*ssov1.LogoutResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthClientMockRecorder) Logout(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthClient)(nil).Logout), varargs...)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockAuthClient) RefreshTokens(ctx context.Context, in *ssov1.RefreshTokenRequest, opts ...grpc.CallOption) (*ssov1.RefreshTokenResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RefreshTokens", varargs...)
+	ret0, _ := ret[0].(*ssov1.RefreshTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockAuthClientMockRecorder) RefreshTokens(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockAuthClient)(nil).RefreshTokens), varargs...)
+}
+
+// Register mocks base method.
+func (m *MockAuthClient) Register(ctx context.Context, in *ssov1.RegisterRequest, opts ...grpc.CallOption) (*ssov1.RegisterResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Register", varargs...)
+	ret0, _ := ret[0].(*ssov1.RegisterResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockAuthClientMockRecorder) Register(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthClient)(nil).Register), varargs...)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockAuthClient) RequestPasswordReset(ctx context.Context, in *ssov1.RequestPasswordResetRequest, opts ...grpc.CallOption) (*ssov1.RequestPasswordResetResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RequestPasswordReset", varargs...)
+	ret0, _ := ret[0].(*ssov1.RequestPasswordResetResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockAuthClientMockRecorder) RequestPasswordReset(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockAuthClient)(nil).RequestPasswordReset), varargs...)
+}
+
+// ResendVerification mocks base method.
+func (m *MockAuthClient) ResendVerification(ctx context.Context, in *ssov1.ResendVerificationRequest, opts ...grpc.CallOption) (*ssov1.ResendVerificationResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ResendVerification", varargs...)
+	ret0, _ := ret[0].(*ssov1.ResendVerificationResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockAuthClientMockRecorder) ResendVerification(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockAuthClient)(nil).ResendVerification), varargs...)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAuthClient) ResetPassword(ctx context.Context, in *ssov1.ResetPasswordRequest, opts ...grpc.CallOption) (*ssov1.ResetPasswordResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ResetPassword", varargs...)
+	ret0, _ := ret[0].(*ssov1.ResetPasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthClientMockRecorder) ResetPassword(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuthClient)(nil).ResetPassword), varargs...)
+}
+
+// RevokeAllSessions mocks base method.
+func (m *MockAuthClient) RevokeAllSessions(ctx context.Context, in *ssov1.RevokeAllSessionsRequest, opts ...grpc.CallOption) (*ssov1.RevokeAllSessionsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeAllSessions", varargs...)
+	ret0, _ := ret[0].(*ssov1.RevokeAllSessionsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAllSessions indicates an expected call of RevokeAllSessions.
+func (mr *MockAuthClientMockRecorder) RevokeAllSessions(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllSessions", reflect.TypeOf((*MockAuthClient)(nil).RevokeAllSessions), varargs...)
+}
+
+// RevokeOAuthConsent mocks base method.
+func (m *MockAuthClient) RevokeOAuthConsent(ctx context.Context, in *ssov1.RevokeOAuthConsentRequest, opts ...grpc.CallOption) (*ssov1.RevokeOAuthConsentResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeOAuthConsent", varargs...)
+	ret0, _ := ret[0].(*ssov1.RevokeOAuthConsentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOAuthConsent indicates an expected call of RevokeOAuthConsent.
+func (mr *MockAuthClientMockRecorder) RevokeOAuthConsent(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOAuthConsent", reflect.TypeOf((*MockAuthClient)(nil).RevokeOAuthConsent), varargs...)
+}
+
+// RevokeRole mocks base method.
+func (m *MockAuthClient) RevokeRole(ctx context.Context, in *ssov1.RevokeRoleRequest, opts ...grpc.CallOption) (*ssov1.RevokeRoleResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeRole", varargs...)
+	ret0, _ := ret[0].(*ssov1.RevokeRoleResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockAuthClientMockRecorder) RevokeRole(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockAuthClient)(nil).RevokeRole), varargs...)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthClient) RevokeSession(ctx context.Context, in *ssov1.RevokeSessionRequest, opts ...grpc.CallOption) (*ssov1.RevokeSessionResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeSession", varargs...)
+	ret0, _ := ret[0].(*ssov1.RevokeSessionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthClientMockRecorder) RevokeSession(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthClient)(nil).RevokeSession), varargs...)
+}
+
+// RotateAppSecret mocks base method.
+func (m *MockAuthClient) RotateAppSecret(ctx context.Context, in *ssov1.RotateAppSecretRequest, opts ...grpc.CallOption) (*ssov1.RotateAppSecretResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RotateAppSecret", varargs...)
+	ret0, _ := ret[0].(*ssov1.RotateAppSecretResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAppSecret indicates an expected call of RotateAppSecret.
+func (mr *MockAuthClientMockRecorder) RotateAppSecret(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAppSecret", reflect.TypeOf((*MockAuthClient)(nil).RotateAppSecret), varargs...)
+}
+
+// RotateSigningKey mocks base method.
+func (m *MockAuthClient) RotateSigningKey(ctx context.Context, in *ssov1.RotateSigningKeyRequest, opts ...grpc.CallOption) (*ssov1.RotateSigningKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RotateSigningKey", varargs...)
+	ret0, _ := ret[0].(*ssov1.RotateSigningKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSigningKey indicates an expected call of RotateSigningKey.
+func (mr *MockAuthClientMockRecorder) RotateSigningKey(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningKey", reflect.TypeOf((*MockAuthClient)(nil).RotateSigningKey), varargs...)
+}
+
+// SuspendUser mocks base method.
+func (m *MockAuthClient) SuspendUser(ctx context.Context, in *ssov1.SuspendUserRequest, opts ...grpc.CallOption) (*ssov1.SuspendUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SuspendUser", varargs...)
+	ret0, _ := ret[0].(*ssov1.SuspendUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockAuthClientMockRecorder) SuspendUser(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockAuthClient)(nil).SuspendUser), varargs...)
+}
+
+// UnbanUser mocks base method.
+func (m *MockAuthClient) UnbanUser(ctx context.Context, in *ssov1.UnbanUserRequest, opts ...grpc.CallOption) (*ssov1.UnbanUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UnbanUser", varargs...)
+	ret0, _ := ret[0].(*ssov1.UnbanUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnbanUser indicates an expected call of UnbanUser.
+func (mr *MockAuthClientMockRecorder) UnbanUser(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanUser", reflect.TypeOf((*MockAuthClient)(nil).UnbanUser), varargs...)
+}
+
+// UpdateApp mocks base method.
+func (m *MockAuthClient) UpdateApp(ctx context.Context, in *ssov1.UpdateAppRequest, opts ...grpc.CallOption) (*ssov1.UpdateAppResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateApp", varargs...)
+	ret0, _ := ret[0].(*ssov1.UpdateAppResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateApp indicates an expected call of UpdateApp.
+func (mr *MockAuthClientMockRecorder) UpdateApp(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApp", reflect.TypeOf((*MockAuthClient)(nil).UpdateApp), varargs...)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockAuthClient) UpdateProfile(ctx context.Context, in *ssov1.UpdateProfileRequest, opts ...grpc.CallOption) (*ssov1.UpdateProfileResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateProfile", varargs...)
+	ret0, _ := ret[0].(*ssov1.UpdateProfileResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockAuthClientMockRecorder) UpdateProfile(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockAuthClient)(nil).UpdateProfile), varargs...)
+}
+
+// UserInfo mocks base method.
+func (m *MockAuthClient) UserInfo(ctx context.Context, in *ssov1.UserInfoRequest, opts ...grpc.CallOption) (*ssov1.UserInfoResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UserInfo", varargs...)
+	ret0, _ := ret[0].(*ssov1.UserInfoResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockAuthClientMockRecorder) UserInfo(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockAuthClient)(nil).UserInfo), varargs...)
+}
+
+// ValidateToken mocks base method.
+func (m *MockAuthClient) ValidateToken(ctx context.Context, in *ssov1.ValidateTokenRequest, opts ...grpc.CallOption) (*ssov1.ValidateTokenResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ValidateToken", varargs...)
+	ret0, _ := ret[0].(*ssov1.ValidateTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateToken indicates an expected call of ValidateToken.
+func (mr *MockAuthClientMockRecorder) ValidateToken(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuthClient)(nil).ValidateToken), varargs...)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuthClient) VerifyEmail(ctx context.Context, in *ssov1.VerifyEmailRequest, opts ...grpc.CallOption) (*ssov1.VerifyEmailResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyEmail", varargs...)
+	ret0, _ := ret[0].(*ssov1.VerifyEmailResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthClientMockRecorder) VerifyEmail(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthClient)(nil).VerifyEmail), varargs...)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockAuthClient) VerifyMFA(ctx context.Context, in *ssov1.VerifyMFARequest, opts ...grpc.CallOption) (*ssov1.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyMFA", varargs...)
+	ret0, _ := ret[0].(*ssov1.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthClientMockRecorder) VerifyMFA(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuthClient)(nil).VerifyMFA), varargs...)
+}
+
+// MockAuthServer is a mock of AuthServer interface.
+type MockAuthServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthServerMockRecorder
+}
+
+// MockAuthServerMockRecorder is the mock recorder for MockAuthServer.
+type MockAuthServerMockRecorder struct {
+	mock *MockAuthServer
+}
+
+// NewMockAuthServer creates a new mock instance.
+func NewMockAuthServer(ctrl *gomock.Controller) *MockAuthServer {
+	mock := &MockAuthServer{ctrl: ctrl}
+	mock.recorder = &MockAuthServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthServer) EXPECT() *MockAuthServerMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockAuthServer) AssignRole(arg0 context.Context, arg1 *ssov1.AssignRoleRequest) (*ssov1.AssignRoleResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.AssignRoleResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockAuthServerMockRecorder) AssignRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockAuthServer)(nil).AssignRole), arg0, arg1)
+}
+
+// BanUser mocks base method.
+func (m *MockAuthServer) BanUser(arg0 context.Context, arg1 *ssov1.BanUserRequest) (*ssov1.BanUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUser", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.BanUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockAuthServerMockRecorder) BanUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockAuthServer)(nil).BanUser), arg0, arg1)
+}
+
+// ChangeEmail mocks base method.
+func (m *MockAuthServer) ChangeEmail(arg0 context.Context, arg1 *ssov1.ChangeEmailRequest) (*ssov1.ChangeEmailResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeEmail", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ChangeEmailResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeEmail indicates an expected call of ChangeEmail.
+func (mr *MockAuthServerMockRecorder) ChangeEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeEmail", reflect.TypeOf((*MockAuthServer)(nil).ChangeEmail), arg0, arg1)
+}
+
+// ChangePassword mocks base method.
+func (m *MockAuthServer) ChangePassword(arg0 context.Context, arg1 *ssov1.ChangePasswordRequest) (*ssov1.ChangePasswordResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ChangePasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthServerMockRecorder) ChangePassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthServer)(nil).ChangePassword), arg0, arg1)
+}
+
+// ClearLoginLockout mocks base method.
+func (m *MockAuthServer) ClearLoginLockout(arg0 context.Context, arg1 *ssov1.ClearLoginLockoutRequest) (*ssov1.ClearLoginLockoutResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginLockout", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ClearLoginLockoutResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClearLoginLockout indicates an expected call of ClearLoginLockout.
+func (mr *MockAuthServerMockRecorder) ClearLoginLockout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginLockout", reflect.TypeOf((*MockAuthServer)(nil).ClearLoginLockout), arg0, arg1)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockAuthServer) ConfirmEmailChange(arg0 context.Context, arg1 *ssov1.ConfirmEmailChangeRequest) (*ssov1.ConfirmEmailChangeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ConfirmEmailChangeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockAuthServerMockRecorder) ConfirmEmailChange(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockAuthServer)(nil).ConfirmEmailChange), arg0, arg1)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockAuthServer) ConfirmTOTP(arg0 context.Context, arg1 *ssov1.ConfirmTOTPRequest) (*ssov1.ConfirmTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ConfirmTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockAuthServerMockRecorder) ConfirmTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockAuthServer)(nil).ConfirmTOTP), arg0, arg1)
+}
+
+// CreateApp mocks base method.
+func (m *MockAuthServer) CreateApp(arg0 context.Context, arg1 *ssov1.CreateAppRequest) (*ssov1.CreateAppResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApp", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.CreateAppResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApp indicates an expected call of CreateApp.
+func (mr *MockAuthServerMockRecorder) CreateApp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApp", reflect.TypeOf((*MockAuthServer)(nil).CreateApp), arg0, arg1)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockAuthServer) DeleteAccount(arg0 context.Context, arg1 *ssov1.DeleteAccountRequest) (*ssov1.DeleteAccountResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.DeleteAccountResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAuthServerMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAuthServer)(nil).DeleteAccount), arg0, arg1)
+}
+
+// DisableApp mocks base method.
+func (m *MockAuthServer) DisableApp(arg0 context.Context, arg1 *ssov1.DisableAppRequest) (*ssov1.DisableAppResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableApp", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.DisableAppResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableApp indicates an expected call of DisableApp.
+func (mr *MockAuthServerMockRecorder) DisableApp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableApp", reflect.TypeOf((*MockAuthServer)(nil).DisableApp), arg0, arg1)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockAuthServer) DisableTOTP(arg0 context.Context, arg1 *ssov1.DisableTOTPRequest) (*ssov1.DisableTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.DisableTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockAuthServerMockRecorder) DisableTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockAuthServer)(nil).DisableTOTP), arg0, arg1)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockAuthServer) EnrollTOTP(arg0 context.Context, arg1 *ssov1.EnrollTOTPRequest) (*ssov1.EnrollTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.EnrollTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockAuthServerMockRecorder) EnrollTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockAuthServer)(nil).EnrollTOTP), arg0, arg1)
+}
+
+// ExportUserData mocks base method.
+func (m *MockAuthServer) ExportUserData(arg0 context.Context, arg1 *ssov1.ExportUserDataRequest) (*ssov1.ExportUserDataResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ExportUserDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockAuthServerMockRecorder) ExportUserData(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockAuthServer)(nil).ExportUserData), arg0, arg1)
+}
+
+// GetJWKS mocks base method.
+func (m *MockAuthServer) GetJWKS(arg0 context.Context, arg1 *ssov1.GetJWKSRequest) (*ssov1.GetJWKSResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJWKS", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.GetJWKSResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJWKS indicates an expected call of GetJWKS.
+func (mr *MockAuthServerMockRecorder) GetJWKS(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockAuthServer)(nil).GetJWKS), arg0, arg1)
+}
+
+// GetOpenIDConfiguration mocks base method.
+func (m *MockAuthServer) GetOpenIDConfiguration(arg0 context.Context, arg1 *ssov1.GetOpenIDConfigurationRequest) (*ssov1.GetOpenIDConfigurationResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenIDConfiguration", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.GetOpenIDConfigurationResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsAdmin indicates an expected call of IsAdmin.
-func (mr *MockAuthClientMockRecorder) IsAdmin(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+// GetOpenIDConfiguration indicates an expected call of GetOpenIDConfiguration.
+func (mr *MockAuthServerMockRecorder) GetOpenIDConfiguration(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, in}, opts...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockAuthClient)(nil).IsAdmin), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenIDConfiguration", reflect.TypeOf((*MockAuthServer)(nil).GetOpenIDConfiguration), arg0, arg1)
 }
 
-// Login mocks base method.
-func (m *MockAuthClient) Login(ctx context.Context, in *ssov1.LoginRequest, opts ...grpc.CallOption) (*ssov1.LoginResponse, error) {
+// GetProfile mocks base method.
+func (m *MockAuthServer) GetProfile(arg0 context.Context, arg1 *ssov1.GetProfileRequest) (*ssov1.GetProfileResponse, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, in}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Login", varargs...)
-	ret0, _ := ret[0].(*ssov1.LoginResponse)
+	ret := m.ctrl.Call(m, "GetProfile", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.GetProfileResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Login indicates an expected call of Login.
-func (mr *MockAuthClientMockRecorder) Login(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockAuthServerMockRecorder) GetProfile(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, in}, opts...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthClient)(nil).Login), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockAuthServer)(nil).GetProfile), arg0, arg1)
 }
 
-// Logout mocks base method.
-func (m *MockAuthClient) Logout(ctx context.Context, in *ssov1.LogoutRequest, opts ...grpc.CallOption) (*ssov1.LogoutResponse, error) {
+// HasPermission mocks base method.
+func (m *MockAuthServer) HasPermission(arg0 context.Context, arg1 *ssov1.HasPermissionRequest) (*ssov1.HasPermissionResponse, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, in}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Logout", varargs...)
-	ret0, _ := ret[0].(*ssov1.LogoutResponse)
+	ret := m.ctrl.Call(m, "HasPermission", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.HasPermissionResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Logout indicates an expected call of Logout.
-func (mr *MockAuthClientMockRecorder) Logout(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+// HasPermission indicates an expected call of HasPermission.
+func (mr *MockAuthServerMockRecorder) HasPermission(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, in}, opts...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthClient)(nil).Logout), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPermission", reflect.TypeOf((*MockAuthServer)(nil).HasPermission), arg0, arg1)
 }
 
-// RefreshTokens mocks base method.
-func (m *MockAuthClient) RefreshTokens(ctx context.Context, in *ssov1.RefreshTokenRequest, opts ...grpc.CallOption) (*ssov1.RefreshTokenResponse, error) {
+// IsAdmin mocks base method.
+func (m *MockAuthServer) IsAdmin(arg0 context.Context, arg1 *ssov1.IsAdminRequest) (*ssov1.IsAdminResponse, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, in}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "RefreshTokens", varargs...)
-	ret0, _ := ret[0].(*ssov1.RefreshTokenResponse)
+	ret := m.ctrl.Call(m, "IsAdmin", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.IsAdminResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// RefreshTokens indicates an expected call of RefreshTokens.
-func (mr *MockAuthClientMockRecorder) RefreshTokens(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+// IsAdmin indicates an expected call of IsAdmin.
+func (mr *MockAuthServerMockRecorder) IsAdmin(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, in}, opts...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockAuthClient)(nil).RefreshTokens), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockAuthServer)(nil).IsAdmin), arg0, arg1)
 }
 
-// Register mocks base method.
-func (m *MockAuthClient) Register(ctx context.Context, in *ssov1.RegisterRequest, opts ...grpc.CallOption) (*ssov1.RegisterResponse, error) {
+// ListAccountErasures mocks base method.
+func (m *MockAuthServer) ListAccountErasures(arg0 context.Context, arg1 *ssov1.ListAccountErasuresRequest) (*ssov1.ListAccountErasuresResponse, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, in}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Register", varargs...)
-	ret0, _ := ret[0].(*ssov1.RegisterResponse)
+	ret := m.ctrl.Call(m, "ListAccountErasures", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ListAccountErasuresResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Register indicates an expected call of Register.
-func (mr *MockAuthClientMockRecorder) Register(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+// ListAccountErasures indicates an expected call of ListAccountErasures.
+func (mr *MockAuthServerMockRecorder) ListAccountErasures(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, in}, opts...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthClient)(nil).Register), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountErasures", reflect.TypeOf((*MockAuthServer)(nil).ListAccountErasures), arg0, arg1)
 }
 
-// ValidateToken mocks base method.
-func (m *MockAuthClient) ValidateToken(ctx context.Context, in *ssov1.ValidateTokenRequest, opts ...grpc.CallOption) (*ssov1.ValidateTokenResponse, error) {
+// ListActiveBans mocks base method.
+func (m *MockAuthServer) ListActiveBans(arg0 context.Context, arg1 *ssov1.ListActiveBansRequest) (*ssov1.ListActiveBansResponse, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, in}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "ValidateToken", varargs...)
-	ret0, _ := ret[0].(*ssov1.ValidateTokenResponse)
+	ret := m.ctrl.Call(m, "ListActiveBans", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ListActiveBansResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ValidateToken indicates an expected call of ValidateToken.
-func (mr *MockAuthClientMockRecorder) ValidateToken(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+// ListActiveBans indicates an expected call of ListActiveBans.
+func (mr *MockAuthServerMockRecorder) ListActiveBans(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, in}, opts...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuthClient)(nil).ValidateToken), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveBans", reflect.TypeOf((*MockAuthServer)(nil).ListActiveBans), arg0, arg1)
 }
 
-// MockAuthServer is a mock of AuthServer interface.
-type MockAuthServer struct {
-	ctrl     *gomock.Controller
-	recorder *MockAuthServerMockRecorder
+// ListApps mocks base method.
+func (m *MockAuthServer) ListApps(arg0 context.Context, arg1 *ssov1.ListAppsRequest) (*ssov1.ListAppsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApps", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ListAppsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// MockAuthServerMockRecorder is the mock recorder for MockAuthServer.
-type MockAuthServerMockRecorder struct {
-	mock *MockAuthServer
+// ListApps indicates an expected call of ListApps.
+func (mr *MockAuthServerMockRecorder) ListApps(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApps", reflect.TypeOf((*MockAuthServer)(nil).ListApps), arg0, arg1)
 }
 
-// NewMockAuthServer creates a new mock instance.
-func NewMockAuthServer(ctrl *gomock.Controller) *MockAuthServer {
-	mock := &MockAuthServer{ctrl: ctrl}
-	mock.recorder = &MockAuthServerMockRecorder{mock}
-	return mock
+// ListAuditEvents mocks base method.
+func (m *MockAuthServer) ListAuditEvents(arg0 context.Context, arg1 *ssov1.ListAuditEventsRequest) (*ssov1.ListAuditEventsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ListAuditEventsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockAuthServer) EXPECT() *MockAuthServerMockRecorder {
-	return m.recorder
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuthServerMockRecorder) ListAuditEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAuthServer)(nil).ListAuditEvents), arg0, arg1)
 }
 
-// IsAdmin mocks base method.
-func (m *MockAuthServer) IsAdmin(arg0 context.Context, arg1 *ssov1.IsAdminRequest) (*ssov1.IsAdminResponse, error) {
+// ListLoginLockouts mocks base method.
+func (m *MockAuthServer) ListLoginLockouts(arg0 context.Context, arg1 *ssov1.ListLoginLockoutsRequest) (*ssov1.ListLoginLockoutsResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAdmin", arg0, arg1)
-	ret0, _ := ret[0].(*ssov1.IsAdminResponse)
+	ret := m.ctrl.Call(m, "ListLoginLockouts", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ListLoginLockoutsResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsAdmin indicates an expected call of IsAdmin.
-func (mr *MockAuthServerMockRecorder) IsAdmin(arg0, arg1 interface{}) *gomock.Call {
+// ListLoginLockouts indicates an expected call of ListLoginLockouts.
+func (mr *MockAuthServerMockRecorder) ListLoginLockouts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockAuthServer)(nil).IsAdmin), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginLockouts", reflect.TypeOf((*MockAuthServer)(nil).ListLoginLockouts), arg0, arg1)
+}
+
+// ListOAuthConsents mocks base method.
+func (m *MockAuthServer) ListOAuthConsents(arg0 context.Context, arg1 *ssov1.ListOAuthConsentsRequest) (*ssov1.ListOAuthConsentsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOAuthConsents", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ListOAuthConsentsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOAuthConsents indicates an expected call of ListOAuthConsents.
+func (mr *MockAuthServerMockRecorder) ListOAuthConsents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOAuthConsents", reflect.TypeOf((*MockAuthServer)(nil).ListOAuthConsents), arg0, arg1)
+}
+
+// ListSessions mocks base method.
+func (m *MockAuthServer) ListSessions(arg0 context.Context, arg1 *ssov1.ListSessionsRequest) (*ssov1.ListSessionsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ListSessionsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockAuthServerMockRecorder) ListSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockAuthServer)(nil).ListSessions), arg0, arg1)
 }
 
 // Login mocks base method.
@@ -254,6 +1414,216 @@ func (mr *MockAuthServerMockRecorder) Register(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthServer)(nil).Register), arg0, arg1)
 }
 
+// RequestPasswordReset mocks base method.
+func (m *MockAuthServer) RequestPasswordReset(arg0 context.Context, arg1 *ssov1.RequestPasswordResetRequest) (*ssov1.RequestPasswordResetResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.RequestPasswordResetResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockAuthServerMockRecorder) RequestPasswordReset(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockAuthServer)(nil).RequestPasswordReset), arg0, arg1)
+}
+
+// ResendVerification mocks base method.
+func (m *MockAuthServer) ResendVerification(arg0 context.Context, arg1 *ssov1.ResendVerificationRequest) (*ssov1.ResendVerificationResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ResendVerificationResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockAuthServerMockRecorder) ResendVerification(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockAuthServer)(nil).ResendVerification), arg0, arg1)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAuthServer) ResetPassword(arg0 context.Context, arg1 *ssov1.ResetPasswordRequest) (*ssov1.ResetPasswordResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.ResetPasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthServerMockRecorder) ResetPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuthServer)(nil).ResetPassword), arg0, arg1)
+}
+
+// RevokeAllSessions mocks base method.
+func (m *MockAuthServer) RevokeAllSessions(arg0 context.Context, arg1 *ssov1.RevokeAllSessionsRequest) (*ssov1.RevokeAllSessionsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllSessions", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.RevokeAllSessionsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAllSessions indicates an expected call of RevokeAllSessions.
+func (mr *MockAuthServerMockRecorder) RevokeAllSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllSessions", reflect.TypeOf((*MockAuthServer)(nil).RevokeAllSessions), arg0, arg1)
+}
+
+// RevokeOAuthConsent mocks base method.
+func (m *MockAuthServer) RevokeOAuthConsent(arg0 context.Context, arg1 *ssov1.RevokeOAuthConsentRequest) (*ssov1.RevokeOAuthConsentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOAuthConsent", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.RevokeOAuthConsentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOAuthConsent indicates an expected call of RevokeOAuthConsent.
+func (mr *MockAuthServerMockRecorder) RevokeOAuthConsent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOAuthConsent", reflect.TypeOf((*MockAuthServer)(nil).RevokeOAuthConsent), arg0, arg1)
+}
+
+// RevokeRole mocks base method.
+func (m *MockAuthServer) RevokeRole(arg0 context.Context, arg1 *ssov1.RevokeRoleRequest) (*ssov1.RevokeRoleResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.RevokeRoleResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockAuthServerMockRecorder) RevokeRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockAuthServer)(nil).RevokeRole), arg0, arg1)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthServer) RevokeSession(arg0 context.Context, arg1 *ssov1.RevokeSessionRequest) (*ssov1.RevokeSessionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.RevokeSessionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthServerMockRecorder) RevokeSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthServer)(nil).RevokeSession), arg0, arg1)
+}
+
+// RotateAppSecret mocks base method.
+func (m *MockAuthServer) RotateAppSecret(arg0 context.Context, arg1 *ssov1.RotateAppSecretRequest) (*ssov1.RotateAppSecretResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateAppSecret", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.RotateAppSecretResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAppSecret indicates an expected call of RotateAppSecret.
+func (mr *MockAuthServerMockRecorder) RotateAppSecret(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAppSecret", reflect.TypeOf((*MockAuthServer)(nil).RotateAppSecret), arg0, arg1)
+}
+
+// RotateSigningKey mocks base method.
+func (m *MockAuthServer) RotateSigningKey(arg0 context.Context, arg1 *ssov1.RotateSigningKeyRequest) (*ssov1.RotateSigningKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSigningKey", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.RotateSigningKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSigningKey indicates an expected call of RotateSigningKey.
+func (mr *MockAuthServerMockRecorder) RotateSigningKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningKey", reflect.TypeOf((*MockAuthServer)(nil).RotateSigningKey), arg0, arg1)
+}
+
+// SuspendUser mocks base method.
+func (m *MockAuthServer) SuspendUser(arg0 context.Context, arg1 *ssov1.SuspendUserRequest) (*ssov1.SuspendUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.SuspendUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockAuthServerMockRecorder) SuspendUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockAuthServer)(nil).SuspendUser), arg0, arg1)
+}
+
+// UnbanUser mocks base method.
+func (m *MockAuthServer) UnbanUser(arg0 context.Context, arg1 *ssov1.UnbanUserRequest) (*ssov1.UnbanUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanUser", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.UnbanUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnbanUser indicates an expected call of UnbanUser.
+func (mr *MockAuthServerMockRecorder) UnbanUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanUser", reflect.TypeOf((*MockAuthServer)(nil).UnbanUser), arg0, arg1)
+}
+
+// UpdateApp mocks base method.
+func (m *MockAuthServer) UpdateApp(arg0 context.Context, arg1 *ssov1.UpdateAppRequest) (*ssov1.UpdateAppResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApp", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.UpdateAppResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateApp indicates an expected call of UpdateApp.
+func (mr *MockAuthServerMockRecorder) UpdateApp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApp", reflect.TypeOf((*MockAuthServer)(nil).UpdateApp), arg0, arg1)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockAuthServer) UpdateProfile(arg0 context.Context, arg1 *ssov1.UpdateProfileRequest) (*ssov1.UpdateProfileResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.UpdateProfileResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockAuthServerMockRecorder) UpdateProfile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockAuthServer)(nil).UpdateProfile), arg0, arg1)
+}
+
+// UserInfo mocks base method.
+func (m *MockAuthServer) UserInfo(arg0 context.Context, arg1 *ssov1.UserInfoRequest) (*ssov1.UserInfoResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInfo", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.UserInfoResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockAuthServerMockRecorder) UserInfo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockAuthServer)(nil).UserInfo), arg0, arg1)
+}
+
 // ValidateToken mocks base method.
 func (m *MockAuthServer) ValidateToken(arg0 context.Context, arg1 *ssov1.ValidateTokenRequest) (*ssov1.ValidateTokenResponse, error) {
 	m.ctrl.T.Helper()
@@ -269,6 +1639,36 @@ func (mr *MockAuthServerMockRecorder) ValidateToken(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuthServer)(nil).ValidateToken), arg0, arg1)
 }
 
+// VerifyEmail mocks base method.
+func (m *MockAuthServer) VerifyEmail(arg0 context.Context, arg1 *ssov1.VerifyEmailRequest) (*ssov1.VerifyEmailResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.VerifyEmailResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthServerMockRecorder) VerifyEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthServer)(nil).VerifyEmail), arg0, arg1)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockAuthServer) VerifyMFA(arg0 context.Context, arg1 *ssov1.VerifyMFARequest) (*ssov1.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", arg0, arg1)
+	ret0, _ := ret[0].(*ssov1.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthServerMockRecorder) VerifyMFA(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuthServer)(nil).VerifyMFA), arg0, arg1)
+}
+
 // mustEmbedUnimplementedAuthServer mocks base method.
 func (m *MockAuthServer) mustEmbedUnimplementedAuthServer() {
 	m.ctrl.T.Helper()
@@ -110,6 +110,21 @@ func (mr *MockTopicStorageMockRecorder) Topics(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Topics", reflect.TypeOf((*MockTopicStorage)(nil).Topics), ctx)
 }
 
+// TopicsByUserID mocks base method.
+func (m *MockTopicStorage) TopicsByUserID(ctx context.Context, userID int64) ([]models.Topic, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopicsByUserID", ctx, userID)
+	ret0, _ := ret[0].([]models.Topic)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopicsByUserID indicates an expected call of TopicsByUserID.
+func (mr *MockTopicStorageMockRecorder) TopicsByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopicsByUserID", reflect.TypeOf((*MockTopicStorage)(nil).TopicsByUserID), ctx, userID)
+}
+
 // MockCommentStorage is a mock of CommentStorage interface.
 type MockCommentStorage struct {
 	ctrl     *gomock.Controller
@@ -163,6 +178,21 @@ func (mr *MockCommentStorageMockRecorder) CommentsByTopicID(ctx, topicID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommentsByTopicID", reflect.TypeOf((*MockCommentStorage)(nil).CommentsByTopicID), ctx, topicID)
 }
 
+// CommentsByUserID mocks base method.
+func (m *MockCommentStorage) CommentsByUserID(ctx context.Context, userID int64) ([]models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommentsByUserID", ctx, userID)
+	ret0, _ := ret[0].([]models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommentsByUserID indicates an expected call of CommentsByUserID.
+func (mr *MockCommentStorageMockRecorder) CommentsByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommentsByUserID", reflect.TypeOf((*MockCommentStorage)(nil).CommentsByUserID), ctx, userID)
+}
+
 // DeleteComment mocks base method.
 func (m *MockCommentStorage) DeleteComment(ctx context.Context, id, topicID int) error {
 	m.ctrl.T.Helper()
@@ -230,19 +260,34 @@ func (m *MockChatMessageStorage) EXPECT() *MockChatMessageStorageMockRecorder {
 	return m.recorder
 }
 
+// ArchiveChatMessagesBefore mocks base method.
+func (m *MockChatMessageStorage) ArchiveChatMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveChatMessagesBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveChatMessagesBefore indicates an expected call of ArchiveChatMessagesBefore.
+func (mr *MockChatMessageStorageMockRecorder) ArchiveChatMessagesBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveChatMessagesBefore", reflect.TypeOf((*MockChatMessageStorage)(nil).ArchiveChatMessagesBefore), ctx, before)
+}
+
 // ChatMessageByID mocks base method.
-func (m *MockChatMessageStorage) ChatMessageByID(ctx context.Context, id int, userID int64) (models.ChatMessage, error) {
+func (m *MockChatMessageStorage) ChatMessageByID(ctx context.Context, id int64) (models.ChatMessage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ChatMessageByID", ctx, id, userID)
+	ret := m.ctrl.Call(m, "ChatMessageByID", ctx, id)
 	ret0, _ := ret[0].(models.ChatMessage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ChatMessageByID indicates an expected call of ChatMessageByID.
-func (mr *MockChatMessageStorageMockRecorder) ChatMessageByID(ctx, id, userID interface{}) *gomock.Call {
+func (mr *MockChatMessageStorageMockRecorder) ChatMessageByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatMessageByID", reflect.TypeOf((*MockChatMessageStorage)(nil).ChatMessageByID), ctx, id, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatMessageByID", reflect.TypeOf((*MockChatMessageStorage)(nil).ChatMessageByID), ctx, id)
 }
 
 // ChatMessages mocks base method.
@@ -260,14 +305,90 @@ func (mr *MockChatMessageStorageMockRecorder) ChatMessages(ctx interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatMessages", reflect.TypeOf((*MockChatMessageStorage)(nil).ChatMessages), ctx)
 }
 
-// DeleteChatMessagesBefore mocks base method.
-func (m *MockChatMessageStorage) DeleteChatMessagesBefore(ctx context.Context, before time.Time) error {
+// ChatMessagesAfter mocks base method.
+func (m *MockChatMessageStorage) ChatMessagesAfter(ctx context.Context, afterID int64, limit int) ([]models.ChatMessage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteChatMessagesBefore", ctx, before)
+	ret := m.ctrl.Call(m, "ChatMessagesAfter", ctx, afterID, limit)
+	ret0, _ := ret[0].([]models.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChatMessagesAfter indicates an expected call of ChatMessagesAfter.
+func (mr *MockChatMessageStorageMockRecorder) ChatMessagesAfter(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatMessagesAfter", reflect.TypeOf((*MockChatMessageStorage)(nil).ChatMessagesAfter), ctx, afterID, limit)
+}
+
+// ChatMessagesByUserID mocks base method.
+func (m *MockChatMessageStorage) ChatMessagesByUserID(ctx context.Context, userID int64) ([]models.ChatMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChatMessagesByUserID", ctx, userID)
+	ret0, _ := ret[0].([]models.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChatMessagesByUserID indicates an expected call of ChatMessagesByUserID.
+func (mr *MockChatMessageStorageMockRecorder) ChatMessagesByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatMessagesByUserID", reflect.TypeOf((*MockChatMessageStorage)(nil).ChatMessagesByUserID), ctx, userID)
+}
+
+// ChatRetentionPolicy mocks base method.
+func (m *MockChatMessageStorage) ChatRetentionPolicy(ctx context.Context) (models.ChatRetentionPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChatRetentionPolicy", ctx)
+	ret0, _ := ret[0].(models.ChatRetentionPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChatRetentionPolicy indicates an expected call of ChatRetentionPolicy.
+func (mr *MockChatMessageStorageMockRecorder) ChatRetentionPolicy(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatRetentionPolicy", reflect.TypeOf((*MockChatMessageStorage)(nil).ChatRetentionPolicy), ctx)
+}
+
+// ChatSlowMode mocks base method.
+func (m *MockChatMessageStorage) ChatSlowMode(ctx context.Context) (time.Duration, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChatSlowMode", ctx)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ChatSlowMode indicates an expected call of ChatSlowMode.
+func (mr *MockChatMessageStorageMockRecorder) ChatSlowMode(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatSlowMode", reflect.TypeOf((*MockChatMessageStorage)(nil).ChatSlowMode), ctx)
+}
+
+// DeleteChatMessage mocks base method.
+func (m *MockChatMessageStorage) DeleteChatMessage(ctx context.Context, id int64, deletedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChatMessage", ctx, id, deletedAt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// DeleteChatMessage indicates an expected call of DeleteChatMessage.
+func (mr *MockChatMessageStorageMockRecorder) DeleteChatMessage(ctx, id, deletedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChatMessage", reflect.TypeOf((*MockChatMessageStorage)(nil).DeleteChatMessage), ctx, id, deletedAt)
+}
+
+// DeleteChatMessagesBefore mocks base method.
+func (m *MockChatMessageStorage) DeleteChatMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChatMessagesBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // DeleteChatMessagesBefore indicates an expected call of DeleteChatMessagesBefore.
 func (mr *MockChatMessageStorageMockRecorder) DeleteChatMessagesBefore(ctx, before interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
@@ -288,3 +409,237 @@ func (mr *MockChatMessageStorageMockRecorder) SaveChatMessage(ctx, userID, conte
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChatMessage", reflect.TypeOf((*MockChatMessageStorage)(nil).SaveChatMessage), ctx, userID, content, email)
 }
+
+// SaveChatRetentionPolicy mocks base method.
+func (m *MockChatMessageStorage) SaveChatRetentionPolicy(ctx context.Context, policy models.ChatRetentionPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChatRetentionPolicy", ctx, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveChatRetentionPolicy indicates an expected call of SaveChatRetentionPolicy.
+func (mr *MockChatMessageStorageMockRecorder) SaveChatRetentionPolicy(ctx, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChatRetentionPolicy", reflect.TypeOf((*MockChatMessageStorage)(nil).SaveChatRetentionPolicy), ctx, policy)
+}
+
+// SaveChatSlowMode mocks base method.
+func (m *MockChatMessageStorage) SaveChatSlowMode(ctx context.Context, interval time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChatSlowMode", ctx, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveChatSlowMode indicates an expected call of SaveChatSlowMode.
+func (mr *MockChatMessageStorageMockRecorder) SaveChatSlowMode(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChatSlowMode", reflect.TypeOf((*MockChatMessageStorage)(nil).SaveChatSlowMode), ctx, interval)
+}
+
+// UpdateChatMessage mocks base method.
+func (m *MockChatMessageStorage) UpdateChatMessage(ctx context.Context, id int64, content string, editedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateChatMessage", ctx, id, content, editedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateChatMessage indicates an expected call of UpdateChatMessage.
+func (mr *MockChatMessageStorageMockRecorder) UpdateChatMessage(ctx, id, content, editedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChatMessage", reflect.TypeOf((*MockChatMessageStorage)(nil).UpdateChatMessage), ctx, id, content, editedAt)
+}
+
+// MockErasureStorage is a mock of ErasureStorage interface.
+type MockErasureStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockErasureStorageMockRecorder
+}
+
+// MockErasureStorageMockRecorder is the mock recorder for MockErasureStorage.
+type MockErasureStorageMockRecorder struct {
+	mock *MockErasureStorage
+}
+
+// NewMockErasureStorage creates a new mock instance.
+func NewMockErasureStorage(ctrl *gomock.Controller) *MockErasureStorage {
+	mock := &MockErasureStorage{ctrl: ctrl}
+	mock.recorder = &MockErasureStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockErasureStorage) EXPECT() *MockErasureStorageMockRecorder {
+	return m.recorder
+}
+
+// AccountErasureCursor mocks base method.
+func (m *MockErasureStorage) AccountErasureCursor(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountErasureCursor", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountErasureCursor indicates an expected call of AccountErasureCursor.
+func (mr *MockErasureStorageMockRecorder) AccountErasureCursor(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountErasureCursor", reflect.TypeOf((*MockErasureStorage)(nil).AccountErasureCursor), ctx)
+}
+
+// EraseUserContent mocks base method.
+func (m *MockErasureStorage) EraseUserContent(ctx context.Context, eventID, userID int64, mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUserContent", ctx, eventID, userID, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EraseUserContent indicates an expected call of EraseUserContent.
+func (mr *MockErasureStorageMockRecorder) EraseUserContent(ctx, eventID, userID, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUserContent", reflect.TypeOf((*MockErasureStorage)(nil).EraseUserContent), ctx, eventID, userID, mode)
+}
+
+// MockDataExportStorage is a mock of DataExportStorage interface.
+type MockDataExportStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockDataExportStorageMockRecorder
+}
+
+// MockDataExportStorageMockRecorder is the mock recorder for MockDataExportStorage.
+type MockDataExportStorageMockRecorder struct {
+	mock *MockDataExportStorage
+}
+
+// NewMockDataExportStorage creates a new mock instance.
+func NewMockDataExportStorage(ctrl *gomock.Controller) *MockDataExportStorage {
+	mock := &MockDataExportStorage{ctrl: ctrl}
+	mock.recorder = &MockDataExportStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDataExportStorage) EXPECT() *MockDataExportStorageMockRecorder {
+	return m.recorder
+}
+
+// ClaimDataExport mocks base method.
+func (m *MockDataExportStorage) ClaimDataExport(ctx context.Context, staleBefore time.Time) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDataExport", ctx, staleBefore)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDataExport indicates an expected call of ClaimDataExport.
+func (mr *MockDataExportStorageMockRecorder) ClaimDataExport(ctx, staleBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDataExport", reflect.TypeOf((*MockDataExportStorage)(nil).ClaimDataExport), ctx, staleBefore)
+}
+
+// CompleteDataExport mocks base method.
+func (m *MockDataExportStorage) CompleteDataExport(ctx context.Context, id int64, archive []byte, completedAt, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteDataExport", ctx, id, archive, completedAt, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteDataExport indicates an expected call of CompleteDataExport.
+func (mr *MockDataExportStorageMockRecorder) CompleteDataExport(ctx, id, archive, completedAt, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteDataExport", reflect.TypeOf((*MockDataExportStorage)(nil).CompleteDataExport), ctx, id, archive, completedAt, expiresAt)
+}
+
+// CreateDataExport mocks base method.
+func (m *MockDataExportStorage) CreateDataExport(ctx context.Context, userID int64) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDataExport", ctx, userID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDataExport indicates an expected call of CreateDataExport.
+func (mr *MockDataExportStorageMockRecorder) CreateDataExport(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDataExport", reflect.TypeOf((*MockDataExportStorage)(nil).CreateDataExport), ctx, userID)
+}
+
+// DataExportArchive mocks base method.
+func (m *MockDataExportStorage) DataExportArchive(ctx context.Context, id int64) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DataExportArchive", ctx, id)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DataExportArchive indicates an expected call of DataExportArchive.
+func (mr *MockDataExportStorageMockRecorder) DataExportArchive(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DataExportArchive", reflect.TypeOf((*MockDataExportStorage)(nil).DataExportArchive), ctx, id)
+}
+
+// DataExportByID mocks base method.
+func (m *MockDataExportStorage) DataExportByID(ctx context.Context, id int64) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DataExportByID", ctx, id)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DataExportByID indicates an expected call of DataExportByID.
+func (mr *MockDataExportStorageMockRecorder) DataExportByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DataExportByID", reflect.TypeOf((*MockDataExportStorage)(nil).DataExportByID), ctx, id)
+}
+
+// DeleteExpiredDataExports mocks base method.
+func (m *MockDataExportStorage) DeleteExpiredDataExports(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredDataExports", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredDataExports indicates an expected call of DeleteExpiredDataExports.
+func (mr *MockDataExportStorageMockRecorder) DeleteExpiredDataExports(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDataExports", reflect.TypeOf((*MockDataExportStorage)(nil).DeleteExpiredDataExports), ctx, now)
+}
+
+// FailDataExport mocks base method.
+func (m *MockDataExportStorage) FailDataExport(ctx context.Context, id int64, reason string, completedAt, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailDataExport", ctx, id, reason, completedAt, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailDataExport indicates an expected call of FailDataExport.
+func (mr *MockDataExportStorageMockRecorder) FailDataExport(ctx, id, reason, completedAt, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailDataExport", reflect.TypeOf((*MockDataExportStorage)(nil).FailDataExport), ctx, id, reason, completedAt, expiresAt)
+}
+
+// UpdateDataExportProgress mocks base method.
+func (m *MockDataExportStorage) UpdateDataExportProgress(ctx context.Context, id int64, progress int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDataExportProgress", ctx, id, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDataExportProgress indicates an expected call of UpdateDataExportProgress.
+func (mr *MockDataExportStorageMockRecorder) UpdateDataExportProgress(ctx, id, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDataExportProgress", reflect.TypeOf((*MockDataExportStorage)(nil).UpdateDataExportProgress), ctx, id, progress)
+}
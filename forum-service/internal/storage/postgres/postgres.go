@@ -251,7 +251,7 @@ func (s *Storage) ChatMessages(ctx context.Context) ([]models.ChatMessage, error
 	const op = "storage.postgres.ChatMessages"
 
 	rows, err := s.db.QueryContext(ctx, `
-        SELECT id, user_id, content, created_at, author_email
+        SELECT id, user_id, content, created_at, author_email, edited_at, deleted_at
         FROM chat_messages
         ORDER BY created_at DESC
     `)
@@ -263,7 +263,7 @@ func (s *Storage) ChatMessages(ctx context.Context) ([]models.ChatMessage, error
 	var messages []models.ChatMessage
 	for rows.Next() {
 		var msg models.ChatMessage
-		if err := rows.Scan(&msg.ID, &msg.UserID, &msg.Content, &msg.CreatedAt, &msg.UserEmail); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.UserID, &msg.Content, &msg.CreatedAt, &msg.UserEmail, &msg.EditedAt, &msg.DeletedAt); err != nil {
 			return nil, fmt.Errorf("%s: scan: %w", op, err)
 		}
 		messages = append(messages, msg)
@@ -276,27 +276,214 @@ func (s *Storage) ChatMessages(ctx context.Context) ([]models.ChatMessage, error
 	return messages, nil
 }
 
-func (s *Storage) DeleteChatMessagesBefore(ctx context.Context, before time.Time) error {
+func (s *Storage) ChatMessagesAfter(ctx context.Context, afterID int64, limit int) ([]models.ChatMessage, error) {
+	const op = "storage.postgres.ChatMessagesAfter"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, user_id, content, created_at, author_email, edited_at, deleted_at
+        FROM chat_messages
+        WHERE id > $1
+        ORDER BY id ASC
+        LIMIT $2
+    `, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var msg models.ChatMessage
+		if err := rows.Scan(&msg.ID, &msg.UserID, &msg.Content, &msg.CreatedAt, &msg.UserEmail, &msg.EditedAt, &msg.DeletedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return messages, nil
+}
+
+func (s *Storage) ChatMessageByID(ctx context.Context, id int64) (models.ChatMessage, error) {
+	const op = "storage.postgres.ChatMessageByID"
+
+	stmt, err := s.db.Prepare("SELECT id, user_id, content, created_at, author_email, edited_at, deleted_at FROM chat_messages WHERE id = $1")
+	if err != nil {
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var msg models.ChatMessage
+	err = stmt.QueryRowContext(ctx, id).Scan(&msg.ID, &msg.UserID, &msg.Content, &msg.CreatedAt, &msg.UserEmail, &msg.EditedAt, &msg.DeletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ChatMessage{}, fmt.Errorf("%s: %w", op, storage.ErrChatMessageNotFound)
+		}
+		return models.ChatMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return msg, nil
+}
+
+func (s *Storage) UpdateChatMessage(ctx context.Context, id int64, content string, editedAt time.Time) error {
+	const op = "storage.postgres.UpdateChatMessage"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE chat_messages SET content = $1, edited_at = $2 WHERE id = $3 AND deleted_at IS NULL", content, editedAt, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrChatMessageNotFound)
+	}
+
+	return nil
+}
+
+// DeleteChatMessage превращает сообщение в tombstone: текст стирается, строка остаётся с deleted_at
+func (s *Storage) DeleteChatMessage(ctx context.Context, id int64, deletedAt time.Time) error {
+	const op = "storage.postgres.DeleteChatMessage"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE chat_messages SET content = '', deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", deletedAt, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrChatMessageNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteChatMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
 	const op = "storage.postgres.DeleteChatMessagesBefore"
 
 	stmt, err := s.db.Prepare("DELETE FROM chat_messages WHERE created_at < $1")
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
 	res, err := stmt.ExecContext(ctx, before)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// ArchiveChatMessagesBefore одним запросом переносит сообщения, созданные раньше before, в chat_messages_archive
+func (s *Storage) ArchiveChatMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.postgres.ArchiveChatMessagesBefore"
+
+	// id сообщения может уже быть в архиве (например, после восстановления из резервной копии):
+	// такая строка перезаписывается, чтобы удалённое из чата сообщение не пропало совсем.
+	// Возвращается число удалённых из чата сообщений, а не вставленных в архив строк.
+	stmt, err := s.db.Prepare(`
+		WITH moved AS (
+			DELETE FROM chat_messages WHERE created_at < $1
+			RETURNING id, user_id, content, author_email, created_at, edited_at, deleted_at
+		), archived AS (
+			INSERT INTO chat_messages_archive (id, user_id, content, author_email, created_at, edited_at, deleted_at)
+			SELECT id, user_id, content, author_email, created_at, edited_at, deleted_at FROM moved
+			ON CONFLICT (id) DO UPDATE SET
+				user_id = EXCLUDED.user_id,
+				content = EXCLUDED.content,
+				author_email = EXCLUDED.author_email,
+				created_at = EXCLUDED.created_at,
+				edited_at = EXCLUDED.edited_at,
+				deleted_at = EXCLUDED.deleted_at,
+				archived_at = now()
+		)
+		SELECT count(*) FROM moved`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var removed int64
+	if err := stmt.QueryRowContext(ctx, before).Scan(&removed); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return removed, nil
+}
+
+func (s *Storage) ChatRetentionPolicy(ctx context.Context) (models.ChatRetentionPolicy, error) {
+	const op = "storage.postgres.ChatRetentionPolicy"
+
+	var (
+		policy        models.ChatRetentionPolicy
+		maxAgeSeconds int64
+	)
+	err := s.db.QueryRowContext(ctx, "SELECT mode, max_age_seconds, updated_by, updated_at FROM chat_retention_policy").
+		Scan(&policy.Mode, &maxAgeSeconds, &policy.UpdatedBy, &policy.UpdatedAt)
+	if err != nil {
+		return models.ChatRetentionPolicy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	policy.MaxAge = time.Duration(maxAgeSeconds) * time.Second
+
+	return policy, nil
+}
+
+func (s *Storage) SaveChatRetentionPolicy(ctx context.Context, policy models.ChatRetentionPolicy) error {
+	const op = "storage.postgres.SaveChatRetentionPolicy"
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO chat_retention_policy (id, mode, max_age_seconds, updated_by, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET mode = $1, max_age_seconds = $2, updated_by = $3, updated_at = $4`,
+		policy.Mode, int64(policy.MaxAge/time.Second), policy.UpdatedBy, policy.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return nil
+	return nil
+}
+
+// ChatSlowMode возвращает сохранённый интервал slow mode. ok == false, если интервал не задавался.
+func (s *Storage) ChatSlowMode(ctx context.Context) (time.Duration, bool, error) {
+	const op = "storage.postgres.ChatSlowMode"
+
+	var seconds sql.NullInt64
+	err := s.db.QueryRowContext(ctx, "SELECT slow_mode_seconds FROM chat_retention_policy").Scan(&seconds)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if !seconds.Valid {
+		return 0, false, nil
+	}
+
+	return time.Duration(seconds.Int64) * time.Second, true, nil
+}
+
+func (s *Storage) SaveChatSlowMode(ctx context.Context, interval time.Duration) error {
+	const op = "storage.postgres.SaveChatSlowMode"
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO chat_retention_policy (id, mode, max_age_seconds, slow_mode_seconds)
+		VALUES (TRUE, 'delete', 86400, $1)
+		ON CONFLICT (id) DO UPDATE SET slow_mode_seconds = $1`,
+		int64(interval/time.Second))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
@@ -325,3 +512,315 @@ func (s *Storage) GetCommentAuthorID(ctx context.Context, id int) (int64, error)
 
 	return authorID, nil
 }
+
+func (s *Storage) AccountErasureCursor(ctx context.Context) (int64, error) {
+	const op = "storage.postgres.AccountErasureCursor"
+
+	var lastEventID int64
+	err := s.db.QueryRowContext(ctx, "SELECT last_event_id FROM account_erasure_cursor").Scan(&lastEventID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lastEventID, nil
+}
+
+// EraseUserContent обезличивает или удаляет (mode) темы, комментарии и сообщения чата пользователя
+// и в той же транзакции сдвигает курсор событий удаления аккаунтов на eventID
+func (s *Storage) EraseUserContent(ctx context.Context, eventID, userID int64, mode string) error {
+	const op = "storage.postgres.EraseUserContent"
+
+	var (
+		queries []string
+		args    = []any{userID}
+	)
+	switch mode {
+	case models.ErasureAnonymize:
+		queries = []string{
+			"UPDATE topics SET user_id = 0, author_email = $2 WHERE user_id = $1",
+			"UPDATE comments SET user_id = 0, author_email = $2 WHERE user_id = $1",
+			"UPDATE chat_messages SET user_id = 0, author_email = $2 WHERE user_id = $1",
+			"UPDATE chat_messages_archive SET user_id = 0, author_email = $2 WHERE user_id = $1",
+		}
+		args = append(args, models.ErasedAuthorEmail)
+	case models.ErasureDelete:
+		// комментарии других пользователей к удаляемым темам удаляются каскадно
+		queries = []string{
+			"DELETE FROM comments WHERE user_id = $1",
+			"DELETE FROM topics WHERE user_id = $1",
+			"DELETE FROM chat_messages WHERE user_id = $1",
+			"DELETE FROM chat_messages_archive WHERE user_id = $1",
+		}
+	default:
+		return fmt.Errorf("%s: unknown erasure mode %q", op, mode)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// готовые выгрузки персональных данных удаляются при любом режиме
+	if _, err := tx.ExecContext(ctx, "DELETE FROM data_exports WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE account_erasure_cursor SET last_event_id = $1, updated_at = now()", eventID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) TopicsByUserID(ctx context.Context, userID int64) ([]models.Topic, error) {
+	const op = "storage.postgres.TopicsByUserID"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, title, content, user_id, created_at, COALESCE(author_email, '')
+        FROM topics
+        WHERE user_id = $1
+        ORDER BY id
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var topics []models.Topic
+	for rows.Next() {
+		var topic models.Topic
+		if err := rows.Scan(&topic.ID, &topic.Title, &topic.Content, &topic.UserID, &topic.CreatedAt, &topic.UserEmail); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		topics = append(topics, topic)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return topics, nil
+}
+
+func (s *Storage) CommentsByUserID(ctx context.Context, userID int64) ([]models.Comment, error) {
+	const op = "storage.postgres.CommentsByUserID"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, topic_id, user_id, content, created_at, COALESCE(author_email, '')
+        FROM comments
+        WHERE user_id = $1
+        ORDER BY id
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.TopicID, &comment.UserID, &comment.Content, &comment.CreatedAt, &comment.UserEmail); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return comments, nil
+}
+
+// ChatMessagesByUserID возвращает сообщения пользователя из чата и из архива
+func (s *Storage) ChatMessagesByUserID(ctx context.Context, userID int64) ([]models.ChatMessage, error) {
+	const op = "storage.postgres.ChatMessagesByUserID"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, user_id, content, created_at, COALESCE(author_email, ''), edited_at, deleted_at
+        FROM chat_messages
+        WHERE user_id = $1
+        UNION ALL
+        SELECT id, user_id, content, created_at, COALESCE(author_email, ''), edited_at, deleted_at
+        FROM chat_messages_archive
+        WHERE user_id = $1
+        ORDER BY id
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var msg models.ChatMessage
+		if err := rows.Scan(&msg.ID, &msg.UserID, &msg.Content, &msg.CreatedAt, &msg.UserEmail, &msg.EditedAt, &msg.DeletedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return messages, nil
+}
+
+const dataExportColumns = "id, user_id, status, progress, error, created_at, completed_at, expires_at"
+
+func scanDataExport(row interface{ Scan(dest ...any) error }) (models.DataExport, error) {
+	var export models.DataExport
+	err := row.Scan(&export.ID, &export.UserID, &export.Status, &export.Progress, &export.Error,
+		&export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	return export, err
+}
+
+// CreateDataExport создаёт выгрузку данных пользователя. Если у пользователя уже есть
+// незавершённая выгрузка, новая не создаётся и возвращается она.
+func (s *Storage) CreateDataExport(ctx context.Context, userID int64) (models.DataExport, error) {
+	const op = "storage.postgres.CreateDataExport"
+
+	export, err := scanDataExport(s.db.QueryRowContext(ctx, `
+		INSERT INTO data_exports (user_id) VALUES ($1)
+		ON CONFLICT (user_id) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING `+dataExportColumns, userID))
+	if err == nil {
+		return export, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	export, err = scanDataExport(s.db.QueryRowContext(ctx, `
+		SELECT `+dataExportColumns+` FROM data_exports
+		WHERE user_id = $1 AND status IN ('pending', 'running')`, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DataExport{}, fmt.Errorf("%s: %w", op, storage.ErrDataExportNotFound)
+		}
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+func (s *Storage) DataExportByID(ctx context.Context, id int64) (models.DataExport, error) {
+	const op = "storage.postgres.DataExportByID"
+
+	export, err := scanDataExport(s.db.QueryRowContext(ctx, "SELECT "+dataExportColumns+" FROM data_exports WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DataExport{}, fmt.Errorf("%s: %w", op, storage.ErrDataExportNotFound)
+		}
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+func (s *Storage) DataExportArchive(ctx context.Context, id int64) ([]byte, error) {
+	const op = "storage.postgres.DataExportArchive"
+
+	var archive []byte
+	err := s.db.QueryRowContext(ctx, "SELECT archive FROM data_exports WHERE id = $1 AND archive IS NOT NULL", id).Scan(&archive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrDataExportNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return archive, nil
+}
+
+// ClaimDataExport переводит в running самую старую ожидающую выгрузку и возвращает её.
+// Выгрузки, зависшие в running с момента до staleBefore (например, после перезапуска), берутся заново.
+func (s *Storage) ClaimDataExport(ctx context.Context, staleBefore time.Time) (models.DataExport, error) {
+	const op = "storage.postgres.ClaimDataExport"
+
+	export, err := scanDataExport(s.db.QueryRowContext(ctx, `
+		UPDATE data_exports SET status = 'running', progress = 0, started_at = now()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR (status = 'running' AND started_at < $1)
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+dataExportColumns, staleBefore))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DataExport{}, fmt.Errorf("%s: %w", op, storage.ErrDataExportNotFound)
+		}
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+func (s *Storage) UpdateDataExportProgress(ctx context.Context, id int64, progress int) error {
+	const op = "storage.postgres.UpdateDataExportProgress"
+
+	_, err := s.db.ExecContext(ctx, "UPDATE data_exports SET progress = $2 WHERE id = $1 AND status = 'running'", id, progress)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) CompleteDataExport(ctx context.Context, id int64, archive []byte, completedAt, expiresAt time.Time) error {
+	const op = "storage.postgres.CompleteDataExport"
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'ready', progress = 100, archive = $2, completed_at = $3, expires_at = $4
+		WHERE id = $1`, id, archive, completedAt, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) FailDataExport(ctx context.Context, id int64, reason string, completedAt, expiresAt time.Time) error {
+	const op = "storage.postgres.FailDataExport"
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'failed', error = $2, completed_at = $3, expires_at = $4
+		WHERE id = $1`, id, reason, completedAt, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredDataExports удаляет завершённые выгрузки вместе с архивами, срок хранения которых истёк
+func (s *Storage) DeleteExpiredDataExports(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteExpiredDataExports"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM data_exports WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rowsAffected, nil
+}
@@ -6,4 +6,5 @@ var (
 	ErrTopicNotFound       = errors.New("topic not found")
 	ErrCommentNotFound     = errors.New("comment not found")
 	ErrChatMessageNotFound = errors.New("chat message not found")
+	ErrDataExportNotFound  = errors.New("data export not found")
 )
@@ -0,0 +1,2 @@
+ALTER TABLE chat_messages DROP COLUMN edited_at;
+ALTER TABLE chat_messages DROP COLUMN deleted_at;
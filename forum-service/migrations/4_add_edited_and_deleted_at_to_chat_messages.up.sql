@@ -0,0 +1,2 @@
+ALTER TABLE chat_messages ADD COLUMN edited_at TIMESTAMPTZ;
+ALTER TABLE chat_messages ADD COLUMN deleted_at TIMESTAMPTZ;
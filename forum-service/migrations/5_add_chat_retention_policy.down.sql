@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS chat_messages_archive;
+DROP TABLE IF EXISTS chat_retention_policy;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS chat_retention_policy (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    mode TEXT NOT NULL CHECK (mode IN ('delete', 'archive', 'keep')),
+    max_age_seconds BIGINT NOT NULL DEFAULT 0,
+    updated_by BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- прежнее поведение: удалять сообщения старше суток
+INSERT INTO chat_retention_policy (mode, max_age_seconds) VALUES ('delete', 86400) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS chat_messages_archive (
+    id INT PRIMARY KEY,
+    user_id INT NOT NULL,
+    content TEXT NOT NULL,
+    author_email TEXT,
+    created_at TIMESTAMPTZ,
+    edited_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_archive_created_at ON chat_messages_archive(created_at);
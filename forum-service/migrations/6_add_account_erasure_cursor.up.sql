@@ -0,0 +1,8 @@
+-- id последнего обработанного события удаления аккаунта из auth-service
+CREATE TABLE IF NOT EXISTS account_erasure_cursor (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_event_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO account_erasure_cursor (last_event_id) VALUES (0) ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+-- выгрузки персональных данных пользователей; archive хранится до expires_at
+CREATE TABLE IF NOT EXISTS data_exports (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'ready', 'failed')),
+    progress INT NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    archive BYTEA,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+-- у пользователя не больше одной незавершённой выгрузки
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_active_user_id ON data_exports(user_id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports(status);
+CREATE INDEX IF NOT EXISTS idx_data_exports_expires_at ON data_exports(expires_at);
@@ -0,0 +1 @@
+ALTER TABLE chat_retention_policy DROP COLUMN IF EXISTS slow_mode_seconds;
@@ -0,0 +1,2 @@
+-- интервал slow mode, заданный администратором; NULL — используется значение из конфига
+ALTER TABLE chat_retention_policy ADD COLUMN IF NOT EXISTS slow_mode_seconds BIGINT;
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	authTestsuite "github.com/14kear/forum-project/auth-service/pkg/testsuite"
+	"github.com/14kear/forum-project/forum-service/internal/config"
+	"github.com/14kear/forum-project/forum-service/tests/suite"
+	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDisabledApp_TokensRejected(t *testing.T) {
+	var adminToken string
+
+	ctx, st := suite.NewWithConfig(t, func(authSuite *authTestsuite.Suite, cfg *config.Config) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		email, password := gofakeit.Email(), "someStrongPassword123!"
+		registered, err := authSuite.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+		require.NoError(t, err)
+
+		setUserRoles(t, authSuite.Cfg.StoragePath, registered.GetUserId(), "user", "admin")
+
+		login, err := authSuite.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: 1})
+		require.NoError(t, err)
+		adminToken = login.GetAccessToken()
+
+		// форум работает от имени отдельного приложения, чтобы его отключение не задело другие тесты
+		created, err := authSuite.AuthClient.CreateApp(ctx, &ssov1.CreateAppRequest{
+			AccessToken: adminToken,
+			AppId:       1,
+			Settings:    &ssov1.AppSettings{Name: "forum " + gofakeit.Email()},
+		})
+		require.NoError(t, err)
+
+		cfg.AppID = int(created.GetApp().GetId())
+		cfg.JWKS.CacheTTL = 0
+		cfg.JWKS.MinRefreshInterval = 0
+	})
+
+	email, password := gofakeit.Email(), "someStrongPassword123!"
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	login, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: int32(st.Cfg.AppID)})
+	require.NoError(t, err)
+
+	createTopic := func() int {
+		body, err := json.Marshal(map[string]string{"title": "Тема", "content": "Контент"})
+		require.NoError(t, err)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, st.BaseURL+"/api/forum/topics", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+login.GetAccessToken())
+		resp, err := st.HTTPClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusCreated, createTopic())
+
+	_, err = st.AuthClient.DisableApp(ctx, &ssov1.DisableAppRequest{AccessToken: adminToken, AppId: 1, TargetAppId: int32(st.Cfg.AppID)})
+	require.NoError(t, err)
+
+	// ключи отключённого приложения пропадают из JWKS, и форум перестаёт принимать его токены
+	assert.Equal(t, http.StatusUnauthorized, createTopic())
+}
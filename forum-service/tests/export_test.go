@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/14kear/forum-project/forum-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dataExport struct {
+	ID          int64  `json:"id"`
+	Status      string `json:"status"`
+	Progress    int    `json:"progress"`
+	DownloadURL string `json:"downloadUrl"`
+}
+
+func TestDataExport_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	title := fmt.Sprintf("Тема для выгрузки %d", time.Now().UnixNano())
+	body, err := json.Marshal(map[string]string{"title": title, "content": "содержимое"})
+	require.NoError(t, err)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, st.BaseURL+"/api/forum/topics", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, st.BaseURL+"/api/forum/export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var started dataExport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+	assert.Equal(t, "pending", started.Status)
+
+	// пока архив не собран, скачать его нельзя
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/forum/export/%d/download", st.BaseURL, started.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	_, err = st.ForumService.ProcessDataExports(ctx, time.Hour, time.Minute)
+	require.NoError(t, err)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/forum/export/%d", st.BaseURL, started.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var ready dataExport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ready))
+	require.Equal(t, "ready", ready.Status)
+	assert.Equal(t, 100, ready.Progress)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, st.BaseURL+ready.DownloadURL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+
+	archive, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[file.Name] = string(data)
+	}
+	assert.Contains(t, files["topics.json"], title)
+	assert.Contains(t, files, "account.json")
+	assert.Contains(t, files, "refresh_tokens.json")
+	assert.False(t, strings.Contains(files["refresh_tokens.json"], "token\":"), "значения токенов не выгружаются")
+}
+
+func TestDataExport_OtherUser_NotFound(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	owner, _ := getTestUserToken(t, st, ctx)
+	other, _ := getTestUserToken(t, st, ctx)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, st.BaseURL+"/api/forum/export", nil)
+	req.Header.Set("Authorization", "Bearer "+owner)
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var started dataExport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+
+	for _, path := range []string{"", "/download"} {
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/forum/export/%d%s", st.BaseURL, started.ID, path), nil)
+		req.Header.Set("Authorization", "Bearer "+other)
+		resp, err := st.HTTPClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
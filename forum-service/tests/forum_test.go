@@ -3,17 +3,20 @@ package tests
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"github.com/14kear/forum-project/forum-service/tests/suite"
 	ssov1 "github.com/14kear/forum-project/protos/gen/go/auth"
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/gorilla/websocket"
+	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 func getTestUserToken(t *testing.T, st *suite.Suite, ctx context.Context) (string, string) {
@@ -36,6 +39,24 @@ func getTestUserToken(t *testing.T, st *suite.Suite, ctx context.Context) (strin
 	return respLogin.GetAccessToken(), respLogin.GetRefreshToken()
 }
 
+// setUserRoles заменяет роли пользователя в базе auth-service на roles
+func setUserRoles(t *testing.T, authStoragePath string, userID int64, roles ...string) {
+	t.Helper()
+
+	db, err := sql.Open("postgres", authStoragePath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`DELETE FROM user_roles WHERE user_id = $1`, userID)
+	require.NoError(t, err)
+	for _, role := range roles {
+		_, err = db.Exec(`
+			INSERT INTO user_roles(user_id, role_id)
+			SELECT $1, id FROM roles WHERE name = $2`, userID, role)
+		require.NoError(t, err)
+	}
+}
+
 func TestCreateTopic_Success(t *testing.T) {
 	ctx, st := suite.New(t)
 
@@ -76,6 +97,28 @@ func TestCreateTopic_Success(t *testing.T) {
 	assert.True(t, got.TopicID > 0)
 }
 
+func TestCreateTopic_ReadOnly_Forbidden(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := gofakeit.Email(), "someStrongPassword123!"
+	registered, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	setUserRoles(t, st.AuthSuite.Cfg.StoragePath, registered.GetUserId(), "read_only")
+
+	login, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: email, Password: password, AppId: 1})
+	require.NoError(t, err)
+
+	body, err := json.Marshal(map[string]string{"title": "Title", "content": "Content"})
+	require.NoError(t, err)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, st.BaseURL+"/api/forum/topics", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+login.GetAccessToken())
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
 func TestCreateTopic_EmptyValues(t *testing.T) {
 	ctx, st := suite.New(t)
 
@@ -1409,6 +1452,303 @@ func TestWebSocketChat_BadJSON_ShouldClose(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// клиент переподключается с since_id и сначала получает пропущенные сообщения по порядку, затем живые.
+func TestWebSocketChat_ResumeSinceID(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	wsURL := fmt.Sprintf("ws%s/api/forum/ws/chat?accessToken=%s", strings.TrimPrefix(st.BaseURL, "http"), token)
+
+	type chatMessage struct {
+		ID      int64  `json:"id"`
+		Content string `json:"content"`
+		UserID  int64  `json:"userID"`
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	require.NoError(t, err)
+
+	// таблица сообщений общая для параллельных тестов, поэтому догрузка может содержать
+	// и чужие сообщения; свои отличаем по автору и уникальному содержимому
+	prefix := fmt.Sprintf("resume-%d-", time.Now().UnixNano())
+
+	var sent []chatMessage
+	for _, content := range []string{"first", "second", "third"} {
+		require.NoError(t, conn.WriteJSON(map[string]string{"content": prefix + content}))
+
+		var msg chatMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.Equal(t, prefix+content, msg.Content)
+		sent = append(sent, msg)
+	}
+	require.NoError(t, conn.Close())
+
+	// переподключаемся, как будто получили только первое сообщение
+	resumed, _, err := websocket.DefaultDialer.DialContext(ctx, fmt.Sprintf("%s&since_id=%d", wsURL, sent[0].ID), nil)
+	require.NoError(t, err)
+	defer resumed.Close()
+
+	// после догрузки соединение работает в обычном режиме
+	require.NoError(t, resumed.WriteJSON(map[string]string{"content": prefix + "live"}))
+	require.NoError(t, resumed.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	var own []chatMessage
+	for len(own) == 0 || own[len(own)-1].Content != prefix+"live" {
+		var got chatMessage
+		require.NoError(t, resumed.ReadJSON(&got))
+		if got.UserID == sent[0].UserID && strings.HasPrefix(got.Content, prefix) {
+			own = append(own, got)
+		}
+	}
+
+	// пропущенные сообщения приходят по порядку до новых
+	require.Len(t, own, 3)
+	assert.Equal(t, sent[1:], own[:2])
+	assert.Greater(t, own[2].ID, sent[2].ID)
+}
+
+// правка и удаление сообщения доходят до всех подключённых клиентов
+func TestWebSocketChat_EditAndDeletePropagate(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	authorToken, _ := getTestUserToken(t, st, ctx)
+	readerToken, _ := getTestUserToken(t, st, ctx)
+
+	baseURL := fmt.Sprintf("ws%s/api/forum/ws/chat?accessToken=", strings.TrimPrefix(st.BaseURL, "http"))
+
+	author, _, err := websocket.DefaultDialer.DialContext(ctx, baseURL+authorToken, nil)
+	require.NoError(t, err)
+	defer author.Close()
+
+	reader, _, err := websocket.DefaultDialer.DialContext(ctx, baseURL+readerToken, nil)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	type chatMessage struct {
+		ID       int64   `json:"id"`
+		Content  string  `json:"content"`
+		EditedAt *string `json:"editedAt"`
+		Deleted  bool    `json:"deleted"`
+		Error    string  `json:"error"`
+	}
+
+	require.NoError(t, author.WriteJSON(map[string]string{"content": "original"}))
+
+	var created chatMessage
+	require.NoError(t, author.ReadJSON(&created))
+	require.NoError(t, reader.ReadJSON(&created))
+
+	// чужое сообщение редактировать нельзя
+	require.NoError(t, reader.WriteJSON(map[string]any{"action": "edit", "id": created.ID, "content": "hacked"}))
+	var denied chatMessage
+	require.NoError(t, reader.ReadJSON(&denied))
+	assert.Equal(t, "forbidden", denied.Error)
+
+	require.NoError(t, author.WriteJSON(map[string]any{"action": "edit", "id": created.ID, "content": "edited"}))
+	for _, conn := range []*websocket.Conn{author, reader} {
+		var edited chatMessage
+		require.NoError(t, conn.ReadJSON(&edited))
+		assert.Equal(t, created.ID, edited.ID)
+		assert.Equal(t, "edited", edited.Content)
+		assert.NotNil(t, edited.EditedAt)
+	}
+
+	require.NoError(t, author.WriteJSON(map[string]any{"action": "delete", "id": created.ID}))
+	for _, conn := range []*websocket.Conn{author, reader} {
+		var tombstone chatMessage
+		require.NoError(t, conn.ReadJSON(&tombstone))
+		assert.Equal(t, created.ID, tombstone.ID)
+		assert.True(t, tombstone.Deleted)
+		assert.Empty(t, tombstone.Content)
+	}
+}
+
+func TestDeleteChatMessage_NotAuthor_Forbidden(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	authorToken, _ := getTestUserToken(t, st, ctx)
+	otherToken, _ := getTestUserToken(t, st, ctx)
+
+	wsURL := fmt.Sprintf("ws%s/api/forum/ws/chat?accessToken=%s", strings.TrimPrefix(st.BaseURL, "http"), authorToken)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(map[string]string{"content": "mine"}))
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	require.NoError(t, conn.ReadJSON(&created))
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/api/forum/ws/chat/messages/%d", st.BaseURL, created.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+otherToken)
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestWebSocketChat_Burst_RateLimited(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	wsURL := fmt.Sprintf("ws%s/api/forum/ws/chat?accessToken=%s", strings.TrimPrefix(st.BaseURL, "http"), token)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// шлём больше сообщений, чем позволяет burst, не дожидаясь ответов
+	const burst = 30
+	for i := 0; i < burst; i++ {
+		require.NoError(t, conn.WriteJSON(map[string]string{"content": fmt.Sprintf("spam %d", i)}))
+	}
+
+	var frame struct {
+		ID           int64  `json:"id"`
+		Error        string `json:"error"`
+		Code         string `json:"code"`
+		RetryAfterMs int64  `json:"retryAfterMs"`
+	}
+	limited := false
+	for i := 0; i < burst && !limited; i++ {
+		frame.ID, frame.Error, frame.Code, frame.RetryAfterMs = 0, "", "", 0
+		require.NoError(t, conn.ReadJSON(&frame))
+		limited = frame.Code != ""
+	}
+
+	require.True(t, limited, "expected a rate limit error frame")
+	assert.Contains(t, []string{"rate_limited", "muted"}, frame.Code)
+	assert.NotEmpty(t, frame.Error)
+	assert.Positive(t, frame.RetryAfterMs)
+}
+
+func TestSetSlowMode_NotAdmin_Forbidden(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, st.BaseURL+"/api/forum/ws/chat/slow-mode", strings.NewReader(`{"intervalSeconds": 10}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	getResp, err := st.HTTPClient.Get(st.BaseURL + "/api/forum/ws/chat/slow-mode")
+	require.NoError(t, err)
+	defer getResp.Body.Close()
+
+	var body struct {
+		IntervalSeconds int `json:"intervalSeconds"`
+	}
+	require.NoError(t, json.NewDecoder(getResp.Body).Decode(&body))
+	assert.Equal(t, 0, body.IntervalSeconds)
+}
+
+func TestWebSocketChat_Reauth_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, refreshToken := getTestUserToken(t, st, ctx)
+
+	wsURL := fmt.Sprintf("ws%s/api/forum/ws/chat?accessToken=%s", strings.TrimPrefix(st.BaseURL, "http"), token)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tokens, err := st.AuthClient.RefreshTokens(ctx, &ssov1.RefreshTokenRequest{RefreshToken: refreshToken, AppId: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, conn.WriteJSON(map[string]string{"action": "auth", "accessToken": tokens.GetAccessToken()}))
+
+	var frame struct {
+		Event     string    `json:"event"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, "reauthenticated", frame.Event)
+	assert.True(t, frame.ExpiresAt.After(time.Now()))
+
+	// после продления чат продолжает работать
+	require.NoError(t, conn.WriteJSON(map[string]string{"content": "still here"}))
+	var msg struct {
+		Content string `json:"content"`
+	}
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, "still here", msg.Content)
+}
+
+func TestWebSocketChat_Reauth_OtherUser_Closes(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+	otherToken, _ := getTestUserToken(t, st, ctx)
+
+	wsURL := fmt.Sprintf("ws%s/api/forum/ws/chat?accessToken=%s", strings.TrimPrefix(st.BaseURL, "http"), token)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(map[string]string{"action": "auth", "accessToken": otherToken}))
+
+	var frame struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, "unauthorized", frame.Code)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "unexpected error: %v", err)
+}
+
+func TestWebSocketChat_Shutdown_SendsCloseFrame(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	wsURL := fmt.Sprintf("ws%s/api/forum/ws/chat?accessToken=%s", strings.TrimPrefix(st.BaseURL, "http"), token)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		readErr <- err
+	}()
+
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, st.App.Stop(stopCtx))
+
+	select {
+	case err := <-readErr:
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no close frame after shutdown")
+	}
+}
+
+func TestWebSocketChat_InvalidSinceID(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	url := fmt.Sprintf("%s/api/forum/ws/chat?accessToken=%s&since_id=abc", st.BaseURL, token)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestGetChatMessages_Success(t *testing.T) {
 	ctx, st := suite.New(t)
 
@@ -1504,6 +1844,63 @@ func TestCleanupOldMessages_RemovesOldChatMessages(t *testing.T) {
 	}
 }
 
+func TestChatRetention_GetPolicy(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, st.BaseURL+"/api/forum/ws/chat/retention", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var policy struct {
+		Mode          string `json:"mode"`
+		MaxAgeSeconds int64  `json:"maxAgeSeconds"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&policy))
+	assert.Contains(t, []string{"delete", "archive", "keep"}, policy.Mode)
+}
+
+func TestChatRetention_NotAdmin_Forbidden(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, st.BaseURL+"/api/forum/ws/chat/retention", strings.NewReader(`{"mode": "keep"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, st.BaseURL+"/api/forum/ws/chat/retention/sweep", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp2, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp2.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp2.StatusCode)
+}
+
+func TestChatRetention_InvalidMode(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	token, _ := getTestUserToken(t, st, ctx)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, st.BaseURL+"/api/forum/ws/chat/retention", strings.NewReader(`{"mode": "forever"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := st.HTTPClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestMiddleware_TokenRefreshFlow(t *testing.T) {
 	ctx, st := suite.New(t)
 
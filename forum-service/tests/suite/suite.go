@@ -30,6 +30,14 @@ type Suite struct {
 
 func New(t *testing.T) (context.Context, *Suite) {
 	t.Helper()
+
+	return NewWithConfig(t, func(*authTestsuite.Suite, *config.Config) {})
+}
+
+// NewWithConfig поднимает форум с конфигом, изменённым configure. configure вызывается
+// после запуска auth-service, поэтому может, например, создать в нём отдельное приложение.
+func NewWithConfig(t *testing.T, configure func(authSuite *authTestsuite.Suite, cfg *config.Config)) (context.Context, *Suite) {
+	t.Helper()
 	t.Parallel()
 
 	// Поднимаем auth-service grpc-сервер через общий testsuite
@@ -37,8 +45,9 @@ func New(t *testing.T) (context.Context, *Suite) {
 	addr := authSuite.GRPCaddr
 
 	cfg := config.Load("../config/local.yaml")
+	configure(authSuite, cfg)
 	log := utils.New(cfg.Env)
-	application := app.NewApp(log, cfg.HTTP.Port, cfg.StoragePath, addr)
+	application := app.NewApp(log, cfg.HTTP.Port, cfg.StoragePath, addr, cfg.AppID, cfg.Service, cfg.Chat, cfg.JWKS, cfg.Bans, cfg.Erasure, cfg.Export, cfg.RequireVerifiedEmail)
 
 	engine := application.HTTPServer.Engine()
 	testServer := httptest.NewServer(engine)